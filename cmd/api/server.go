@@ -0,0 +1,361 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+	"golang.org/x/time/rate"
+
+	"golang-echo/internal/apidoc"
+	"golang-echo/internal/handler"
+	appMiddleware "golang-echo/internal/middleware"
+	"golang-echo/internal/repository"
+	"golang-echo/internal/service"
+	"golang-echo/pkg/audit"
+	appConfig "golang-echo/pkg/config"
+	"golang-echo/pkg/constants"
+	"golang-echo/pkg/mailer"
+	"golang-echo/pkg/oauth"
+	"golang-echo/pkg/oidc"
+	"golang-echo/pkg/openapi"
+	"golang-echo/pkg/request"
+	"golang-echo/pkg/response"
+	"golang-echo/pkg/utils"
+)
+
+// newServer wires the services and registers every route, returning the router and its OpenAPI document
+// db may be nil when nothing will be served, e.g. to write the document or to inspect the route table
+func newServer(cfg *appConfig.Config, db *sqlx.DB) (*echo.Echo, *openapi.Document, error) {
+	// Create validator with one translator per configured locale
+	validator, err := utils.NewValidator(cfg.I18n.Locales, cfg.I18n.DefaultLocale)
+	if err != nil {
+		return nil, nil, fmt.Errorf("create validator: %w", err)
+	}
+	if err := validator.RegisterAllCustomValidators(); err != nil {
+		return nil, nil, fmt.Errorf("register custom validators: %w", err)
+	}
+
+	// Create JWT Manager
+	jwtManager := utils.NewJWTManager(cfg.JWT.Secret, cfg.JWT.Duration)
+
+	// Create password policy
+	passwordPolicy := &utils.PasswordPolicy{
+		MinLength:        cfg.Password.MinLength,
+		MaxLength:        cfg.Password.MaxLength,
+		RequireUpper:     cfg.Password.RequireUpper,
+		RequireLower:     cfg.Password.RequireLower,
+		RequireDigit:     cfg.Password.RequireDigit,
+		RequireSymbol:    cfg.Password.RequireSymbol,
+		ForbidEmail:      cfg.Password.ForbidEmail,
+		MinStrengthScore: cfg.Password.MinStrengthScore,
+	}
+	if cfg.Password.BreachedListPath != "" {
+		breached, err := utils.NewBreachedPasswordList(cfg.Password.BreachedListPath)
+		if err != nil {
+			return nil, nil, fmt.Errorf("load breached password list: %w", err)
+		}
+		passwordPolicy.Breached = breached
+	}
+
+	// Create password hasher: new hashes use the configured algorithm, the other one is kept for verification
+	argon2Hasher := &utils.Argon2idHasher{
+		Memory:      cfg.Password.Argon2Memory,
+		Iterations:  cfg.Password.Argon2Iterations,
+		Parallelism: cfg.Password.Argon2Parallelism,
+		SaltLength:  16,
+		KeyLength:   32,
+	}
+	bcryptHasher := &utils.BcryptHasher{Cost: cfg.Password.BcryptCost}
+	passwordHasher := utils.NewPasswordHasher(argon2Hasher, bcryptHasher)
+	if cfg.Password.HashAlgorithm == "bcrypt" {
+		passwordHasher = utils.NewPasswordHasher(bcryptHasher, argon2Hasher)
+		// Longer passwords would fail to hash
+		if passwordPolicy.MaxLength == 0 || passwordPolicy.MaxLength > utils.BcryptMaxPasswordBytes {
+			passwordPolicy.MaxLength = utils.BcryptMaxPasswordBytes
+		}
+		passwordPolicy.MaxBytes = utils.BcryptMaxPasswordBytes
+	}
+
+	// Setup repositories & services
+	userRepo := repository.NewUserRepository(db)
+	auditor := service.NewAuditor(repository.NewAuditEventRepository(db))
+	auditHandler := handler.NewAuditHandler(auditor, validator)
+	tokenVersionService := service.NewTokenVersionService(userRepo, cfg.JWT.VersionCacheTTL)
+	sessionService := service.NewSessionService(repository.NewUserSessionRepository(db), jwtManager, cfg.JWT.VersionCacheTTL)
+	sessionHandler := handler.NewSessionHandler(sessionService)
+	userService := service.NewUserService(userRepo, sessionService, tokenVersionService, auditor, passwordPolicy, passwordHasher)
+	userHandler := handler.NewUserHandler(userService, validator)
+	impersonationService := service.NewImpersonationService(userService, sessionService, auditor, cfg.JWT.ImpersonationDuration)
+	impersonationHandler := handler.NewImpersonationHandler(impersonationService, validator)
+	mail, err := mailer.New(mailer.Config{
+		Driver:       cfg.Mail.Driver,
+		From:         cfg.Mail.From,
+		SMTPHost:     cfg.Mail.SMTPHost,
+		SMTPPort:     cfg.Mail.SMTPPort,
+		SMTPUsername: cfg.Mail.SMTPUsername,
+		SMTPPassword: cfg.Mail.SMTPPassword,
+		Production:   cfg.IsProduction(),
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("create mailer: %w", err)
+	}
+	invitationService := service.NewInvitationService(
+		repository.NewUserInvitationRepository(db),
+		userRepo,
+		mail,
+		auditor,
+		passwordPolicy,
+		passwordHasher,
+		service.InvitationSettings{
+			TTL:       cfg.Invitation.TTL,
+			MaxTTL:    cfg.Invitation.MaxTTL,
+			AcceptURL: cfg.Invitation.AcceptURL,
+		},
+	)
+	invitationHandler := handler.NewInvitationHandler(invitationService, validator)
+	userExportService := service.NewUserExportService(userRepo, repository.NewJobRepository(db), auditor, service.ExportSettings{
+		Dir:    cfg.Export.Dir,
+		JobTTL: cfg.Export.JobTTL,
+	})
+	userExportHandler := handler.NewUserExportHandler(userExportService, validator)
+	userImportService := service.NewUserImportService(
+		userRepo,
+		repository.NewJobRepository(db),
+		tokenVersionService,
+		auditor,
+		validator,
+		passwordPolicy,
+		passwordHasher,
+		service.ImportSettings{MaxBytes: cfg.Import.MaxBytes},
+	)
+	userImportHandler := handler.NewUserImportHandler(userImportService, validator)
+	apiKeyRepo := repository.NewAPIKeyRepository(db)
+	apiKeyService := service.NewAPIKeyService(apiKeyRepo, userRepo)
+	apiKeyHandler := handler.NewAPIKeyHandler(apiKeyService, validator)
+	batchHandler := handler.NewBatchHandler(validator, response.DefaultMessageCatalog(), handler.BatchSettings{
+		MaxRequests:    cfg.Batch.MaxRequests,
+		MaxConcurrency: cfg.Batch.MaxConcurrency,
+	})
+
+	// External identity providers (OIDC)
+	var identityProviders []oidc.IdentityProvider
+	for name, providerCfg := range cfg.OIDC.Providers {
+		identityProviders = append(identityProviders, oidc.NewProvider(oidc.ProviderConfig{
+			Name:         name,
+			Issuer:       providerCfg.Issuer,
+			ClientID:     providerCfg.ClientID,
+			ClientSecret: providerCfg.ClientSecret,
+			RedirectURL:  providerCfg.RedirectURL,
+			Scopes:       providerCfg.Scopes,
+		}, nil))
+	}
+	identityRepo := repository.NewUserIdentityRepository(db)
+	oidcService := service.NewOIDCService(
+		oidc.NewRegistry(identityProviders...),
+		oidc.NewStateCodec(cfg.JWT.Secret, cfg.OIDC.StateTTL),
+		userRepo,
+		identityRepo,
+		sessionService,
+		auditor,
+		passwordHasher,
+	)
+	oidcHandler := handler.NewOIDCHandler(oidcService, cfg.OIDC.StateTTL, cfg.IsProduction())
+
+	// OAuth2 authorization server for our own client apps
+	if cfg.OAuth.SigningKeyPath == "" {
+		slog.Warn("OAUTH_SIGNING_KEY_PATH is not set, ID tokens are signed with an ephemeral key")
+	}
+	idTokenSigner, err := oauth.NewIDTokenSigner(cfg.OAuth.SigningKeyPath)
+	if err != nil {
+		return nil, nil, fmt.Errorf("load oauth signing key: %w", err)
+	}
+	oauthService := service.NewOAuthService(
+		repository.NewOAuthClientRepository(db),
+		repository.NewOAuthGrantRepository(db),
+		userRepo,
+		jwtManager,
+		idTokenSigner,
+		service.OAuthSettings{
+			Issuer:          cfg.OAuth.Issuer,
+			AuthCodeTTL:     cfg.OAuth.AuthCodeTTL,
+			AccessTokenTTL:  cfg.OAuth.AccessTokenTTL,
+			RefreshTokenTTL: cfg.OAuth.RefreshTokenTTL,
+			ClientCacheTTL:  cfg.JWT.VersionCacheTTL,
+		},
+	)
+	oauthHandler := handler.NewOAuthHandler(oauthService, userService, sessionService, jwtManager, validator, cfg.IsProduction())
+
+	// Setup Echo
+	e := echo.New()
+	e.Use(middleware.Recover())
+	e.Validator = validator
+	e.Binder = request.NewBinder()
+	e.HTTPErrorHandler = handler.NewHTTPErrorHandler(response.DefaultMessageCatalog())
+
+	// Add middlewares
+	// e.Use(middleware.RequestLoggerWithConfig(middleware.RequestLoggerConfig{
+	// 	LogStatus:    true,
+	// 	LogMethod:    true,
+	// 	LogURI:       true,
+	// 	LogRemoteIP:  true,
+	// 	LogRequestID: true,
+	// 	LogLatency:   true,
+	// 	LogValuesFunc: func(c echo.Context, v middleware.RequestLoggerValues) error {
+	// 		slog.LogAttrs(
+	// 			context.Background(),
+	// 			slog.LevelInfo,
+	// 			"http_request",
+	// 			slog.String("method", v.Method),
+	// 			slog.String("uri", v.URI),
+	// 			slog.Int("status", v.Status),
+	// 			slog.String("remote_ip", v.RemoteIP),
+	// 			slog.String("latency", v.Latency.String()),
+	// 			slog.String("request_id", v.RequestID),
+	// 		)
+	// 		return nil
+	// 	},
+	// }))
+	e.Use(middleware.CORS())
+	e.Use(middleware.RequestID())
+	e.Use(appMiddleware.AuditContextMiddleware())
+	e.Use(appMiddleware.LocaleMiddleware(validator.Locales(), validator.DefaultLocale()))
+	e.Use(middleware.Secure())
+	e.Use(middleware.Gzip())
+	// Rate limiter
+	e.Use(middleware.RateLimiterWithConfig(middleware.RateLimiterConfig{
+		Store: middleware.NewRateLimiterMemoryStore(
+			rate.Limit(float64(cfg.RateLimit.RequestsPerMin) / 60),
+		),
+		DenyHandler: func(c echo.Context, identifier string, err error) error {
+			return response.TooManyRequests("RATE_LIMIT_EXCEEDED", "Too many requests. Please try again later.", nil)
+		},
+	}))
+
+	// Health check
+	e.GET("/health", func(c echo.Context) error {
+		return c.JSON(200, map[string]string{"status": "ok"})
+	})
+
+	// API documentation, built from the routes below once they are all registered
+	docsHandler := handler.NewDocsHandler()
+	e.GET("/openapi.json", docsHandler.OpenAPI)
+	e.GET("/docs", docsHandler.UI)
+
+	// OAuth2 authorization server
+	e.GET(oauth.PathDiscovery, oauthHandler.Discovery)
+	e.GET(oauth.PathJWKS, oauthHandler.JWKS)
+	e.GET(oauth.PathAuthorize, oauthHandler.Authorize)
+	e.POST(oauth.PathAuthorize, oauthHandler.AuthorizeDecision)
+	e.POST(oauth.PathToken, oauthHandler.Token)
+	e.POST(oauth.PathRevoke, oauthHandler.Revoke)
+	userInfoAuth := appMiddleware.JWTMiddleware(jwtManager, sessionService, tokenVersionService, oauthService)
+	e.GET(oauth.PathUserInfo, oauthHandler.UserInfo, userInfoAuth)
+	e.POST(oauth.PathUserInfo, oauthHandler.UserInfo, userInfoAuth)
+
+	// Idempotency-Key support for create endpoints; not for those whose response holds a secret
+	// (API keys, OAuth client secrets, tokens), which would be kept in the store
+	idempotencyStore := repository.NewIdempotencyRepository(db)
+	if cfg.Idempotency.Store == "memory" {
+		idempotencyStore = appMiddleware.NewIdempotencyMemoryStore()
+	}
+	idempotent := appMiddleware.Idempotency(appMiddleware.IdempotencyConfig{
+		Store:       idempotencyStore,
+		TTL:         cfg.Idempotency.TTL,
+		LockTimeout: cfg.Idempotency.LockTimeout,
+	})
+
+	// Routes
+	apiV1 := e.Group("/api/v1")
+
+	// Public routes
+	apiV1.POST("/users", userHandler.CreateUser, idempotent)
+	apiV1.POST("/users/login", userHandler.Login)
+	apiV1.POST("/invitations/accept", invitationHandler.AcceptInvitation)
+	apiV1.GET("/auth/oidc/:provider/start", oidcHandler.StartLogin)
+	apiV1.GET("/auth/oidc/:provider/callback", oidcHandler.Callback)
+
+	// Protected routes (JWT bearer token or API key)
+	protected := apiV1.Group("")
+	protected.Use(appMiddleware.APIKeyMiddleware(apiKeyService))
+	protected.Use(appMiddleware.JWTMiddleware(jwtManager, sessionService, tokenVersionService, oauthService))
+	// Access tokens a user granted to an OAuth client carry OpenID scopes only, good for userinfo alone
+	protected.Use(appMiddleware.DenyOAuthUser())
+	protected.Use(appMiddleware.AuditImpersonation(auditor))
+	// Impersonation tokens may look around but not change the user's credentials or sign-ins
+	denyImpersonation := appMiddleware.DenyImpersonation()
+	protected.GET("/my-info", userHandler.GetMyInfo)
+	protected.PUT("/my-info/password", userHandler.ChangePassword, denyImpersonation)
+	protected.GET("/my-info/sessions", sessionHandler.FindMySessions)
+	protected.DELETE("/my-info/sessions", sessionHandler.RevokeMyOtherSessions, denyImpersonation)
+	protected.DELETE("/my-info/sessions/:id", sessionHandler.RevokeMySession, denyImpersonation)
+	protected.GET("/my-info/consents", oauthHandler.FindMyConsents)
+	protected.DELETE("/my-info/consents/:client_id", oauthHandler.RevokeConsent, denyImpersonation)
+	// The requests of a batch are routed like any other, each authenticated with the batch's credentials
+	protected.POST("/batch", batchHandler.Batch)
+
+	// Admin only routes; API keys additionally need the route's permission
+	admin := protected.Group("")
+	admin.Use(appMiddleware.AdminMiddleware())
+	// Admin reads of user data are audited
+	usersRead := appMiddleware.RequirePermission(constants.PermissionUsersRead)
+	auditUserRead := appMiddleware.AuditRead(auditor, audit.TargetUser, "id")
+	admin.GET("/users", userHandler.FindAllUsers, auditUserRead, usersRead)
+	admin.GET("/users/:id", userHandler.FindUserByID, auditUserRead, usersRead)
+	admin.GET("/users/by-email", userHandler.FindUserByEmail, auditUserRead, usersRead)
+	admin.GET("/users/by-phone", userHandler.FindUserByPhone, auditUserRead, usersRead)
+	admin.GET("/users/:id/sessions", sessionHandler.FindUserSessions, auditUserRead, usersRead)
+	usersWrite := appMiddleware.RequirePermission(constants.PermissionUsersWrite)
+	admin.PUT("/users/:id/role", userHandler.UpdateRole, usersWrite)
+	admin.PUT("/users/:id/status", userHandler.UpdateStatus, usersWrite)
+	admin.GET("/users/:id/status-changes", userHandler.FindStatusChanges, auditUserRead, usersRead)
+	admin.POST("/admin/users/:id/impersonate", impersonationHandler.Impersonate, appMiddleware.RequirePermission(constants.PermissionUsersImpersonate))
+	// Exports are audited by the service, with the number of users exported
+	usersExport := appMiddleware.RequirePermission(constants.PermissionUsersExport)
+	admin.GET("/admin/users/export", userExportHandler.ExportUsers, usersExport)
+	admin.POST("/admin/users/export-jobs", userExportHandler.CreateUserExportJob, usersExport, idempotent)
+	admin.GET("/admin/users/export-jobs/:id", userExportHandler.FindUserExportJob, usersExport)
+	admin.GET("/admin/users/export-jobs/:id/download", userExportHandler.DownloadUserExport, usersExport)
+	// Imports are audited by the service once they finish, with what they did
+	usersImport := appMiddleware.RequirePermission(constants.PermissionUsersImport)
+	admin.POST("/admin/users/import", userImportHandler.ImportUsers, usersImport)
+	admin.GET("/admin/users/import-jobs/:id", userImportHandler.FindUserImportJob, usersImport)
+
+	auditRead := appMiddleware.RequirePermission(constants.PermissionAuditRead)
+	auditEventsRead := appMiddleware.AuditRead(auditor, "audit_events", "")
+	admin.GET("/admin/audit-events", auditHandler.FindAuditEvents, auditEventsRead, auditRead)
+	admin.GET("/admin/audit-events/verify", auditHandler.VerifyAuditChain, auditEventsRead, auditRead)
+
+	invitationsManage := appMiddleware.RequirePermission(constants.PermissionInvitationsManage)
+	admin.POST("/admin/invitations", invitationHandler.CreateInvitation, invitationsManage, idempotent)
+	admin.GET("/admin/invitations", invitationHandler.FindAllInvitations, invitationsManage)
+	admin.POST("/admin/invitations/:id/resend", invitationHandler.ResendInvitation, invitationsManage)
+	admin.DELETE("/admin/invitations/:id", invitationHandler.RevokeInvitation, invitationsManage)
+
+	apiKeysManage := appMiddleware.RequirePermission(constants.PermissionAPIKeysManage)
+	admin.POST("/admin/api-keys", apiKeyHandler.CreateAPIKey, apiKeysManage)
+	admin.GET("/admin/api-keys", apiKeyHandler.FindAllAPIKeys, apiKeysManage)
+	admin.DELETE("/admin/api-keys/:id", apiKeyHandler.RevokeAPIKey, apiKeysManage)
+
+	oauthClientsManage := appMiddleware.RequirePermission(constants.PermissionOAuthClientsManage)
+	admin.POST("/admin/oauth-clients", oauthHandler.CreateClient, oauthClientsManage)
+	admin.GET("/admin/oauth-clients", oauthHandler.FindAllClients, oauthClientsManage)
+	admin.DELETE("/admin/oauth-clients/:id", oauthHandler.RevokeClient, oauthClientsManage)
+
+	spec, err := apidoc.Build(e.Routes())
+	if err != nil {
+		return nil, nil, fmt.Errorf("build openapi document: %w", err)
+	}
+	if err := docsHandler.SetDocument(spec); err != nil {
+		return nil, nil, fmt.Errorf("encode openapi document: %w", err)
+	}
+	// Contract validation wraps the handlers of the routes above; global middlewares apply per request
+	e.Use(appMiddleware.OpenAPIValidator(spec, appMiddleware.OpenAPIValidatorConfig{
+		ValidateRequests:   cfg.OpenAPI.ValidateRequests,
+		ResponseValidation: cfg.ResponseValidationMode(),
+	}))
+
+	return e, spec, nil
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+
+	appMiddleware "golang-echo/internal/middleware"
+	"golang-echo/pkg/client"
+	appConfig "golang-echo/pkg/config"
+	"golang-echo/pkg/oauth"
+	"golang-echo/pkg/openapi"
+	"golang-echo/pkg/response"
+)
+
+// newTestServer builds the real router without a database, failing responses that drift from the document
+// Only requests answered before a repository is reached can be served
+func newTestServer(t *testing.T) (*echo.Echo, *openapi.Document) {
+	t.Helper()
+	cfg, err := appConfig.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg.Server.Env = "test"
+	cfg.OpenAPI.ResponseValidation = appMiddleware.ResponseValidationFail
+	cfg.RateLimit.RequestsPerMin = 1 << 20
+	cfg.Idempotency.Store = "memory"
+	cfg.Export.Dir = t.TempDir()
+
+	e, spec, err := newServer(cfg, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return e, spec
+}
+
+func serve(e *echo.Echo, method string, path string, body string, header map[string]string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	if body != "" {
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	}
+	for name, value := range header {
+		req.Header.Set(name, value)
+	}
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestRoutesMatchOpenAPIDocument(t *testing.T) {
+	e, _ := newTestServer(t)
+	bearer := map[string]string{echo.HeaderAuthorization: "Bearer not-a-jwt"}
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   string
+		header map[string]string
+		status int
+	}{
+		{"health", http.MethodGet, "/health", "", nil, http.StatusOK},
+		{"document", http.MethodGet, "/openapi.json", "", nil, http.StatusOK},
+		{"docs page", http.MethodGet, "/docs", "", nil, http.StatusOK},
+		{"discovery", http.MethodGet, oauth.PathDiscovery, "", nil, http.StatusOK},
+		{"jwks", http.MethodGet, oauth.PathJWKS, "", nil, http.StatusOK},
+		{"sign-up without a body", http.MethodPost, "/api/v1/users", "{}", nil, http.StatusBadRequest},
+		{"sign-up with a malformed body", http.MethodPost, "/api/v1/users", "{", nil, http.StatusBadRequest},
+		{"sign-up with a bad Idempotency-Key", http.MethodPost, "/api/v1/users", "{}", map[string]string{appMiddleware.HeaderIdempotencyKey: "a b"}, http.StatusBadRequest},
+		{"login without a body", http.MethodPost, "/api/v1/users/login", "{}", nil, http.StatusBadRequest},
+		{"my info without a token", http.MethodGet, "/api/v1/my-info", "", nil, http.StatusUnauthorized},
+		{"my info with a bad token", http.MethodGet, "/api/v1/my-info", "", bearer, http.StatusUnauthorized},
+		{"users without a token", http.MethodGet, "/api/v1/users", "", nil, http.StatusUnauthorized},
+		{"user with a bad API key", http.MethodGet, "/api/v1/users/1", "", map[string]string{appMiddleware.HeaderXAPIKey: "nope"}, http.StatusUnauthorized},
+		{"batch without a token", http.MethodPost, "/api/v1/batch", `{"requests":[]}`, nil, http.StatusUnauthorized},
+		{"token without client credentials", http.MethodPost, oauth.PathToken, "", nil, http.StatusUnauthorized},
+		{"problem details", http.MethodGet, "/api/v1/my-info", "", map[string]string{echo.HeaderAccept: response.MIMEApplicationProblemJSON}, http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := serve(e, tt.method, tt.path, tt.body, tt.header)
+			if strings.Contains(rec.Body.String(), "RESPONSE_CONTRACT_VIOLATION") {
+				t.Fatalf("response drifted from the document: %s", rec.Body.String())
+			}
+			if rec.Code != tt.status {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.status, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestResponseDriftFails(t *testing.T) {
+	e, spec := newTestServer(t)
+
+	// Document a field /health doesn't send, as if the handler had dropped it
+	op := spec.Operation(http.MethodGet, "/health")
+	if op == nil {
+		t.Fatal("GET /health is not documented")
+	}
+	schema, err := spec.Resolve(op.Responses["200"].Content[echo.MIMEApplicationJSON].Schema)
+	if err != nil {
+		t.Fatal(err)
+	}
+	schema.Required = append(schema.Required, "version")
+
+	rec := serve(e, http.MethodGet, "/health", "", nil)
+	if rec.Code != http.StatusInternalServerError {
+		t.Fatalf("status = %d, want 500: %s", rec.Code, rec.Body.String())
+	}
+	var body response.ErrorResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	if body.Code != "RESPONSE_CONTRACT_VIOLATION" || body.Errors["version"] == "" {
+		t.Errorf("got %+v, want RESPONSE_CONTRACT_VIOLATION about version", body)
+	}
+}
+
+// The Go SDK must keep up with the route table
+func TestClientRoutes(t *testing.T) {
+	e, _ := newTestServer(t)
+	if err := client.CheckRoutes(e.Routes()); err != nil {
+		t.Errorf("pkg/client is out of date with the routes:\n%v", err)
+	}
+}
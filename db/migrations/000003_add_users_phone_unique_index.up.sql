@@ -0,0 +1,27 @@
+-- Normalise any stored phone numbers to E.164 (+84...) before enforcing uniqueness
+UPDATE users SET phone = '+84' || substring(phone FROM 2) WHERE phone ~ '^0[0-9]{9}$';
+UPDATE users SET phone = '+' || phone WHERE phone ~ '^84[0-9]{9}$';
+
+-- Numbers stored in different formats may now collide; list them rather than let the index fail
+-- on the first one. The whole file rolls back: resolve them by hand, clear the dirty flag with
+-- `migrate force 2`, then run `make migrate` again.
+DO $$
+DECLARE
+    duplicates text;
+BEGIN
+    SELECT string_agg(phone || ' (users ' || ids || ')', ', ' ORDER BY phone) INTO duplicates
+    FROM (
+        SELECT phone, string_agg(id::text, ', ' ORDER BY id) AS ids
+        FROM users
+        WHERE phone <> ''
+        GROUP BY phone
+        HAVING count(*) > 1
+    ) d;
+    IF duplicates IS NOT NULL THEN
+        RAISE EXCEPTION 'duplicate phone numbers after normalisation: %', duplicates;
+    END IF;
+END;
+$$;
+
+-- Partial unique index: users without a phone ('') are not constrained
+CREATE UNIQUE INDEX users_phone_unique ON users(phone) WHERE phone <> '';
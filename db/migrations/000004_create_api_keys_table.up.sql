@@ -0,0 +1,19 @@
+-- API keys for service-to-service clients
+-- The secret is never stored: key_hash is the SHA-256 of the secret part of the key
+CREATE TABLE api_keys(
+    id SERIAL NOT NULL,
+    name varchar(100) NOT NULL,
+    prefix varchar(32) NOT NULL,
+    key_hash varchar(255) NOT NULL,
+    user_id integer NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    permissions text[] NOT NULL DEFAULT '{}',
+    expires_at timestamp without time zone,
+    last_used_at timestamp without time zone,
+    revoked_at timestamp without time zone,
+    created_by integer REFERENCES users(id) ON DELETE SET NULL,
+    created_at timestamp without time zone NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY(id)
+);
+
+CREATE UNIQUE INDEX idx_api_keys_prefix ON api_keys(prefix);
+CREATE INDEX idx_api_keys_user_id ON api_keys(user_id);
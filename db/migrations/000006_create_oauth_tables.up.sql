@@ -0,0 +1,71 @@
+-- OAuth2 authorization server: client registry, consents, authorization codes and refresh tokens
+-- Secrets, codes and refresh tokens are never stored in clear, only their SHA-256
+
+-- Registered client applications; public clients (SPAs, CLIs) have no secret and must use PKCE
+CREATE TABLE oauth_clients(
+    id SERIAL NOT NULL,
+    client_id varchar(64) NOT NULL,
+    client_secret_hash varchar(255),
+    name varchar(100) NOT NULL,
+    redirect_uris text[] NOT NULL DEFAULT '{}',
+    grant_types text[] NOT NULL DEFAULT '{}',
+    scopes text[] NOT NULL DEFAULT '{}',
+    -- The user client_credentials tokens act on behalf of
+    user_id integer REFERENCES users(id) ON DELETE CASCADE,
+    revoked_at timestamp without time zone,
+    created_by integer REFERENCES users(id) ON DELETE SET NULL,
+    created_at timestamp without time zone NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY(id)
+);
+
+CREATE UNIQUE INDEX idx_oauth_clients_client_id ON oauth_clients(client_id);
+
+-- Scopes a user has approved for a client; later requests within these scopes skip the consent screen
+CREATE TABLE oauth_consents(
+    id SERIAL NOT NULL,
+    user_id integer NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    client_id varchar(64) NOT NULL REFERENCES oauth_clients(client_id) ON DELETE CASCADE,
+    scopes text[] NOT NULL DEFAULT '{}',
+    created_at timestamp without time zone NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at timestamp without time zone NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY(id)
+);
+
+CREATE UNIQUE INDEX idx_oauth_consents_user_client ON oauth_consents(user_id, client_id);
+
+-- Short-lived, single-use authorization codes
+CREATE TABLE oauth_authorization_codes(
+    code_hash varchar(64) NOT NULL,
+    client_id varchar(64) NOT NULL REFERENCES oauth_clients(client_id) ON DELETE CASCADE,
+    user_id integer NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    redirect_uri text NOT NULL,
+    scope text NOT NULL DEFAULT '',
+    code_challenge varchar(128) NOT NULL,
+    nonce varchar(255) NOT NULL DEFAULT '',
+    expires_at timestamp without time zone NOT NULL,
+    used_at timestamp without time zone,
+    created_at timestamp without time zone NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY(code_hash)
+);
+
+CREATE INDEX idx_oauth_authorization_codes_expires_at ON oauth_authorization_codes(expires_at);
+
+-- Refresh tokens are rotated on every use; all tokens descending from one grant share family_id,
+-- so replaying a rotated token can revoke the whole family
+CREATE TABLE oauth_refresh_tokens(
+    id SERIAL NOT NULL,
+    token_hash varchar(64) NOT NULL,
+    family_id varchar(64) NOT NULL,
+    client_id varchar(64) NOT NULL REFERENCES oauth_clients(client_id) ON DELETE CASCADE,
+    user_id integer NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    scope text NOT NULL DEFAULT '',
+    expires_at timestamp without time zone NOT NULL,
+    rotated_at timestamp without time zone,
+    revoked_at timestamp without time zone,
+    created_at timestamp without time zone NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY(id)
+);
+
+CREATE UNIQUE INDEX idx_oauth_refresh_tokens_token_hash ON oauth_refresh_tokens(token_hash);
+CREATE INDEX idx_oauth_refresh_tokens_family_id ON oauth_refresh_tokens(family_id);
+CREATE INDEX idx_oauth_refresh_tokens_user_client ON oauth_refresh_tokens(user_id, client_id);
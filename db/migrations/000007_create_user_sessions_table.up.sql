@@ -0,0 +1,17 @@
+-- One row per successful login, tied to the issued access token through its jti (token_id)
+CREATE TABLE user_sessions(
+    id SERIAL NOT NULL,
+    user_id integer NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    token_id varchar(64) NOT NULL,
+    user_agent text NOT NULL DEFAULT '',
+    ip_address varchar(45) NOT NULL DEFAULT '',
+    device_label varchar(100) NOT NULL DEFAULT '',
+    created_at timestamp without time zone NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    last_seen_at timestamp without time zone NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    expires_at timestamp without time zone NOT NULL,
+    revoked_at timestamp without time zone,
+    PRIMARY KEY(id)
+);
+
+CREATE UNIQUE INDEX idx_user_sessions_token_id ON user_sessions(token_id);
+CREATE INDEX idx_user_sessions_user_id ON user_sessions(user_id);
@@ -0,0 +1,3 @@
+-- Tokens carry the user's token_version; bumping it invalidates every token issued before
+-- (role change, password change, suspension)
+ALTER TABLE users ADD COLUMN token_version integer NOT NULL DEFAULT 1;
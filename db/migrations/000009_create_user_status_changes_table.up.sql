@@ -0,0 +1,13 @@
+-- Audit trail of account status changes made by admins; the reason is mandatory
+CREATE TABLE user_status_changes(
+    id SERIAL NOT NULL,
+    user_id integer NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    actor_id integer REFERENCES users(id) ON DELETE SET NULL,
+    from_status varchar(20) NOT NULL,
+    to_status varchar(20) NOT NULL,
+    reason text NOT NULL,
+    created_at timestamp without time zone NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY(id)
+);
+
+CREATE INDEX idx_user_status_changes_user_id ON user_status_changes(user_id);
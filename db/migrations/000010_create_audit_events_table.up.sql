@@ -0,0 +1,42 @@
+-- Append-only audit log. Rows form a hash chain (hash covers the row and prev_hash),
+-- so edits or deletions that bypass the trigger below are still detectable
+CREATE TABLE audit_events(
+    id BIGSERIAL NOT NULL,
+    occurred_at timestamp with time zone NOT NULL,
+    actor_id integer,
+    actor_type varchar(20) NOT NULL,
+    action varchar(100) NOT NULL,
+    target_type varchar(50) NOT NULL DEFAULT '',
+    target_id varchar(255) NOT NULL DEFAULT '',
+    request_id varchar(64) NOT NULL DEFAULT '',
+    ip_address varchar(45) NOT NULL DEFAULT '',
+    user_agent text NOT NULL DEFAULT '',
+    outcome varchar(20) NOT NULL,
+    diff jsonb,
+    metadata jsonb,
+    prev_hash char(64) NOT NULL,
+    hash char(64) NOT NULL,
+    PRIMARY KEY(id)
+);
+
+ALTER TABLE audit_events ADD CONSTRAINT chk_audit_events_outcome
+    CHECK (outcome IN ('success', 'failure', 'denied'));
+
+CREATE INDEX idx_audit_events_occurred_at ON audit_events(occurred_at);
+CREATE INDEX idx_audit_events_actor_id ON audit_events(actor_id);
+CREATE INDEX idx_audit_events_target ON audit_events(target_type, target_id);
+CREATE INDEX idx_audit_events_action ON audit_events(action);
+
+CREATE FUNCTION audit_events_append_only() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'audit_events is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER trg_audit_events_append_only
+    BEFORE UPDATE OR DELETE ON audit_events
+    FOR EACH ROW EXECUTE FUNCTION audit_events_append_only();
+
+CREATE TRIGGER trg_audit_events_no_truncate
+    BEFORE TRUNCATE ON audit_events
+    FOR EACH STATEMENT EXECUTE FUNCTION audit_events_append_only();
@@ -0,0 +1,4 @@
+-- Admin behind an impersonation token; actor_id is then the impersonated user
+ALTER TABLE audit_events ADD COLUMN impersonator_id integer;
+
+CREATE INDEX idx_audit_events_impersonator_id ON audit_events(impersonator_id) WHERE impersonator_id IS NOT NULL;
@@ -0,0 +1,23 @@
+-- Admin-issued invitations; the invitee sets their own password when accepting
+-- Only the SHA-256 hash of the invitation token is stored
+CREATE TABLE user_invitations(
+    id SERIAL NOT NULL,
+    email varchar(255) NOT NULL,
+    role varchar(50) NOT NULL DEFAULT 'user',
+    token_hash char(64) NOT NULL,
+    invited_by integer REFERENCES users(id) ON DELETE SET NULL,
+    accepted_user_id integer REFERENCES users(id) ON DELETE SET NULL,
+    send_count integer NOT NULL DEFAULT 0,
+    last_sent_at timestamp without time zone,
+    expires_at timestamp without time zone NOT NULL,
+    accepted_at timestamp without time zone,
+    revoked_at timestamp without time zone,
+    created_at timestamp without time zone NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at timestamp without time zone NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY(id)
+);
+
+CREATE UNIQUE INDEX idx_user_invitations_token_hash ON user_invitations(token_hash);
+-- At most one open invitation per email
+CREATE UNIQUE INDEX user_invitations_email_open ON user_invitations(lower(email))
+    WHERE accepted_at IS NULL AND revoked_at IS NULL;
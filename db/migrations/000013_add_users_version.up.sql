@@ -0,0 +1,3 @@
+-- Bumped on every change to a user row; single-user responses carry it in their ETag,
+-- and If-Match on updates is checked against it (optimistic concurrency control)
+ALTER TABLE users ADD COLUMN version integer NOT NULL DEFAULT 1;
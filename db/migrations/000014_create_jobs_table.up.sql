@@ -0,0 +1,20 @@
+-- Background jobs, e.g. user exports: params and result are the job kind's own JSON
+-- file_path is the job's output, removed once expires_at has passed
+CREATE TABLE jobs(
+    id SERIAL NOT NULL,
+    kind varchar(50) NOT NULL,
+    status varchar(20) NOT NULL DEFAULT 'pending',
+    created_by integer REFERENCES users(id) ON DELETE SET NULL,
+    params jsonb NOT NULL DEFAULT '{}',
+    result jsonb,
+    error text,
+    file_path text,
+    created_at timestamp without time zone NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    started_at timestamp without time zone,
+    finished_at timestamp without time zone,
+    expires_at timestamp without time zone,
+    PRIMARY KEY(id)
+);
+
+CREATE INDEX idx_jobs_kind_created_at ON jobs(kind, created_at);
+CREATE INDEX idx_jobs_expires_at ON jobs(expires_at) WHERE file_path IS NOT NULL;
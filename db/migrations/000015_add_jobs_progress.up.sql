@@ -0,0 +1,2 @@
+-- How far a running job has got, in the job kind's own JSON; updated as it runs
+ALTER TABLE jobs ADD COLUMN progress jsonb;
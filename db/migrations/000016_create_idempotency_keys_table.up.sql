@@ -0,0 +1,15 @@
+-- Responses to requests sent with an Idempotency-Key, replayed when the request is retried
+-- key is a hash of the caller, route and Idempotency-Key; status is 0 while the first request is in flight
+CREATE TABLE idempotency_keys(
+    key varchar(64) NOT NULL,
+    fingerprint varchar(64) NOT NULL,
+    status integer NOT NULL DEFAULT 0,
+    header jsonb,
+    body bytea,
+    created_at timestamp without time zone NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    locked_until timestamp without time zone NOT NULL,
+    expires_at timestamp without time zone NOT NULL,
+    PRIMARY KEY(key)
+);
+
+CREATE INDEX idx_idempotency_keys_expires_at ON idempotency_keys(expires_at);
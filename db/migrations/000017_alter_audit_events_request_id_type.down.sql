@@ -0,0 +1,14 @@
+-- audit_events is hash-chained: truncating a request ID would break verification of its event,
+-- so refuse to roll back while any is longer than the old column allows
+DO $$
+DECLARE
+    too_long bigint;
+BEGIN
+    SELECT count(*) INTO too_long FROM audit_events WHERE length(request_id) > 64;
+    IF too_long > 0 THEN
+        RAISE EXCEPTION '% audit events have a request_id longer than 64 characters; cannot narrow the column', too_long;
+    END IF;
+END;
+$$;
+
+ALTER TABLE audit_events ALTER COLUMN request_id TYPE varchar(64);
@@ -0,0 +1,2 @@
+-- Request IDs come from the client's X-Request-ID, or a batch's ID with a suffix, so their length is not bounded
+ALTER TABLE audit_events ALTER COLUMN request_id TYPE text;
@@ -0,0 +1,649 @@
+// Package apidoc builds the OpenAPI document from the routes registered on Echo and the
+// per-route documentation in Operations: request and response types, auth and AppError keys.
+package apidoc
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"golang-echo/internal/middleware"
+	"golang-echo/pkg/oauth"
+	"golang-echo/pkg/openapi"
+	"golang-echo/pkg/response"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Auth is how a route authenticates its caller
+type Auth int
+
+const (
+	Public Auth = iota
+	// Bearer routes take a JWT access token only
+	Bearer
+	// User routes take a JWT access token or an API key
+	User
+	// Admin routes take an admin's JWT, or an API key holding the operation's Permission
+	Admin
+)
+
+// Operation documents one route
+type Operation struct {
+	ID                string // operationId; defaults to the handler's method name
+	Summary           string
+	Description       string
+	Tag               string
+	Auth              Auth
+	Permission        string   // API key permission required by Admin routes
+	DenyImpersonation bool     // the route rejects impersonation tokens
+	Query             any      // struct whose query tags are the query parameters
+	Body              any      // JSON request body
+	Form              any      // application/x-www-form-urlencoded request body, fields named by form tags
+	Upload            []string // content types of a request body that is a file, read as is
+	IfMatch           bool     // the update requires If-Match with the resource's ETag
+	Idempotent        bool     // the route takes an Idempotency-Key (middleware.Idempotency)
+	// Fields are the result fields selectable with the fields query parameter; a sparse result
+	// only has the selected ones, so its schema requires none
+	Fields []string
+	Result Result
+	// Errors lists the AppError keys the handler and its services return; keys implied by
+	// Auth, Query/Body binding and :id path parameters are added automatically
+	Errors []string
+	// OAuthErrors marks protocol endpoints that answer errors in the RFC 6749 format instead of ErrorResponse
+	OAuthErrors bool
+}
+
+type envelope int
+
+const (
+	noEnvelope envelope = iota
+	dataEnvelope
+	listEnvelope
+	pageEnvelope
+)
+
+// Result describes a route's successful response
+type Result struct {
+	Status      int
+	Description string
+	ContentType string
+	envelope    envelope
+	data        any
+	// contentTypes are the formats of a file download
+	contentTypes []string
+}
+
+// Data is a 200 response wrapped in response.Response
+func Data(v any) Result {
+	return Result{Status: http.StatusOK, envelope: dataEnvelope, data: v}
+}
+
+// Created is a 201 response wrapped in response.Response
+func Created(v any) Result {
+	return Result{Status: http.StatusCreated, envelope: dataEnvelope, data: v}
+}
+
+// Accepted is a 202 response wrapped in response.Response, for work that finishes in the background
+func Accepted(v any) Result {
+	return Result{Status: http.StatusAccepted, envelope: dataEnvelope, data: v}
+}
+
+// List is a 200 response wrapped in response.ListResponse, without pagination
+func List(item any) Result {
+	return Result{Status: http.StatusOK, envelope: listEnvelope, data: item}
+}
+
+// Page is a 200 response wrapped in response.ListResponse with pagination
+func Page(item any) Result {
+	return Result{Status: http.StatusOK, envelope: pageEnvelope, data: item}
+}
+
+// NoContent is an empty 204 response
+func NoContent() Result {
+	return Result{Status: http.StatusNoContent, Description: "No Content"}
+}
+
+// Raw is a 200 JSON response without the envelope
+func Raw(v any) Result {
+	return Result{Status: http.StatusOK, data: v}
+}
+
+// Redirect is a redirect to the Location header
+func Redirect(status int, description string) Result {
+	return Result{Status: status, Description: description}
+}
+
+// HTML is a 200 HTML page
+func HTML(description string) Result {
+	return Result{Status: http.StatusOK, Description: description, ContentType: echo.MIMETextHTMLCharsetUTF8}
+}
+
+// File is a 200 file download in any of contentTypes, sent as an attachment
+func File(description string, contentTypes ...string) Result {
+	return Result{Status: http.StatusOK, Description: description, contentTypes: contentTypes}
+}
+
+// Security scheme names
+const (
+	schemeBearer = "bearerAuth"
+	schemeAPIKey = "apiKeyAuth"
+)
+
+var (
+	// commonErrors can be returned by every route
+	commonErrors = []string{"RATE_LIMIT_EXCEEDED", "INTERNAL_SERVER_ERROR", "SERVER_INTERNAL_ERROR"}
+	bearerErrors = []string{
+		"MISSING_TOKEN", "INVALID_TOKEN_FORMAT", "INVALID_TOKEN", "TOKEN_REVOKED", "SESSION_REVOKED",
+		"ACCOUNT_SUSPENDED", "ACCOUNT_INACTIVE", "ACCOUNT_PENDING", "ACCOUNT_DISABLED",
+	}
+	apiKeyErrors = []string{"INVALID_API_KEY", "API_KEY_EXPIRED", "API_KEY_REVOKED"}
+	// scopeErrors: access tokens a user granted to an OAuth client only reach userinfo
+	scopeErrors = []string{"INSUFFICIENT_SCOPE"}
+	adminErrors = []string{"FORBIDDEN", "IMPERSONATION_FORBIDDEN", "INSUFFICIENT_PERMISSION"}
+	bindErrors  = []string{"BIND_ERROR", "VALIDATION_FAILED"}
+)
+
+var tagDescriptions = map[string]string{
+	"Auth":          "Password and social login",
+	"Account":       "The signed-in user's own account",
+	"Users":         "User administration",
+	"Invitations":   "Inviting users to sign up",
+	"API keys":      "Service-to-service credentials",
+	"OAuth":         "OAuth 2.0 / OpenID Connect authorization server",
+	"OAuth clients": "Client applications of the authorization server",
+	"Audit":         "Tamper-evident audit log",
+	"Batch":         "Several requests in one round trip",
+	"Meta":          "Health and API documentation",
+}
+
+// Build documents every route; it fails if a route has no entry in Operations or an entry has no route
+func Build(routes []*echo.Route) (*openapi.Document, error) {
+	b := &builder{gen: openapi.NewGenerator(), operationIDs: map[string]string{}}
+	if err := b.defineEnvelopes(); err != nil {
+		return nil, err
+	}
+
+	spec := &openapi.Document{
+		OpenAPI: openapi.Version,
+		Info: openapi.Info{
+			Title:   "golang-echo API",
+			Version: "1.0.0",
+			Description: "Successful responses are wrapped in Response or ListResponse; errors use ErrorResponse, " +
+				"or RFC 9457 problem details when the request sends Accept: application/problem+json. " +
+				"Responses are negotiated on Accept: JSON by default, application/msgpack with the same structure, " +
+				"and application/x-ndjson (lists also text/csv) with the data only; request bodies can be MessagePack too. " +
+				"x-error-keys lists every error code an operation can return.",
+		},
+		Paths: map[string]*openapi.PathItem{},
+	}
+
+	// Sorted so generated component names don't depend on registration order
+	routes = append([]*echo.Route(nil), routes...)
+	sort.Slice(routes, func(i, j int) bool {
+		if routes[i].Path != routes[j].Path {
+			return routes[i].Path < routes[j].Path
+		}
+		return routes[i].Method < routes[j].Method
+	})
+
+	var errs []error
+	documented := map[string]bool{}
+	tags := map[string]bool{}
+	for _, route := range routes {
+		if !isHTTPMethod(route.Method) {
+			continue // Group.Use registers catch-all not-found routes
+		}
+		key := route.Method + " " + route.Path
+		entry, ok := Operations[key]
+		if !ok {
+			errs = append(errs, fmt.Errorf("route %s is not documented in apidoc.Operations", key))
+			continue
+		}
+		documented[key] = true
+
+		op, err := b.operation(route, entry)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", key, err))
+			continue
+		}
+		path := openapi.TemplatePath(route.Path)
+		if spec.Paths[path] == nil {
+			spec.Paths[path] = &openapi.PathItem{}
+		}
+		(*spec.Paths[path])[strings.ToLower(route.Method)] = op
+		tags[entry.Tag] = true
+	}
+	for key := range Operations {
+		if !documented[key] {
+			errs = append(errs, fmt.Errorf("apidoc.Operations documents %s, which is not registered", key))
+		}
+	}
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+
+	for _, name := range sortedKeys(tags) {
+		spec.Tags = append(spec.Tags, openapi.Tag{Name: name, Description: tagDescriptions[name]})
+	}
+	spec.Components = openapi.Components{
+		Schemas: b.gen.Components(),
+		SecuritySchemes: map[string]*openapi.SecurityScheme{
+			schemeBearer: {
+				Type:         "http",
+				Scheme:       "bearer",
+				BearerFormat: "JWT",
+				Description:  "Access token from login, OIDC login, impersonation or the OAuth token endpoint",
+			},
+			schemeAPIKey: {
+				Type: "apiKey",
+				In:   "header",
+				Name: middleware.HeaderXAPIKey,
+				Description: "API key (ak_...), also accepted as Authorization: ApiKey <key>. " +
+					"Security requirement roles list the permission the key needs",
+			},
+		},
+	}
+	return spec, nil
+}
+
+// Marshal encodes doc the way it is served and checked in
+func Marshal(doc *openapi.Document) ([]byte, error) {
+	data, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
+
+type builder struct {
+	gen          *openapi.Generator
+	operationIDs map[string]string
+}
+
+func (b *builder) defineEnvelopes() error {
+	envelopes := []struct {
+		name string
+		t    reflect.Type
+	}{
+		{"Response", reflect.TypeOf(response.Response[any]{})},
+		{"ListResponse", reflect.TypeOf(response.ListResponse[any]{})},
+		{"ErrorResponse", reflect.TypeOf(response.ErrorResponse{})},
+		{"ProblemDetails", reflect.TypeOf(response.ProblemDetails{})},
+		{"OAuthError", reflect.TypeOf(oauth.Error{})},
+	}
+	for _, envelope := range envelopes {
+		if _, err := b.gen.Define(envelope.name, envelope.t, openapi.ResponseMode); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (b *builder) operation(route *echo.Route, doc Operation) (*openapi.Operation, error) {
+	id := doc.ID
+	if id == "" {
+		id = handlerName(route.Name)
+		if id == "" {
+			return nil, fmt.Errorf("handler %s has no method name, set Operation.ID", route.Name)
+		}
+	}
+	if other, taken := b.operationIDs[id]; taken {
+		return nil, fmt.Errorf("operationId %q is also used by %s, set Operation.ID", id, other)
+	}
+	b.operationIDs[id] = route.Method + " " + route.Path
+
+	op := &openapi.Operation{
+		OperationID: id,
+		Summary:     doc.Summary,
+		Description: doc.Description,
+		Tags:        []string{doc.Tag},
+		Responses:   map[string]*openapi.Response{},
+	}
+	errorKeys := append([]string{}, commonErrors...)
+	errorKeys = append(errorKeys, doc.Errors...)
+
+	switch doc.Auth {
+	case Bearer:
+		op.Security = []openapi.SecurityRequirement{{schemeBearer: {}}}
+		errorKeys = append(errorKeys, bearerErrors...)
+	case User:
+		op.Security = []openapi.SecurityRequirement{{schemeBearer: {}}, {schemeAPIKey: {}}}
+		errorKeys = append(errorKeys, bearerErrors...)
+		errorKeys = append(errorKeys, apiKeyErrors...)
+		errorKeys = append(errorKeys, scopeErrors...)
+	case Admin:
+		if doc.Permission == "" {
+			return nil, errors.New("admin operations need a Permission")
+		}
+		op.Security = []openapi.SecurityRequirement{{schemeBearer: {}}, {schemeAPIKey: {doc.Permission}}}
+		errorKeys = append(errorKeys, bearerErrors...)
+		errorKeys = append(errorKeys, apiKeyErrors...)
+		errorKeys = append(errorKeys, scopeErrors...)
+		errorKeys = append(errorKeys, adminErrors...)
+		op.Description = joinSentences(op.Description, "Requires the admin role; API keys need the "+doc.Permission+" permission.")
+	}
+	if doc.DenyImpersonation {
+		errorKeys = append(errorKeys, "IMPERSONATION_FORBIDDEN")
+		op.Description = joinSentences(op.Description, "Not allowed with an impersonation token.")
+	}
+
+	for _, name := range pathParams(route.Path) {
+		schema := &openapi.Schema{Type: "string"}
+		if name == "id" {
+			schema = &openapi.Schema{Type: "integer", Format: "int32"}
+			errorKeys = append(errorKeys, "INVALID_ID")
+		}
+		errorKeys = append(errorKeys, "VALIDATION_FAILED") // from the optional request validation
+		op.Parameters = append(op.Parameters, &openapi.Parameter{Name: name, In: "path", Required: true, Schema: schema})
+	}
+	if doc.Query != nil {
+		params, err := b.gen.Parameters(reflect.TypeOf(doc.Query), "query", "query")
+		if err != nil {
+			return nil, err
+		}
+		op.Parameters = append(op.Parameters, params...)
+		errorKeys = append(errorKeys, bindErrors...)
+	}
+	if doc.Body != nil {
+		schema, err := b.gen.Schema(reflect.TypeOf(doc.Body), openapi.RequestMode)
+		if err != nil {
+			return nil, err
+		}
+		op.RequestBody = &openapi.RequestBody{
+			Required: true,
+			Content: map[string]openapi.MediaType{
+				echo.MIMEApplicationJSON:        {Schema: schema},
+				response.MIMEApplicationMsgpack: {Schema: schema},
+			},
+		}
+		errorKeys = append(errorKeys, bindErrors...)
+	}
+	if doc.Form != nil {
+		schema, err := b.gen.Object(reflect.TypeOf(doc.Form), "form")
+		if err != nil {
+			return nil, err
+		}
+		op.RequestBody = &openapi.RequestBody{
+			Required: true,
+			Content:  map[string]openapi.MediaType{echo.MIMEApplicationForm: {Schema: schema}},
+		}
+	}
+
+	if doc.Upload != nil {
+		op.RequestBody = &openapi.RequestBody{Required: true, Content: map[string]openapi.MediaType{}}
+		for _, contentType := range doc.Upload {
+			op.RequestBody.Content[contentType] = openapi.MediaType{Schema: &openapi.Schema{Type: "string"}}
+		}
+	}
+
+	if doc.IfMatch {
+		op.Parameters = append(op.Parameters, &openapi.Parameter{
+			Name: response.HeaderIfMatch, In: "header", Required: true,
+			Description: "The ETag the resource was read with, or * to update whatever version is current",
+			Schema:      &openapi.Schema{Type: "string"},
+		})
+		errorKeys = append(errorKeys, "PRECONDITION_REQUIRED", "PRECONDITION_FAILED")
+	}
+
+	if doc.Idempotent {
+		op.Parameters = append(op.Parameters, &openapi.Parameter{
+			Name: middleware.HeaderIdempotencyKey, In: "header",
+			Description: "A unique key of up to 255 printable ASCII characters, e.g. a UUID, that makes the request safe to retry: a retry with the same key and body gets the first response again",
+			Schema:      &openapi.Schema{Type: "string"},
+		})
+		errorKeys = append(errorKeys, "INVALID_IDEMPOTENCY_KEY", "IDEMPOTENCY_BODY_TOO_LARGE", "IDEMPOTENCY_KEY_IN_USE", "IDEMPOTENCY_KEY_REUSED")
+	}
+
+	if doc.Fields != nil {
+		op.Parameters = append(op.Parameters, &openapi.Parameter{
+			Name: "fields", In: "query",
+			Description: "Comma-separated fields to return instead of the whole resource, any of: " + strings.Join(doc.Fields, ", "),
+			Schema:      &openapi.Schema{Type: "string"},
+		})
+		errorKeys = append(errorKeys, "VALIDATION_FAILED")
+	}
+
+	success, err := b.result(doc.Result, doc.Fields)
+	if err != nil {
+		return nil, err
+	}
+	op.Responses[strconv.Itoa(doc.Result.Status)] = success
+	if tagged(doc.Result) {
+		success.Headers = map[string]*openapi.Header{response.HeaderETag: etagHeader}
+		if route.Method == http.MethodGet {
+			op.Parameters = append(op.Parameters, &openapi.Parameter{
+				Name: response.HeaderIfNoneMatch, In: "header",
+				Description: "ETags the client has cached; a match is answered with 304",
+				Schema:      &openapi.Schema{Type: "string"},
+			})
+			op.Responses[strconv.Itoa(http.StatusNotModified)] = &openapi.Response{
+				Description: http.StatusText(http.StatusNotModified),
+				Headers:     map[string]*openapi.Header{response.HeaderETag: etagHeader},
+			}
+		}
+	}
+
+	if doc.Idempotent {
+		if success.Headers == nil {
+			success.Headers = map[string]*openapi.Header{}
+		}
+		success.Headers[middleware.HeaderIdempotentReplayed] = &openapi.Header{
+			Description: "true when the response is the one kept for an earlier request with the same Idempotency-Key",
+			Schema:      &openapi.Schema{Type: "string"},
+		}
+	}
+
+	op.ErrorKeys = dedupe(errorKeys)
+	if err := b.errorResponses(op, doc.OAuthErrors); err != nil {
+		return nil, err
+	}
+	return op, nil
+}
+
+func (b *builder) result(result Result, fields []string) (*openapi.Response, error) {
+	resp := &openapi.Response{Description: result.Description}
+	if resp.Description == "" {
+		resp.Description = http.StatusText(result.Status)
+	}
+	if result.Status >= 300 && result.Status < 400 {
+		resp.Headers = map[string]*openapi.Header{
+			"Location": {Description: "Where the user agent is sent", Schema: &openapi.Schema{Type: "string", Format: "uri"}},
+		}
+		return resp, nil
+	}
+	if result.ContentType != "" {
+		resp.Content = map[string]openapi.MediaType{result.ContentType: {Schema: &openapi.Schema{Type: "string"}}}
+		return resp, nil
+	}
+	if result.contentTypes != nil {
+		resp.Content = map[string]openapi.MediaType{}
+		for _, contentType := range result.contentTypes {
+			resp.Content[contentType] = openapi.MediaType{Schema: &openapi.Schema{Type: "string"}}
+		}
+		resp.Headers = map[string]*openapi.Header{
+			echo.HeaderContentDisposition: {Description: "attachment, with the file name", Schema: &openapi.Schema{Type: "string"}},
+		}
+		return resp, nil
+	}
+	if result.data == nil {
+		return resp, nil
+	}
+
+	data, err := b.gen.Schema(reflect.TypeOf(result.data), openapi.ResponseMode)
+	if err != nil {
+		return nil, err
+	}
+	if fields != nil {
+		sparse, err := b.sparse(reflect.TypeOf(result.data), fields)
+		if err != nil {
+			return nil, err
+		}
+		data = &openapi.Schema{AnyOf: []*openapi.Schema{data, sparse}}
+	}
+	// The envelopes are negotiated: MessagePack carries the same structure, NDJSON and CSV the bare data
+	switch result.envelope {
+	case noEnvelope:
+		resp.Content = map[string]openapi.MediaType{echo.MIMEApplicationJSON: {Schema: data}}
+	case dataEnvelope:
+		schema := &openapi.Schema{AllOf: []*openapi.Schema{
+			openapi.Ref("Response"),
+			{Type: "object", Properties: map[string]*openapi.Schema{"data": data}},
+		}}
+		resp.Content = map[string]openapi.MediaType{
+			echo.MIMEApplicationJSON:        {Schema: schema},
+			response.MIMEApplicationMsgpack: {Schema: schema},
+			response.MIMEApplicationNDJSON:  {Schema: data},
+		}
+	case listEnvelope, pageEnvelope:
+		page := &openapi.Schema{Type: "object", Properties: map[string]*openapi.Schema{
+			"data": {Type: "array", Items: data},
+		}}
+		if result.envelope == pageEnvelope {
+			page.Required = []string{"pagination"}
+			resp.Headers = paginationHeaders
+		}
+		schema := &openapi.Schema{AllOf: []*openapi.Schema{openapi.Ref("ListResponse"), page}}
+		resp.Content = map[string]openapi.MediaType{
+			echo.MIMEApplicationJSON:        {Schema: schema},
+			response.MIMEApplicationMsgpack: {Schema: schema},
+			response.MIMEApplicationNDJSON:  {Schema: data},
+			response.MIMETextCSV:            {Schema: &openapi.Schema{Type: "string", Description: "A header row of the field names, then a row per item"}},
+		}
+	}
+	return resp, nil
+}
+
+// sparse is the schema of t limited to fields, none of them required
+func (b *builder) sparse(t reflect.Type, fields []string) (*openapi.Schema, error) {
+	object, err := b.gen.Object(t, "json")
+	if err != nil {
+		return nil, err
+	}
+	sparse := &openapi.Schema{Type: "object", Description: "Only the fields selected with the fields parameter", Properties: map[string]*openapi.Schema{}}
+	for _, name := range fields {
+		property, ok := object.Properties[name]
+		if !ok {
+			return nil, fmt.Errorf("selectable field %s is not a field of %s", name, t)
+		}
+		sparse.Properties[name] = property
+	}
+	return sparse, nil
+}
+
+// paginationHeaders carry the pagination of CSV and NDJSON lists, which have no envelope
+var paginationHeaders = map[string]*openapi.Header{
+	response.HeaderTotalCount: {Description: "Total items (CSV and NDJSON only)", Schema: &openapi.Schema{Type: "integer"}},
+	response.HeaderPage:       {Description: "Page number (CSV and NDJSON only)", Schema: &openapi.Schema{Type: "integer"}},
+	response.HeaderPageSize:   {Description: "Page size (CSV and NDJSON only)", Schema: &openapi.Schema{Type: "integer"}},
+	response.HeaderTotalPages: {Description: "Total pages (CSV and NDJSON only)", Schema: &openapi.Schema{Type: "integer"}},
+}
+
+var etagHeader = &openapi.Header{Description: "Strong entity tag of the resource's current version", Schema: &openapi.Schema{Type: "string"}}
+
+// tagged reports whether response.Success sends an ETag with the result
+func tagged(result Result) bool {
+	if result.envelope != dataEnvelope || result.Status != http.StatusOK || result.data == nil {
+		return false
+	}
+	return reflect.PointerTo(reflect.TypeOf(result.data)).Implements(reflect.TypeOf((*response.Tagged)(nil)).Elem())
+}
+
+// errorResponses adds one response per error status, restricting code to the keys returned with it
+func (b *builder) errorResponses(op *openapi.Operation, oauthErrors bool) error {
+	byStatus := map[int][]string{}
+	for _, key := range op.ErrorKeys {
+		status, ok := ErrorStatus[key]
+		if !ok {
+			return fmt.Errorf("error key %s has no status in apidoc.ErrorStatus", key)
+		}
+		byStatus[status] = append(byStatus[status], key)
+	}
+	for status, keys := range byStatus {
+		codes := make([]any, len(keys))
+		for i, key := range keys {
+			codes[i] = key
+		}
+		code := &openapi.Schema{Type: "object", Properties: map[string]*openapi.Schema{"code": {Type: "string", Enum: codes}}}
+		op.Responses[strconv.Itoa(status)] = &openapi.Response{
+			Description: http.StatusText(status) + ": " + strings.Join(keys, ", "),
+			Content: map[string]openapi.MediaType{
+				echo.MIMEApplicationJSON:            {Schema: &openapi.Schema{AllOf: []*openapi.Schema{openapi.Ref("ErrorResponse"), code}}},
+				response.MIMEApplicationMsgpack:     {Schema: &openapi.Schema{AllOf: []*openapi.Schema{openapi.Ref("ErrorResponse"), code}}},
+				response.MIMEApplicationProblemJSON: {Schema: &openapi.Schema{AllOf: []*openapi.Schema{openapi.Ref("ProblemDetails"), code}}},
+			},
+		}
+	}
+	if oauthErrors {
+		for _, status := range []int{http.StatusBadRequest, http.StatusUnauthorized} {
+			op.Responses[strconv.Itoa(status)] = &openapi.Response{
+				Description: http.StatusText(status) + ": OAuth error (RFC 6749 section 5.2)",
+				Content:     map[string]openapi.MediaType{echo.MIMEApplicationJSON: {Schema: openapi.Ref("OAuthError")}},
+			}
+		}
+	}
+	return nil
+}
+
+// handlerName extracts the method name from a handler's function name,
+// e.g. golang-echo/internal/handler.IUserHandler.CreateUser-fm → createUser
+func handlerName(funcName string) string {
+	name, isMethod := strings.CutSuffix(funcName, "-fm")
+	if !isMethod {
+		return "" // a closure or plain function
+	}
+	name = name[strings.LastIndex(name, ".")+1:]
+	return strings.ToLower(name[:1]) + name[1:]
+}
+
+func pathParams(path string) []string {
+	var names []string
+	for _, segment := range strings.Split(path, "/") {
+		if strings.HasPrefix(segment, ":") {
+			names = append(names, segment[1:])
+		}
+	}
+	return names
+}
+
+func isHTTPMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch,
+		http.MethodDelete, http.MethodOptions, http.MethodConnect, http.MethodTrace:
+		return true
+	}
+	return false
+}
+
+func joinSentences(a, b string) string {
+	if a == "" {
+		return b
+	}
+	return a + " " + b
+}
+
+func dedupe(keys []string) []string {
+	seen := map[string]bool{}
+	unique := keys[:0:0]
+	for _, key := range keys {
+		if !seen[key] {
+			seen[key] = true
+			unique = append(unique, key)
+		}
+	}
+	sort.Strings(unique)
+	return unique
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,75 @@
+package apidoc
+
+import "net/http"
+
+// ErrorStatus is the HTTP status each AppError key is returned with
+// Every key an Operation can return must be listed here
+var ErrorStatus = map[string]int{
+	"BIND_ERROR":                  http.StatusBadRequest,
+	"VALIDATION_FAILED":           http.StatusBadRequest,
+	"INVALID_ID":                  http.StatusBadRequest,
+	"INVALID_PHONE":               http.StatusBadRequest,
+	"INVALID_IMPORT_FILE":         http.StatusBadRequest,
+	"INVALID_IDEMPOTENCY_KEY":     http.StatusBadRequest,
+	"BATCH_NESTED":                http.StatusBadRequest,
+	"BATCH_TOO_LARGE":             http.StatusBadRequest,
+	"CANNOT_CHANGE_OWN_STATUS":    http.StatusBadRequest,
+	"CANNOT_IMPERSONATE_SELF":     http.StatusBadRequest,
+	"USER_NOT_ACTIVE":             http.StatusBadRequest,
+	"INVITATION_EXPIRED":          http.StatusBadRequest,
+	"INVITATION_REVOKED":          http.StatusBadRequest,
+	"OAUTH_INVALID_CLIENT":        http.StatusBadRequest,
+	"OAUTH_INVALID_REDIRECT_URI":  http.StatusBadRequest,
+	"OIDC_INVALID_STATE":          http.StatusBadRequest,
+	"OIDC_MISSING_CODE":           http.StatusBadRequest,
+	"MISSING_TOKEN":               http.StatusUnauthorized,
+	"INVALID_TOKEN_FORMAT":        http.StatusUnauthorized,
+	"INVALID_TOKEN":               http.StatusUnauthorized,
+	"TOKEN_REVOKED":               http.StatusUnauthorized,
+	"SESSION_REVOKED":             http.StatusUnauthorized,
+	"INVALID_CONTEXT":             http.StatusUnauthorized,
+	"INVALID_CREDENTIALS":         http.StatusUnauthorized,
+	"INVALID_API_KEY":             http.StatusUnauthorized,
+	"API_KEY_EXPIRED":             http.StatusUnauthorized,
+	"API_KEY_REVOKED":             http.StatusUnauthorized,
+	"OIDC_EXCHANGE_FAILED":        http.StatusUnauthorized,
+	"OIDC_LOGIN_DENIED":           http.StatusUnauthorized,
+	"FORBIDDEN":                   http.StatusForbidden,
+	"INSUFFICIENT_PERMISSION":     http.StatusForbidden,
+	"INSUFFICIENT_SCOPE":          http.StatusForbidden,
+	"IMPERSONATION_FORBIDDEN":     http.StatusForbidden,
+	"CANNOT_IMPERSONATE_ADMIN":    http.StatusForbidden,
+	"ACCOUNT_SUSPENDED":           http.StatusForbidden,
+	"ACCOUNT_INACTIVE":            http.StatusForbidden,
+	"ACCOUNT_PENDING":             http.StatusForbidden,
+	"ACCOUNT_DISABLED":            http.StatusForbidden,
+	"OIDC_EMAIL_NOT_VERIFIED":     http.StatusForbidden,
+	"USER_NOT_FOUND":              http.StatusNotFound,
+	"SESSION_NOT_FOUND":           http.StatusNotFound,
+	"API_KEY_NOT_FOUND":           http.StatusNotFound,
+	"OAUTH_CLIENT_NOT_FOUND":      http.StatusNotFound,
+	"CONSENT_NOT_FOUND":           http.StatusNotFound,
+	"INVITATION_NOT_FOUND":        http.StatusNotFound,
+	"OIDC_PROVIDER_NOT_FOUND":     http.StatusNotFound,
+	"IMPORT_JOB_NOT_FOUND":        http.StatusNotFound,
+	"EXPORT_JOB_NOT_FOUND":        http.StatusNotFound,
+	"EMAIL_ALREADY_REGISTERED":    http.StatusConflict,
+	"PHONE_ALREADY_REGISTERED":    http.StatusConflict,
+	"INVITATION_ALREADY_PENDING":  http.StatusConflict,
+	"INVITATION_ALREADY_ACCEPTED": http.StatusConflict,
+	"INVITATION_CLOSED":           http.StatusConflict,
+	"EXPORT_NOT_READY":            http.StatusConflict,
+	"IDEMPOTENCY_KEY_IN_USE":      http.StatusConflict,
+	"EXPORT_EXPIRED":              http.StatusGone,
+	"PRECONDITION_FAILED":         http.StatusPreconditionFailed,
+	"PRECONDITION_REQUIRED":       http.StatusPreconditionRequired,
+	"IMPORT_TOO_LARGE":            http.StatusRequestEntityTooLarge,
+	"IDEMPOTENCY_BODY_TOO_LARGE":  http.StatusRequestEntityTooLarge,
+	"UNSUPPORTED_IMPORT_FORMAT":   http.StatusUnsupportedMediaType,
+	"IDEMPOTENCY_KEY_REUSED":      http.StatusUnprocessableEntity,
+	"RATE_LIMIT_EXCEEDED":         http.StatusTooManyRequests,
+	"INTERNAL_SERVER_ERROR":       http.StatusInternalServerError,
+	"SERVER_INTERNAL_ERROR":       http.StatusInternalServerError,
+	"MAIL_DELIVERY_FAILED":        http.StatusBadGateway,
+	"OIDC_PROVIDER_UNAVAILABLE":   http.StatusBadGateway,
+}
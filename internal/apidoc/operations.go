@@ -0,0 +1,384 @@
+package apidoc
+
+import (
+	"golang-echo/internal/model"
+	"golang-echo/pkg/constants"
+	"golang-echo/pkg/oauth"
+	"golang-echo/pkg/oidc"
+	"golang-echo/pkg/request"
+	"golang-echo/pkg/response"
+	"net/http"
+)
+
+// Query parameters and bodies read with c.QueryParam/c.FormValue rather than bound to a struct
+type (
+	emailQuery struct {
+		Email string `query:"email" validate:"required,email"`
+	}
+	phoneQuery struct {
+		Phone string `query:"phone" validate:"required"`
+	}
+	oidcCallbackQuery struct {
+		Code             string `query:"code"`
+		State            string `query:"state"`
+		Error            string `query:"error"`
+		ErrorDescription string `query:"error_description"`
+	}
+	authorizeDecisionForm struct {
+		model.AuthorizeRequest
+		Action   string `form:"action" validate:"required,oneof=approve deny switch_account"`
+		Email    string `form:"email"`    // when not signed in yet
+		Password string `form:"password"` // when not signed in yet
+	}
+	revokeTokenForm struct {
+		Token        string `form:"token" validate:"required"`
+		ClientID     string `form:"client_id"`
+		ClientSecret string `form:"client_secret"`
+	}
+)
+
+// Operations documents every route, keyed by method and Echo path
+var Operations = map[string]Operation{
+	// Meta
+	"GET /health": {
+		ID: "health", Summary: "Health check", Tag: "Meta",
+		Result: Raw(struct {
+			Status string `json:"status"`
+		}{}),
+	},
+	"GET /openapi.json": {
+		ID: "openAPI", Summary: "This OpenAPI document", Tag: "Meta",
+		Result: Raw(map[string]any{}),
+	},
+	"GET /docs": {
+		ID: "docs", Summary: "Interactive API documentation", Tag: "Meta",
+		Result: HTML("API reference UI for /openapi.json"),
+	},
+
+	// OAuth 2.0 / OpenID Connect authorization server
+	"GET " + oauth.PathDiscovery: {
+		Summary: "OpenID Provider metadata", Tag: "OAuth",
+		Result: Raw(oauth.ServerMetadata{}),
+	},
+	"GET " + oauth.PathJWKS: {
+		Summary: "Keys that sign ID tokens", Tag: "OAuth",
+		Result: Raw(oidc.JSONWebKeySet{}),
+	},
+	"GET " + oauth.PathAuthorize: {
+		Summary:     "Authorization endpoint",
+		Description: "Redirects back to the client when the user is signed in and already consented, otherwise shows the login and consent page. Protocol errors are redirected to the client's redirect_uri.",
+		Tag:         "OAuth",
+		Query:       model.AuthorizeRequest{},
+		Result:      HTML("Login and consent page (302 to the client when consent was already given)"),
+		Errors:      []string{"OAUTH_INVALID_CLIENT", "OAUTH_INVALID_REDIRECT_URI"},
+	},
+	"POST " + oauth.PathAuthorize: {
+		Summary: "Submit the login and consent form", Tag: "OAuth",
+		Form:   authorizeDecisionForm{},
+		Result: Redirect(http.StatusSeeOther, "Back to the client's redirect_uri with a code or an error"),
+		Errors: []string{"BIND_ERROR", "OAUTH_INVALID_CLIENT", "OAUTH_INVALID_REDIRECT_URI", "ACCOUNT_SUSPENDED", "ACCOUNT_INACTIVE", "ACCOUNT_PENDING", "ACCOUNT_DISABLED"},
+	},
+	"POST " + oauth.PathToken: {
+		Summary:     "Token endpoint",
+		Description: "authorization_code (with PKCE), refresh_token and client_credentials grants. Client credentials may be sent with HTTP Basic auth instead of the form.",
+		Tag:         "OAuth",
+		Form:        model.TokenRequest{},
+		Result:      Raw(model.TokenResponse{}),
+		OAuthErrors: true,
+	},
+	"POST " + oauth.PathRevoke: {
+		Summary: "Revoke a refresh token (RFC 7009)", Tag: "OAuth",
+		Form:        revokeTokenForm{},
+		Result:      Result{Status: http.StatusOK, Description: "Revoked, or the token was unknown"},
+		OAuthErrors: true,
+	},
+	"GET " + oauth.PathUserInfo: {
+		Summary: "OpenID Connect userinfo", Tag: "OAuth", Auth: Bearer,
+		Result: Raw(model.UserInfo{}),
+		Errors: []string{"INVALID_CONTEXT", "INSUFFICIENT_SCOPE", "USER_NOT_FOUND"},
+	},
+	"POST " + oauth.PathUserInfo: {
+		ID: "userInfoPost", Summary: "OpenID Connect userinfo", Tag: "OAuth", Auth: Bearer,
+		Result: Raw(model.UserInfo{}),
+		Errors: []string{"INVALID_CONTEXT", "INSUFFICIENT_SCOPE", "USER_NOT_FOUND"},
+	},
+
+	// Public
+	"POST /api/v1/users": {
+		Summary: "Register", Tag: "Auth",
+		Body:       model.CreateUserRequest{},
+		Idempotent: true,
+		Result:     Created(model.User{}),
+		Errors:     []string{"INVALID_PHONE", "EMAIL_ALREADY_REGISTERED", "PHONE_ALREADY_REGISTERED"},
+	},
+	"POST /api/v1/users/login": {
+		Summary: "Log in with email and password", Tag: "Auth",
+		Body:   model.LoginRequest{},
+		Result: Data(model.LoginResponse{}),
+		Errors: []string{"INVALID_CREDENTIALS", "ACCOUNT_SUSPENDED", "ACCOUNT_INACTIVE", "ACCOUNT_PENDING", "ACCOUNT_DISABLED"},
+	},
+	"POST /api/v1/invitations/accept": {
+		Summary:     "Accept an invitation",
+		Description: "Creates the invited account with the invitee's password; the token comes from the invitation email.",
+		Tag:         "Invitations",
+		Body:        model.AcceptInvitationRequest{},
+		Result:      Created(model.User{}),
+		Errors: []string{
+			"INVITATION_NOT_FOUND", "INVITATION_EXPIRED", "INVITATION_REVOKED", "INVITATION_ALREADY_ACCEPTED",
+			"INVITATION_CLOSED", "INVALID_PHONE", "EMAIL_ALREADY_REGISTERED", "PHONE_ALREADY_REGISTERED",
+		},
+	},
+	"GET /api/v1/auth/oidc/:provider/start": {
+		Summary: "Start social login", Tag: "Auth",
+		Result: Redirect(http.StatusFound, "To the identity provider"),
+		Errors: []string{"OIDC_PROVIDER_NOT_FOUND", "OIDC_PROVIDER_UNAVAILABLE"},
+	},
+	"GET /api/v1/auth/oidc/:provider/callback": {
+		Summary: "Complete social login", Tag: "Auth",
+		Query:  oidcCallbackQuery{},
+		Result: Data(model.LoginResponse{}),
+		Errors: []string{
+			"OIDC_LOGIN_DENIED", "OIDC_INVALID_STATE", "OIDC_MISSING_CODE", "OIDC_PROVIDER_NOT_FOUND", "OIDC_EXCHANGE_FAILED",
+			"OIDC_EMAIL_NOT_VERIFIED", "ACCOUNT_SUSPENDED", "ACCOUNT_INACTIVE", "ACCOUNT_PENDING", "ACCOUNT_DISABLED",
+		},
+	},
+
+	// Signed-in user
+	"GET /api/v1/my-info": {
+		Summary: "Current user", Tag: "Account", Auth: User,
+		Fields: model.SelectableUserFields,
+		Result: Data(model.User{}),
+		Errors: []string{"INVALID_CONTEXT", "USER_NOT_FOUND"},
+	},
+	"PUT /api/v1/my-info/password": {
+		Summary:     "Change password",
+		Description: "Signs out every session, including the current one.",
+		Tag:         "Account", Auth: User, DenyImpersonation: true,
+		Body:   model.ChangePasswordRequest{},
+		Result: NoContent(),
+		Errors: []string{"INVALID_CONTEXT", "USER_NOT_FOUND"},
+	},
+	"GET /api/v1/my-info/sessions": {
+		Summary: "List my sessions", Tag: "Account", Auth: User,
+		Result: List(model.UserSession{}),
+		Errors: []string{"INVALID_CONTEXT"},
+	},
+	"DELETE /api/v1/my-info/sessions": {
+		Summary: "Sign out of all other sessions", Tag: "Account", Auth: User, DenyImpersonation: true,
+		Result: Data(model.RevokeSessionsResponse{}),
+		Errors: []string{"INVALID_CONTEXT"},
+	},
+	"DELETE /api/v1/my-info/sessions/:id": {
+		Summary: "Sign out a session", Tag: "Account", Auth: User, DenyImpersonation: true,
+		Result: NoContent(),
+		Errors: []string{"INVALID_CONTEXT", "SESSION_NOT_FOUND"},
+	},
+	"GET /api/v1/my-info/consents": {
+		Summary: "List applications I authorized", Tag: "Account", Auth: User,
+		Result: List(model.OAuthConsent{}),
+		Errors: []string{"INVALID_CONTEXT"},
+	},
+	"DELETE /api/v1/my-info/consents/:client_id": {
+		Summary: "Revoke an application's access", Tag: "Account", Auth: User, DenyImpersonation: true,
+		Result: NoContent(),
+		Errors: []string{"INVALID_CONTEXT", "CONSENT_NOT_FOUND"},
+	},
+
+	// Batch
+	"POST /api/v1/batch": {
+		Summary: "Send several requests at once",
+		Description: "Runs each request as if it had been sent on its own with the batch's credentials, in order or, with parallel, " +
+			"a few at a time, and returns their statuses, ETag and Location headers and JSON bodies in order. " +
+			"Every request is authorised and rate limited on its own; a failed one doesn't stop the others. " +
+			"A response that isn't JSON, or is over 1 MiB, is replaced with a 502 BATCH_UNSUPPORTED_RESPONSE. Batches can't be nested.",
+		Tag: "Batch", Auth: User,
+		Body:   model.BatchRequest{},
+		Result: Data([]model.BatchResult{}),
+		Errors: []string{"BATCH_NESTED", "BATCH_TOO_LARGE"},
+	},
+
+	// Users (admin)
+	"GET /api/v1/users": {
+		Summary: "List users", Tag: "Users", Auth: Admin, Permission: constants.PermissionUsersRead,
+		Query:  request.PaginationReq{},
+		Fields: model.SelectableUserFields,
+		Result: Page(model.User{}),
+	},
+	"GET /api/v1/users/:id": {
+		Summary: "Get a user", Tag: "Users", Auth: Admin, Permission: constants.PermissionUsersRead,
+		Fields: model.SelectableUserFields,
+		Result: Data(model.User{}),
+		Errors: []string{"USER_NOT_FOUND"},
+	},
+	"GET /api/v1/users/by-email": {
+		Summary: "Find a user by email", Tag: "Users", Auth: Admin, Permission: constants.PermissionUsersRead,
+		Query:  emailQuery{},
+		Fields: model.SelectableUserFields,
+		Result: Data(model.User{}),
+		Errors: []string{"USER_NOT_FOUND"},
+	},
+	"GET /api/v1/users/by-phone": {
+		Summary: "Find a user by phone number", Tag: "Users", Auth: Admin, Permission: constants.PermissionUsersRead,
+		Query:  phoneQuery{},
+		Fields: model.SelectableUserFields,
+		Result: Data(model.User{}),
+		Errors: []string{"INVALID_PHONE", "USER_NOT_FOUND"},
+	},
+	"GET /api/v1/users/:id/sessions": {
+		Summary: "List a user's sessions", Tag: "Users", Auth: Admin, Permission: constants.PermissionUsersRead,
+		Result: List(model.UserSession{}),
+	},
+	"PUT /api/v1/users/:id/role": {
+		Summary:     "Change a user's role",
+		Description: "Revokes the user's existing tokens. Send the user's ETag in If-Match; 412 means it changed since.",
+		Tag:         "Users", Auth: Admin, Permission: constants.PermissionUsersWrite,
+		IfMatch: true,
+		Body:    model.UpdateRoleRequest{},
+		Result:  Data(model.User{}),
+		Errors:  []string{"USER_NOT_FOUND"},
+	},
+	"PUT /api/v1/users/:id/status": {
+		Summary:     "Change a user's account status",
+		Description: "Leaving active revokes the user's existing tokens. Send the user's ETag in If-Match; 412 means it changed since.",
+		Tag:         "Users", Auth: Admin, Permission: constants.PermissionUsersWrite,
+		IfMatch: true,
+		Body:    model.UpdateUserStatusRequest{},
+		Result:  Data(model.User{}),
+		Errors:  []string{"USER_NOT_FOUND", "CANNOT_CHANGE_OWN_STATUS"},
+	},
+	"GET /api/v1/users/:id/status-changes": {
+		Summary: "A user's status history", Tag: "Users", Auth: Admin, Permission: constants.PermissionUsersRead,
+		Result: List(model.UserStatusChange{}),
+	},
+	"POST /api/v1/admin/users/:id/impersonate": {
+		Summary:     "Impersonate a user",
+		Description: "Issues a short-lived token for the user carrying the admin in its act claim; every request made with it is audited.",
+		Tag:         "Users", Auth: Admin, Permission: constants.PermissionUsersImpersonate,
+		Body:   model.ImpersonateRequest{},
+		Result: Created(model.ImpersonationResponse{}),
+		Errors: []string{"USER_NOT_FOUND", "CANNOT_IMPERSONATE_SELF", "CANNOT_IMPERSONATE_ADMIN", "USER_NOT_ACTIVE"},
+	},
+	"GET /api/v1/admin/users/export": {
+		Summary:     "Export users",
+		Description: "Streams every user from one consistent snapshot, as CSV (the default) or NDJSON picked by format or else by Accept. A failure after the first rows aborts the connection, so an incomplete file is never mistaken for a complete one.",
+		Tag:         "Users", Auth: Admin, Permission: constants.PermissionUsersExport,
+		Query:  model.UserExportQuery{},
+		Fields: model.SelectableUserFields,
+		Result: File("The users, a CSV row or NDJSON line each", response.MIMETextCSV, response.MIMEApplicationNDJSON),
+	},
+	"POST /api/v1/admin/users/export-jobs": {
+		Summary:     "Start a user export job",
+		Description: "Writes the export to a file in the background. Poll the job, at the Location header, until it has a download_url.",
+		Tag:         "Users", Auth: Admin, Permission: constants.PermissionUsersExport,
+		Body:       model.CreateUserExportRequest{},
+		Idempotent: true,
+		Result:     Accepted(model.Job{}),
+	},
+	"GET /api/v1/admin/users/export-jobs/:id": {
+		Summary: "Get a user export job", Tag: "Users", Auth: Admin, Permission: constants.PermissionUsersExport,
+		Result: Data(model.Job{}),
+		Errors: []string{"EXPORT_JOB_NOT_FOUND"},
+	},
+	"GET /api/v1/admin/users/export-jobs/:id/download": {
+		Summary:     "Download a user export",
+		Description: "The file of a succeeded export job, until the job's expires_at.",
+		Tag:         "Users", Auth: Admin, Permission: constants.PermissionUsersExport,
+		Result: File("The exported users", response.MIMETextCSV, response.MIMEApplicationNDJSON),
+		Errors: []string{"EXPORT_JOB_NOT_FOUND", "EXPORT_NOT_READY", "EXPORT_EXPIRED"},
+	},
+	"POST /api/v1/admin/users/import": {
+		Summary: "Import users",
+		Description: "Starts importing the CSV or NDJSON file in the body, with the columns name, email, phone, role and password; a CSV file names them in its header row. " +
+			"Rows are validated like sign-ups and invalid ones are reported, not imported. on_conflict decides what a registered email does: skip, update the user, or fail (the default), which imports nothing. " +
+			"The whole file is imported in one transaction. Poll the job, at the Location header, for its progress and the report; a dry run only produces the report.",
+		Tag: "Users", Auth: Admin, Permission: constants.PermissionUsersImport,
+		Query:  model.ImportUsersQuery{},
+		Upload: []string{response.MIMETextCSV, response.MIMEApplicationNDJSON},
+		Result: Accepted(model.Job{}),
+		Errors: []string{"UNSUPPORTED_IMPORT_FORMAT", "IMPORT_TOO_LARGE", "INVALID_IMPORT_FILE"},
+	},
+	"GET /api/v1/admin/users/import-jobs/:id": {
+		Summary: "Get a user import job", Tag: "Users", Auth: Admin, Permission: constants.PermissionUsersImport,
+		Result: Data(model.Job{}),
+		Errors: []string{"IMPORT_JOB_NOT_FOUND"},
+	},
+
+	// Audit log (admin)
+	"GET /api/v1/admin/audit-events": {
+		Summary: "Search audit events", Tag: "Audit", Auth: Admin, Permission: constants.PermissionAuditRead,
+		Query:  model.AuditEventQuery{},
+		Result: Page(model.AuditEvent{}),
+	},
+	"GET /api/v1/admin/audit-events/verify": {
+		Summary: "Verify the audit hash chain", Tag: "Audit", Auth: Admin, Permission: constants.PermissionAuditRead,
+		Result: Data(model.AuditChainReport{}),
+	},
+
+	// Invitations (admin)
+	"POST /api/v1/admin/invitations": {
+		Summary:     "Invite a user",
+		Description: "The invitation is created even if the email can't be sent; email_sent reports delivery.",
+		Tag:         "Invitations", Auth: Admin, Permission: constants.PermissionInvitationsManage,
+		Body:       model.CreateInvitationRequest{},
+		Idempotent: true,
+		Result:     Created(model.InvitationResponse{}),
+		Errors:     []string{"EMAIL_ALREADY_REGISTERED", "INVITATION_ALREADY_PENDING"},
+	},
+	"GET /api/v1/admin/invitations": {
+		Summary: "List invitations", Tag: "Invitations", Auth: Admin, Permission: constants.PermissionInvitationsManage,
+		Query:  model.InvitationQuery{},
+		Result: Page(model.UserInvitation{}),
+	},
+	"POST /api/v1/admin/invitations/:id/resend": {
+		Summary:     "Resend an invitation",
+		Description: "Issues a new link, invalidating the previous one, and resets the expiry.",
+		Tag:         "Invitations", Auth: Admin, Permission: constants.PermissionInvitationsManage,
+		Result: Data(model.InvitationResponse{}),
+		Errors: []string{"INVITATION_NOT_FOUND", "INVITATION_CLOSED", "MAIL_DELIVERY_FAILED"},
+	},
+	"DELETE /api/v1/admin/invitations/:id": {
+		Summary: "Revoke an invitation", Tag: "Invitations", Auth: Admin, Permission: constants.PermissionInvitationsManage,
+		Result: NoContent(),
+		Errors: []string{"INVITATION_NOT_FOUND", "INVITATION_CLOSED"},
+	},
+
+	// API keys (admin)
+	"POST /api/v1/admin/api-keys": {
+		Summary:     "Create an API key",
+		Description: "The plaintext key is only returned in this response.",
+		Tag:         "API keys", Auth: Admin, Permission: constants.PermissionAPIKeysManage,
+		Body:   model.CreateAPIKeyRequest{},
+		Result: Created(model.CreateAPIKeyResponse{}),
+		Errors: []string{"USER_NOT_FOUND"},
+	},
+	"GET /api/v1/admin/api-keys": {
+		Summary: "List API keys", Tag: "API keys", Auth: Admin, Permission: constants.PermissionAPIKeysManage,
+		Query:  request.PaginationReq{},
+		Result: Page(model.APIKey{}),
+	},
+	"DELETE /api/v1/admin/api-keys/:id": {
+		Summary: "Revoke an API key", Tag: "API keys", Auth: Admin, Permission: constants.PermissionAPIKeysManage,
+		Result: NoContent(),
+		Errors: []string{"API_KEY_NOT_FOUND"},
+	},
+
+	// OAuth clients (admin)
+	"POST /api/v1/admin/oauth-clients": {
+		Summary:     "Register an OAuth client",
+		Description: "The client secret of confidential clients is only returned in this response.",
+		Tag:         "OAuth clients", Auth: Admin, Permission: constants.PermissionOAuthClientsManage,
+		Body:   model.CreateOAuthClientRequest{},
+		Result: Created(model.CreateOAuthClientResponse{}),
+		Errors: []string{"USER_NOT_FOUND"},
+	},
+	"GET /api/v1/admin/oauth-clients": {
+		Summary: "List OAuth clients", Tag: "OAuth clients", Auth: Admin, Permission: constants.PermissionOAuthClientsManage,
+		Query:  request.PaginationReq{},
+		Result: Page(model.OAuthClient{}),
+	},
+	"DELETE /api/v1/admin/oauth-clients/:id": {
+		Summary: "Revoke an OAuth client", Tag: "OAuth clients", Auth: Admin, Permission: constants.PermissionOAuthClientsManage,
+		Result: NoContent(),
+		Errors: []string{"OAUTH_CLIENT_NOT_FOUND"},
+	},
+}
@@ -0,0 +1,84 @@
+package handler
+
+import (
+	appMiddleware "golang-echo/internal/middleware"
+	"golang-echo/internal/model"
+	"golang-echo/internal/service"
+	"golang-echo/pkg/request"
+	"golang-echo/pkg/response"
+	"golang-echo/pkg/utils"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+)
+
+type IAPIKeyHandler interface {
+	CreateAPIKey(c echo.Context) error
+	FindAllAPIKeys(c echo.Context) error
+	RevokeAPIKey(c echo.Context) error
+}
+
+type apiKeyHandler struct {
+	apiKeyService service.IAPIKeyService
+	validator     *utils.CustomValidator
+}
+
+func (h *apiKeyHandler) CreateAPIKey(c echo.Context) error {
+	var req model.CreateAPIKeyRequest
+	if err := c.Bind(&req); err != nil {
+		return response.BadRequest("BIND_ERROR", "Invalid request body", err)
+	}
+
+	if err := c.Validate(&req); err != nil {
+		fieldErrors := h.validator.ExtractValidationErrors(err, appMiddleware.GetLocaleFromContext(c))
+		if len(fieldErrors) == 0 {
+			c.Logger().Errorf("Validation error (non-field): %v, Type: %T", err, err)
+			return response.BadRequest("VALIDATION_FAILED", "Validation failed", err)
+		}
+		return response.BadRequestWithFields("VALIDATION_FAILED", "Validation failed", fieldErrors)
+	}
+
+	created, err := h.apiKeyService.CreateAPIKey(c.Request().Context(), appMiddleware.GetUserIDFromContext(c), &req)
+	if err != nil {
+		return err
+	}
+	return response.Created(c, "SUCCESS", "API key created successfully. Store the key now, it won't be shown again", created)
+}
+
+func (h *apiKeyHandler) FindAllAPIKeys(c echo.Context) error {
+	var pagReq request.PaginationReq
+	if err := c.Bind(&pagReq); err != nil {
+		return response.BadRequest("BIND_ERROR", "Invalid pagination parameters", err)
+	}
+	offset, limit, page, pageSize := pagReq.GetQueryParams()
+	keys, total, err := h.apiKeyService.FindAllAPIKeys(c.Request().Context(), limit, offset)
+	if err != nil {
+		return err
+	}
+	totalPages := int(total+int64(pageSize)-1) / pageSize
+	pagination := &response.PaginationMeta{
+		Page:       page,
+		PageSize:   pageSize,
+		TotalPages: totalPages,
+		TotalItems: total,
+	}
+	return response.ListWithPagination(c, "SUCCESS", "API keys retrieved successfully", keys, pagination)
+}
+
+func (h *apiKeyHandler) RevokeAPIKey(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return response.BadRequest("INVALID_ID", "Invalid API key ID format", err)
+	}
+	if err := h.apiKeyService.RevokeAPIKey(c.Request().Context(), id); err != nil {
+		return err
+	}
+	return response.NoContent(c)
+}
+
+func NewAPIKeyHandler(apiKeyService service.IAPIKeyService, validator *utils.CustomValidator) IAPIKeyHandler {
+	return &apiKeyHandler{
+		apiKeyService: apiKeyService,
+		validator:     validator,
+	}
+}
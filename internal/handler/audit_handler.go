@@ -0,0 +1,69 @@
+package handler
+
+import (
+	appMiddleware "golang-echo/internal/middleware"
+	"golang-echo/internal/model"
+	"golang-echo/internal/service"
+	"golang-echo/pkg/response"
+	"golang-echo/pkg/utils"
+
+	"github.com/labstack/echo/v4"
+)
+
+type IAuditHandler interface {
+	FindAuditEvents(c echo.Context) error
+	VerifyAuditChain(c echo.Context) error
+}
+
+type auditHandler struct {
+	auditor   service.IAuditor
+	validator *utils.CustomValidator
+}
+
+// FindAuditEvents lists audit events, newest first
+// Filters: actor_id, impersonator_id, action, target_type, target_id, outcome, request_id, from/to (RFC 3339)
+func (h *auditHandler) FindAuditEvents(c echo.Context) error {
+	var query model.AuditEventQuery
+	if err := c.Bind(&query); err != nil {
+		return response.BadRequest("BIND_ERROR", "Invalid query parameters", err)
+	}
+
+	if err := c.Validate(&query); err != nil {
+		fieldErrors := h.validator.ExtractValidationErrors(err, appMiddleware.GetLocaleFromContext(c))
+		if len(fieldErrors) == 0 {
+			c.Logger().Errorf("Validation error (non-field): %v, Type: %T", err, err)
+			return response.BadRequest("VALIDATION_FAILED", "Validation failed", err)
+		}
+		return response.BadRequestWithFields("VALIDATION_FAILED", "Validation failed", fieldErrors)
+	}
+
+	offset, limit, page, pageSize := query.GetQueryParams()
+	events, total, err := h.auditor.FindEvents(c.Request().Context(), &query, limit, offset)
+	if err != nil {
+		return err
+	}
+	totalPages := int(total+int64(pageSize)-1) / pageSize
+	pagination := &response.PaginationMeta{
+		Page:       page,
+		PageSize:   pageSize,
+		TotalPages: totalPages,
+		TotalItems: total,
+	}
+	return response.ListWithPagination(c, "SUCCESS", "Audit events retrieved successfully", events, pagination)
+}
+
+// VerifyAuditChain recomputes the audit log hash chain to detect tampering
+func (h *auditHandler) VerifyAuditChain(c echo.Context) error {
+	report, err := h.auditor.VerifyChain(c.Request().Context())
+	if err != nil {
+		return err
+	}
+	return response.Success(c, "SUCCESS", "Audit chain verified", report)
+}
+
+func NewAuditHandler(auditor service.IAuditor, validator *utils.CustomValidator) IAuditHandler {
+	return &auditHandler{
+		auditor:   auditor,
+		validator: validator,
+	}
+}
@@ -0,0 +1,273 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	appMiddleware "golang-echo/internal/middleware"
+	"golang-echo/internal/model"
+	"golang-echo/pkg/response"
+	"golang-echo/pkg/utils"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/labstack/echo/v4"
+)
+
+// maxBatchResponse bounds the response kept for one request of a batch
+const maxBatchResponse = 1 << 20
+
+var (
+	// batchForwardedHeaders are the batch request's headers every request of the batch is sent with:
+	// its credentials, language and client
+	batchForwardedHeaders = []string{
+		echo.HeaderAuthorization, appMiddleware.HeaderXAPIKey, "Accept-Language",
+		"User-Agent", echo.HeaderXForwardedFor, echo.HeaderXRealIP,
+	}
+	// batchRequestHeaders are the headers a request of a batch may set itself
+	batchRequestHeaders = []string{response.HeaderIfMatch, response.HeaderIfNoneMatch, appMiddleware.HeaderIdempotencyKey}
+	// batchResultHeaders are the response headers a result keeps
+	batchResultHeaders = []string{response.HeaderETag, echo.HeaderLocation}
+
+	errBatchResponseUnsupported = errors.New("response can't be returned in a batch")
+)
+
+// batchContextKey marks the context of a request run by a batch
+type batchContextKey struct{}
+
+type IBatchHandler interface {
+	Batch(c echo.Context) error
+}
+
+// BatchSettings limits batch requests
+type BatchSettings struct {
+	MaxRequests    int // requests in one batch
+	MaxConcurrency int // requests of a parallel batch run at once
+}
+
+type batchHandler struct {
+	validator *utils.CustomValidator
+	catalog   *response.MessageCatalog
+	settings  BatchSettings
+}
+
+// Batch runs the requests of the body through the router as if the caller had sent them one by one,
+// with the batch's credentials, and returns their responses in order
+// Each request goes through every middleware, so it is authorised, rate limited and audited on its own;
+// a batch can't contain another batch
+func (h *batchHandler) Batch(c echo.Context) error {
+	if c.Request().Context().Value(batchContextKey{}) != nil {
+		return response.BadRequest("BATCH_NESTED", "A batch can't contain another batch", nil)
+	}
+
+	var req model.BatchRequest
+	if err := c.Bind(&req); err != nil {
+		return response.BadRequest("BIND_ERROR", "Invalid request body", err)
+	}
+
+	locale := appMiddleware.GetLocaleFromContext(c)
+	if err := c.Validate(&req); err != nil {
+		fieldErrors := h.validator.ExtractValidationErrors(err, locale)
+		if len(fieldErrors) == 0 {
+			c.Logger().Errorf("Validation error (non-field): %v, Type: %T", err, err)
+			return response.BadRequest("VALIDATION_FAILED", "Validation failed", err)
+		}
+		return response.BadRequestWithFields("VALIDATION_FAILED", "Validation failed", fieldErrors)
+	}
+	if len(req.Requests) > h.settings.MaxRequests {
+		return response.BadRequest("BATCH_TOO_LARGE", fmt.Sprintf("A batch can have at most %d requests", h.settings.MaxRequests), nil)
+	}
+
+	// Requests are validated one by one, so their errors say which request they are about
+	fieldErrors := map[string]string{}
+	for i := range req.Requests {
+		operation := &req.Requests[i]
+		operation.Method = strings.ToUpper(operation.Method)
+		prefix := "requests[" + strconv.Itoa(i) + "]."
+		if err := c.Validate(operation); err != nil {
+			for field, message := range h.validator.ExtractValidationErrors(err, locale) {
+				fieldErrors[prefix+field] = message
+			}
+		}
+		if _, err := url.ParseRequestURI(operation.Path); operation.Path != "" && (err != nil || !strings.HasPrefix(operation.Path, "/api/v1/")) {
+			fieldErrors[prefix+"path"] = "path must be a request path under /api/v1/"
+		}
+		headers := make(map[string]string, len(operation.Headers))
+		for name, value := range operation.Headers {
+			name = http.CanonicalHeaderKey(name)
+			if !slices.Contains(batchRequestHeaders, name) {
+				fieldErrors[prefix+"headers"] = "only " + strings.Join(batchRequestHeaders, ", ") + " can be set"
+			}
+			headers[name] = value
+		}
+		operation.Headers = headers
+	}
+	if len(fieldErrors) > 0 {
+		return response.BadRequestWithFields("VALIDATION_FAILED", "Validation failed", fieldErrors)
+	}
+
+	results := make([]model.BatchResult, len(req.Requests))
+	if !req.Parallel {
+		for i, operation := range req.Requests {
+			results[i] = h.run(c, i, operation)
+		}
+		return response.Success(c, "SUCCESS", "Batch completed", results)
+	}
+
+	var wg sync.WaitGroup
+	slots := make(chan struct{}, max(h.settings.MaxConcurrency, 1))
+	for i, operation := range req.Requests {
+		wg.Add(1)
+		slots <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-slots }()
+			results[i] = h.run(c, i, operation)
+		}()
+	}
+	wg.Wait()
+	return response.Success(c, "SUCCESS", "Batch completed", results)
+}
+
+// run sends the i-th request of the batch through the router and returns its response
+// c is only read, so parallel requests can share it
+func (h *batchHandler) run(c echo.Context, i int, operation model.BatchOperation) (result model.BatchResult) {
+	parent := c.Request()
+	requestID := c.Response().Header().Get(echo.HeaderXRequestID) + "-" + strconv.Itoa(i+1)
+	ctx := context.WithValue(parent.Context(), batchContextKey{}, true)
+
+	var body io.Reader = http.NoBody
+	if len(operation.Body) > 0 {
+		body = bytes.NewReader(operation.Body)
+	}
+	req, err := http.NewRequestWithContext(ctx, operation.Method, operation.Path, body)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to create batch request", slog.Any("error", err))
+		return h.errorResult(c, requestID, response.Internal(err))
+	}
+	req.Host = parent.Host
+	req.RemoteAddr = parent.RemoteAddr
+	for _, name := range batchForwardedHeaders {
+		if values := parent.Header.Values(name); len(values) > 0 {
+			req.Header[name] = values
+		}
+	}
+	for name, value := range operation.Headers {
+		req.Header.Set(name, value)
+	}
+	req.Header.Set(echo.HeaderAccept, echo.MIMEApplicationJSON)
+	if len(operation.Body) > 0 {
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	}
+	req.Header.Set(echo.HeaderXRequestID, requestID)
+
+	writer := &batchResponseWriter{header: http.Header{}}
+	defer func() {
+		// Recover re-panics http.ErrAbortHandler, which handlers use to abandon a response they
+		// started, e.g. a stream that failed to write here
+		if r := recover(); r != nil {
+			if r != http.ErrAbortHandler {
+				panic(r)
+			}
+			result = h.errorResult(c, requestID, batchAbortError(writer))
+		}
+	}()
+	c.Echo().ServeHTTP(writer, req)
+
+	if writer.unsupported {
+		return h.errorResult(c, requestID, batchAbortError(writer))
+	}
+	result.Status = writer.statusCode()
+	for _, name := range batchResultHeaders {
+		if value := writer.header.Get(name); value != "" {
+			if result.Headers == nil {
+				result.Headers = map[string]string{}
+			}
+			result.Headers[name] = value
+		}
+	}
+	if writer.body.Len() > 0 {
+		result.Body = json.RawMessage(writer.body.Bytes())
+	}
+	return result
+}
+
+// batchAbortError is the error a request of a batch is answered with when its response can't be kept
+func batchAbortError(writer *batchResponseWriter) *response.AppError {
+	if writer.unsupported {
+		return response.NewAppError(http.StatusBadGateway, "BATCH_UNSUPPORTED_RESPONSE",
+			"The response is not JSON or is too large to return in a batch; send the request on its own", errBatchResponseUnsupported)
+	}
+	return response.Internal(nil)
+}
+
+// errorResult is a result for a request of the batch that failed without a response of its own
+func (h *batchHandler) errorResult(c echo.Context, requestID string, appErr *response.AppError) model.BatchResult {
+	body, _ := json.Marshal(response.ErrorResponse{
+		Code:      appErr.Key,
+		Message:   h.catalog.Message(appMiddleware.GetLocaleFromContext(c), appErr.Key, appErr.Message),
+		RequestID: requestID,
+	})
+	return model.BatchResult{Status: appErr.Code, Body: body}
+}
+
+// batchResponseWriter keeps the response to a request of a batch
+// Only JSON (or empty) responses up to maxBatchResponse are kept; anything else fails to write,
+// so streams stop early
+type batchResponseWriter struct {
+	header      http.Header
+	status      int
+	body        bytes.Buffer
+	unsupported bool
+}
+
+func (w *batchResponseWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *batchResponseWriter) WriteHeader(status int) {
+	if w.status != 0 {
+		return
+	}
+	w.status = status
+	mediaType, _, _ := mime.ParseMediaType(w.header.Get(echo.HeaderContentType))
+	if mediaType != "" && mediaType != echo.MIMEApplicationJSON && !strings.HasSuffix(mediaType, "+json") {
+		w.unsupported = true
+	}
+}
+
+func (w *batchResponseWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.WriteHeader(http.StatusOK)
+	}
+	if !w.unsupported && w.body.Len()+len(b) > maxBatchResponse {
+		w.unsupported = true
+		w.body.Reset()
+	}
+	if w.unsupported {
+		return 0, errBatchResponseUnsupported
+	}
+	return w.body.Write(b)
+}
+
+func (w *batchResponseWriter) Flush() {}
+
+func (w *batchResponseWriter) statusCode() int {
+	if w.status == 0 {
+		return http.StatusOK
+	}
+	return w.status
+}
+
+func NewBatchHandler(validator *utils.CustomValidator, catalog *response.MessageCatalog, settings BatchSettings) IBatchHandler {
+	return &batchHandler{validator: validator, catalog: catalog, settings: settings}
+}
@@ -0,0 +1,47 @@
+package handler
+
+import (
+	_ "embed"
+	"golang-echo/internal/apidoc"
+	"golang-echo/pkg/openapi"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+)
+
+// docsPage renders /openapi.json with Swagger UI
+//
+//go:embed templates/docs.html
+var docsPage []byte
+
+type IDocsHandler interface {
+	OpenAPI(c echo.Context) error
+	UI(c echo.Context) error
+	// SetDocument sets the document to serve; call it once every route is registered, before serving
+	SetDocument(doc *openapi.Document) error
+}
+
+type docsHandler struct {
+	spec []byte
+}
+
+func (h *docsHandler) OpenAPI(c echo.Context) error {
+	return c.Blob(http.StatusOK, echo.MIMEApplicationJSON, h.spec)
+}
+
+func (h *docsHandler) UI(c echo.Context) error {
+	return c.HTMLBlob(http.StatusOK, docsPage)
+}
+
+func (h *docsHandler) SetDocument(doc *openapi.Document) error {
+	spec, err := apidoc.Marshal(doc)
+	if err != nil {
+		return err
+	}
+	h.spec = spec
+	return nil
+}
+
+func NewDocsHandler() IDocsHandler {
+	return &docsHandler{}
+}
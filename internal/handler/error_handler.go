@@ -3,6 +3,7 @@ package handler
 import (
 	"errors"
 	"fmt"
+	appMiddleware "golang-echo/internal/middleware"
 	"golang-echo/pkg/response"
 	"log/slog"
 	"net/http"
@@ -10,7 +11,20 @@ import (
 	"github.com/labstack/echo/v4"
 )
 
+// NewHTTPErrorHandler creates the HTTP error handler
+// AppError messages are localised through catalog using the locale negotiated by LocaleMiddleware
+func NewHTTPErrorHandler(catalog *response.MessageCatalog) echo.HTTPErrorHandler {
+	return func(err error, c echo.Context) {
+		handleHTTPError(err, c, catalog)
+	}
+}
+
+// CustomHTTPErrorHandler handles errors without message localisation
 func CustomHTTPErrorHandler(err error, c echo.Context) {
+	handleHTTPError(err, c, nil)
+}
+
+func handleHTTPError(err error, c echo.Context, catalog *response.MessageCatalog) {
 	// 1. If response is already committed, we cannot send any more data
 	if c.Response().Committed {
 		return
@@ -30,7 +44,7 @@ func CustomHTTPErrorHandler(err error, c echo.Context) {
 		// This is an application error (AppError)
 		code = appErr.Code
 		key = appErr.Key
-		msg = appErr.Message
+		msg = catalog.Message(appMiddleware.GetLocaleFromContext(c), appErr.Key, appErr.Message)
 
 		// Log the underlying error
 		// if appErr.Err != nil {
@@ -47,6 +61,7 @@ func CustomHTTPErrorHandler(err error, c echo.Context) {
 		// Only log this error, DO NOT return details to client for security reasons
 		// Just silently handle it without exposing details
 		slog.ErrorContext(c.Request().Context(), "unknown error occurred", slog.Any("error", err))
+		msg = catalog.Message(appMiddleware.GetLocaleFromContext(c), key, msg)
 	}
 
 	// 3. Build standard error response
@@ -63,10 +78,14 @@ func CustomHTTPErrorHandler(err error, c echo.Context) {
 	}
 
 	// 4. Send response to client
+	// Clients asking for application/problem+json get RFC 9457 format, everyone else keeps ErrorResponse,
+	// as JSON or MessagePack (CSV and NDJSON clients get JSON)
 	if c.Request().Method == http.MethodHead {
 		err = c.NoContent(code)
+	} else if response.WantsProblemJSON(c) {
+		err = response.Problem(c, errorResponse.ToProblemDetails(code, c))
 	} else {
-		err = c.JSON(code, errorResponse)
+		err = response.Send(c, code, errorResponse)
 	}
 
 	// Fallback if sending JSON also fails
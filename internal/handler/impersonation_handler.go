@@ -0,0 +1,58 @@
+package handler
+
+import (
+	appMiddleware "golang-echo/internal/middleware"
+	"golang-echo/internal/model"
+	"golang-echo/internal/service"
+	"golang-echo/pkg/response"
+	"golang-echo/pkg/utils"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+)
+
+type IImpersonationHandler interface {
+	Impersonate(c echo.Context) error
+}
+
+type impersonationHandler struct {
+	impersonationService service.IImpersonationService
+	validator            *utils.CustomValidator
+}
+
+// Impersonate issues a short-lived token acting as the user, for support engineers to reproduce what they see
+// The token can't reach admin routes or change the user's credentials
+func (h *impersonationHandler) Impersonate(c echo.Context) error {
+	userID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return response.BadRequest("INVALID_ID", "Invalid user ID format", err)
+	}
+
+	var req model.ImpersonateRequest
+	if err := c.Bind(&req); err != nil {
+		return response.BadRequest("BIND_ERROR", "Invalid request body", err)
+	}
+
+	if err := c.Validate(&req); err != nil {
+		fieldErrors := h.validator.ExtractValidationErrors(err, appMiddleware.GetLocaleFromContext(c))
+		if len(fieldErrors) == 0 {
+			c.Logger().Errorf("Validation error (non-field): %v, Type: %T", err, err)
+			return response.BadRequest("VALIDATION_FAILED", "Validation failed", err)
+		}
+		return response.BadRequestWithFields("VALIDATION_FAILED", "Validation failed", fieldErrors)
+	}
+
+	actorID := appMiddleware.GetUserIDFromContext(c)
+	resp, err := h.impersonationService.Impersonate(c.Request().Context(), actorID, userID, &req, sessionClient(c))
+	if err != nil {
+		return err
+	}
+	return response.Created(c, "SUCCESS", "Impersonation token issued", resp)
+}
+
+func NewImpersonationHandler(impersonationService service.IImpersonationService, validator *utils.CustomValidator) IImpersonationHandler {
+	return &impersonationHandler{
+		impersonationService: impersonationService,
+		validator:            validator,
+	}
+}
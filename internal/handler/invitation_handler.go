@@ -0,0 +1,136 @@
+package handler
+
+import (
+	appMiddleware "golang-echo/internal/middleware"
+	"golang-echo/internal/model"
+	"golang-echo/internal/service"
+	"golang-echo/pkg/response"
+	"golang-echo/pkg/utils"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+)
+
+type IInvitationHandler interface {
+	CreateInvitation(c echo.Context) error
+	FindAllInvitations(c echo.Context) error
+	ResendInvitation(c echo.Context) error
+	RevokeInvitation(c echo.Context) error
+	AcceptInvitation(c echo.Context) error
+}
+
+type invitationHandler struct {
+	invitationService service.IInvitationService
+	validator         *utils.CustomValidator
+}
+
+func (h *invitationHandler) CreateInvitation(c echo.Context) error {
+	var req model.CreateInvitationRequest
+	if err := c.Bind(&req); err != nil {
+		return response.BadRequest("BIND_ERROR", "Invalid request body", err)
+	}
+
+	if err := c.Validate(&req); err != nil {
+		fieldErrors := h.validator.ExtractValidationErrors(err, appMiddleware.GetLocaleFromContext(c))
+		if len(fieldErrors) == 0 {
+			c.Logger().Errorf("Validation error (non-field): %v, Type: %T", err, err)
+			return response.BadRequest("VALIDATION_FAILED", "Validation failed", err)
+		}
+		return response.BadRequestWithFields("VALIDATION_FAILED", "Validation failed", fieldErrors)
+	}
+
+	actorID := appMiddleware.GetUserIDFromContext(c)
+	resp, err := h.invitationService.CreateInvitation(c.Request().Context(), actorID, &req)
+	if err != nil {
+		return err
+	}
+	message := "Invitation created successfully"
+	if !resp.EmailSent {
+		message = "Invitation created, but the email could not be sent. Use resend to try again"
+	}
+	return response.Created(c, "SUCCESS", message, resp)
+}
+
+// FindAllInvitations lists invitations, newest first, optionally filtered by status
+func (h *invitationHandler) FindAllInvitations(c echo.Context) error {
+	var query model.InvitationQuery
+	if err := c.Bind(&query); err != nil {
+		return response.BadRequest("BIND_ERROR", "Invalid query parameters", err)
+	}
+
+	if err := c.Validate(&query); err != nil {
+		fieldErrors := h.validator.ExtractValidationErrors(err, appMiddleware.GetLocaleFromContext(c))
+		if len(fieldErrors) == 0 {
+			c.Logger().Errorf("Validation error (non-field): %v, Type: %T", err, err)
+			return response.BadRequest("VALIDATION_FAILED", "Validation failed", err)
+		}
+		return response.BadRequestWithFields("VALIDATION_FAILED", "Validation failed", fieldErrors)
+	}
+
+	offset, limit, page, pageSize := query.GetQueryParams()
+	invitations, total, err := h.invitationService.FindInvitations(c.Request().Context(), query.Status, limit, offset)
+	if err != nil {
+		return err
+	}
+	totalPages := int(total+int64(pageSize)-1) / pageSize
+	pagination := &response.PaginationMeta{
+		Page:       page,
+		PageSize:   pageSize,
+		TotalPages: totalPages,
+		TotalItems: total,
+	}
+	return response.ListWithPagination(c, "SUCCESS", "Invitations retrieved successfully", invitations, pagination)
+}
+
+func (h *invitationHandler) ResendInvitation(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return response.BadRequest("INVALID_ID", "Invalid invitation ID format", err)
+	}
+	resp, err := h.invitationService.ResendInvitation(c.Request().Context(), id)
+	if err != nil {
+		return err
+	}
+	return response.Success(c, "SUCCESS", "Invitation resent successfully", resp)
+}
+
+func (h *invitationHandler) RevokeInvitation(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return response.BadRequest("INVALID_ID", "Invalid invitation ID format", err)
+	}
+	if err := h.invitationService.RevokeInvitation(c.Request().Context(), id); err != nil {
+		return err
+	}
+	return response.NoContent(c)
+}
+
+// AcceptInvitation is public: the invitation token proves the invitee owns the email
+func (h *invitationHandler) AcceptInvitation(c echo.Context) error {
+	var req model.AcceptInvitationRequest
+	if err := c.Bind(&req); err != nil {
+		return response.BadRequest("BIND_ERROR", "Invalid request body", err)
+	}
+
+	if err := c.Validate(&req); err != nil {
+		fieldErrors := h.validator.ExtractValidationErrors(err, appMiddleware.GetLocaleFromContext(c))
+		if len(fieldErrors) == 0 {
+			c.Logger().Errorf("Validation error (non-field): %v, Type: %T", err, err)
+			return response.BadRequest("VALIDATION_FAILED", "Validation failed", err)
+		}
+		return response.BadRequestWithFields("VALIDATION_FAILED", "Validation failed", fieldErrors)
+	}
+
+	user, err := h.invitationService.AcceptInvitation(c.Request().Context(), &req)
+	if err != nil {
+		return err
+	}
+	return response.Created(c, "SUCCESS", "Account created successfully. You can now log in", user)
+}
+
+func NewInvitationHandler(invitationService service.IInvitationService, validator *utils.CustomValidator) IInvitationHandler {
+	return &invitationHandler{
+		invitationService: invitationService,
+		validator:         validator,
+	}
+}
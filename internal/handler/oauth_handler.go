@@ -0,0 +1,434 @@
+package handler
+
+import (
+	"embed"
+	"errors"
+	"html/template"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	appMiddleware "golang-echo/internal/middleware"
+	"golang-echo/internal/model"
+	"golang-echo/internal/service"
+	"golang-echo/pkg/oauth"
+	"golang-echo/pkg/request"
+	"golang-echo/pkg/response"
+	"golang-echo/pkg/utils"
+
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	// oauthSessionCookie keeps the user signed in to the authorization server between authorization requests
+	oauthSessionCookie     = "oauth_session"
+	oauthSessionCookiePath = "/oauth"
+)
+
+//go:embed templates/oauth_authorize.html
+var oauthTemplates embed.FS
+
+var authorizePage = template.Must(template.ParseFS(oauthTemplates, "templates/oauth_authorize.html"))
+
+// scopeDescriptions are shown on the consent screen
+var scopeDescriptions = map[string]string{
+	oauth.ScopeOpenID:        "Sign you in",
+	oauth.ScopeProfile:       "See your name",
+	oauth.ScopeEmail:         "See your email address",
+	oauth.ScopeOfflineAccess: "Stay signed in when you are not using the app",
+}
+
+type IOAuthHandler interface {
+	Discovery(c echo.Context) error
+	JWKS(c echo.Context) error
+	Authorize(c echo.Context) error
+	AuthorizeDecision(c echo.Context) error
+	Token(c echo.Context) error
+	Revoke(c echo.Context) error
+	UserInfo(c echo.Context) error
+
+	CreateClient(c echo.Context) error
+	FindAllClients(c echo.Context) error
+	RevokeClient(c echo.Context) error
+
+	FindMyConsents(c echo.Context) error
+	RevokeConsent(c echo.Context) error
+}
+
+type oauthHandler struct {
+	oauthService   service.IOAuthService
+	userService    service.IUserService
+	sessionService service.ISessionService
+	jwtManager     *utils.JWTManager
+	validator      *utils.CustomValidator
+	secureCookies  bool
+}
+
+func (h *oauthHandler) Discovery(c echo.Context) error {
+	return c.JSON(http.StatusOK, h.oauthService.Metadata())
+}
+
+func (h *oauthHandler) JWKS(c echo.Context) error {
+	return c.JSON(http.StatusOK, h.oauthService.JWKS())
+}
+
+// Authorize is the authorization endpoint; it redirects straight back when the user is signed in and
+// already consented, otherwise it shows the login and consent page
+func (h *oauthHandler) Authorize(c echo.Context) error {
+	var req model.AuthorizeRequest
+	if err := c.Bind(&req); err != nil {
+		return response.BadRequest("BIND_ERROR", "Invalid authorization request", err)
+	}
+
+	client, scopes, err := h.oauthService.ValidateAuthorizeRequest(c.Request().Context(), &req)
+	if err != nil {
+		return h.authorizeError(c, &req, err)
+	}
+
+	claims := h.sessionClaims(c)
+	if claims != nil {
+		consented, err := h.oauthService.HasConsent(c.Request().Context(), claims.UserID, client.ClientID, scopes)
+		if err != nil {
+			return err
+		}
+		if consented {
+			redirectURL, err := h.oauthService.Authorize(c.Request().Context(), claims.UserID, &req, scopes)
+			if err != nil {
+				return err
+			}
+			return c.Redirect(http.StatusFound, redirectURL)
+		}
+	}
+
+	return h.renderAuthorizePage(c, http.StatusOK, &req, client, scopes, claims, "", "")
+}
+
+// AuthorizeDecision handles the login and consent form
+func (h *oauthHandler) AuthorizeDecision(c echo.Context) error {
+	var req model.AuthorizeRequest
+	if err := c.Bind(&req); err != nil {
+		return response.BadRequest("BIND_ERROR", "Invalid authorization request", err)
+	}
+
+	client, scopes, err := h.oauthService.ValidateAuthorizeRequest(c.Request().Context(), &req)
+	if err != nil {
+		return h.authorizeError(c, &req, err)
+	}
+
+	switch c.FormValue("action") {
+	case "deny":
+		denied := oauth.NewError(http.StatusForbidden, oauth.ErrorAccessDenied, "the user denied the request")
+		return c.Redirect(http.StatusSeeOther, denied.RedirectURL(req.RedirectURI, req.State))
+	case "switch_account":
+		h.clearSession(c)
+		return h.renderAuthorizePage(c, http.StatusOK, &req, client, scopes, nil, "", "")
+	case "approve":
+	default:
+		return response.BadRequest("BIND_ERROR", "Invalid authorization request", nil)
+	}
+
+	claims := h.sessionClaims(c)
+	if claims == nil {
+		email := strings.TrimSpace(c.FormValue("email"))
+		loginResp, err := h.userService.Login(c.Request().Context(), &model.LoginRequest{
+			Email:    email,
+			Password: c.FormValue("password"),
+		}, sessionClient(c))
+		if err != nil {
+			var appErr *response.AppError
+			if errors.As(err, &appErr) && appErr.Code == http.StatusUnauthorized {
+				return h.renderAuthorizePage(c, http.StatusUnauthorized, &req, client, scopes, nil, email, "Invalid email or password")
+			}
+			return err
+		}
+
+		h.setSession(c, loginResp.AccessToken)
+		claims = &utils.JWTClaims{UserID: loginResp.User.ID, Name: loginResp.User.Name}
+	}
+
+	redirectURL, err := h.oauthService.Authorize(c.Request().Context(), claims.UserID, &req, scopes)
+	if err != nil {
+		return err
+	}
+	return c.Redirect(http.StatusSeeOther, redirectURL)
+}
+
+// authorizeError redirects protocol errors back to the client; anything else means the redirect URI
+// can't be trusted, so the error is shown to the user instead
+func (h *oauthHandler) authorizeError(c echo.Context, req *model.AuthorizeRequest, err error) error {
+	var oauthErr *oauth.Error
+	if errors.As(err, &oauthErr) {
+		return c.Redirect(http.StatusFound, oauthErr.RedirectURL(req.RedirectURI, req.State))
+	}
+	return err
+}
+
+type authorizePageData struct {
+	Action     string
+	ClientName string
+	Scopes     []string
+	UserName   string
+	Email      string
+	Error      string
+	Params     map[string]string
+}
+
+func (h *oauthHandler) renderAuthorizePage(
+	c echo.Context,
+	status int,
+	req *model.AuthorizeRequest,
+	client *model.OAuthClient,
+	scopes []string,
+	claims *utils.JWTClaims,
+	email string,
+	errorMessage string,
+) error {
+	data := authorizePageData{
+		Action:     oauth.PathAuthorize,
+		ClientName: client.Name,
+		Email:      email,
+		Error:      errorMessage,
+		Params: map[string]string{
+			"response_type":         req.ResponseType,
+			"client_id":             req.ClientID,
+			"redirect_uri":          req.RedirectURI,
+			"scope":                 oauth.FormatScope(scopes),
+			"state":                 req.State,
+			"nonce":                 req.Nonce,
+			"code_challenge":        req.CodeChallenge,
+			"code_challenge_method": req.CodeChallengeMethod,
+		},
+	}
+	for _, scope := range scopes {
+		data.Scopes = append(data.Scopes, scopeDescriptions[scope])
+	}
+	if claims != nil {
+		data.UserName = claims.Name
+	}
+
+	var page strings.Builder
+	if err := authorizePage.Execute(&page, data); err != nil {
+		return response.Internal(err)
+	}
+	// The page collects credentials, so it must never be cached or framed
+	c.Response().Header().Set("Cache-Control", "no-store")
+	c.Response().Header().Set("X-Frame-Options", "DENY")
+	return c.HTML(status, page.String())
+}
+
+// sessionClaims returns the signed-in user, or nil
+func (h *oauthHandler) sessionClaims(c echo.Context) *utils.JWTClaims {
+	cookie, err := c.Cookie(oauthSessionCookie)
+	if err != nil {
+		return nil
+	}
+	claims, err := h.jwtManager.VerifyToken(cookie.Value)
+	// Tokens issued to OAuth clients must not double as an authorization server session,
+	// nor may impersonation tokens grant consent on the user's behalf
+	if err != nil || claims.ClientID != "" || claims.ID == "" || claims.Actor != nil {
+		return nil
+	}
+	if err := h.sessionService.ValidateSession(c.Request().Context(), claims.ID, claims.UserID); err != nil {
+		return nil
+	}
+	return claims
+}
+
+func (h *oauthHandler) setSession(c echo.Context, token string) {
+	c.SetCookie(&http.Cookie{
+		Name:     oauthSessionCookie,
+		Value:    token,
+		Path:     oauthSessionCookiePath,
+		MaxAge:   int(h.jwtManager.Duration().Seconds()),
+		HttpOnly: true,
+		Secure:   h.secureCookies,
+		SameSite: http.SameSiteLaxMode, // not sent on cross-site form posts, so the consent form can't be forged
+	})
+}
+
+func (h *oauthHandler) clearSession(c echo.Context) {
+	c.SetCookie(&http.Cookie{
+		Name:     oauthSessionCookie,
+		Path:     oauthSessionCookiePath,
+		MaxAge:   -1,
+		HttpOnly: true,
+		Secure:   h.secureCookies,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+// Token is the token endpoint; it speaks plain OAuth JSON rather than the usual response envelope
+func (h *oauthHandler) Token(c echo.Context) error {
+	var req model.TokenRequest
+	if err := c.Bind(&req); err != nil {
+		return writeOAuthError(c, oauth.InvalidRequest("malformed token request"))
+	}
+
+	clientID, clientSecret := clientCredentialsFromBasicAuth(c)
+	tokenResp, err := h.oauthService.Token(c.Request().Context(), &req, clientID, clientSecret)
+	if err != nil {
+		return writeOAuthError(c, err)
+	}
+
+	c.Response().Header().Set("Cache-Control", "no-store")
+	c.Response().Header().Set("Pragma", "no-cache")
+	return c.JSON(http.StatusOK, tokenResp)
+}
+
+// Revoke is the token revocation endpoint (RFC 7009)
+func (h *oauthHandler) Revoke(c echo.Context) error {
+	clientID, clientSecret := clientCredentialsFromBasicAuth(c)
+	if clientID == "" {
+		clientID, clientSecret = c.FormValue("client_id"), c.FormValue("client_secret")
+	}
+	if err := h.oauthService.RevokeToken(c.Request().Context(), c.FormValue("token"), clientID, clientSecret); err != nil {
+		return writeOAuthError(c, err)
+	}
+	return c.NoContent(http.StatusOK)
+}
+
+// clientCredentialsFromBasicAuth reads client_secret_basic credentials, which are form-urlencoded (RFC 6749 2.3.1)
+func clientCredentialsFromBasicAuth(c echo.Context) (string, string) {
+	username, password, ok := c.Request().BasicAuth()
+	if !ok {
+		return "", ""
+	}
+	return formUnescape(username), formUnescape(password)
+}
+
+func formUnescape(value string) string {
+	if unescaped, err := url.QueryUnescape(value); err == nil {
+		return unescaped
+	}
+	return value
+}
+
+func writeOAuthError(c echo.Context, err error) error {
+	var oauthErr *oauth.Error
+	if !errors.As(err, &oauthErr) {
+		oauthErr = oauth.ServerError(err)
+	}
+	if oauthErr.Status >= http.StatusInternalServerError {
+		slog.ErrorContext(c.Request().Context(), "oauth endpoint failed", slog.String("path", c.Path()), slog.Any("error", oauthErr.Err))
+	}
+	if oauthErr.Code == oauth.ErrorInvalidClient {
+		c.Response().Header().Set(echo.HeaderWWWAuthenticate, `Basic realm="oauth"`)
+	}
+	c.Response().Header().Set("Cache-Control", "no-store")
+	return c.JSON(oauthErr.Status, oauthErr)
+}
+
+func (h *oauthHandler) UserInfo(c echo.Context) error {
+	userID := appMiddleware.GetUserIDFromContext(c)
+	if userID == 0 {
+		return response.Unauthorized("INVALID_CONTEXT", "User ID not found in context", nil)
+	}
+
+	info, err := h.oauthService.UserInfo(c.Request().Context(), userID, appMiddleware.GetClientIDFromContext(c), appMiddleware.GetScopeFromContext(c))
+	if err != nil {
+		return err
+	}
+	// OIDC clients expect the bare claims object
+	return c.JSON(http.StatusOK, info)
+}
+
+func (h *oauthHandler) CreateClient(c echo.Context) error {
+	var req model.CreateOAuthClientRequest
+	if err := c.Bind(&req); err != nil {
+		return response.BadRequest("BIND_ERROR", "Invalid request body", err)
+	}
+
+	if err := c.Validate(&req); err != nil {
+		fieldErrors := h.validator.ExtractValidationErrors(err, appMiddleware.GetLocaleFromContext(c))
+		if len(fieldErrors) == 0 {
+			c.Logger().Errorf("Validation error (non-field): %v, Type: %T", err, err)
+			return response.BadRequest("VALIDATION_FAILED", "Validation failed", err)
+		}
+		return response.BadRequestWithFields("VALIDATION_FAILED", "Validation failed", fieldErrors)
+	}
+
+	created, err := h.oauthService.CreateClient(c.Request().Context(), appMiddleware.GetUserIDFromContext(c), &req)
+	if err != nil {
+		return err
+	}
+	message := "OAuth client created successfully"
+	if created.ClientSecret != "" {
+		message += ". Store the client secret now, it won't be shown again"
+	}
+	return response.Created(c, "SUCCESS", message, created)
+}
+
+func (h *oauthHandler) FindAllClients(c echo.Context) error {
+	var pagReq request.PaginationReq
+	if err := c.Bind(&pagReq); err != nil {
+		return response.BadRequest("BIND_ERROR", "Invalid pagination parameters", err)
+	}
+	offset, limit, page, pageSize := pagReq.GetQueryParams()
+	clients, total, err := h.oauthService.FindAllClients(c.Request().Context(), limit, offset)
+	if err != nil {
+		return err
+	}
+	totalPages := int(total+int64(pageSize)-1) / pageSize
+	pagination := &response.PaginationMeta{
+		Page:       page,
+		PageSize:   pageSize,
+		TotalPages: totalPages,
+		TotalItems: total,
+	}
+	return response.ListWithPagination(c, "SUCCESS", "OAuth clients retrieved successfully", clients, pagination)
+}
+
+func (h *oauthHandler) RevokeClient(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return response.BadRequest("INVALID_ID", "Invalid OAuth client ID format", err)
+	}
+	if err := h.oauthService.RevokeClient(c.Request().Context(), id); err != nil {
+		return err
+	}
+	return response.NoContent(c)
+}
+
+func (h *oauthHandler) FindMyConsents(c echo.Context) error {
+	userID := appMiddleware.GetUserIDFromContext(c)
+	if userID == 0 {
+		return response.Unauthorized("INVALID_CONTEXT", "User ID not found in context", nil)
+	}
+	consents, err := h.oauthService.FindMyConsents(c.Request().Context(), userID)
+	if err != nil {
+		return err
+	}
+	return response.List(c, "SUCCESS", "Consents retrieved successfully", consents)
+}
+
+// RevokeConsent withdraws the user's consent for a client and revokes its refresh tokens
+func (h *oauthHandler) RevokeConsent(c echo.Context) error {
+	userID := appMiddleware.GetUserIDFromContext(c)
+	if userID == 0 {
+		return response.Unauthorized("INVALID_CONTEXT", "User ID not found in context", nil)
+	}
+	if err := h.oauthService.RevokeConsent(c.Request().Context(), userID, c.Param("client_id")); err != nil {
+		return err
+	}
+	return response.NoContent(c)
+}
+
+func NewOAuthHandler(
+	oauthService service.IOAuthService,
+	userService service.IUserService,
+	sessionService service.ISessionService,
+	jwtManager *utils.JWTManager,
+	validator *utils.CustomValidator,
+	secureCookies bool,
+) IOAuthHandler {
+	return &oauthHandler{
+		oauthService:   oauthService,
+		userService:    userService,
+		sessionService: sessionService,
+		jwtManager:     jwtManager,
+		validator:      validator,
+		secureCookies:  secureCookies,
+	}
+}
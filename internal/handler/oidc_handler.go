@@ -0,0 +1,88 @@
+package handler
+
+import (
+	"golang-echo/internal/service"
+	"golang-echo/pkg/response"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	oidcFlowCookie     = "oidc_flow"
+	oidcFlowCookiePath = "/api/v1/auth/oidc"
+)
+
+type IOIDCHandler interface {
+	StartLogin(c echo.Context) error
+	Callback(c echo.Context) error
+}
+
+type oidcHandler struct {
+	oidcService   service.IOIDCService
+	flowTTL       time.Duration
+	secureCookies bool
+}
+
+// StartLogin redirects the user agent to the provider with a fresh state, nonce and PKCE challenge
+func (h *oidcHandler) StartLogin(c echo.Context) error {
+	authURL, flowState, err := h.oidcService.StartLogin(c.Request().Context(), c.Param("provider"))
+	if err != nil {
+		return err
+	}
+
+	c.SetCookie(&http.Cookie{
+		Name:     oidcFlowCookie,
+		Value:    flowState,
+		Path:     oidcFlowCookiePath,
+		MaxAge:   int(h.flowTTL.Seconds()),
+		HttpOnly: true,
+		Secure:   h.secureCookies,
+		SameSite: http.SameSiteLaxMode, // must survive the top-level redirect back from the provider
+	})
+	return c.Redirect(http.StatusFound, authURL)
+}
+
+// Callback completes the authorization code flow and returns the same payload as /users/login
+func (h *oidcHandler) Callback(c echo.Context) error {
+	if providerErr := c.QueryParam("error"); providerErr != "" {
+		return response.Unauthorized("OIDC_LOGIN_DENIED", "Identity provider login failed: "+providerErr, nil)
+	}
+
+	cookie, err := c.Cookie(oidcFlowCookie)
+	if err != nil {
+		return response.BadRequest("OIDC_INVALID_STATE", "Login state is invalid or expired. Please start again", err)
+	}
+	// The flow state is single-use
+	c.SetCookie(&http.Cookie{
+		Name:     oidcFlowCookie,
+		Path:     oidcFlowCookiePath,
+		MaxAge:   -1,
+		HttpOnly: true,
+		Secure:   h.secureCookies,
+		SameSite: http.SameSiteLaxMode,
+	})
+
+	loginResp, err := h.oidcService.CompleteLogin(
+		c.Request().Context(),
+		c.Param("provider"),
+		c.QueryParam("code"),
+		c.QueryParam("state"),
+		cookie.Value,
+		sessionClient(c),
+	)
+	if err != nil {
+		return err
+	}
+
+	return response.Success(c, "SUCCESS", "Login successful", loginResp)
+}
+
+func NewOIDCHandler(oidcService service.IOIDCService, flowTTL time.Duration, secureCookies bool) IOIDCHandler {
+	return &oidcHandler{
+		oidcService:   oidcService,
+		flowTTL:       flowTTL,
+		secureCookies: secureCookies,
+	}
+}
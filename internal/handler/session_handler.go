@@ -0,0 +1,90 @@
+package handler
+
+import (
+	appMiddleware "golang-echo/internal/middleware"
+	"golang-echo/internal/model"
+	"golang-echo/internal/service"
+	"golang-echo/pkg/response"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+)
+
+type ISessionHandler interface {
+	FindMySessions(c echo.Context) error
+	RevokeMySession(c echo.Context) error
+	RevokeMyOtherSessions(c echo.Context) error
+	FindUserSessions(c echo.Context) error
+}
+
+type sessionHandler struct {
+	sessionService service.ISessionService
+}
+
+// sessionClient describes the caller for the session created on login
+func sessionClient(c echo.Context) model.SessionClient {
+	return model.SessionClient{
+		UserAgent: c.Request().UserAgent(),
+		IPAddress: c.RealIP(),
+	}
+}
+
+func (h *sessionHandler) FindMySessions(c echo.Context) error {
+	userID := appMiddleware.GetUserIDFromContext(c)
+	if userID == 0 {
+		return response.Unauthorized("INVALID_CONTEXT", "User ID not found in context", nil)
+	}
+	sessions, err := h.sessionService.FindSessions(c.Request().Context(), userID, appMiddleware.GetTokenIDFromContext(c))
+	if err != nil {
+		return err
+	}
+	return response.List(c, "SUCCESS", "Sessions retrieved successfully", sessions)
+}
+
+// RevokeMySession signs out one session; revoking the current session is a logout
+func (h *sessionHandler) RevokeMySession(c echo.Context) error {
+	userID := appMiddleware.GetUserIDFromContext(c)
+	if userID == 0 {
+		return response.Unauthorized("INVALID_CONTEXT", "User ID not found in context", nil)
+	}
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return response.BadRequest("INVALID_ID", "Invalid session ID format", err)
+	}
+	if err := h.sessionService.RevokeSession(c.Request().Context(), userID, id); err != nil {
+		return err
+	}
+	return response.NoContent(c)
+}
+
+// RevokeMyOtherSessions signs out everywhere except the session making the request
+func (h *sessionHandler) RevokeMyOtherSessions(c echo.Context) error {
+	userID := appMiddleware.GetUserIDFromContext(c)
+	if userID == 0 {
+		return response.Unauthorized("INVALID_CONTEXT", "User ID not found in context", nil)
+	}
+	revoked, err := h.sessionService.RevokeOtherSessions(c.Request().Context(), userID, appMiddleware.GetTokenIDFromContext(c))
+	if err != nil {
+		return err
+	}
+	return response.Success(c, "SUCCESS", "Signed out of all other sessions", model.RevokeSessionsResponse{Revoked: revoked})
+}
+
+// FindUserSessions lets support staff see where an account is signed in
+func (h *sessionHandler) FindUserSessions(c echo.Context) error {
+	userID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return response.BadRequest("INVALID_ID", "Invalid user ID format", err)
+	}
+	sessions, err := h.sessionService.FindSessions(c.Request().Context(), userID, "")
+	if err != nil {
+		return err
+	}
+	return response.List(c, "SUCCESS", "Sessions retrieved successfully", sessions)
+}
+
+func NewSessionHandler(sessionService service.ISessionService) ISessionHandler {
+	return &sessionHandler{
+		sessionService: sessionService,
+	}
+}
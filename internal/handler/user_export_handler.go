@@ -0,0 +1,181 @@
+package handler
+
+import (
+	appMiddleware "golang-echo/internal/middleware"
+	"golang-echo/internal/model"
+	"golang-echo/internal/service"
+	"golang-echo/pkg/response"
+	"golang-echo/pkg/utils"
+	"log/slog"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+type IUserExportHandler interface {
+	ExportUsers(c echo.Context) error
+	CreateUserExportJob(c echo.Context) error
+	FindUserExportJob(c echo.Context) error
+	DownloadUserExport(c echo.Context) error
+}
+
+type userExportHandler struct {
+	exportService service.IUserExportService
+	validator     *utils.CustomValidator
+}
+
+// exportContentTypes are the media types of the export formats
+var exportContentTypes = map[string]string{
+	model.ExportFormatCSV:    response.MIMETextCSV + "; charset=utf-8",
+	model.ExportFormatNDJSON: response.MIMEApplicationNDJSON,
+}
+
+// ExportUsers streams every user as CSV or NDJSON, picked by ?format= or else by Accept
+func (h *userExportHandler) ExportUsers(c echo.Context) error {
+	var query model.UserExportQuery
+	if err := c.Bind(&query); err != nil {
+		return response.BadRequest("BIND_ERROR", "Invalid query parameters", err)
+	}
+
+	if err := c.Validate(&query); err != nil {
+		fieldErrors := h.validator.ExtractValidationErrors(err, appMiddleware.GetLocaleFromContext(c))
+		if len(fieldErrors) == 0 {
+			c.Logger().Errorf("Validation error (non-field): %v, Type: %T", err, err)
+			return response.BadRequest("VALIDATION_FAILED", "Validation failed", err)
+		}
+		return response.BadRequestWithFields("VALIDATION_FAILED", "Validation failed", fieldErrors)
+	}
+
+	fields, err := userFields(c)
+	if err != nil {
+		return err
+	}
+	if fields == nil {
+		fields = append(model.UserFields{}, model.SelectableUserFields...)
+	}
+	format := query.Format
+	if format == "" {
+		format = model.ExportFormatCSV
+		if response.Negotiate(c.Request(), response.MIMETextCSV, response.MIMEApplicationNDJSON) == response.MIMEApplicationNDJSON {
+			format = model.ExportFormatNDJSON
+		}
+	}
+
+	res := c.Response()
+	res.Header().Add(echo.HeaderVary, echo.HeaderAccept)
+	w := &exportWriter{res: res, contentType: exportContentTypes[format], filename: exportFilename(time.Now(), format)}
+	if _, err := h.exportService.ExportUsers(c.Request().Context(), w, format, fields); err != nil {
+		if !res.Committed {
+			return response.Internal(err)
+		}
+		// Too late for an error response: abort the connection so the client sees a truncated download
+		slog.ErrorContext(c.Request().Context(), "user export failed after the response started", slog.Any("error", err))
+		panic(http.ErrAbortHandler)
+	}
+	w.commit() // an NDJSON export of no users writes nothing
+	return nil
+}
+
+// exportWriter sends the response headers with the first bytes written, so an export that fails
+// before then still gets an error response
+type exportWriter struct {
+	res         *echo.Response
+	contentType string
+	filename    string
+}
+
+func (w *exportWriter) commit() {
+	if w.res.Committed {
+		return
+	}
+	w.res.Header().Set(echo.HeaderContentType, w.contentType)
+	w.res.Header().Set(echo.HeaderContentDisposition, `attachment; filename="`+w.filename+`"`)
+	w.res.WriteHeader(http.StatusOK)
+}
+
+func (w *exportWriter) Write(p []byte) (int, error) {
+	w.commit()
+	return w.res.Write(p)
+}
+
+func (w *exportWriter) Flush() {
+	w.res.Flush()
+}
+
+func exportFilename(at time.Time, format string) string {
+	return "users-" + at.UTC().Format("20060102-150405") + "." + format
+}
+
+// CreateUserExportJob starts an export in the background; poll the job until it has a download_url
+func (h *userExportHandler) CreateUserExportJob(c echo.Context) error {
+	var req model.CreateUserExportRequest
+	if err := c.Bind(&req); err != nil {
+		return response.BadRequest("BIND_ERROR", "Invalid request body", err)
+	}
+
+	if err := c.Validate(&req); err != nil {
+		fieldErrors := h.validator.ExtractValidationErrors(err, appMiddleware.GetLocaleFromContext(c))
+		if len(fieldErrors) == 0 {
+			c.Logger().Errorf("Validation error (non-field): %v, Type: %T", err, err)
+			return response.BadRequest("VALIDATION_FAILED", "Validation failed", err)
+		}
+		return response.BadRequestWithFields("VALIDATION_FAILED", "Validation failed", fieldErrors)
+	}
+
+	actorID := appMiddleware.GetUserIDFromContext(c)
+	job, err := h.exportService.CreateExportJob(c.Request().Context(), actorID, &req)
+	if err != nil {
+		return err
+	}
+	c.Response().Header().Set(echo.HeaderLocation, exportJobPath(job.ID))
+	return response.Accepted(c, "SUCCESS", "Export started", job)
+}
+
+func (h *userExportHandler) FindUserExportJob(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return response.BadRequest("INVALID_ID", "Invalid export job ID format", err)
+	}
+	job, err := h.exportService.FindExportJob(c.Request().Context(), id)
+	if err != nil {
+		return err
+	}
+	if job.Status == model.JobStatusSucceeded && job.FilePath != nil && job.ExpiresAt != nil && job.ExpiresAt.After(time.Now()) {
+		job.DownloadURL = exportJobPath(job.ID) + "/download"
+	}
+	return response.Success(c, "SUCCESS", "Export job retrieved successfully", job)
+}
+
+func (h *userExportHandler) DownloadUserExport(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return response.BadRequest("INVALID_ID", "Invalid export job ID format", err)
+	}
+	job, file, err := h.exportService.OpenExport(c.Request().Context(), id)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return response.Internal(err)
+	}
+	format := filepath.Ext(*job.FilePath)[1:]
+	c.Response().Header().Set(echo.HeaderContentType, exportContentTypes[format])
+	c.Response().Header().Set(echo.HeaderContentDisposition, `attachment; filename="`+exportFilename(*job.FinishedAt, format)+`"`)
+	http.ServeContent(c.Response(), c.Request(), "", info.ModTime(), file)
+	return nil
+}
+
+// exportJobPath is the URL path of an export job
+func exportJobPath(id int) string {
+	return "/api/v1/admin/users/export-jobs/" + strconv.Itoa(id)
+}
+
+func NewUserExportHandler(exportService service.IUserExportService, validator *utils.CustomValidator) IUserExportHandler {
+	return &userExportHandler{exportService: exportService, validator: validator}
+}
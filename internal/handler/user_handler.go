@@ -17,8 +17,13 @@ type IUserHandler interface {
 	FindAllUsers(c echo.Context) error
 	FindUserByID(c echo.Context) error
 	FindUserByEmail(c echo.Context) error
+	FindUserByPhone(c echo.Context) error
 	Login(c echo.Context) error
 	GetMyInfo(c echo.Context) error
+	ChangePassword(c echo.Context) error
+	UpdateRole(c echo.Context) error
+	UpdateStatus(c echo.Context) error
+	FindStatusChanges(c echo.Context) error
 }
 
 type userHandler struct {
@@ -33,7 +38,7 @@ func (h *userHandler) Login(c echo.Context) error {
 	}
 
 	if err := c.Validate(&req); err != nil {
-		fieldErrors := h.validator.ExtractValidationErrors(err)
+		fieldErrors := h.validator.ExtractValidationErrors(err, appMiddleware.GetLocaleFromContext(c))
 		if len(fieldErrors) == 0 {
 			c.Logger().Errorf("Validation error (non-field): %v, Type: %T", err, err)
 			return response.BadRequest("VALIDATION_FAILED", "Validation failed", err)
@@ -41,7 +46,7 @@ func (h *userHandler) Login(c echo.Context) error {
 		return response.BadRequestWithFields("VALIDATION_FAILED", "Validation failed", fieldErrors)
 	}
 
-	loginResp, err := h.userService.Login(c.Request().Context(), &req)
+	loginResp, err := h.userService.Login(c.Request().Context(), &req, sessionClient(c))
 	if err != nil {
 		return err
 	}
@@ -57,7 +62,7 @@ func (h *userHandler) CreateUser(c echo.Context) error {
 
 	if err := c.Validate(&req); err != nil {
 		// Extract field-level validation errors using the validator instance
-		fieldErrors := h.validator.ExtractValidationErrors(err)
+		fieldErrors := h.validator.ExtractValidationErrors(err, appMiddleware.GetLocaleFromContext(c))
 		// If no field errors were extracted, it means validation failed for some other reason
 		if len(fieldErrors) == 0 {
 			// Log for debugging
@@ -78,8 +83,12 @@ func (h *userHandler) FindAllUsers(c echo.Context) error {
 	if err := c.Bind(&pagReq); err != nil {
 		return response.BadRequest("BIND_ERROR", "Invalid pagination parameters", err)
 	}
+	fields, err := userFields(c)
+	if err != nil {
+		return err
+	}
 	offset, limit, page, pageSize := pagReq.GetQueryParams()
-	users, total, err := h.userService.FindAllUsers(c.Request().Context(), limit, offset)
+	users, total, err := h.userService.FindAllUsers(c.Request().Context(), limit, offset, fields)
 	if err != nil {
 		return err
 	}
@@ -90,28 +99,54 @@ func (h *userHandler) FindAllUsers(c echo.Context) error {
 		TotalPages: totalPages,
 		TotalItems: total,
 	}
+	if fields != nil {
+		return response.ListWithPagination(c, "SUCCESS", "Users retrieved successfully", fields.Views(users), pagination)
+	}
 	return response.ListWithPagination(c, "SUCCESS", "Users retrieved successfully", users, pagination)
 }
+
 func (h *userHandler) FindUserByID(c echo.Context) error {
 	id := c.Param("id")
 	userID, err := strconv.Atoi(id)
 	if err != nil {
 		return response.BadRequest("INVALID_ID", "Invalid user ID format", err)
 	}
-	user, err := h.userService.FindUserByID(c.Request().Context(), userID)
+	fields, err := userFields(c)
+	if err != nil {
+		return err
+	}
+	user, err := h.userService.FindUserByID(c.Request().Context(), userID, fields)
 	if err != nil {
 		return err
 	}
-	return response.Success(c, "SUCCESS", "User retrieved successfully", user)
+	return successUser(c, "User retrieved successfully", fields, user)
 }
 
 func (h *userHandler) FindUserByEmail(c echo.Context) error {
+	fields, err := userFields(c)
+	if err != nil {
+		return err
+	}
 	email := c.QueryParam("email")
-	user, err := h.userService.FindUserByEmail(c.Request().Context(), email)
+	user, err := h.userService.FindUserByEmail(c.Request().Context(), email, fields)
+	if err != nil {
+		return err
+	}
+	return successUser(c, "User retrieved successfully", fields, user)
+}
+
+// FindUserByPhone accepts any supported phone format (0912..., 84912..., +84912...)
+func (h *userHandler) FindUserByPhone(c echo.Context) error {
+	fields, err := userFields(c)
 	if err != nil {
 		return err
 	}
-	return response.Success(c, "SUCCESS", "User retrieved successfully", user)
+	phone := c.QueryParam("phone")
+	user, err := h.userService.FindUserByPhone(c.Request().Context(), phone, fields)
+	if err != nil {
+		return err
+	}
+	return successUser(c, "User retrieved successfully", fields, user)
 }
 
 func (h *userHandler) GetMyInfo(c echo.Context) error {
@@ -120,13 +155,154 @@ func (h *userHandler) GetMyInfo(c echo.Context) error {
 		return response.Unauthorized("INVALID_CONTEXT", "User ID not found in context", nil)
 	}
 
-	// Convert int to string using strconv
-	user, err := h.userService.FindUserByID(c.Request().Context(), userID)
+	fields, err := userFields(c)
+	if err != nil {
+		return err
+	}
+	user, err := h.userService.FindUserByID(c.Request().Context(), userID, fields)
 	if err != nil {
 		return err
 	}
 
-	return response.Success(c, "SUCCESS", "User info retrieved successfully", user)
+	return successUser(c, "User info retrieved successfully", fields, user)
+}
+
+func (h *userHandler) ChangePassword(c echo.Context) error {
+	userID := appMiddleware.GetUserIDFromContext(c)
+	if userID == 0 {
+		return response.Unauthorized("INVALID_CONTEXT", "User ID not found in context", nil)
+	}
+
+	var req model.ChangePasswordRequest
+	if err := c.Bind(&req); err != nil {
+		return response.BadRequest("BIND_ERROR", "Invalid request body", err)
+	}
+
+	if err := c.Validate(&req); err != nil {
+		fieldErrors := h.validator.ExtractValidationErrors(err, appMiddleware.GetLocaleFromContext(c))
+		if len(fieldErrors) == 0 {
+			c.Logger().Errorf("Validation error (non-field): %v, Type: %T", err, err)
+			return response.BadRequest("VALIDATION_FAILED", "Validation failed", err)
+		}
+		return response.BadRequestWithFields("VALIDATION_FAILED", "Validation failed", fieldErrors)
+	}
+
+	if err := h.userService.ChangePassword(c.Request().Context(), userID, &req); err != nil {
+		return err
+	}
+	return response.NoContent(c)
+}
+
+// UpdateRole changes a user's role; tokens issued with the old role stop working
+func (h *userHandler) UpdateRole(c echo.Context) error {
+	userID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return response.BadRequest("INVALID_ID", "Invalid user ID format", err)
+	}
+
+	var req model.UpdateRoleRequest
+	if err := c.Bind(&req); err != nil {
+		return response.BadRequest("BIND_ERROR", "Invalid request body", err)
+	}
+
+	if err := c.Validate(&req); err != nil {
+		fieldErrors := h.validator.ExtractValidationErrors(err, appMiddleware.GetLocaleFromContext(c))
+		if len(fieldErrors) == 0 {
+			c.Logger().Errorf("Validation error (non-field): %v, Type: %T", err, err)
+			return response.BadRequest("VALIDATION_FAILED", "Validation failed", err)
+		}
+		return response.BadRequestWithFields("VALIDATION_FAILED", "Validation failed", fieldErrors)
+	}
+
+	version, err := ifMatchVersion(c, userID)
+	if err != nil {
+		return err
+	}
+	user, err := h.userService.UpdateRole(c.Request().Context(), userID, version, req.Role)
+	if err != nil {
+		return err
+	}
+	return response.Success(c, "SUCCESS", "User role updated successfully", user)
+}
+
+// UpdateStatus activates, deactivates or suspends an account; a reason is required for the audit trail
+func (h *userHandler) UpdateStatus(c echo.Context) error {
+	userID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return response.BadRequest("INVALID_ID", "Invalid user ID format", err)
+	}
+
+	var req model.UpdateUserStatusRequest
+	if err := c.Bind(&req); err != nil {
+		return response.BadRequest("BIND_ERROR", "Invalid request body", err)
+	}
+
+	if err := c.Validate(&req); err != nil {
+		fieldErrors := h.validator.ExtractValidationErrors(err, appMiddleware.GetLocaleFromContext(c))
+		if len(fieldErrors) == 0 {
+			c.Logger().Errorf("Validation error (non-field): %v, Type: %T", err, err)
+			return response.BadRequest("VALIDATION_FAILED", "Validation failed", err)
+		}
+		return response.BadRequestWithFields("VALIDATION_FAILED", "Validation failed", fieldErrors)
+	}
+
+	version, err := ifMatchVersion(c, userID)
+	if err != nil {
+		return err
+	}
+	actorID := appMiddleware.GetUserIDFromContext(c)
+	user, err := h.userService.UpdateStatus(c.Request().Context(), actorID, userID, version, &req)
+	if err != nil {
+		return err
+	}
+	return response.Success(c, "SUCCESS", "User status updated successfully", user)
+}
+
+// userFields parses the ?fields= sparse fieldset; nil means the whole user
+func userFields(c echo.Context) (model.UserFields, error) {
+	fields, fieldErrors := model.ParseUserFields("fields", c.QueryParam("fields"))
+	if fieldErrors != nil {
+		return nil, response.BadRequestWithFields("VALIDATION_FAILED", "Validation failed", fieldErrors)
+	}
+	return fields, nil
+}
+
+// successUser answers with user, limited to the request's sparse fieldset if it has one
+func successUser(c echo.Context, message string, fields model.UserFields, user *model.User) error {
+	if fields != nil {
+		return response.Success(c, "SUCCESS", message, fields.View(user))
+	}
+	return response.Success(c, "SUCCESS", message, user)
+}
+
+// ifMatchVersion returns the version of the user an update's If-Match header expects, 0 for If-Match: *
+// Updates must send it, so they can't silently overwrite a change made since the user was read
+func ifMatchVersion(c echo.Context, userID int) (int, error) {
+	header := c.Request().Header.Get(response.HeaderIfMatch)
+	if header == "" {
+		return 0, response.PreconditionRequired("PRECONDITION_REQUIRED", "Send the user's ETag in If-Match", nil)
+	}
+	for _, tag := range response.ETagList(header) {
+		if tag == "*" {
+			return 0, nil
+		}
+		if version, ok := model.UserVersionFromETag(userID, tag); ok {
+			return version, nil
+		}
+	}
+	return 0, response.PreconditionFailed("PRECONDITION_FAILED", "The user has changed since it was read; fetch it again and retry", nil)
+}
+
+func (h *userHandler) FindStatusChanges(c echo.Context) error {
+	userID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return response.BadRequest("INVALID_ID", "Invalid user ID format", err)
+	}
+	changes, err := h.userService.FindStatusChanges(c.Request().Context(), userID)
+	if err != nil {
+		return err
+	}
+	return response.List(c, "SUCCESS", "Status changes retrieved successfully", changes)
 }
 
 func NewUserHandler(userService service.IUserService, validator *utils.CustomValidator) IUserHandler {
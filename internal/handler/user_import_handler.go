@@ -0,0 +1,81 @@
+package handler
+
+import (
+	appMiddleware "golang-echo/internal/middleware"
+	"golang-echo/internal/model"
+	"golang-echo/internal/service"
+	"golang-echo/pkg/response"
+	"golang-echo/pkg/utils"
+	"mime"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+)
+
+type IUserImportHandler interface {
+	ImportUsers(c echo.Context) error
+	FindUserImportJob(c echo.Context) error
+}
+
+type userImportHandler struct {
+	importService service.IUserImportService
+	validator     *utils.CustomValidator
+}
+
+// importFormats maps the Content-Types an import file can be sent as to its format
+var importFormats = map[string]string{
+	response.MIMETextCSV:           model.ExportFormatCSV,
+	response.MIMEApplicationNDJSON: model.ExportFormatNDJSON,
+	"application/ndjson":           model.ExportFormatNDJSON,
+	"application/jsonl":            model.ExportFormatNDJSON,
+}
+
+// ImportUsers starts importing the CSV or NDJSON file in the body; the job reports progress and,
+// when finished, what happened to every row that wasn't imported
+func (h *userImportHandler) ImportUsers(c echo.Context) error {
+	mediaType, _, _ := mime.ParseMediaType(c.Request().Header.Get(echo.HeaderContentType))
+	format, ok := importFormats[mediaType]
+	if !ok {
+		return response.UnsupportedMediaType("UNSUPPORTED_IMPORT_FORMAT", "Send the file as text/csv or application/x-ndjson", nil)
+	}
+
+	// Only the query is bound: the body is the file
+	var query model.ImportUsersQuery
+	if err := (&echo.DefaultBinder{}).BindQueryParams(c, &query); err != nil {
+		return response.BadRequest("BIND_ERROR", "Invalid query parameters", err)
+	}
+
+	if err := c.Validate(&query); err != nil {
+		fieldErrors := h.validator.ExtractValidationErrors(err, appMiddleware.GetLocaleFromContext(c))
+		if len(fieldErrors) == 0 {
+			c.Logger().Errorf("Validation error (non-field): %v, Type: %T", err, err)
+			return response.BadRequest("VALIDATION_FAILED", "Validation failed", err)
+		}
+		return response.BadRequestWithFields("VALIDATION_FAILED", "Validation failed", fieldErrors)
+	}
+
+	actorID := appMiddleware.GetUserIDFromContext(c)
+	locale := appMiddleware.GetLocaleFromContext(c)
+	job, err := h.importService.CreateImportJob(c.Request().Context(), actorID, format, c.Request().Body, &query, locale)
+	if err != nil {
+		return err
+	}
+	c.Response().Header().Set(echo.HeaderLocation, "/api/v1/admin/users/import-jobs/"+strconv.Itoa(job.ID))
+	return response.Accepted(c, "SUCCESS", "Import started", job)
+}
+
+func (h *userImportHandler) FindUserImportJob(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return response.BadRequest("INVALID_ID", "Invalid import job ID format", err)
+	}
+	job, err := h.importService.FindImportJob(c.Request().Context(), id)
+	if err != nil {
+		return err
+	}
+	return response.Success(c, "SUCCESS", "Import job retrieved successfully", job)
+}
+
+func NewUserImportHandler(importService service.IUserImportService, validator *utils.CustomValidator) IUserImportHandler {
+	return &userImportHandler{importService: importService, validator: validator}
+}
@@ -0,0 +1,314 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"golang-echo/internal/model"
+	"golang-echo/pkg/response"
+
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	HeaderIdempotencyKey = "Idempotency-Key"
+	// HeaderIdempotentReplayed is set on a response replayed for a retry
+	HeaderIdempotentReplayed = "Idempotent-Replayed"
+
+	maxIdempotencyKeyLength = 255
+	// maxIdempotentBody bounds the request body hashed into the fingerprint, and the response body kept
+	maxIdempotentBody = 1 << 20
+	// idempotencyCleanupInterval is how often expired records are deleted, on the back of a request
+	idempotencyCleanupInterval = 10 * time.Minute
+)
+
+// IdempotencyStore keeps the responses of requests sent with an Idempotency-Key
+// repository.NewIdempotencyRepository stores them in Postgres, NewIdempotencyMemoryStore in the process
+type IdempotencyStore interface {
+	// Lock claims record.Key for an in-flight request, unless another request holds it or its
+	// response is still kept; then it returns that record and leaves it as it is
+	Lock(ctx context.Context, record *model.IdempotencyRecord) (*model.IdempotencyRecord, error)
+	// Save stores the response of a request that locked its key
+	Save(ctx context.Context, record *model.IdempotencyRecord) error
+	// Unlock frees the key of a request whose response is not kept, so a retry runs again
+	Unlock(ctx context.Context, record *model.IdempotencyRecord) error
+	// DeleteExpired removes the records expired by now
+	DeleteExpired(ctx context.Context, now time.Time) error
+}
+
+type IdempotencyConfig struct {
+	Store IdempotencyStore
+	// TTL is how long a response is replayed for its key
+	TTL time.Duration
+	// LockTimeout is how long a request holds its key; a key still held after that can be used again
+	LockTimeout time.Duration
+}
+
+// unkeptHeaders are response headers set on the way out by other middlewares, which set them again on a replay
+var unkeptHeaders = []string{echo.HeaderContentLength, echo.HeaderContentEncoding, echo.HeaderVary}
+
+// Idempotency makes a POST sent with an Idempotency-Key safe to retry: the first request runs and its
+// response is kept for config.TTL; a retry with the same key and request gets that response again,
+// with Idempotent-Replayed: true, instead of running a second time
+// Keys are scoped to the caller and the path, so put it after the authentication middlewares;
+// an unauthenticated caller is scoped by client IP
+// Reusing a key for a different request is a 422 IDEMPOTENCY_KEY_REUSED, and a retry that arrives
+// while the first request is still running a 409 IDEMPOTENCY_KEY_IN_USE with Retry-After
+// 5xx responses are not kept, so a retry after a server error runs again
+func Idempotency(config IdempotencyConfig) echo.MiddlewareFunc {
+	var lastCleanup atomic.Int64
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			req := c.Request()
+			key := req.Header.Get(HeaderIdempotencyKey)
+			if req.Method != http.MethodPost || key == "" {
+				return next(c)
+			}
+			if !validIdempotencyKey(key) {
+				return response.BadRequest("INVALID_IDEMPOTENCY_KEY", "Idempotency-Key must be 1 to 255 printable ASCII characters", nil)
+			}
+
+			body, err := io.ReadAll(io.LimitReader(req.Body, maxIdempotentBody+1))
+			if err != nil {
+				return response.BadRequest("BIND_ERROR", "Invalid request body", err)
+			}
+			if len(body) > maxIdempotentBody {
+				return response.PayloadTooLarge("IDEMPOTENCY_BODY_TOO_LARGE", "The request body is too large to send with an Idempotency-Key", nil)
+			}
+			req.Body = io.NopCloser(bytes.NewReader(body))
+
+			now := time.Now()
+			record := &model.IdempotencyRecord{
+				Key:         idempotencyHash(idempotencyScope(c), req.Method, req.URL.Path, key),
+				Fingerprint: idempotencyHash(req.Method, req.URL.RequestURI(), req.Header.Get(echo.HeaderContentType), string(body)),
+				CreatedAt:   now,
+				LockedUntil: now.Add(config.LockTimeout),
+				ExpiresAt:   now.Add(config.TTL),
+			}
+			// The outcome is kept even if the client goes away, since that's when it will retry
+			ctx := context.WithoutCancel(req.Context())
+			if last := lastCleanup.Load(); now.Sub(time.Unix(0, last)) >= idempotencyCleanupInterval && lastCleanup.CompareAndSwap(last, now.UnixNano()) {
+				go func() {
+					if err := config.Store.DeleteExpired(ctx, now); err != nil {
+						slog.ErrorContext(ctx, "failed to delete expired idempotency keys", slog.Any("error", err))
+					}
+				}()
+			}
+
+			existing, err := config.Store.Lock(ctx, record)
+			if err != nil {
+				return response.Internal(err)
+			}
+			if existing != nil {
+				return replayIdempotent(c, record, existing)
+			}
+
+			res := c.Response()
+			before := res.Header().Clone()
+			recorder := &idempotencyRecorder{ResponseWriter: res.Writer}
+			res.Writer = recorder
+			kept := false
+			defer func() {
+				res.Writer = recorder.ResponseWriter
+				// Also reached when the handler panics
+				if !kept {
+					if err := config.Store.Unlock(ctx, record); err != nil {
+						slog.ErrorContext(ctx, "failed to unlock idempotency key", slog.Any("error", err))
+					}
+				}
+			}()
+
+			if err := next(c); err != nil {
+				// Write the error response now so it is kept too
+				c.Error(err)
+			}
+			if recorder.status == 0 || recorder.status >= http.StatusInternalServerError || recorder.truncated {
+				return nil
+			}
+
+			header := http.Header{}
+			for name, values := range res.Header() {
+				if slices.Contains(unkeptHeaders, name) || slices.Equal(before[name], values) {
+					continue
+				}
+				header[name] = values
+			}
+			record.Status = recorder.status
+			record.Header, _ = json.Marshal(header)
+			record.Body = recorder.body.Bytes()
+			record.ExpiresAt = time.Now().Add(config.TTL)
+			if err := config.Store.Save(ctx, record); err != nil {
+				slog.ErrorContext(ctx, "failed to save idempotent response", slog.Any("error", err))
+				return nil
+			}
+			kept = true
+			return nil
+		}
+	}
+}
+
+// replayIdempotent answers a request whose key is already taken
+func replayIdempotent(c echo.Context, record *model.IdempotencyRecord, existing *model.IdempotencyRecord) error {
+	if existing.Fingerprint != record.Fingerprint {
+		return response.UnprocessableEntity("IDEMPOTENCY_KEY_REUSED", "This Idempotency-Key was already used for a different request", nil)
+	}
+	if existing.InFlight() {
+		c.Response().Header().Set("Retry-After", "1")
+		return response.Conflict("IDEMPOTENCY_KEY_IN_USE", "A request with this Idempotency-Key is still in progress; retry later", nil)
+	}
+
+	res := c.Response()
+	var header http.Header
+	if len(existing.Header) > 0 {
+		if err := json.Unmarshal(existing.Header, &header); err != nil {
+			return response.Internal(err)
+		}
+	}
+	for name, values := range header {
+		res.Header()[name] = values
+	}
+	res.Header().Set(HeaderIdempotentReplayed, "true")
+	res.WriteHeader(existing.Status)
+	if len(existing.Body) > 0 {
+		_, err := res.Write(existing.Body)
+		return err
+	}
+	return nil
+}
+
+// idempotencyScope identifies the caller a key belongs to, so two callers never share a key
+func idempotencyScope(c echo.Context) string {
+	if userID := GetUserIDFromContext(c); userID != 0 {
+		return "user:" + strconv.Itoa(userID)
+	}
+	return "ip:" + c.RealIP()
+}
+
+// validIdempotencyKey accepts what fits a header value and a log line: printable ASCII, e.g. a UUID
+func validIdempotencyKey(key string) bool {
+	if len(key) > maxIdempotencyKeyLength {
+		return false
+	}
+	for i := 0; i < len(key); i++ {
+		if key[i] < 0x21 || key[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+// idempotencyHash hashes parts, length-prefixed so their boundaries can't shift
+func idempotencyHash(parts ...string) string {
+	hash := sha256.New()
+	for _, part := range parts {
+		hash.Write([]byte(strconv.Itoa(len(part)) + ":" + part))
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// idempotencyRecorder writes the response through and keeps a copy of it
+type idempotencyRecorder struct {
+	http.ResponseWriter
+	status    int
+	body      bytes.Buffer
+	truncated bool // the body was too large to keep
+}
+
+func (r *idempotencyRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *idempotencyRecorder) Write(b []byte) (int, error) {
+	if r.status == 0 {
+		r.WriteHeader(http.StatusOK)
+	}
+	if !r.truncated {
+		if r.body.Len()+len(b) > maxIdempotentBody {
+			r.truncated = true
+			r.body.Reset()
+		} else {
+			r.body.Write(b)
+		}
+	}
+	return r.ResponseWriter.Write(b)
+}
+
+func (r *idempotencyRecorder) Flush() {
+	if flusher, ok := r.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+func (r *idempotencyRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
+// idempotencyMemoryStore is an IdempotencyStore for a single instance; records are lost on restart
+type idempotencyMemoryStore struct {
+	mu      sync.Mutex
+	records map[string]model.IdempotencyRecord
+}
+
+// NewIdempotencyMemoryStore keeps idempotency records in the process, for a single instance
+func NewIdempotencyMemoryStore() IdempotencyStore {
+	return &idempotencyMemoryStore{records: map[string]model.IdempotencyRecord{}}
+}
+
+func (s *idempotencyMemoryStore) Lock(ctx context.Context, record *model.IdempotencyRecord) (*model.IdempotencyRecord, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if existing, ok := s.records[record.Key]; ok {
+		expired := !existing.ExpiresAt.After(record.CreatedAt)
+		abandoned := existing.InFlight() && !existing.LockedUntil.After(record.CreatedAt)
+		if !expired && !abandoned {
+			return &existing, nil
+		}
+	}
+	locked := *record
+	locked.Status, locked.Header, locked.Body = 0, nil, nil
+	s.records[record.Key] = locked
+	return nil, nil
+}
+
+func (s *idempotencyMemoryStore) Save(ctx context.Context, record *model.IdempotencyRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if existing, ok := s.records[record.Key]; ok && existing.InFlight() && existing.Fingerprint == record.Fingerprint {
+		saved := *record
+		saved.Body = bytes.Clone(record.Body)
+		s.records[record.Key] = saved
+	}
+	return nil
+}
+
+func (s *idempotencyMemoryStore) Unlock(ctx context.Context, record *model.IdempotencyRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if existing, ok := s.records[record.Key]; ok && existing.InFlight() && existing.Fingerprint == record.Fingerprint {
+		delete(s.records, record.Key)
+	}
+	return nil
+}
+
+func (s *idempotencyMemoryStore) DeleteExpired(ctx context.Context, now time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for key, record := range s.records {
+		if !record.ExpiresAt.After(now) {
+			delete(s.records, key)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,64 @@
+package middleware
+
+import (
+	"log/slog"
+	"strconv"
+
+	"golang-echo/pkg/audit"
+	"golang-echo/pkg/response"
+
+	"github.com/labstack/echo/v4"
+)
+
+// DenyImpersonation blocks impersonation tokens from a route, e.g. credential changes
+func DenyImpersonation() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if GetImpersonatorIDFromContext(c) != 0 {
+				return response.Forbidden("IMPERSONATION_FORBIDDEN", "This action is not available while impersonating a user", nil)
+			}
+			return next(c)
+		}
+	}
+}
+
+// AuditImpersonation logs and audits every request made with an impersonation token,
+// with both the impersonated user and the admin behind it. Must run after JWTMiddleware
+func AuditImpersonation(recorder AuditRecorder) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			impersonatorID := GetImpersonatorIDFromContext(c)
+			if impersonatorID == 0 {
+				return next(c)
+			}
+
+			err := next(c)
+
+			route := c.Request().Method + " " + c.Path()
+			ctx := c.Request().Context()
+			slog.InfoContext(ctx, "impersonated request",
+				slog.Int("user_id", GetUserIDFromContext(c)),
+				slog.Int("impersonator_id", impersonatorID),
+				slog.String("route", route),
+				slog.String("uri", c.Request().RequestURI),
+			)
+			recorder.Record(ctx, audit.Entry{
+				Action:     audit.ActionImpersonatedRequest,
+				TargetType: audit.TargetUser,
+				TargetID:   strconv.Itoa(GetUserIDFromContext(c)),
+				Outcome:    audit.OutcomeOf(err),
+				Metadata:   map[string]any{"route": route, "uri": c.Request().RequestURI},
+			})
+			return err
+		}
+	}
+}
+
+// GetImpersonatorIDFromContext returns the admin behind an impersonation token, or 0
+func GetImpersonatorIDFromContext(c echo.Context) int {
+	impersonatorID, ok := c.Get("impersonator_id").(int)
+	if !ok {
+		return 0
+	}
+	return impersonatorID
+}
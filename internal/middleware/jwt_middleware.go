@@ -1,18 +1,47 @@
 package middleware
 
 import (
+	"context"
 	"strings"
 
+	"golang-echo/pkg/audit"
 	"golang-echo/pkg/constants"
+	"golang-echo/pkg/oauth"
 	"golang-echo/pkg/response"
 	"golang-echo/pkg/utils"
 
 	"github.com/labstack/echo/v4"
 )
 
-func JWTMiddleware(jwtManager *utils.JWTManager) echo.MiddlewareFunc {
+// SessionValidator checks that the login session behind a token hasn't been revoked
+type SessionValidator interface {
+	ValidateSession(ctx context.Context, tokenID string, userID int) error
+}
+
+// TokenVersionChecker rejects tokens of non-active accounts and tokens issued before the user's token_version was bumped
+type TokenVersionChecker interface {
+	CheckTokenVersion(ctx context.Context, userID int, tokenVersion int) error
+}
+
+// ClientChecker rejects the access tokens of revoked OAuth clients
+type ClientChecker interface {
+	CheckClient(ctx context.Context, clientID string) error
+}
+
+// JWTMiddleware authenticates bearer tokens
+// Every token must belong to an active account and carry the user's current token_version
+// (both checked against a short-lived cache).
+// Login tokens carry their session id as jti and are rejected once that session is revoked;
+// OAuth access tokens (client_id set) have no session: they are short-lived (oauth.access_token_ttl)
+// and rejected once their client is revoked
+func JWTMiddleware(jwtManager *utils.JWTManager, sessions SessionValidator, versions TokenVersionChecker, clients ClientChecker) echo.MiddlewareFunc {
 	return func(next echo.HandlerFunc) echo.HandlerFunc {
 		return func(c echo.Context) error {
+			// Already authenticated by APIKeyMiddleware
+			if GetAuthMethodFromContext(c) == AuthMethodAPIKey {
+				return next(c)
+			}
+
 			authHeader := c.Request().Header.Get("Authorization")
 			if authHeader == "" {
 				return response.Unauthorized("MISSING_TOKEN", "Authorization header is missing", nil)
@@ -28,19 +57,62 @@ func JWTMiddleware(jwtManager *utils.JWTManager) echo.MiddlewareFunc {
 			if err != nil {
 				return response.Unauthorized("INVALID_TOKEN", "Token is invalid or expired", err)
 			}
+			if err := versions.CheckTokenVersion(c.Request().Context(), claims.UserID, claims.TokenVersion); err != nil {
+				return err
+			}
+			// An impersonation token dies with the admin's own access
+			if claims.Actor != nil {
+				if err := versions.CheckTokenVersion(c.Request().Context(), claims.Actor.UserID, claims.Actor.TokenVersion); err != nil {
+					return err
+				}
+			}
+			if claims.ClientID == "" {
+				if claims.ID == "" {
+					return response.Unauthorized("INVALID_TOKEN", "Token is invalid or expired", nil)
+				}
+				if err := sessions.ValidateSession(c.Request().Context(), claims.ID, claims.UserID); err != nil {
+					return err
+				}
+				c.Set("token_id", claims.ID)
+			} else if err := clients.CheckClient(c.Request().Context(), claims.ClientID); err != nil {
+				return err
+			}
 
 			c.Set("user_id", claims.UserID)
 			c.Set("email", claims.Email)
 			c.Set("name", claims.Name)
 			c.Set("role", claims.Role)
+			c.Set("auth_method", AuthMethodJWT)
+			if claims.ClientID != "" {
+				c.Set("client_id", claims.ClientID)
+				c.Set("scope", claims.Scope)
+			}
+			// OAuth tokens are limited to their granted scopes, like API keys
+			switch {
+			case claims.GrantType == oauth.GrantTypeClientCredentials:
+				c.Set("auth_method", AuthMethodOAuthClient)
+				c.Set("permissions", oauth.ParseScope(claims.Scope))
+			case claims.ClientID != "":
+				c.Set("auth_method", AuthMethodOAuthUser)
+				c.Set("permissions", oauth.ParseScope(claims.Scope))
+			}
+			audit.SetActor(c.Request().Context(), claims.UserID, GetAuthMethodFromContext(c))
+			if claims.Actor != nil {
+				c.Set("impersonator_id", claims.Actor.UserID)
+				audit.SetImpersonator(c.Request().Context(), claims.Actor.UserID)
+			}
 			return next(c)
 		}
 	}
 }
 
+// AdminMiddleware restricts routes to admins; impersonation tokens never pass, whoever they act as
 func AdminMiddleware() echo.MiddlewareFunc {
 	return func(next echo.HandlerFunc) echo.HandlerFunc {
 		return func(c echo.Context) error {
+			if GetImpersonatorIDFromContext(c) != 0 {
+				return response.Forbidden("IMPERSONATION_FORBIDDEN", "This action is not available while impersonating a user", nil)
+			}
 			role, ok := c.Get("role").(string)
 			if !ok || !constants.IsAdmin(role) {
 				return response.Forbidden("FORBIDDEN", "You do not have permission to access this resource", nil)
@@ -65,3 +137,29 @@ func GetEmailFromContext(c echo.Context) string {
 	}
 	return email
 }
+
+// GetClientIDFromContext returns the OAuth client the access token was issued to, if any
+func GetClientIDFromContext(c echo.Context) string {
+	clientID, ok := c.Get("client_id").(string)
+	if !ok {
+		return ""
+	}
+	return clientID
+}
+
+func GetScopeFromContext(c echo.Context) string {
+	scope, ok := c.Get("scope").(string)
+	if !ok {
+		return ""
+	}
+	return scope
+}
+
+// GetTokenIDFromContext returns the jti of the login token, which identifies the current session
+func GetTokenIDFromContext(c echo.Context) string {
+	tokenID, ok := c.Get("token_id").(string)
+	if !ok {
+		return ""
+	}
+	return tokenID
+}
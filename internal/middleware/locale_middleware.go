@@ -0,0 +1,28 @@
+package middleware
+
+import (
+	"golang-echo/pkg/utils"
+
+	"github.com/labstack/echo/v4"
+)
+
+// LocaleMiddleware negotiates the request locale from ?lang= or Accept-Language
+// and stores it in the context for validation messages and error localisation
+func LocaleMiddleware(supported []string, defaultLocale string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			locale := utils.NegotiateLocale(c.Request(), supported, defaultLocale)
+			c.Set("locale", locale)
+			c.Response().Header().Set("Content-Language", locale)
+			return next(c)
+		}
+	}
+}
+
+func GetLocaleFromContext(c echo.Context) string {
+	locale, ok := c.Get("locale").(string)
+	if !ok {
+		return ""
+	}
+	return locale
+}
@@ -0,0 +1,240 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"strings"
+
+	"golang-echo/pkg/msgpack"
+	"golang-echo/pkg/openapi"
+	"golang-echo/pkg/request"
+	"golang-echo/pkg/response"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Response validation modes
+const (
+	ResponseValidationOff  = "off"
+	ResponseValidationLog  = "log"  // log violations and send the response unchanged
+	ResponseValidationFail = "fail" // replace the response with a 500 RESPONSE_CONTRACT_VIOLATION
+)
+
+type OpenAPIValidatorConfig struct {
+	// ValidateRequests rejects requests whose path parameters, query or JSON (or MessagePack) body don't match the operation
+	ValidateRequests bool
+	// ResponseValidation is one of the ResponseValidation* modes
+	ResponseValidation string
+}
+
+// OpenAPIValidator checks requests, and optionally responses, against the operation doc has for the matched route
+// Install it with e.Use once every route is registered, so it runs after routing but before group middlewares
+// and handlers. Requests that fail get the VALIDATION_FAILED field map handlers produce with
+// CustomValidator.ExtractValidationErrors; routes the document doesn't know are passed through
+func OpenAPIValidator(doc *openapi.Document, config OpenAPIValidatorConfig) echo.MiddlewareFunc {
+	validateResponses := config.ResponseValidation == ResponseValidationLog || config.ResponseValidation == ResponseValidationFail
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			req := c.Request()
+			op := doc.Operation(req.Method, openapi.TemplatePath(c.Path()))
+			if op == nil {
+				return next(c)
+			}
+
+			if config.ValidateRequests {
+				fields, err := validateRequest(doc, op, c)
+				if err != nil {
+					return err
+				}
+				if len(fields) > 0 {
+					return response.BadRequestWithFields("VALIDATION_FAILED", "Validation failed", openapi.FieldErrorMap(fields))
+				}
+			}
+			if !validateResponses || req.Method == http.MethodHead {
+				return next(c)
+			}
+
+			res := c.Response()
+			recorder := &responseRecorder{ResponseWriter: res.Writer}
+			res.Writer = recorder
+			err := next(c)
+			if err != nil {
+				// Write the error response now so it is validated too
+				c.Error(err)
+			}
+			res.Writer = recorder.ResponseWriter
+			if !recorder.buffering {
+				return nil
+			}
+
+			fields := doc.ValidateResponse(op, recorder.status, res.Header().Get(echo.HeaderContentType), recorder.body.Bytes())
+			if len(fields) == 0 {
+				recorder.flush()
+				return nil
+			}
+
+			slog.WarnContext(req.Context(), "response does not match the OpenAPI document",
+				slog.String("operation", op.OperationID),
+				slog.Int("status", recorder.status),
+				slog.Any("errors", openapi.FieldErrorMap(fields)),
+			)
+			if config.ResponseValidation == ResponseValidationLog {
+				recorder.flush()
+				return nil
+			}
+			// The echo.Response is already committed, so the replacement goes straight to the writer
+			body, _ := json.Marshal(response.ErrorResponse{
+				Code:      "RESPONSE_CONTRACT_VIOLATION",
+				Message:   "Response does not match the API contract",
+				Errors:    openapi.FieldErrorMap(fields),
+				RequestID: res.Header().Get(echo.HeaderXRequestID),
+			})
+			header := recorder.Header()
+			header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			header.Del(echo.HeaderContentLength)
+			recorder.ResponseWriter.WriteHeader(http.StatusInternalServerError)
+			recorder.ResponseWriter.Write(body)
+			return nil
+		}
+	}
+}
+
+func validateRequest(doc *openapi.Document, op *openapi.Operation, c echo.Context) ([]openapi.FieldError, error) {
+	var fields []openapi.FieldError
+	query := c.QueryParams()
+	for _, param := range op.Parameters {
+		var values []string
+		switch param.In {
+		case "path":
+			values = []string{c.Param(param.Name)}
+		case "query":
+			values = query[param.Name]
+		default:
+			continue
+		}
+		if len(values) == 0 {
+			if param.Required {
+				fields = append(fields, openapi.FieldError{Field: param.Name, Message: param.Name + " is a required field"})
+			}
+			continue
+		}
+		errs, err := validateParameter(doc, param, values)
+		if err != nil {
+			return nil, err
+		}
+		fields = append(fields, errs...)
+	}
+
+	if op.RequestBody == nil {
+		return fields, nil
+	}
+	// MessagePack bodies are checked against the JSON schema, since they bind like the equivalent JSON
+	req := c.Request()
+	contentType := req.Header.Get(echo.HeaderContentType)
+	isMessagePack := request.IsMessagePack(contentType)
+	mediaType, ok := op.RequestBody.Content[echo.MIMEApplicationJSON]
+	if !ok || !isJSON(contentType) && !isMessagePack {
+		return fields, nil
+	}
+	body, err := io.ReadAll(req.Body)
+	if err != nil {
+		return nil, response.BadRequest("BIND_ERROR", "Invalid request body", err)
+	}
+	req.Body = io.NopCloser(bytes.NewReader(body))
+	if len(bytes.TrimSpace(body)) == 0 {
+		return fields, nil
+	}
+	if isMessagePack {
+		if body, err = msgpack.ToJSON(body); err != nil {
+			return fields, nil // malformed MessagePack is left to the handler's BIND_ERROR too
+		}
+	}
+	errs, err := doc.ValidateJSON(mediaType.Schema, body)
+	if err != nil {
+		var syntaxErr *json.SyntaxError
+		if errors.As(err, &syntaxErr) || errors.Is(err, io.ErrUnexpectedEOF) {
+			return fields, nil // malformed JSON is left to the handler's BIND_ERROR
+		}
+		return nil, err
+	}
+	return append(fields, errs...), nil
+}
+
+func validateParameter(doc *openapi.Document, param *openapi.Parameter, values []string) ([]openapi.FieldError, error) {
+	schema, err := doc.Resolve(param.Schema)
+	if err != nil {
+		return nil, err
+	}
+	if schema != nil && schema.Type == "array" {
+		items := make([]any, len(values))
+		for i, value := range values {
+			items[i] = doc.ParseParameter(schema.Items, value)
+		}
+		return doc.ValidateValue(schema, items, param.Name)
+	}
+	// Like Echo's binder, a repeated parameter binds its first value
+	return doc.ValidateValue(schema, doc.ParseParameter(schema, values[0]), param.Name)
+}
+
+// isJSON reports whether contentType is JSON, including problem+json and other +json types
+func isJSON(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	return mediaType == echo.MIMEApplicationJSON || strings.HasSuffix(mediaType, "+json")
+}
+
+// responseRecorder holds back JSON and empty responses until they have been validated
+// Anything else (HTML, redirects with a body, streams) is written through as it comes
+type responseRecorder struct {
+	http.ResponseWriter
+	status    int
+	buffering bool
+	body      bytes.Buffer
+}
+
+func (r *responseRecorder) WriteHeader(status int) {
+	r.status = status
+	contentType := r.Header().Get(echo.HeaderContentType)
+	r.buffering = contentType == "" || isJSON(contentType)
+	if !r.buffering {
+		r.ResponseWriter.WriteHeader(status)
+	}
+}
+
+func (r *responseRecorder) Write(b []byte) (int, error) {
+	if r.status == 0 {
+		r.WriteHeader(http.StatusOK)
+	}
+	if r.buffering {
+		return r.body.Write(b)
+	}
+	return r.ResponseWriter.Write(b)
+}
+
+func (r *responseRecorder) Flush() {
+	if r.buffering {
+		return
+	}
+	if flusher, ok := r.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+func (r *responseRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
+// flush sends the held back response
+func (r *responseRecorder) flush() {
+	r.ResponseWriter.WriteHeader(r.status)
+	if r.body.Len() > 0 {
+		r.ResponseWriter.Write(r.body.Bytes())
+	}
+}
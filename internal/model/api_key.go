@@ -0,0 +1,34 @@
+package model
+
+import (
+	"time"
+
+	"github.com/lib/pq"
+)
+
+type APIKey struct {
+	ID          int            `json:"id" db:"id"`
+	Name        string         `json:"name" db:"name"`
+	Prefix      string         `json:"prefix" db:"prefix"`
+	KeyHash     string         `json:"-" db:"key_hash"`
+	UserID      int            `json:"user_id" db:"user_id"`
+	Permissions pq.StringArray `json:"permissions" db:"permissions"`
+	ExpiresAt   *time.Time     `json:"expires_at" db:"expires_at"`
+	LastUsedAt  *time.Time     `json:"last_used_at" db:"last_used_at"`
+	RevokedAt   *time.Time     `json:"revoked_at" db:"revoked_at"`
+	CreatedBy   *int           `json:"created_by" db:"created_by"`
+	CreatedAt   time.Time      `json:"created_at" db:"created_at"`
+}
+
+type CreateAPIKeyRequest struct {
+	Name        string     `json:"name" validate:"required,min=3,max=100"`
+	UserID      int        `json:"user_id" validate:"omitempty,gt=0"` // defaults to the calling admin
+	Permissions []string   `json:"permissions" validate:"required,min=1,dive,required"`
+	ExpiresAt   *time.Time `json:"expires_at"`
+}
+
+// CreateAPIKeyResponse is the only place the plaintext key is ever returned
+type CreateAPIKeyResponse struct {
+	Key    string  `json:"key"`
+	APIKey *APIKey `json:"api_key"`
+}
@@ -0,0 +1,28 @@
+package model
+
+import "encoding/json"
+
+// BatchRequest is the body of POST /batch: API requests to run for the caller in one round trip
+type BatchRequest struct {
+	Requests []BatchOperation `json:"requests" validate:"required,min=1"`
+	// Parallel runs the requests concurrently, a few at a time; otherwise they run in order
+	Parallel bool `json:"parallel"`
+}
+
+// BatchOperation is one request of a batch, sent with the batch's credentials
+type BatchOperation struct {
+	Method string `json:"method" validate:"required,oneof=GET POST PUT PATCH DELETE"`
+	// Path is the request's path, which must be under /api/v1/, with its query string, e.g. /api/v1/users/42
+	Path string `json:"path" validate:"required"`
+	// Headers may set If-Match, If-None-Match and Idempotency-Key
+	Headers map[string]string `json:"headers,omitempty"`
+	Body    json.RawMessage   `json:"body,omitempty"` // JSON request body
+}
+
+// BatchResult is the response to one request of a batch, in the order of the requests
+type BatchResult struct {
+	Status int `json:"status"`
+	// Headers has the response's ETag and Location, when it set them
+	Headers map[string]string `json:"headers,omitempty"`
+	Body    json.RawMessage   `json:"body,omitempty"` // the JSON response, e.g. its envelope
+}
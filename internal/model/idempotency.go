@@ -0,0 +1,27 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// IdempotencyRecord is the stored outcome of a request sent with an Idempotency-Key
+type IdempotencyRecord struct {
+	// Key identifies the caller, route and Idempotency-Key, hashed
+	Key string `db:"key"`
+	// Fingerprint is a hash of the request; a retry must match it
+	Fingerprint string `db:"fingerprint"`
+	// Status is the response status, 0 while the first request is in flight
+	Status int             `db:"status"`
+	Header json.RawMessage `db:"header"` // the response headers the handler set, as a JSON http.Header
+	Body   []byte          `db:"body"`
+	// LockedUntil is when an in-flight request's hold on the key lapses
+	LockedUntil time.Time `db:"locked_until"`
+	ExpiresAt   time.Time `db:"expires_at"`
+	CreatedAt   time.Time `db:"created_at"`
+}
+
+// InFlight reports whether the record's request has not finished yet
+func (r *IdempotencyRecord) InFlight() bool {
+	return r.Status == 0
+}
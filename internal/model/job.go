@@ -0,0 +1,44 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Job kinds
+const (
+	JobKindUserExport = "user_export" // Params UserExportParams, Result UserExportResult
+	JobKindUserImport = "user_import" // Params UserImportParams, Progress UserImportProgress, Result UserImportReport
+)
+
+// Job statuses
+const (
+	JobStatusPending   = "pending"
+	JobStatusRunning   = "running"
+	JobStatusSucceeded = "succeeded"
+	JobStatusFailed    = "failed"
+)
+
+// Job is a background task run for an admin; Params and Result are specific to its Kind
+type Job struct {
+	ID         int             `json:"id" db:"id"`
+	Kind       string          `json:"kind" db:"kind"`
+	Status     string          `json:"status" db:"status"`
+	CreatedBy  *int            `json:"created_by" db:"created_by"`
+	Params     json.RawMessage `json:"params" db:"params"`
+	Progress   json.RawMessage `json:"progress,omitempty" db:"progress"`
+	Result     json.RawMessage `json:"result,omitempty" db:"result"`
+	Error      *string         `json:"error,omitempty" db:"error"`
+	FilePath   *string         `json:"-" db:"file_path"`
+	CreatedAt  time.Time       `json:"created_at" db:"created_at"`
+	StartedAt  *time.Time      `json:"started_at" db:"started_at"`
+	FinishedAt *time.Time      `json:"finished_at" db:"finished_at"`
+	ExpiresAt  *time.Time      `json:"expires_at" db:"expires_at"`
+	// DownloadURL is where a finished job's file can be fetched until ExpiresAt
+	DownloadURL string `json:"download_url,omitempty" db:"-"`
+}
+
+// Finished reports whether the job has stopped running, successfully or not
+func (j *Job) Finished() bool {
+	return j.Status == JobStatusSucceeded || j.Status == JobStatusFailed
+}
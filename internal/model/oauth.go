@@ -0,0 +1,116 @@
+package model
+
+import (
+	"time"
+
+	"github.com/lib/pq"
+)
+
+type OAuthClient struct {
+	ID               int            `json:"id" db:"id"`
+	ClientID         string         `json:"client_id" db:"client_id"`
+	ClientSecretHash *string        `json:"-" db:"client_secret_hash"`
+	Name             string         `json:"name" db:"name"`
+	RedirectURIs     pq.StringArray `json:"redirect_uris" db:"redirect_uris"`
+	GrantTypes       pq.StringArray `json:"grant_types" db:"grant_types"`
+	Scopes           pq.StringArray `json:"scopes" db:"scopes"`
+	UserID           *int           `json:"user_id" db:"user_id"`
+	RevokedAt        *time.Time     `json:"revoked_at" db:"revoked_at"`
+	CreatedBy        *int           `json:"created_by" db:"created_by"`
+	CreatedAt        time.Time      `json:"created_at" db:"created_at"`
+}
+
+// IsConfidential reports whether the client authenticates with a secret
+func (c *OAuthClient) IsConfidential() bool {
+	return c.ClientSecretHash != nil
+}
+
+type OAuthConsent struct {
+	ID        int            `json:"id" db:"id"`
+	UserID    int            `json:"user_id" db:"user_id"`
+	ClientID  string         `json:"client_id" db:"client_id"`
+	Scopes    pq.StringArray `json:"scopes" db:"scopes"`
+	CreatedAt time.Time      `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at" db:"updated_at"`
+}
+
+type OAuthAuthorizationCode struct {
+	CodeHash      string     `db:"code_hash"`
+	ClientID      string     `db:"client_id"`
+	UserID        int        `db:"user_id"`
+	RedirectURI   string     `db:"redirect_uri"`
+	Scope         string     `db:"scope"`
+	CodeChallenge string     `db:"code_challenge"`
+	Nonce         string     `db:"nonce"`
+	ExpiresAt     time.Time  `db:"expires_at"`
+	UsedAt        *time.Time `db:"used_at"`
+	CreatedAt     time.Time  `db:"created_at"`
+}
+
+type OAuthRefreshToken struct {
+	ID        int        `db:"id"`
+	TokenHash string     `db:"token_hash"`
+	FamilyID  string     `db:"family_id"`
+	ClientID  string     `db:"client_id"`
+	UserID    int        `db:"user_id"`
+	Scope     string     `db:"scope"`
+	ExpiresAt time.Time  `db:"expires_at"`
+	RotatedAt *time.Time `db:"rotated_at"`
+	RevokedAt *time.Time `db:"revoked_at"`
+	CreatedAt time.Time  `db:"created_at"`
+}
+
+type CreateOAuthClientRequest struct {
+	Name         string   `json:"name" validate:"required,min=3,max=100"`
+	Confidential bool     `json:"confidential"`
+	RedirectURIs []string `json:"redirect_uris" validate:"omitempty,dive,required,url"`
+	GrantTypes   []string `json:"grant_types" validate:"required,min=1,dive,oneof=authorization_code refresh_token client_credentials"`
+	Scopes       []string `json:"scopes" validate:"required,min=1,dive,required"`
+	UserID       int      `json:"user_id" validate:"omitempty,gt=0"` // client_credentials only, defaults to the calling admin
+}
+
+// CreateOAuthClientResponse is the only place the plaintext client secret is ever returned
+type CreateOAuthClientResponse struct {
+	ClientSecret string       `json:"client_secret,omitempty"`
+	Client       *OAuthClient `json:"client"`
+}
+
+// AuthorizeRequest holds the parameters of an authorization request (RFC 6749 4.1.1, RFC 7636 4.3)
+type AuthorizeRequest struct {
+	ResponseType        string `query:"response_type" form:"response_type"`
+	ClientID            string `query:"client_id" form:"client_id"`
+	RedirectURI         string `query:"redirect_uri" form:"redirect_uri"`
+	Scope               string `query:"scope" form:"scope"`
+	State               string `query:"state" form:"state"`
+	Nonce               string `query:"nonce" form:"nonce"`
+	CodeChallenge       string `query:"code_challenge" form:"code_challenge"`
+	CodeChallengeMethod string `query:"code_challenge_method" form:"code_challenge_method"`
+}
+
+// TokenRequest holds the form parameters of a token request for every supported grant
+type TokenRequest struct {
+	GrantType    string `form:"grant_type"`
+	Code         string `form:"code"`
+	RedirectURI  string `form:"redirect_uri"`
+	CodeVerifier string `form:"code_verifier"`
+	RefreshToken string `form:"refresh_token"`
+	Scope        string `form:"scope"`
+	ClientID     string `form:"client_id"`
+	ClientSecret string `form:"client_secret"`
+}
+
+type TokenResponse struct {
+	AccessToken  string `json:"access_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int    `json:"expires_in"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+	IDToken      string `json:"id_token,omitempty"`
+	Scope        string `json:"scope,omitempty"`
+}
+
+// UserInfo is the OpenID Connect userinfo response; claims are released according to the token's scope
+type UserInfo struct {
+	Subject string `json:"sub"`
+	Name    string `json:"name,omitempty"`
+	Email   string `json:"email,omitempty"`
+}
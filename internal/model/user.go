@@ -1,30 +1,218 @@
 package model
 
 import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
 	"time"
 )
 
 type User struct {
-	ID        int       `json:"id" db:"id"`
-	Name      string    `json:"name" db:"name"`
-	Email     string    `json:"email" db:"email"`
-	Password  string    `json:"password" db:"password"`
-	Phone     string    `json:"phone" db:"phone"`
-	Role      string    `json:"role" db:"role"`
+	ID       int    `json:"id" db:"id"`
+	Name     string `json:"name" db:"name"`
+	Email    string `json:"email" db:"email"`
+	Password string `json:"password" db:"password"`
+	Phone    string `json:"phone" db:"phone"`
+	Role     string `json:"role" db:"role"`
+	Status   string `json:"status" db:"status"`
+	// TokenVersion is embedded in issued tokens; bumping it invalidates them
+	TokenVersion int `json:"-" db:"token_version"`
+	// Version is bumped on every change; it is the user's ETag and what If-Match is checked against
+	Version   int       `json:"version" db:"version"`
 	CreatedAt time.Time `json:"created_at" db:"created_at"`
 	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
 }
 
+// ETag is the strong entity tag of the user's representation, e.g. "12-3" for version 3 of user 12
+func (u *User) ETag() string {
+	if u == nil {
+		return ""
+	}
+	return fmt.Sprintf(`"%d-%d"`, u.ID, u.Version)
+}
+
+// UserVersionFromETag returns the version an ETag of the given user stands for
+// Weak tags never match, since If-Match uses strong comparison
+func UserVersionFromETag(userID int, etag string) (int, bool) {
+	unquoted, ok := strings.CutPrefix(etag, `"`)
+	if !ok {
+		return 0, false
+	}
+	unquoted, ok = strings.CutSuffix(unquoted, `"`)
+	if !ok {
+		return 0, false
+	}
+	id, version, ok := strings.Cut(unquoted, "-")
+	if !ok || id != strconv.Itoa(userID) {
+		return 0, false
+	}
+	v, err := strconv.Atoi(version)
+	if err != nil || v < 1 {
+		return 0, false
+	}
+	return v, true
+}
+
+// SelectableUserFields are the User fields a client can pick with ?fields=, in response order
+// Their JSON names are also their column names; password and token_version are never selectable
+var SelectableUserFields = []string{"id", "name", "email", "phone", "role", "status", "version", "created_at", "updated_at"}
+
+// UserFields is a sparse fieldset of User, by JSON name; nil means the whole User
+type UserFields []string
+
+// ParseUserFields parses a comma-separated fields parameter named param
+// Unknown fields are returned as a field error on param, for BadRequestWithFields
+func ParseUserFields(param string, raw string) (UserFields, map[string]string) {
+	if strings.TrimSpace(raw) == "" {
+		return nil, nil
+	}
+	selected := map[string]bool{}
+	var unknown []string
+	for _, name := range strings.Split(raw, ",") {
+		name = strings.TrimSpace(name)
+		switch {
+		case name == "":
+		case !isSelectableUserField(name):
+			unknown = append(unknown, name)
+		default:
+			selected[name] = true
+		}
+	}
+	if len(unknown) > 0 {
+		return nil, map[string]string{param: fmt.Sprintf("%s has unknown fields %s; selectable fields are %s",
+			param, strings.Join(unknown, ", "), strings.Join(SelectableUserFields, ", "))}
+	}
+	var fields UserFields
+	for _, name := range SelectableUserFields {
+		if selected[name] {
+			fields = append(fields, name)
+		}
+	}
+	return fields, nil
+}
+
+func isSelectableUserField(name string) bool {
+	for _, field := range SelectableUserFields {
+		if field == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Columns returns the columns to select for the fieldset: its fields, plus id and version for the ETag
+func (f UserFields) Columns() []string {
+	columns := []string{"id", "version"}
+	for _, name := range f {
+		if name != "id" && name != "version" {
+			columns = append(columns, name)
+		}
+	}
+	return columns
+}
+
+// View returns user encoded with only the fieldset's fields
+func (f UserFields) View(user *User) *UserView {
+	return &UserView{user: user, fields: f}
+}
+
+// Views is View for each of users
+func (f UserFields) Views(users []*User) []*UserView {
+	views := make([]*UserView, len(users))
+	for i, user := range users {
+		views[i] = f.View(user)
+	}
+	return views
+}
+
+// UserView is a User loaded and encoded with a sparse fieldset; it keeps the User's ETag
+type UserView struct {
+	user   *User
+	fields UserFields
+}
+
+func (v *UserView) ETag() string {
+	return v.user.ETag()
+}
+
+func (v *UserView) MarshalJSON() ([]byte, error) {
+	full, err := json.Marshal(v.user)
+	if err != nil {
+		return nil, err
+	}
+	var values map[string]json.RawMessage
+	if err := json.Unmarshal(full, &values); err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, name := range v.fields {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		fmt.Fprintf(&buf, "%q:%s", name, values[name])
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
 type CreateUserRequest struct {
 	Name     string `json:"name" validate:"required,min=3,max=100"`
 	Email    string `json:"email" validate:"required,email"`
-	Password string `json:"password" validate:"required,min=6,max=50"`
+	Password string `json:"password" validate:"required"` // length and strength rules come from the password policy
 	Phone    string `json:"phone" validate:"required,vi_phone"`
 }
 
 type LoginRequest struct {
 	Email    string `json:"email" validate:"required,email"`
-	Password string `json:"password" validate:"required,min=6,max=50"`
+	Password string `json:"password" validate:"required"` // no length rules: the policy may have changed since the password was set
+	// DeviceName optionally labels the session, e.g. "Work laptop"
+	DeviceName string `json:"device_name" validate:"omitempty,max=100"`
+}
+
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password" validate:"required"`
+	NewPassword     string `json:"new_password" validate:"required"`
+}
+
+type UpdateRoleRequest struct {
+	Role string `json:"role" validate:"required,oneof=admin user"`
+}
+
+type UpdateUserStatusRequest struct {
+	Status string `json:"status" validate:"required,oneof=active inactive suspended pending"`
+	Reason string `json:"reason" validate:"required,min=3,max=500"`
+}
+
+// UserStatusChange records who changed an account's status, and why
+type UserStatusChange struct {
+	ID         int       `json:"id" db:"id"`
+	UserID     int       `json:"user_id" db:"user_id"`
+	ActorID    *int      `json:"actor_id" db:"actor_id"`
+	FromStatus string    `json:"from_status" db:"from_status"`
+	ToStatus   string    `json:"to_status" db:"to_status"`
+	Reason     string    `json:"reason" db:"reason"`
+	CreatedAt  time.Time `json:"created_at" db:"created_at"`
+}
+
+type ImpersonateRequest struct {
+	Reason string `json:"reason" validate:"required,min=3,max=500"`
+}
+
+type ImpersonationResponse struct {
+	AccessToken    string    `json:"access_token"`
+	ExpiresAt      time.Time `json:"expires_at"`
+	SessionID      int       `json:"session_id"`
+	ImpersonatorID int       `json:"impersonator_id"`
+	User           *User     `json:"user"`
+}
+
+// UserTokenState is what JWTMiddleware needs to know about a token's user
+type UserTokenState struct {
+	TokenVersion int    `db:"token_version"`
+	Status       string `db:"status"`
 }
 
 type LoginResponse struct {
@@ -0,0 +1,32 @@
+package model
+
+// Export formats
+const (
+	ExportFormatCSV    = "csv"
+	ExportFormatNDJSON = "ndjson"
+)
+
+// UserExportQuery is what GET /admin/users/export streams; without format, Accept decides
+// The fields parameter, a sparse fieldset as in ParseUserFields, defaults to every selectable field
+type UserExportQuery struct {
+	Format string `query:"format" validate:"omitempty,oneof=csv ndjson"`
+}
+
+// CreateUserExportRequest starts an export job writing the users to a file
+type CreateUserExportRequest struct {
+	Format string `json:"format" validate:"required,oneof=csv ndjson"`
+	// Fields defaults to every selectable field
+	Fields []string `json:"fields"`
+}
+
+// UserExportParams are the Params of a user export job
+type UserExportParams struct {
+	Format string     `json:"format"`
+	Fields UserFields `json:"fields"`
+}
+
+// UserExportResult is the Result of a finished user export job
+type UserExportResult struct {
+	Rows  int64 `json:"rows"`
+	Bytes int64 `json:"bytes"`
+}
@@ -0,0 +1,98 @@
+package model
+
+// What an import does with a row whose email is already registered
+const (
+	ImportConflictSkip   = "skip"   // leave the existing user as it is
+	ImportConflictUpdate = "update" // overwrite the existing user's name, phone, and role and password when given
+	ImportConflictFail   = "fail"   // import nothing
+)
+
+// ImportUserColumns are the columns of an import file; the CSV header names them, in any order,
+// and NDJSON objects use them as keys. name, email and phone are required
+var ImportUserColumns = []string{"name", "email", "phone", "role", "password"}
+
+// ImportUsersQuery configures POST /admin/users/import, whose body is the CSV or NDJSON file
+type ImportUsersQuery struct {
+	// DryRun validates the file and reports what would happen without changing any user
+	DryRun     bool   `query:"dry_run"`
+	OnConflict string `query:"on_conflict" validate:"omitempty,oneof=skip update fail"`
+}
+
+// ImportUserRow is one user of an import file, validated like CreateUserRequest
+// Rows without a password get a random one that is never revealed; role defaults to user
+type ImportUserRow struct {
+	Name     string `json:"name" validate:"required,min=3,max=100"`
+	Email    string `json:"email" validate:"required,email"`
+	Phone    string `json:"phone" validate:"required,vi_phone"`
+	Role     string `json:"role" validate:"omitempty,oneof=admin user"`
+	Password string `json:"password"` // checked against the password policy when present
+}
+
+// UserImportRecord is a validated row ready to be written: phone normalised, password hashed
+// Role and Password are nil when the row left them out
+type UserImportRecord struct {
+	Row      int
+	Name     string
+	Email    string
+	Phone    string
+	Role     *string
+	Password *string
+}
+
+// UserImportBatchResult is what writing a batch of records did
+type UserImportBatchResult struct {
+	Inserted int64
+	Updated  int64
+	Skipped  int64
+	// UpdatedIDs are the users updated under ImportConflictUpdate
+	UpdatedIDs []int
+	// Conflicts are the rows left out because their email (under ImportConflictFail) or phone is taken,
+	// or repeats an earlier row of the file
+	Conflicts []UserImportConflict
+}
+
+// UserImportConflict is a row whose field (email or phone) belongs to an existing user, or is
+// already on row FirstRow of the file
+type UserImportConflict struct {
+	Row      int    `db:"row_number"`
+	Field    string `db:"field"`
+	FirstRow int    `db:"first_row"`
+}
+
+// UserImportParams are the Params of a user import job
+type UserImportParams struct {
+	Format     string `json:"format"`
+	DryRun     bool   `json:"dry_run"`
+	OnConflict string `json:"on_conflict"`
+	// Locale is the language of the row errors
+	Locale string `json:"locale"`
+	Bytes  int64  `json:"bytes"`
+}
+
+// UserImportProgress is the Progress of a running user import job
+type UserImportProgress struct {
+	Rows      int64 `json:"rows"`
+	BytesRead int64 `json:"bytes_read"`
+	Bytes     int64 `json:"bytes"`
+}
+
+// UserImportReport is the Result of a user import job; for a dry run the counts are what the import would do
+type UserImportReport struct {
+	DryRun   bool  `json:"dry_run"`
+	Rows     int64 `json:"rows"`
+	Inserted int64 `json:"inserted"`
+	Updated  int64 `json:"updated"`
+	Skipped  int64 `json:"skipped"`
+	Failed   int64 `json:"failed"`
+	// Errors lists the failed rows, up to a limit; ErrorsTruncated is set when there were more
+	Errors          []UserImportRowError `json:"errors"`
+	ErrorsTruncated bool                 `json:"errors_truncated"`
+}
+
+// UserImportRowError says why a row was not imported: its errors are keyed by column,
+// or by "row" for a line that could not be read
+type UserImportRowError struct {
+	Row    int               `json:"row"`
+	Email  string            `json:"email,omitempty"`
+	Errors map[string]string `json:"errors"`
+}
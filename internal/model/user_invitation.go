@@ -0,0 +1,70 @@
+package model
+
+import (
+	"golang-echo/pkg/request"
+	"time"
+)
+
+// Invitation statuses, derived from the timestamps
+const (
+	InvitationStatusPending  = "pending"
+	InvitationStatusAccepted = "accepted"
+	InvitationStatusRevoked  = "revoked"
+	InvitationStatusExpired  = "expired"
+)
+
+type UserInvitation struct {
+	ID             int        `json:"id" db:"id"`
+	Email          string     `json:"email" db:"email"`
+	Role           string     `json:"role" db:"role"`
+	TokenHash      string     `json:"-" db:"token_hash"`
+	InvitedBy      *int       `json:"invited_by" db:"invited_by"`
+	AcceptedUserID *int       `json:"accepted_user_id" db:"accepted_user_id"`
+	SendCount      int        `json:"send_count" db:"send_count"`
+	LastSentAt     *time.Time `json:"last_sent_at" db:"last_sent_at"`
+	ExpiresAt      time.Time  `json:"expires_at" db:"expires_at"`
+	AcceptedAt     *time.Time `json:"accepted_at" db:"accepted_at"`
+	RevokedAt      *time.Time `json:"revoked_at" db:"revoked_at"`
+	CreatedAt      time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at" db:"updated_at"`
+	Status         string     `json:"status" db:"-"`
+}
+
+// ComputeStatus derives Status from the invitation's timestamps
+func (i *UserInvitation) ComputeStatus(now time.Time) {
+	switch {
+	case i.AcceptedAt != nil:
+		i.Status = InvitationStatusAccepted
+	case i.RevokedAt != nil:
+		i.Status = InvitationStatusRevoked
+	case !now.Before(i.ExpiresAt):
+		i.Status = InvitationStatusExpired
+	default:
+		i.Status = InvitationStatusPending
+	}
+}
+
+type CreateInvitationRequest struct {
+	Email string `json:"email" validate:"required,email"`
+	Role  string `json:"role" validate:"required,oneof=admin user"`
+	// ExpiresAt defaults to the configured invitation TTL
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+type InvitationQuery struct {
+	request.PaginationReq
+	Status string `query:"status" validate:"omitempty,oneof=pending accepted revoked expired"`
+}
+
+type AcceptInvitationRequest struct {
+	Token    string `json:"token" validate:"required"`
+	Name     string `json:"name" validate:"required,min=3,max=100"`
+	Password string `json:"password" validate:"required"` // length and strength rules come from the password policy
+	Phone    string `json:"phone" validate:"omitempty,vi_phone"`
+}
+
+// InvitationResponse reports whether the invitation email went out; a failed delivery can be retried with resend
+type InvitationResponse struct {
+	Invitation *UserInvitation `json:"invitation"`
+	EmailSent  bool            `json:"email_sent"`
+}
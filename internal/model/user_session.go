@@ -0,0 +1,30 @@
+package model
+
+import (
+	"time"
+)
+
+type UserSession struct {
+	ID          int        `json:"id" db:"id"`
+	UserID      int        `json:"user_id" db:"user_id"`
+	TokenID     string     `json:"-" db:"token_id"`
+	UserAgent   string     `json:"user_agent" db:"user_agent"`
+	IPAddress   string     `json:"ip_address" db:"ip_address"`
+	DeviceLabel string     `json:"device_label" db:"device_label"`
+	CreatedAt   time.Time  `json:"created_at" db:"created_at"`
+	LastSeenAt  time.Time  `json:"last_seen_at" db:"last_seen_at"`
+	ExpiresAt   time.Time  `json:"expires_at" db:"expires_at"`
+	RevokedAt   *time.Time `json:"revoked_at,omitempty" db:"revoked_at"`
+	Current     bool       `json:"current" db:"-"` // the session of the token making the request
+}
+
+// SessionClient describes where a login came from
+type SessionClient struct {
+	UserAgent  string
+	IPAddress  string
+	DeviceName string // optional label chosen by the client, otherwise derived from the user agent
+}
+
+type RevokeSessionsResponse struct {
+	Revoked int64 `json:"revoked"`
+}
@@ -0,0 +1,113 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"golang-echo/internal/model"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+type IAPIKeyRepository interface {
+	Create(ctx context.Context, key *model.APIKey) error
+	FindAll(ctx context.Context, limit int, offset int) ([]*model.APIKey, int64, error)
+	FindByPrefix(ctx context.Context, prefix string) (*model.APIKey, error)
+	Revoke(ctx context.Context, id int) error
+	TouchLastUsed(ctx context.Context, id int, usedAt time.Time) error
+}
+
+type apiKeyRepository struct {
+	db *sqlx.DB
+}
+
+const apiKeyColumns = `id, name, prefix, key_hash, user_id, permissions, expires_at, last_used_at, revoked_at, created_by, created_at`
+
+func (r *apiKeyRepository) Create(ctx context.Context, key *model.APIKey) error {
+	query := `
+        INSERT INTO api_keys (name, prefix, key_hash, user_id, permissions, expires_at, created_by, created_at)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+        RETURNING id
+    `
+	key.CreatedAt = time.Now()
+	err := r.db.QueryRowContext(ctx, query,
+		key.Name, key.Prefix, key.KeyHash, key.UserID, key.Permissions, key.ExpiresAt, key.CreatedBy, key.CreatedAt,
+	).Scan(&key.ID)
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+			return ErrDuplicate
+		}
+		// Foreign key violation: user_id doesn't exist
+		if errors.As(err, &pqErr) && pqErr.Code == "23503" {
+			return ErrNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+func (r *apiKeyRepository) FindAll(ctx context.Context, limit int, offset int) ([]*model.APIKey, int64, error) {
+	var (
+		keys  []*model.APIKey
+		total int64
+	)
+
+	countQuery := `SELECT COUNT(*) FROM api_keys`
+	if err := r.db.GetContext(ctx, &total, countQuery); err != nil {
+		return nil, 0, err
+	}
+
+	if total == 0 {
+		return []*model.APIKey{}, 0, nil
+	}
+
+	dataQuery := `SELECT ` + apiKeyColumns + ` FROM api_keys ORDER BY id DESC LIMIT $1 OFFSET $2`
+	if err := r.db.SelectContext(ctx, &keys, dataQuery, limit, offset); err != nil {
+		return nil, 0, err
+	}
+
+	return keys, total, nil
+}
+
+func (r *apiKeyRepository) FindByPrefix(ctx context.Context, prefix string) (*model.APIKey, error) {
+	query := `SELECT ` + apiKeyColumns + ` FROM api_keys WHERE prefix = $1`
+	var key model.APIKey
+	err := r.db.GetContext(ctx, &key, query, prefix)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return &key, nil
+}
+
+// Revoke marks a key as revoked; revoking an already revoked key is a no-op
+func (r *apiKeyRepository) Revoke(ctx context.Context, id int) error {
+	query := `UPDATE api_keys SET revoked_at = COALESCE(revoked_at, $1) WHERE id = $2`
+	result, err := r.db.ExecContext(ctx, query, time.Now(), id)
+	if err != nil {
+		return err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *apiKeyRepository) TouchLastUsed(ctx context.Context, id int, usedAt time.Time) error {
+	query := `UPDATE api_keys SET last_used_at = $1 WHERE id = $2`
+	_, err := r.db.ExecContext(ctx, query, usedAt, id)
+	return err
+}
+
+func NewAPIKeyRepository(db *sqlx.DB) IAPIKeyRepository {
+	return &apiKeyRepository{db: db}
+}
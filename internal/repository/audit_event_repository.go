@@ -0,0 +1,142 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"golang-echo/internal/model"
+	"strconv"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// auditChainLockID is the advisory lock serialising appends, so each row links to its true predecessor
+const auditChainLockID = 7_263_001
+
+type IAuditEventRepository interface {
+	// Append links the event to the chain (setting PrevHash and Hash) and stores it
+	Append(ctx context.Context, event *model.AuditEvent) error
+	FindAll(ctx context.Context, query *model.AuditEventQuery, limit int, offset int) ([]*model.AuditEvent, int64, error)
+	// FindAfter returns up to limit events with id > afterID in chain order
+	FindAfter(ctx context.Context, afterID int64, limit int) ([]*model.AuditEvent, error)
+}
+
+type auditEventRepository struct {
+	db *sqlx.DB
+}
+
+const auditEventColumns = `id, occurred_at, actor_id, actor_type, impersonator_id, action, target_type, target_id, request_id, ip_address, user_agent, outcome, diff, metadata, prev_hash, hash`
+
+func (r *auditEventRepository) Append(ctx context.Context, event *model.AuditEvent) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock($1)`, auditChainLockID); err != nil {
+		return err
+	}
+
+	prevHash := model.AuditChainGenesis
+	err = tx.GetContext(ctx, &prevHash, `SELECT hash FROM audit_events ORDER BY id DESC LIMIT 1`)
+	if err != nil && err != sql.ErrNoRows {
+		return err
+	}
+	event.PrevHash = prevHash
+	event.Hash = event.ComputeHash(prevHash)
+
+	query := `
+        INSERT INTO audit_events (occurred_at, actor_id, actor_type, impersonator_id, action, target_type, target_id, request_id,
+            ip_address, user_agent, outcome, diff, metadata, prev_hash, hash)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15)
+        RETURNING id
+    `
+	err = tx.QueryRowContext(ctx, query,
+		event.OccurredAt, event.ActorID, event.ActorType, event.ImpersonatorID, event.Action, event.TargetType, event.TargetID, event.RequestID,
+		event.IPAddress, event.UserAgent, event.Outcome, nullJSON(event.Diff), nullJSON(event.Metadata), event.PrevHash, event.Hash,
+	).Scan(&event.ID)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (r *auditEventRepository) FindAll(ctx context.Context, query *model.AuditEventQuery, limit int, offset int) ([]*model.AuditEvent, int64, error) {
+	var (
+		conditions []string
+		args       []any
+	)
+	where := func(condition string, arg any) {
+		args = append(args, arg)
+		conditions = append(conditions, strings.Replace(condition, "?", "$"+strconv.Itoa(len(args)), 1))
+	}
+	if query.ActorID != 0 {
+		where("actor_id = ?", query.ActorID)
+	}
+	if query.ImpersonatorID != 0 {
+		where("impersonator_id = ?", query.ImpersonatorID)
+	}
+	if query.Action != "" {
+		where("action = ?", query.Action)
+	}
+	if query.TargetType != "" {
+		where("target_type = ?", query.TargetType)
+	}
+	if query.TargetID != "" {
+		where("target_id = ?", query.TargetID)
+	}
+	if query.Outcome != "" {
+		where("outcome = ?", query.Outcome)
+	}
+	if query.RequestID != "" {
+		where("request_id = ?", query.RequestID)
+	}
+	if !query.From.IsZero() {
+		where("occurred_at >= ?", query.From)
+	}
+	if !query.To.IsZero() {
+		where("occurred_at < ?", query.To)
+	}
+	whereClause := ""
+	if len(conditions) > 0 {
+		whereClause = " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	var total int64
+	if err := r.db.GetContext(ctx, &total, `SELECT COUNT(*) FROM audit_events`+whereClause, args...); err != nil {
+		return nil, 0, err
+	}
+	if total == 0 {
+		return []*model.AuditEvent{}, 0, nil
+	}
+
+	dataQuery := `SELECT ` + auditEventColumns + ` FROM audit_events` + whereClause +
+		` ORDER BY id DESC LIMIT $` + strconv.Itoa(len(args)+1) + ` OFFSET $` + strconv.Itoa(len(args)+2)
+	events := []*model.AuditEvent{}
+	if err := r.db.SelectContext(ctx, &events, dataQuery, append(args, limit, offset)...); err != nil {
+		return nil, 0, err
+	}
+	return events, total, nil
+}
+
+func (r *auditEventRepository) FindAfter(ctx context.Context, afterID int64, limit int) ([]*model.AuditEvent, error) {
+	query := `SELECT ` + auditEventColumns + ` FROM audit_events WHERE id > $1 ORDER BY id LIMIT $2`
+	events := []*model.AuditEvent{}
+	if err := r.db.SelectContext(ctx, &events, query, afterID, limit); err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+// nullJSON stores empty JSON as SQL NULL rather than an invalid jsonb value
+func nullJSON(raw []byte) any {
+	if len(raw) == 0 {
+		return nil
+	}
+	return string(raw)
+}
+
+func NewAuditEventRepository(db *sqlx.DB) IAuditEventRepository {
+	return &auditEventRepository{db: db}
+}
@@ -1,8 +1,15 @@
 package repository
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+)
 
 var (
 	ErrNotFound  = errors.New("record not found")
 	ErrDuplicate = errors.New("record already exists")
+	// ErrVersionMismatch means the record changed since the version the caller expected
+	ErrVersionMismatch = errors.New("record version mismatch")
+	// ErrDuplicatePhone wraps ErrDuplicate, so errors.Is(err, ErrDuplicate) still matches
+	ErrDuplicatePhone = fmt.Errorf("%w: phone", ErrDuplicate)
 )
@@ -0,0 +1,79 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"golang-echo/internal/middleware"
+	"golang-echo/internal/model"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type idempotencyRepository struct {
+	db *sqlx.DB
+}
+
+const idempotencyColumns = `key, fingerprint, status, header, body, created_at, locked_until, expires_at`
+
+func (r *idempotencyRepository) Lock(ctx context.Context, record *model.IdempotencyRecord) (*model.IdempotencyRecord, error) {
+	// An expired record, or one whose request stopped holding it without a response, is taken over
+	query := `
+        INSERT INTO idempotency_keys (key, fingerprint, status, created_at, locked_until, expires_at)
+        VALUES ($1, $2, 0, $3, $4, $5)
+        ON CONFLICT (key) DO UPDATE SET
+            fingerprint = EXCLUDED.fingerprint, status = 0, header = NULL, body = NULL,
+            created_at = EXCLUDED.created_at, locked_until = EXCLUDED.locked_until, expires_at = EXCLUDED.expires_at
+        WHERE idempotency_keys.expires_at <= EXCLUDED.created_at
+            OR (idempotency_keys.status = 0 AND idempotency_keys.locked_until <= EXCLUDED.created_at)
+        RETURNING key
+    `
+	// The record holding the key may be deleted between the two statements; then try again
+	for attempt := 0; ; attempt++ {
+		var key string
+		err := r.db.QueryRowContext(ctx, query,
+			record.Key, record.Fingerprint, record.CreatedAt, record.LockedUntil, record.ExpiresAt,
+		).Scan(&key)
+		if err == nil {
+			return nil, nil
+		}
+		if err != sql.ErrNoRows {
+			return nil, err
+		}
+
+		var existing model.IdempotencyRecord
+		err = r.db.GetContext(ctx, &existing, `SELECT `+idempotencyColumns+` FROM idempotency_keys WHERE key = $1`, record.Key)
+		if err == nil {
+			return &existing, nil
+		}
+		if err != sql.ErrNoRows || attempt > 0 {
+			return nil, err
+		}
+	}
+}
+
+func (r *idempotencyRepository) Save(ctx context.Context, record *model.IdempotencyRecord) error {
+	query := `
+        UPDATE idempotency_keys SET status = $1, header = $2, body = $3, expires_at = $4
+        WHERE key = $5 AND fingerprint = $6 AND status = 0
+    `
+	_, err := r.db.ExecContext(ctx, query,
+		record.Status, nullJSON(record.Header), record.Body, record.ExpiresAt, record.Key, record.Fingerprint,
+	)
+	return err
+}
+
+func (r *idempotencyRepository) Unlock(ctx context.Context, record *model.IdempotencyRecord) error {
+	query := `DELETE FROM idempotency_keys WHERE key = $1 AND fingerprint = $2 AND status = 0`
+	_, err := r.db.ExecContext(ctx, query, record.Key, record.Fingerprint)
+	return err
+}
+
+func (r *idempotencyRepository) DeleteExpired(ctx context.Context, now time.Time) error {
+	_, err := r.db.ExecContext(ctx, `DELETE FROM idempotency_keys WHERE expires_at <= $1`, now)
+	return err
+}
+
+func NewIdempotencyRepository(db *sqlx.DB) middleware.IdempotencyStore {
+	return &idempotencyRepository{db: db}
+}
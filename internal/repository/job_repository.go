@@ -0,0 +1,109 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"golang-echo/internal/model"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type IJobRepository interface {
+	// Create stores a pending job
+	Create(ctx context.Context, job *model.Job) error
+	// FindByID returns ErrNotFound unless a job of kind has id
+	FindByID(ctx context.Context, kind string, id int) (*model.Job, error)
+	// Start marks a pending job running
+	Start(ctx context.Context, id int, at time.Time) error
+	// UpdateProgress stores how far a running job has got
+	UpdateProgress(ctx context.Context, id int, progress []byte) error
+	// Finish stores the outcome of a job: its status, result or error, and output file
+	Finish(ctx context.Context, job *model.Job) error
+	// ClaimExpiredFiles forgets the output files of jobs expired by now and returns their paths for deletion
+	ClaimExpiredFiles(ctx context.Context, now time.Time) ([]string, error)
+}
+
+type jobRepository struct {
+	db *sqlx.DB
+}
+
+const jobColumns = `id, kind, status, created_by, params, progress, result, error, file_path, created_at, started_at, finished_at, expires_at`
+
+func (r *jobRepository) Create(ctx context.Context, job *model.Job) error {
+	query := `
+        INSERT INTO jobs (kind, status, created_by, params, created_at)
+        VALUES ($1, $2, $3, $4, $5)
+        RETURNING id
+    `
+	job.Status = model.JobStatusPending
+	job.CreatedAt = time.Now()
+	return r.db.QueryRowContext(ctx, query, job.Kind, job.Status, job.CreatedBy, string(job.Params), job.CreatedAt).Scan(&job.ID)
+}
+
+func (r *jobRepository) FindByID(ctx context.Context, kind string, id int) (*model.Job, error) {
+	var job model.Job
+	err := r.db.GetContext(ctx, &job, `SELECT `+jobColumns+` FROM jobs WHERE id = $1 AND kind = $2`, id, kind)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return &job, nil
+}
+
+func (r *jobRepository) Start(ctx context.Context, id int, at time.Time) error {
+	query := `UPDATE jobs SET status = $1, started_at = $2 WHERE id = $3 AND status = $4`
+	result, err := r.db.ExecContext(ctx, query, model.JobStatusRunning, at, id, model.JobStatusPending)
+	if err != nil {
+		return err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *jobRepository) UpdateProgress(ctx context.Context, id int, progress []byte) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE jobs SET progress = $1 WHERE id = $2`, nullJSON(progress), id)
+	return err
+}
+
+func (r *jobRepository) Finish(ctx context.Context, job *model.Job) error {
+	query := `
+        UPDATE jobs SET status = $1, result = $2, error = $3, file_path = $4, finished_at = $5, expires_at = $6
+        WHERE id = $7
+    `
+	_, err := r.db.ExecContext(ctx, query,
+		job.Status, nullJSON(job.Result), job.Error, job.FilePath, job.FinishedAt, job.ExpiresAt, job.ID,
+	)
+	return err
+}
+
+func (r *jobRepository) ClaimExpiredFiles(ctx context.Context, now time.Time) ([]string, error) {
+	// RETURNING sees the new row, so the old paths come from the locked CTE
+	query := `
+        WITH expired AS (
+            SELECT id, file_path FROM jobs
+            WHERE file_path IS NOT NULL AND expires_at <= $1
+            FOR UPDATE SKIP LOCKED
+        )
+        UPDATE jobs SET file_path = NULL
+        FROM expired WHERE jobs.id = expired.id
+        RETURNING expired.file_path
+    `
+	var paths []string
+	if err := r.db.SelectContext(ctx, &paths, query, now); err != nil {
+		return nil, err
+	}
+	return paths, nil
+}
+
+func NewJobRepository(db *sqlx.DB) IJobRepository {
+	return &jobRepository{db: db}
+}
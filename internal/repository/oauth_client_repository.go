@@ -0,0 +1,116 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"golang-echo/internal/model"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+type IOAuthClientRepository interface {
+	Create(ctx context.Context, client *model.OAuthClient) error
+	FindAll(ctx context.Context, limit int, offset int) ([]*model.OAuthClient, int64, error)
+	FindByClientID(ctx context.Context, clientID string) (*model.OAuthClient, error)
+	// Revoke returns the client_id of the revoked client
+	Revoke(ctx context.Context, id int) (string, error)
+}
+
+type oauthClientRepository struct {
+	db *sqlx.DB
+}
+
+const oauthClientColumns = `id, client_id, client_secret_hash, name, redirect_uris, grant_types, scopes, user_id, revoked_at, created_by, created_at`
+
+func (r *oauthClientRepository) Create(ctx context.Context, client *model.OAuthClient) error {
+	query := `
+        INSERT INTO oauth_clients (client_id, client_secret_hash, name, redirect_uris, grant_types, scopes, user_id, created_by, created_at)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+        RETURNING id
+    `
+	client.CreatedAt = time.Now()
+	err := r.db.QueryRowContext(ctx, query,
+		client.ClientID, client.ClientSecretHash, client.Name, client.RedirectURIs, client.GrantTypes, client.Scopes,
+		client.UserID, client.CreatedBy, client.CreatedAt,
+	).Scan(&client.ID)
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+			return ErrDuplicate
+		}
+		// Foreign key violation: user_id doesn't exist
+		if errors.As(err, &pqErr) && pqErr.Code == "23503" {
+			return ErrNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+func (r *oauthClientRepository) FindAll(ctx context.Context, limit int, offset int) ([]*model.OAuthClient, int64, error) {
+	var (
+		clients []*model.OAuthClient
+		total   int64
+	)
+
+	countQuery := `SELECT COUNT(*) FROM oauth_clients`
+	if err := r.db.GetContext(ctx, &total, countQuery); err != nil {
+		return nil, 0, err
+	}
+
+	if total == 0 {
+		return []*model.OAuthClient{}, 0, nil
+	}
+
+	dataQuery := `SELECT ` + oauthClientColumns + ` FROM oauth_clients ORDER BY id DESC LIMIT $1 OFFSET $2`
+	if err := r.db.SelectContext(ctx, &clients, dataQuery, limit, offset); err != nil {
+		return nil, 0, err
+	}
+
+	return clients, total, nil
+}
+
+func (r *oauthClientRepository) FindByClientID(ctx context.Context, clientID string) (*model.OAuthClient, error) {
+	query := `SELECT ` + oauthClientColumns + ` FROM oauth_clients WHERE client_id = $1`
+	var client model.OAuthClient
+	err := r.db.GetContext(ctx, &client, query, clientID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return &client, nil
+}
+
+// Revoke disables a client and its outstanding refresh tokens; revoking twice is a no-op
+func (r *oauthClientRepository) Revoke(ctx context.Context, id int) (string, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return "", err
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	var clientID string
+	query := `UPDATE oauth_clients SET revoked_at = COALESCE(revoked_at, $1) WHERE id = $2 RETURNING client_id`
+	if err := tx.QueryRowContext(ctx, query, now, id).Scan(&clientID); err != nil {
+		if err == sql.ErrNoRows {
+			return "", ErrNotFound
+		}
+		return "", err
+	}
+
+	tokensQuery := `UPDATE oauth_refresh_tokens SET revoked_at = $1 WHERE client_id = $2 AND revoked_at IS NULL`
+	if _, err := tx.ExecContext(ctx, tokensQuery, now, clientID); err != nil {
+		return "", err
+	}
+	return clientID, tx.Commit()
+}
+
+func NewOAuthClientRepository(db *sqlx.DB) IOAuthClientRepository {
+	return &oauthClientRepository{db: db}
+}
@@ -0,0 +1,188 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"golang-echo/internal/model"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// IOAuthGrantRepository stores what users granted to OAuth clients: consents, authorization codes and refresh tokens
+type IOAuthGrantRepository interface {
+	FindConsent(ctx context.Context, userID int, clientID string) (*model.OAuthConsent, error)
+	FindConsentsByUser(ctx context.Context, userID int) ([]*model.OAuthConsent, error)
+	// SaveConsent records scopes for the user and client, adding to previously granted scopes
+	SaveConsent(ctx context.Context, userID int, clientID string, scopes []string) error
+	// DeleteConsent removes the consent and revokes the refresh tokens issued under it
+	DeleteConsent(ctx context.Context, userID int, clientID string) error
+
+	CreateAuthorizationCode(ctx context.Context, code *model.OAuthAuthorizationCode) error
+	// ConsumeAuthorizationCode marks an unused code as used and returns it; used or unknown codes return ErrNotFound
+	ConsumeAuthorizationCode(ctx context.Context, codeHash string, usedAt time.Time) (*model.OAuthAuthorizationCode, error)
+
+	CreateRefreshToken(ctx context.Context, token *model.OAuthRefreshToken) error
+	FindRefreshToken(ctx context.Context, tokenHash string) (*model.OAuthRefreshToken, error)
+	// RotateRefreshToken marks an active token as rotated; returns ErrNotFound if it was already rotated or revoked
+	RotateRefreshToken(ctx context.Context, id int, rotatedAt time.Time) error
+	RevokeRefreshToken(ctx context.Context, id int) error
+	RevokeRefreshTokenFamily(ctx context.Context, familyID string) error
+}
+
+type oauthGrantRepository struct {
+	db *sqlx.DB
+}
+
+const (
+	oauthConsentColumns      = `id, user_id, client_id, scopes, created_at, updated_at`
+	oauthRefreshTokenColumns = `id, token_hash, family_id, client_id, user_id, scope, expires_at, rotated_at, revoked_at, created_at`
+)
+
+func (r *oauthGrantRepository) FindConsent(ctx context.Context, userID int, clientID string) (*model.OAuthConsent, error) {
+	query := `SELECT ` + oauthConsentColumns + ` FROM oauth_consents WHERE user_id = $1 AND client_id = $2`
+	var consent model.OAuthConsent
+	err := r.db.GetContext(ctx, &consent, query, userID, clientID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return &consent, nil
+}
+
+func (r *oauthGrantRepository) FindConsentsByUser(ctx context.Context, userID int) ([]*model.OAuthConsent, error) {
+	query := `SELECT ` + oauthConsentColumns + ` FROM oauth_consents WHERE user_id = $1 ORDER BY updated_at DESC`
+	consents := []*model.OAuthConsent{}
+	if err := r.db.SelectContext(ctx, &consents, query, userID); err != nil {
+		return nil, err
+	}
+	return consents, nil
+}
+
+func (r *oauthGrantRepository) SaveConsent(ctx context.Context, userID int, clientID string, scopes []string) error {
+	query := `
+        INSERT INTO oauth_consents (user_id, client_id, scopes, created_at, updated_at)
+        VALUES ($1, $2, $3, $4, $4)
+        ON CONFLICT (user_id, client_id) DO UPDATE
+        SET scopes = ARRAY(SELECT DISTINCT unnest(oauth_consents.scopes || EXCLUDED.scopes)),
+            updated_at = EXCLUDED.updated_at
+    `
+	_, err := r.db.ExecContext(ctx, query, userID, clientID, pq.StringArray(scopes), time.Now())
+	return err
+}
+
+func (r *oauthGrantRepository) DeleteConsent(ctx context.Context, userID int, clientID string) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, `DELETE FROM oauth_consents WHERE user_id = $1 AND client_id = $2`, userID, clientID)
+	if err != nil {
+		return err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return ErrNotFound
+	}
+
+	tokensQuery := `UPDATE oauth_refresh_tokens SET revoked_at = $1 WHERE user_id = $2 AND client_id = $3 AND revoked_at IS NULL`
+	if _, err := tx.ExecContext(ctx, tokensQuery, time.Now(), userID, clientID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (r *oauthGrantRepository) CreateAuthorizationCode(ctx context.Context, code *model.OAuthAuthorizationCode) error {
+	query := `
+        INSERT INTO oauth_authorization_codes (code_hash, client_id, user_id, redirect_uri, scope, code_challenge, nonce, expires_at, created_at)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+    `
+	code.CreatedAt = time.Now()
+	_, err := r.db.ExecContext(ctx, query,
+		code.CodeHash, code.ClientID, code.UserID, code.RedirectURI, code.Scope, code.CodeChallenge, code.Nonce, code.ExpiresAt, code.CreatedAt,
+	)
+	return err
+}
+
+func (r *oauthGrantRepository) ConsumeAuthorizationCode(ctx context.Context, codeHash string, usedAt time.Time) (*model.OAuthAuthorizationCode, error) {
+	// The conditional update makes redemption atomic: concurrent requests with the same code can't both succeed
+	query := `
+        UPDATE oauth_authorization_codes SET used_at = $1
+        WHERE code_hash = $2 AND used_at IS NULL
+        RETURNING code_hash, client_id, user_id, redirect_uri, scope, code_challenge, nonce, expires_at, used_at, created_at
+    `
+	var code model.OAuthAuthorizationCode
+	err := r.db.GetContext(ctx, &code, query, usedAt, codeHash)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return &code, nil
+}
+
+func (r *oauthGrantRepository) CreateRefreshToken(ctx context.Context, token *model.OAuthRefreshToken) error {
+	query := `
+        INSERT INTO oauth_refresh_tokens (token_hash, family_id, client_id, user_id, scope, expires_at, created_at)
+        VALUES ($1, $2, $3, $4, $5, $6, $7)
+        RETURNING id
+    `
+	token.CreatedAt = time.Now()
+	return r.db.QueryRowContext(ctx, query,
+		token.TokenHash, token.FamilyID, token.ClientID, token.UserID, token.Scope, token.ExpiresAt, token.CreatedAt,
+	).Scan(&token.ID)
+}
+
+func (r *oauthGrantRepository) FindRefreshToken(ctx context.Context, tokenHash string) (*model.OAuthRefreshToken, error) {
+	query := `SELECT ` + oauthRefreshTokenColumns + ` FROM oauth_refresh_tokens WHERE token_hash = $1`
+	var token model.OAuthRefreshToken
+	err := r.db.GetContext(ctx, &token, query, tokenHash)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return &token, nil
+}
+
+func (r *oauthGrantRepository) RotateRefreshToken(ctx context.Context, id int, rotatedAt time.Time) error {
+	query := `UPDATE oauth_refresh_tokens SET rotated_at = $1 WHERE id = $2 AND rotated_at IS NULL AND revoked_at IS NULL`
+	result, err := r.db.ExecContext(ctx, query, rotatedAt, id)
+	if err != nil {
+		return err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *oauthGrantRepository) RevokeRefreshToken(ctx context.Context, id int) error {
+	query := `UPDATE oauth_refresh_tokens SET revoked_at = COALESCE(revoked_at, $1) WHERE id = $2`
+	_, err := r.db.ExecContext(ctx, query, time.Now(), id)
+	return err
+}
+
+func (r *oauthGrantRepository) RevokeRefreshTokenFamily(ctx context.Context, familyID string) error {
+	query := `UPDATE oauth_refresh_tokens SET revoked_at = $1 WHERE family_id = $2 AND revoked_at IS NULL`
+	_, err := r.db.ExecContext(ctx, query, time.Now(), familyID)
+	return err
+}
+
+func NewOAuthGrantRepository(db *sqlx.DB) IOAuthGrantRepository {
+	return &oauthGrantRepository{db: db}
+}
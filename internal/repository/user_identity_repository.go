@@ -0,0 +1,63 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"golang-echo/internal/model"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+type IUserIdentityRepository interface {
+	Create(ctx context.Context, identity *model.UserIdentity) error
+	FindByProviderSubject(ctx context.Context, provider string, subject string) (*model.UserIdentity, error)
+	TouchLastLogin(ctx context.Context, id int, at time.Time) error
+}
+
+type userIdentityRepository struct {
+	db *sqlx.DB
+}
+
+func (r *userIdentityRepository) Create(ctx context.Context, identity *model.UserIdentity) error {
+	query := `INSERT INTO user_identities (user_id, provider, subject, email, created_at, last_login_at) VALUES ($1, $2, $3, $4, $5, $6) RETURNING id`
+	now := time.Now()
+	identity.CreatedAt = now
+	identity.LastLoginAt = &now
+	err := r.db.QueryRowContext(ctx, query,
+		identity.UserID, identity.Provider, identity.Subject, identity.Email, identity.CreatedAt, identity.LastLoginAt,
+	).Scan(&identity.ID)
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+			return ErrDuplicate
+		}
+		return err
+	}
+	return nil
+}
+
+func (r *userIdentityRepository) FindByProviderSubject(ctx context.Context, provider string, subject string) (*model.UserIdentity, error) {
+	query := `SELECT id, user_id, provider, subject, email, created_at, last_login_at FROM user_identities WHERE provider = $1 AND subject = $2`
+	var identity model.UserIdentity
+	err := r.db.GetContext(ctx, &identity, query, provider, subject)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return &identity, nil
+}
+
+func (r *userIdentityRepository) TouchLastLogin(ctx context.Context, id int, at time.Time) error {
+	query := `UPDATE user_identities SET last_login_at = $1 WHERE id = $2`
+	_, err := r.db.ExecContext(ctx, query, at, id)
+	return err
+}
+
+func NewUserIdentityRepository(db *sqlx.DB) IUserIdentityRepository {
+	return &userIdentityRepository{db: db}
+}
@@ -0,0 +1,187 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"golang-echo/internal/model"
+	"strconv"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+type IUserInvitationRepository interface {
+	// Create stores a new invitation; an open invitation for the same email is ErrDuplicate
+	Create(ctx context.Context, invitation *model.UserInvitation) error
+	// FindAll lists invitations, optionally only those in status (pending, accepted, revoked, expired)
+	FindAll(ctx context.Context, status string, limit int, offset int) ([]*model.UserInvitation, int64, error)
+	FindByID(ctx context.Context, id int) (*model.UserInvitation, error)
+	FindByTokenHash(ctx context.Context, tokenHash string) (*model.UserInvitation, error)
+	// RenewToken replaces the token and expiry of an open invitation
+	RenewToken(ctx context.Context, id int, tokenHash string, expiresAt time.Time) error
+	MarkSent(ctx context.Context, id int, at time.Time) error
+	Revoke(ctx context.Context, id int) error
+	// Accept consumes a pending invitation and creates user with the invited email and role, atomically
+	Accept(ctx context.Context, tokenHash string, user *model.User) (*model.UserInvitation, error)
+}
+
+type userInvitationRepository struct {
+	db *sqlx.DB
+}
+
+const userInvitationColumns = `id, email, role, token_hash, invited_by, accepted_user_id, send_count, last_sent_at, expires_at, accepted_at, revoked_at, created_at, updated_at`
+
+// invitationOpen matches invitations that are neither accepted nor revoked (pending or expired)
+const invitationOpen = `accepted_at IS NULL AND revoked_at IS NULL`
+
+func (r *userInvitationRepository) Create(ctx context.Context, invitation *model.UserInvitation) error {
+	query := `
+        INSERT INTO user_invitations (email, role, token_hash, invited_by, expires_at, created_at, updated_at)
+        VALUES ($1, $2, $3, $4, $5, $6, $6)
+        RETURNING id
+    `
+	now := time.Now()
+	invitation.CreatedAt = now
+	invitation.UpdatedAt = now
+	err := r.db.QueryRowContext(ctx, query,
+		invitation.Email, invitation.Role, invitation.TokenHash, invitation.InvitedBy, invitation.ExpiresAt, now,
+	).Scan(&invitation.ID)
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+			return ErrDuplicate
+		}
+		return err
+	}
+	return nil
+}
+
+func (r *userInvitationRepository) FindAll(ctx context.Context, status string, limit int, offset int) ([]*model.UserInvitation, int64, error) {
+	var (
+		where string
+		args  []any
+	)
+	switch status {
+	case model.InvitationStatusPending:
+		where = ` WHERE ` + invitationOpen + ` AND expires_at > $1`
+		args = append(args, time.Now())
+	case model.InvitationStatusExpired:
+		where = ` WHERE ` + invitationOpen + ` AND expires_at <= $1`
+		args = append(args, time.Now())
+	case model.InvitationStatusAccepted:
+		where = ` WHERE accepted_at IS NOT NULL`
+	case model.InvitationStatusRevoked:
+		where = ` WHERE revoked_at IS NOT NULL`
+	}
+
+	var total int64
+	if err := r.db.GetContext(ctx, &total, `SELECT COUNT(*) FROM user_invitations`+where, args...); err != nil {
+		return nil, 0, err
+	}
+	if total == 0 {
+		return []*model.UserInvitation{}, 0, nil
+	}
+
+	query := `SELECT ` + userInvitationColumns + ` FROM user_invitations` + where +
+		` ORDER BY id DESC LIMIT $` + strconv.Itoa(len(args)+1) + ` OFFSET $` + strconv.Itoa(len(args)+2)
+	invitations := []*model.UserInvitation{}
+	if err := r.db.SelectContext(ctx, &invitations, query, append(args, limit, offset)...); err != nil {
+		return nil, 0, err
+	}
+	return invitations, total, nil
+}
+
+func (r *userInvitationRepository) FindByID(ctx context.Context, id int) (*model.UserInvitation, error) {
+	return r.findOne(ctx, `SELECT `+userInvitationColumns+` FROM user_invitations WHERE id = $1`, id)
+}
+
+func (r *userInvitationRepository) FindByTokenHash(ctx context.Context, tokenHash string) (*model.UserInvitation, error) {
+	return r.findOne(ctx, `SELECT `+userInvitationColumns+` FROM user_invitations WHERE token_hash = $1`, tokenHash)
+}
+
+func (r *userInvitationRepository) findOne(ctx context.Context, query string, arg any) (*model.UserInvitation, error) {
+	var invitation model.UserInvitation
+	err := r.db.GetContext(ctx, &invitation, query, arg)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return &invitation, nil
+}
+
+func (r *userInvitationRepository) RenewToken(ctx context.Context, id int, tokenHash string, expiresAt time.Time) error {
+	query := `UPDATE user_invitations SET token_hash = $1, expires_at = $2, updated_at = $3 WHERE id = $4 AND ` + invitationOpen
+	return execAffectingOne(ctx, r.db, query, tokenHash, expiresAt, time.Now(), id)
+}
+
+func (r *userInvitationRepository) MarkSent(ctx context.Context, id int, at time.Time) error {
+	query := `UPDATE user_invitations SET send_count = send_count + 1, last_sent_at = $1 WHERE id = $2`
+	_, err := r.db.ExecContext(ctx, query, at, id)
+	return err
+}
+
+func (r *userInvitationRepository) Revoke(ctx context.Context, id int) error {
+	now := time.Now()
+	query := `UPDATE user_invitations SET revoked_at = $1, updated_at = $1 WHERE id = $2 AND ` + invitationOpen
+	return execAffectingOne(ctx, r.db, query, now, id)
+}
+
+func (r *userInvitationRepository) Accept(ctx context.Context, tokenHash string, user *model.User) (*model.UserInvitation, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	var invitation model.UserInvitation
+	query := `
+        UPDATE user_invitations SET accepted_at = $1, updated_at = $1
+        WHERE token_hash = $2 AND ` + invitationOpen + ` AND expires_at > $1
+        RETURNING ` + userInvitationColumns
+	if err := tx.GetContext(ctx, &invitation, query, now, tokenHash); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+
+	user.Email = invitation.Email
+	user.Role = invitation.Role
+	if err := insertUser(ctx, tx, user); err != nil {
+		return nil, err
+	}
+
+	if _, err := tx.ExecContext(ctx, `UPDATE user_invitations SET accepted_user_id = $1 WHERE id = $2`, user.ID, invitation.ID); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	invitation.AcceptedUserID = &user.ID
+	return &invitation, nil
+}
+
+// execAffectingOne runs an UPDATE and reports ErrNotFound when no row matched
+func execAffectingOne(ctx context.Context, db sqlx.ExecerContext, query string, args ...any) error {
+	result, err := db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func NewUserInvitationRepository(db *sqlx.DB) IUserInvitationRepository {
+	return &userInvitationRepository{db: db}
+}
@@ -4,7 +4,9 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"fmt"
 	"golang-echo/internal/model"
+	"strings"
 	"time"
 
 	"github.com/jmoiron/sqlx"
@@ -13,9 +15,41 @@ import (
 
 type IUserRepository interface {
 	Create(ctx context.Context, user *model.User) error
-	FindAll(ctx context.Context, limit int, offset int) ([]*model.User, int64, error)
+	// FindAll selects only fields' columns, or the whole user when fields is nil
+	FindAll(ctx context.Context, limit int, offset int, fields model.UserFields) ([]*model.User, int64, error)
 	FindUserByID(ctx context.Context, id int) (*model.User, error)
+	// FindUserFields finds the user whose unique column (id, email or phone) equals value, selecting only fields' columns
+	FindUserFields(ctx context.Context, column string, value any, fields model.UserFields) (*model.User, error)
 	FindUserByEmail(ctx context.Context, email string) (*model.User, error)
+	FindUserByPhone(ctx context.Context, phone string) (*model.User, error)
+	UpdatePassword(ctx context.Context, id int, hashedPassword string) error
+	UpdatePasswordHash(ctx context.Context, id int, oldHash string, newHash string) error
+	// UpdateRole returns ErrVersionMismatch unless the user is at version; version 0 skips the check
+	UpdateRole(ctx context.Context, id int, role string, version int) error
+	FindTokenState(ctx context.Context, id int) (*model.UserTokenState, error)
+	// UpdateStatus changes the status and records change; leaving active also bumps token_version
+	// Like UpdateRole, it returns ErrVersionMismatch unless the user is at version (0 skips the check)
+	UpdateStatus(ctx context.Context, change *model.UserStatusChange, version int) error
+	FindStatusChanges(ctx context.Context, userID int) ([]*model.UserStatusChange, error)
+	// ExportUsers calls each for every user, by id, with fields' columns selected
+	// It reads through a cursor in one read-only snapshot, so the export is consistent and only a batch is held in memory
+	ExportUsers(ctx context.Context, fields model.UserFields, each func(*model.User) error) error
+	// ImportUsers writes the batches next returns, until it returns nil, in one transaction, and
+	// reports what each did to done. See UserImportOptions for conflicts and dry runs
+	ImportUsers(ctx context.Context, options UserImportOptions, next func() ([]*model.UserImportRecord, error), done func(*model.UserImportBatchResult) error) error
+}
+
+// UserImportOptions configure ImportUsers
+// Rows whose phone belongs to another user, or whose email or phone is on an earlier row of the
+// file, are always left out and reported as conflicts; a registered email is skipped, updated or,
+// under model.ImportConflictFail, reported as a conflict that makes ImportUsers roll back and
+// return ErrDuplicate once every batch has been checked
+type UserImportOptions struct {
+	OnConflict string
+	// DryRun only works out what the import would do, writing nothing
+	DryRun bool
+	// Password is the hash given to new users whose row has no password
+	Password string
 }
 
 type userRepository struct {
@@ -23,15 +57,28 @@ type userRepository struct {
 }
 
 func (r *userRepository) Create(ctx context.Context, user *model.User) error {
-	query := `INSERT INTO users (name, email, password, created_at, updated_at) VALUES ($1, $2, $3, $4, $5) RETURNING id`
+	return insertUser(ctx, r.db, user)
+}
+
+// insertUser inserts user with q, which may be a transaction; role is left to the database default unless set
+func insertUser(ctx context.Context, q sqlx.QueryerContext, user *model.User) error {
+	query := `INSERT INTO users (name, email, password, phone, created_at, updated_at) VALUES ($1, $2, $3, $4, $5, $6) RETURNING id, role, status, token_version, version`
 	now := time.Now()
 	user.CreatedAt = now
 	user.UpdatedAt = now
-	err := r.db.QueryRowContext(ctx, query, user.Name, user.Email, user.Password, user.CreatedAt, user.UpdatedAt).Scan(&user.ID)
+	args := []any{user.Name, user.Email, user.Password, user.Phone, user.CreatedAt, user.UpdatedAt}
+	if user.Role != "" {
+		query = `INSERT INTO users (name, email, password, phone, created_at, updated_at, role) VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING id, role, status, token_version, version`
+		args = append(args, user.Role)
+	}
+	err := q.QueryRowxContext(ctx, query, args...).Scan(&user.ID, &user.Role, &user.Status, &user.TokenVersion, &user.Version)
 	if err != nil {
 		// Check for PostgreSQL unique constraint violation (error code 23505)
 		var pqErr *pq.Error
 		if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+			if pqErr.Constraint == "users_phone_unique" {
+				return ErrDuplicatePhone
+			}
 			return ErrDuplicate
 		}
 		return err
@@ -39,7 +86,7 @@ func (r *userRepository) Create(ctx context.Context, user *model.User) error {
 	return nil
 }
 
-func (r *userRepository) FindAll(ctx context.Context, limit int, offset int) ([]*model.User, int64, error) {
+func (r *userRepository) FindAll(ctx context.Context, limit int, offset int, fields model.UserFields) ([]*model.User, int64, error) {
 	var (
 		users []*model.User
 		total int64
@@ -54,8 +101,13 @@ func (r *userRepository) FindAll(ctx context.Context, limit int, offset int) ([]
 		return []*model.User{}, 0, nil
 	}
 
+	columns := "id, name, email, password, phone, role, status, version, created_at, updated_at"
+	if fields != nil {
+		// Only whitelisted names reach the query
+		columns = strings.Join(fields.Columns(), ", ")
+	}
 	dataQuery := `
-        SELECT id, name, email, password, created_at, updated_at
+        SELECT ` + columns + `
         FROM users
         ORDER BY id DESC
         LIMIT $1 OFFSET $2
@@ -68,7 +120,7 @@ func (r *userRepository) FindAll(ctx context.Context, limit int, offset int) ([]
 }
 
 func (r *userRepository) FindUserByID(ctx context.Context, id int) (*model.User, error) {
-	query := `SELECT id, name, email, password, role, created_at, updated_at FROM users WHERE id = $1`
+	query := `SELECT id, name, email, password, phone, role, status, token_version, version, created_at, updated_at FROM users WHERE id = $1`
 	var user model.User
 	err := r.db.GetContext(ctx, &user, query, id)
 	if err != nil {
@@ -80,8 +132,26 @@ func (r *userRepository) FindUserByID(ctx context.Context, id int) (*model.User,
 	return &user, nil
 }
 
+func (r *userRepository) FindUserFields(ctx context.Context, column string, value any, fields model.UserFields) (*model.User, error) {
+	switch column {
+	case "id", "email", "phone":
+	default:
+		return nil, fmt.Errorf("users can't be looked up by %q", column)
+	}
+	query := `SELECT ` + strings.Join(fields.Columns(), ", ") + ` FROM users WHERE ` + column + ` = $1`
+	var user model.User
+	err := r.db.GetContext(ctx, &user, query, value)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return &user, nil
+}
+
 func (r *userRepository) FindUserByEmail(ctx context.Context, email string) (*model.User, error) {
-	query := `SELECT id, name, email, password, role, created_at, updated_at FROM users WHERE email = $1`
+	query := `SELECT id, name, email, password, phone, role, status, token_version, version, created_at, updated_at FROM users WHERE email = $1`
 	var user model.User
 	err := r.db.GetContext(ctx, &user, query, email)
 	if err != nil {
@@ -93,6 +163,364 @@ func (r *userRepository) FindUserByEmail(ctx context.Context, email string) (*mo
 	return &user, nil
 }
 
+func (r *userRepository) FindUserByPhone(ctx context.Context, phone string) (*model.User, error) {
+	query := `SELECT id, name, email, password, phone, role, status, token_version, version, created_at, updated_at FROM users WHERE phone = $1`
+	var user model.User
+	err := r.db.GetContext(ctx, &user, query, phone)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return &user, nil
+}
+
+// UpdatePassword sets a new password and bumps token_version, signing the user out everywhere
+func (r *userRepository) UpdatePassword(ctx context.Context, id int, hashedPassword string) error {
+	query := `UPDATE users SET password = $1, token_version = token_version + 1, version = version + 1, updated_at = $2 WHERE id = $3`
+	result, err := r.db.ExecContext(ctx, query, hashedPassword, time.Now(), id)
+	if err != nil {
+		return err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// UpdatePasswordHash replaces the stored hash of an unchanged password (e.g. algorithm upgrade)
+// It only applies if the stored hash is still oldHash, so a concurrent password change always wins
+// version is left alone: nothing in the user's representation changes, so its ETag stays valid
+func (r *userRepository) UpdatePasswordHash(ctx context.Context, id int, oldHash string, newHash string) error {
+	query := `UPDATE users SET password = $1 WHERE id = $2 AND password = $3`
+	_, err := r.db.ExecContext(ctx, query, newHash, id, oldHash)
+	return err
+}
+
+// UpdateRole changes the role and bumps token_version, so tokens carrying the old role stop working
+func (r *userRepository) UpdateRole(ctx context.Context, id int, role string, version int) error {
+	query := `
+        UPDATE users SET role = $1, token_version = token_version + 1, version = version + 1, updated_at = $2
+        WHERE id = $3 AND ($4 = 0 OR version = $4)
+    `
+	result, err := r.db.ExecContext(ctx, query, role, time.Now(), id, version)
+	if err != nil {
+		return err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return r.missingOrChanged(ctx, id)
+	}
+	return nil
+}
+
+// missingOrChanged explains why a versioned update matched no row
+func (r *userRepository) missingOrChanged(ctx context.Context, id int) error {
+	var exists bool
+	if err := r.db.GetContext(ctx, &exists, `SELECT EXISTS(SELECT 1 FROM users WHERE id = $1)`, id); err != nil {
+		return err
+	}
+	if !exists {
+		return ErrNotFound
+	}
+	return ErrVersionMismatch
+}
+
+func (r *userRepository) FindTokenState(ctx context.Context, id int) (*model.UserTokenState, error) {
+	query := `SELECT token_version, status FROM users WHERE id = $1`
+	var state model.UserTokenState
+	err := r.db.GetContext(ctx, &state, query, id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return &state, nil
+}
+
+func (r *userRepository) UpdateStatus(ctx context.Context, change *model.UserStatusChange, version int) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var current struct {
+		Status  string `db:"status"`
+		Version int    `db:"version"`
+	}
+	if err := tx.GetContext(ctx, &current, `SELECT status, version FROM users WHERE id = $1 FOR UPDATE`, change.UserID); err != nil {
+		if err == sql.ErrNoRows {
+			return ErrNotFound
+		}
+		return err
+	}
+	if version != 0 && current.Version != version {
+		return ErrVersionMismatch
+	}
+	change.FromStatus = current.Status
+
+	now := time.Now()
+	query := `
+        UPDATE users SET status = $1, updated_at = $2, version = version + 1,
+            token_version = CASE WHEN $1 <> 'active' AND status = 'active' THEN token_version + 1 ELSE token_version END
+        WHERE id = $3
+    `
+	if _, err := tx.ExecContext(ctx, query, change.ToStatus, now, change.UserID); err != nil {
+		return err
+	}
+
+	change.CreatedAt = now
+	insert := `
+        INSERT INTO user_status_changes (user_id, actor_id, from_status, to_status, reason, created_at)
+        VALUES ($1, $2, $3, $4, $5, $6)
+        RETURNING id
+    `
+	if err := tx.QueryRowContext(ctx, insert,
+		change.UserID, change.ActorID, change.FromStatus, change.ToStatus, change.Reason, now,
+	).Scan(&change.ID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (r *userRepository) FindStatusChanges(ctx context.Context, userID int) ([]*model.UserStatusChange, error) {
+	query := `
+        SELECT id, user_id, actor_id, from_status, to_status, reason, created_at
+        FROM user_status_changes
+        WHERE user_id = $1
+        ORDER BY id DESC
+    `
+	changes := []*model.UserStatusChange{}
+	if err := r.db.SelectContext(ctx, &changes, query, userID); err != nil {
+		return nil, err
+	}
+	return changes, nil
+}
+
+// exportBatchSize is how many rows ExportUsers fetches from its cursor at a time
+const exportBatchSize = 500
+
+func (r *userRepository) ExportUsers(ctx context.Context, fields model.UserFields, each func(*model.User) error) error {
+	tx, err := r.db.BeginTxx(ctx, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Only whitelisted names reach the query
+	declare := `DECLARE users_export NO SCROLL CURSOR FOR SELECT ` + strings.Join(fields.Columns(), ", ") + ` FROM users ORDER BY id`
+	if _, err := tx.ExecContext(ctx, declare); err != nil {
+		return err
+	}
+	fetch := fmt.Sprintf(`FETCH %d FROM users_export`, exportBatchSize)
+	for {
+		var users []*model.User
+		if err := tx.SelectContext(ctx, &users, fetch); err != nil {
+			return err
+		}
+		for _, user := range users {
+			if err := each(user); err != nil {
+				return err
+			}
+		}
+		if len(users) < exportBatchSize {
+			return tx.Commit()
+		}
+	}
+}
+
+func (r *userRepository) ImportUsers(ctx context.Context, options UserImportOptions, next func() ([]*model.UserImportRecord, error), done func(*model.UserImportBatchResult) error) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Each batch is copied into the staging table, checked against users, then written from it
+	// users_import_seen keeps the rows of earlier batches, for duplicates within the file
+	staging := `
+        CREATE TEMP TABLE users_import (
+            row_number integer, name text, email text, phone text, role text, password text
+        ) ON COMMIT DROP;
+        CREATE TEMP TABLE users_import_seen (
+            row_number integer, email text UNIQUE, phone text UNIQUE
+        ) ON COMMIT DROP
+    `
+	if _, err := tx.ExecContext(ctx, staging); err != nil {
+		return err
+	}
+	failed := false
+	for {
+		records, err := next()
+		if err != nil {
+			return err
+		}
+		if records == nil {
+			break
+		}
+		if err := stageUserImport(ctx, tx, records); err != nil {
+			return err
+		}
+		result, err := importStagedUsers(ctx, tx, options, len(records), failed)
+		if err != nil {
+			return err
+		}
+		if options.OnConflict == model.ImportConflictFail && len(result.Conflicts) > 0 {
+			for _, conflict := range result.Conflicts {
+				if conflict.Field == "email" && conflict.FirstRow == 0 {
+					failed = true
+				}
+			}
+		}
+		if err := done(result); err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, `TRUNCATE users_import`); err != nil {
+			return err
+		}
+	}
+	if failed && !options.DryRun {
+		return ErrDuplicate
+	}
+	if options.DryRun {
+		return nil // the deferred rollback drops the staging table
+	}
+	return tx.Commit()
+}
+
+// stageUserImport copies records into the staging table
+func stageUserImport(ctx context.Context, tx *sqlx.Tx, records []*model.UserImportRecord) error {
+	stmt, err := tx.PrepareContext(ctx, pq.CopyIn("users_import", "row_number", "name", "email", "phone", "role", "password"))
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+	for _, record := range records {
+		if _, err := stmt.ExecContext(ctx, record.Row, record.Name, record.Email, record.Phone, record.Role, record.Password); err != nil {
+			return err
+		}
+	}
+	_, err = stmt.ExecContext(ctx) // flushes the COPY
+	return err
+}
+
+// importStagedUsers writes the staged batch of staged rows; once failed, it only looks for conflicts
+func importStagedUsers(ctx context.Context, tx *sqlx.Tx, options UserImportOptions, staged int, failed bool) (*model.UserImportBatchResult, error) {
+	result := &model.UserImportBatchResult{}
+
+	// A row whose email or phone is on an earlier row of the file, in this batch or a previous one,
+	// is left out; the earlier row may itself conflict with users
+	var repeated []model.UserImportConflict
+	query := `
+        WITH firsts AS (
+            SELECT i.row_number,
+                COALESCE(se.row_number, min(i.row_number) OVER (PARTITION BY i.email)) AS email_first,
+                COALESCE(sp.row_number, min(i.row_number) OVER (PARTITION BY i.phone)) AS phone_first
+            FROM users_import i
+            LEFT JOIN users_import_seen se ON se.email = i.email
+            LEFT JOIN users_import_seen sp ON sp.phone = i.phone
+        )
+        DELETE FROM users_import i USING firsts f
+        WHERE i.row_number = f.row_number AND (f.email_first < f.row_number OR f.phone_first < f.row_number)
+        RETURNING i.row_number,
+            CASE WHEN f.email_first < f.row_number THEN 'email' ELSE 'phone' END AS field,
+            CASE WHEN f.email_first < f.row_number THEN f.email_first ELSE f.phone_first END AS first_row
+    `
+	if err := tx.SelectContext(ctx, &repeated, query); err != nil {
+		return nil, err
+	}
+	result.Conflicts = append(result.Conflicts, repeated...)
+	staged -= len(repeated)
+	if _, err := tx.ExecContext(ctx, `INSERT INTO users_import_seen SELECT row_number, email, phone FROM users_import`); err != nil {
+		return nil, err
+	}
+
+	var phoneTaken []int
+	query = `
+        DELETE FROM users_import i USING users u
+        WHERE u.phone = i.phone AND u.email <> i.email
+        RETURNING i.row_number
+    `
+	if err := tx.SelectContext(ctx, &phoneTaken, query); err != nil {
+		return nil, err
+	}
+	for _, row := range phoneTaken {
+		result.Conflicts = append(result.Conflicts, model.UserImportConflict{Row: row, Field: "phone"})
+	}
+	staged -= len(phoneTaken)
+
+	var emailTaken []int
+	if err := tx.SelectContext(ctx, &emailTaken, `SELECT i.row_number FROM users_import i JOIN users u ON u.email = i.email`); err != nil {
+		return nil, err
+	}
+	existing := int64(len(emailTaken))
+	if options.OnConflict == model.ImportConflictFail {
+		for _, row := range emailTaken {
+			result.Conflicts = append(result.Conflicts, model.UserImportConflict{Row: row, Field: "email"})
+		}
+		failed = failed || existing > 0
+	}
+	if options.DryRun || failed {
+		switch options.OnConflict {
+		case model.ImportConflictSkip:
+			result.Skipped = existing
+		case model.ImportConflictUpdate:
+			result.Updated = existing
+		}
+		if !failed {
+			result.Inserted = int64(staged) - existing
+		}
+		return result, nil
+	}
+
+	now := time.Now()
+	if options.OnConflict == model.ImportConflictUpdate {
+		// Like UpdateRole and a password change, a new role or password signs the user out everywhere
+		update := `
+            UPDATE users u SET
+                name = i.name, phone = i.phone,
+                role = COALESCE(i.role, u.role), password = COALESCE(i.password, u.password),
+                token_version = CASE WHEN i.password IS NOT NULL OR COALESCE(i.role, u.role) <> u.role
+                    THEN u.token_version + 1 ELSE u.token_version END,
+                version = u.version + 1, updated_at = $1
+            FROM users_import i
+            WHERE u.email = i.email
+            RETURNING u.id
+        `
+		if err := tx.SelectContext(ctx, &result.UpdatedIDs, update, now); err != nil {
+			return nil, err
+		}
+		result.Updated = int64(len(result.UpdatedIDs))
+	}
+	// ON CONFLICT covers users registered since the check above, which are skipped
+	insert := `
+        INSERT INTO users (name, email, password, phone, role, created_at, updated_at)
+        SELECT i.name, i.email, COALESCE(i.password, $1), i.phone, COALESCE(i.role, 'user'), $2, $2
+        FROM users_import i
+        WHERE NOT EXISTS (SELECT 1 FROM users u WHERE u.email = i.email)
+        ON CONFLICT DO NOTHING
+    `
+	inserted, err := tx.ExecContext(ctx, insert, options.Password, now)
+	if err != nil {
+		return nil, err
+	}
+	if result.Inserted, err = inserted.RowsAffected(); err != nil {
+		return nil, err
+	}
+	result.Skipped = int64(staged) - result.Inserted - result.Updated
+	return result, nil
+}
+
 func NewUserRepository(db *sqlx.DB) IUserRepository {
 	return &userRepository{db: db}
 }
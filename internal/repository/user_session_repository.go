@@ -0,0 +1,98 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"golang-echo/internal/model"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type IUserSessionRepository interface {
+	Create(ctx context.Context, session *model.UserSession) error
+	FindActiveByUser(ctx context.Context, userID int) ([]*model.UserSession, error)
+	FindByTokenID(ctx context.Context, tokenID string) (*model.UserSession, error)
+	// Revoke revokes one of the user's sessions and returns its token ID; sessions of other users are reported as ErrNotFound
+	Revoke(ctx context.Context, userID int, id int) (string, error)
+	// RevokeAllExcept revokes every active session of the user except the one for keepTokenID
+	// and returns the token IDs of the sessions it revoked
+	RevokeAllExcept(ctx context.Context, userID int, keepTokenID string) ([]string, error)
+	TouchLastSeen(ctx context.Context, id int, at time.Time) error
+}
+
+type userSessionRepository struct {
+	db *sqlx.DB
+}
+
+const userSessionColumns = `id, user_id, token_id, user_agent, ip_address, device_label, created_at, last_seen_at, expires_at, revoked_at`
+
+func (r *userSessionRepository) Create(ctx context.Context, session *model.UserSession) error {
+	query := `
+        INSERT INTO user_sessions (user_id, token_id, user_agent, ip_address, device_label, created_at, last_seen_at, expires_at)
+        VALUES ($1, $2, $3, $4, $5, $6, $6, $7)
+        RETURNING id
+    `
+	now := time.Now()
+	session.CreatedAt = now
+	session.LastSeenAt = now
+	return r.db.QueryRowContext(ctx, query,
+		session.UserID, session.TokenID, session.UserAgent, session.IPAddress, session.DeviceLabel, now, session.ExpiresAt,
+	).Scan(&session.ID)
+}
+
+func (r *userSessionRepository) FindActiveByUser(ctx context.Context, userID int) ([]*model.UserSession, error) {
+	query := `SELECT ` + userSessionColumns + ` FROM user_sessions
+        WHERE user_id = $1 AND revoked_at IS NULL AND expires_at > $2
+        ORDER BY last_seen_at DESC`
+	sessions := []*model.UserSession{}
+	if err := r.db.SelectContext(ctx, &sessions, query, userID, time.Now()); err != nil {
+		return nil, err
+	}
+	return sessions, nil
+}
+
+func (r *userSessionRepository) FindByTokenID(ctx context.Context, tokenID string) (*model.UserSession, error) {
+	query := `SELECT ` + userSessionColumns + ` FROM user_sessions WHERE token_id = $1`
+	var session model.UserSession
+	err := r.db.GetContext(ctx, &session, query, tokenID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return &session, nil
+}
+
+func (r *userSessionRepository) Revoke(ctx context.Context, userID int, id int) (string, error) {
+	query := `UPDATE user_sessions SET revoked_at = COALESCE(revoked_at, $1) WHERE id = $2 AND user_id = $3 RETURNING token_id`
+	var tokenID string
+	err := r.db.QueryRowContext(ctx, query, time.Now(), id, userID).Scan(&tokenID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return "", ErrNotFound
+		}
+		return "", err
+	}
+	return tokenID, nil
+}
+
+func (r *userSessionRepository) RevokeAllExcept(ctx context.Context, userID int, keepTokenID string) ([]string, error) {
+	query := `UPDATE user_sessions SET revoked_at = $1 WHERE user_id = $2 AND token_id <> $3 AND revoked_at IS NULL AND expires_at > $1 RETURNING token_id`
+	tokenIDs := []string{}
+	if err := r.db.SelectContext(ctx, &tokenIDs, query, time.Now(), userID, keepTokenID); err != nil {
+		return nil, err
+	}
+	return tokenIDs, nil
+}
+
+func (r *userSessionRepository) TouchLastSeen(ctx context.Context, id int, at time.Time) error {
+	query := `UPDATE user_sessions SET last_seen_at = $1 WHERE id = $2`
+	_, err := r.db.ExecContext(ctx, query, at, id)
+	return err
+}
+
+func NewUserSessionRepository(db *sqlx.DB) IUserSessionRepository {
+	return &userSessionRepository{db: db}
+}
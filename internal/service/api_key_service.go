@@ -0,0 +1,141 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"golang-echo/internal/model"
+	"golang-echo/internal/repository"
+	"golang-echo/pkg/constants"
+	"golang-echo/pkg/response"
+	"golang-echo/pkg/utils"
+	"log/slog"
+	"time"
+)
+
+// lastUsedResolution bounds how often last_used_at is written for a busy key
+const lastUsedResolution = time.Minute
+
+type IAPIKeyService interface {
+	CreateAPIKey(ctx context.Context, creatorID int, req *model.CreateAPIKeyRequest) (*model.CreateAPIKeyResponse, error)
+	FindAllAPIKeys(ctx context.Context, limit int, offset int) ([]*model.APIKey, int64, error)
+	RevokeAPIKey(ctx context.Context, id int) error
+	AuthenticateAPIKey(ctx context.Context, rawKey string) (*model.APIKey, *model.User, error)
+}
+
+type apiKeyService struct {
+	apiKeyRepo repository.IAPIKeyRepository
+	userRepo   repository.IUserRepository
+}
+
+func NewAPIKeyService(apiKeyRepo repository.IAPIKeyRepository, userRepo repository.IUserRepository) IAPIKeyService {
+	return &apiKeyService{
+		apiKeyRepo: apiKeyRepo,
+		userRepo:   userRepo,
+	}
+}
+
+func (s *apiKeyService) CreateAPIKey(ctx context.Context, creatorID int, req *model.CreateAPIKeyRequest) (*model.CreateAPIKeyResponse, error) {
+	for _, permission := range req.Permissions {
+		if !constants.IsValidPermission(permission) {
+			return nil, response.BadRequestWithFields("VALIDATION_FAILED", "Validation failed", map[string]string{
+				"permissions": "unknown permission " + permission,
+			})
+		}
+	}
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		return nil, response.BadRequestWithFields("VALIDATION_FAILED", "Validation failed", map[string]string{
+			"expires_at": "expires_at must be in the future",
+		})
+	}
+
+	ownerID := req.UserID
+	if ownerID == 0 {
+		ownerID = creatorID
+	}
+
+	key, prefix, secretHash, err := utils.GenerateAPIKey()
+	if err != nil {
+		return nil, response.Internal(err)
+	}
+
+	apiKey := &model.APIKey{
+		Name:        req.Name,
+		Prefix:      prefix,
+		KeyHash:     secretHash,
+		UserID:      ownerID,
+		Permissions: req.Permissions,
+		ExpiresAt:   req.ExpiresAt,
+		CreatedBy:   &creatorID,
+	}
+	if err := s.apiKeyRepo.Create(ctx, apiKey); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, response.NotFound("USER_NOT_FOUND", "User not found", err)
+		}
+		slog.ErrorContext(ctx, "failed to create api key", slog.Int("user_id", ownerID), slog.Any("error", err))
+		return nil, response.Internal(err)
+	}
+
+	return &model.CreateAPIKeyResponse{Key: key, APIKey: apiKey}, nil
+}
+
+func (s *apiKeyService) FindAllAPIKeys(ctx context.Context, limit int, offset int) ([]*model.APIKey, int64, error) {
+	return s.apiKeyRepo.FindAll(ctx, limit, offset)
+}
+
+func (s *apiKeyService) RevokeAPIKey(ctx context.Context, id int) error {
+	if err := s.apiKeyRepo.Revoke(ctx, id); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return response.NotFound("API_KEY_NOT_FOUND", "API key not found", err)
+		}
+		return response.Internal(err)
+	}
+	return nil
+}
+
+// AuthenticateAPIKey resolves a raw key to the key record and the user it acts on behalf of
+func (s *apiKeyService) AuthenticateAPIKey(ctx context.Context, rawKey string) (*model.APIKey, *model.User, error) {
+	prefix, secret, ok := utils.ParseAPIKey(rawKey)
+	if !ok {
+		return nil, nil, response.Unauthorized("INVALID_API_KEY", "API key is invalid", nil)
+	}
+
+	apiKey, err := s.apiKeyRepo.FindByPrefix(ctx, prefix)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, nil, response.Unauthorized("INVALID_API_KEY", "API key is invalid", err)
+		}
+		return nil, nil, response.Internal(err)
+	}
+	if !utils.VerifyAPIKeySecret(secret, apiKey.KeyHash) {
+		return nil, nil, response.Unauthorized("INVALID_API_KEY", "API key is invalid", nil)
+	}
+
+	now := time.Now()
+	if apiKey.RevokedAt != nil {
+		return nil, nil, response.Unauthorized("API_KEY_REVOKED", "API key has been revoked", nil)
+	}
+	if apiKey.ExpiresAt != nil && now.After(*apiKey.ExpiresAt) {
+		return nil, nil, response.Unauthorized("API_KEY_EXPIRED", "API key has expired", nil)
+	}
+
+	user, err := s.userRepo.FindUserByID(ctx, apiKey.UserID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, nil, response.Unauthorized("INVALID_API_KEY", "API key is invalid", err)
+		}
+		return nil, nil, response.Internal(err)
+	}
+	// A key acts as its owner, so it stops working while the owner's account can't be used
+	if err := accountStatusError(user.Status); err != nil {
+		return nil, nil, err
+	}
+
+	if apiKey.LastUsedAt == nil || now.Sub(*apiKey.LastUsedAt) >= lastUsedResolution {
+		if err := s.apiKeyRepo.TouchLastUsed(ctx, apiKey.ID, now); err != nil {
+			slog.WarnContext(ctx, "failed to update api key last_used_at", slog.Int("api_key_id", apiKey.ID), slog.Any("error", err))
+		}
+		apiKey.LastUsedAt = &now
+	}
+
+	return apiKey, user, nil
+}
@@ -0,0 +1,93 @@
+package service
+
+import (
+	"context"
+	"golang-echo/internal/model"
+	"golang-echo/pkg/audit"
+	"golang-echo/pkg/constants"
+	"golang-echo/pkg/response"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type IImpersonationService interface {
+	// Impersonate issues a short-lived token that acts as userID on behalf of the admin actorID
+	Impersonate(ctx context.Context, actorID int, userID int, req *model.ImpersonateRequest, client model.SessionClient) (*model.ImpersonationResponse, error)
+}
+
+type impersonationService struct {
+	userService    IUserService
+	sessionService ISessionService
+	auditor        IAuditor
+	duration       time.Duration
+}
+
+func NewImpersonationService(userService IUserService, sessionService ISessionService, auditor IAuditor, duration time.Duration) IImpersonationService {
+	return &impersonationService{
+		userService:    userService,
+		sessionService: sessionService,
+		auditor:        auditor,
+		duration:       duration,
+	}
+}
+
+func (s *impersonationService) Impersonate(ctx context.Context, actorID int, userID int, req *model.ImpersonateRequest, client model.SessionClient) (*model.ImpersonationResponse, error) {
+	if actorID == userID {
+		return nil, response.BadRequest("CANNOT_IMPERSONATE_SELF", "You cannot impersonate yourself", nil)
+	}
+	actor, err := s.userService.FindUserByID(ctx, actorID, nil)
+	if err != nil {
+		return nil, err
+	}
+	user, err := s.userService.FindUserByID(ctx, userID, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	entry := audit.Entry{
+		Action:     audit.ActionImpersonate,
+		TargetType: audit.TargetUser,
+		TargetID:   strconv.Itoa(userID),
+		Metadata:   map[string]any{"reason": strings.TrimSpace(req.Reason)},
+	}
+	// Impersonating another admin would hand out admin access under someone else's name
+	if constants.IsAdmin(user.Role) {
+		entry.Outcome = audit.OutcomeDenied
+		s.auditor.Record(ctx, entry)
+		return nil, response.Forbidden("CANNOT_IMPERSONATE_ADMIN", "Admin accounts cannot be impersonated", nil)
+	}
+	if user.Status != constants.UserStatusActive {
+		entry.Outcome = audit.OutcomeFailure
+		s.auditor.Record(ctx, entry)
+		return nil, response.BadRequest("USER_NOT_ACTIVE", "Only active users can be impersonated", nil)
+	}
+
+	token, session, err := s.sessionService.CreateImpersonationSession(ctx, user, actor, client, s.duration)
+	if err != nil {
+		return nil, err
+	}
+
+	entry.Outcome = audit.OutcomeSuccess
+	entry.Metadata = map[string]any{
+		"reason":     strings.TrimSpace(req.Reason),
+		"session_id": session.ID,
+		"expires_at": session.ExpiresAt.UTC(),
+	}
+	s.auditor.Record(ctx, entry)
+	slog.InfoContext(ctx, "impersonation started",
+		slog.Int("user_id", user.ID),
+		slog.Int("actor_id", actor.ID),
+		slog.Int("session_id", session.ID),
+	)
+
+	user.Password = ""
+	return &model.ImpersonationResponse{
+		AccessToken:    token,
+		ExpiresAt:      session.ExpiresAt,
+		SessionID:      session.ID,
+		ImpersonatorID: actor.ID,
+		User:           user,
+	}, nil
+}
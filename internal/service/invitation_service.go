@@ -0,0 +1,338 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"golang-echo/internal/model"
+	"golang-echo/internal/repository"
+	"golang-echo/pkg/audit"
+	"golang-echo/pkg/mailer"
+	"golang-echo/pkg/response"
+	"golang-echo/pkg/utils"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// invitationTokenPrefix marks invitation tokens, like ak_ for API keys
+const invitationTokenPrefix = "inv_"
+
+type IInvitationService interface {
+	// CreateInvitation invites email to sign up with role and emails them a link to set their password
+	CreateInvitation(ctx context.Context, actorID int, req *model.CreateInvitationRequest) (*model.InvitationResponse, error)
+	FindInvitations(ctx context.Context, status string, limit int, offset int) ([]*model.UserInvitation, int64, error)
+	// ResendInvitation issues a fresh link (the old one stops working) and extends the expiry
+	ResendInvitation(ctx context.Context, id int) (*model.InvitationResponse, error)
+	RevokeInvitation(ctx context.Context, id int) error
+	// AcceptInvitation creates the invited account with the invitee's chosen password
+	AcceptInvitation(ctx context.Context, req *model.AcceptInvitationRequest) (*model.User, error)
+}
+
+type InvitationSettings struct {
+	TTL       time.Duration
+	MaxTTL    time.Duration
+	AcceptURL string
+}
+
+type invitationService struct {
+	invitationRepo repository.IUserInvitationRepository
+	userRepo       repository.IUserRepository
+	mailer         mailer.Mailer
+	auditor        IAuditor
+	passwordPolicy *utils.PasswordPolicy
+	passwordHasher *utils.PasswordHasher
+	settings       InvitationSettings
+}
+
+func NewInvitationService(
+	invitationRepo repository.IUserInvitationRepository,
+	userRepo repository.IUserRepository,
+	mailer mailer.Mailer,
+	auditor IAuditor,
+	passwordPolicy *utils.PasswordPolicy,
+	passwordHasher *utils.PasswordHasher,
+	settings InvitationSettings,
+) IInvitationService {
+	return &invitationService{
+		invitationRepo: invitationRepo,
+		userRepo:       userRepo,
+		mailer:         mailer,
+		auditor:        auditor,
+		passwordPolicy: passwordPolicy,
+		passwordHasher: passwordHasher,
+		settings:       settings,
+	}
+}
+
+func (s *invitationService) CreateInvitation(ctx context.Context, actorID int, req *model.CreateInvitationRequest) (*model.InvitationResponse, error) {
+	email := strings.TrimSpace(req.Email)
+	now := time.Now()
+	expiresAt := now.Add(s.settings.TTL)
+	if req.ExpiresAt != nil {
+		if !req.ExpiresAt.After(now) || req.ExpiresAt.After(now.Add(s.settings.MaxTTL)) {
+			return nil, response.BadRequestWithFields("VALIDATION_FAILED", "Validation failed", map[string]string{
+				"expires_at": "expires_at must be in the future and within " + s.settings.MaxTTL.String(),
+			})
+		}
+		expiresAt = *req.ExpiresAt
+	}
+
+	if _, err := s.userRepo.FindUserByEmail(ctx, email); err == nil {
+		return nil, response.Conflict("EMAIL_ALREADY_REGISTERED", "Email is already registered", nil)
+	} else if !errors.Is(err, repository.ErrNotFound) {
+		return nil, response.Internal(err)
+	}
+
+	token, tokenHash, err := newInvitationToken()
+	if err != nil {
+		return nil, response.Internal(err)
+	}
+	invitation := &model.UserInvitation{
+		Email:     email,
+		Role:      req.Role,
+		TokenHash: tokenHash,
+		InvitedBy: &actorID,
+		ExpiresAt: expiresAt,
+	}
+	if err := s.invitationRepo.Create(ctx, invitation); err != nil {
+		if errors.Is(err, repository.ErrDuplicate) {
+			return nil, response.Conflict("INVITATION_ALREADY_PENDING", "An invitation for this email is already pending", err)
+		}
+		slog.ErrorContext(ctx, "failed to create invitation", slog.String("email", email), slog.Any("error", err))
+		return nil, response.Internal(err)
+	}
+
+	emailSent := s.send(ctx, invitation, token) == nil
+	invitation.ComputeStatus(time.Now())
+	s.auditor.Record(ctx, audit.Entry{
+		Action:     audit.ActionInvitationCreate,
+		TargetType: audit.TargetInvitation,
+		TargetID:   strconv.Itoa(invitation.ID),
+		Outcome:    audit.OutcomeSuccess,
+		Diff:       audit.Diff(nil, map[string]any{"email": invitation.Email, "role": invitation.Role, "expires_at": invitation.ExpiresAt.UTC()}),
+		Metadata:   map[string]any{"email_sent": emailSent},
+	})
+	return &model.InvitationResponse{Invitation: invitation, EmailSent: emailSent}, nil
+}
+
+func (s *invitationService) FindInvitations(ctx context.Context, status string, limit int, offset int) ([]*model.UserInvitation, int64, error) {
+	invitations, total, err := s.invitationRepo.FindAll(ctx, status, limit, offset)
+	if err != nil {
+		return nil, 0, response.Internal(err)
+	}
+	now := time.Now()
+	for _, invitation := range invitations {
+		invitation.ComputeStatus(now)
+	}
+	return invitations, total, nil
+}
+
+func (s *invitationService) ResendInvitation(ctx context.Context, id int) (*model.InvitationResponse, error) {
+	invitation, err := s.findOpenInvitation(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	token, tokenHash, err := newInvitationToken()
+	if err != nil {
+		return nil, response.Internal(err)
+	}
+	expiresAt := time.Now().Add(s.settings.TTL)
+	if err := s.invitationRepo.RenewToken(ctx, id, tokenHash, expiresAt); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, response.Conflict("INVITATION_CLOSED", "The invitation has already been accepted or revoked", err)
+		}
+		return nil, response.Internal(err)
+	}
+	invitation.TokenHash = tokenHash
+	invitation.ExpiresAt = expiresAt
+
+	sendErr := s.send(ctx, invitation, token)
+	s.auditor.Record(ctx, audit.Entry{
+		Action:     audit.ActionInvitationResend,
+		TargetType: audit.TargetInvitation,
+		TargetID:   strconv.Itoa(id),
+		Outcome:    audit.OutcomeOf(sendErr),
+		Metadata:   map[string]any{"expires_at": expiresAt.UTC()},
+	})
+	if sendErr != nil {
+		return nil, response.NewAppError(http.StatusBadGateway, "MAIL_DELIVERY_FAILED", "The invitation email could not be sent. Please try again later", sendErr)
+	}
+
+	// Re-read for the updated send count
+	if invitation, err = s.invitationRepo.FindByID(ctx, id); err != nil {
+		return nil, response.Internal(err)
+	}
+	invitation.ComputeStatus(time.Now())
+	return &model.InvitationResponse{Invitation: invitation, EmailSent: true}, nil
+}
+
+func (s *invitationService) RevokeInvitation(ctx context.Context, id int) error {
+	if _, err := s.findOpenInvitation(ctx, id); err != nil {
+		return err
+	}
+	if err := s.invitationRepo.Revoke(ctx, id); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return response.Conflict("INVITATION_CLOSED", "The invitation has already been accepted or revoked", err)
+		}
+		return response.Internal(err)
+	}
+	s.auditor.Record(ctx, audit.Entry{
+		Action:     audit.ActionInvitationRevoke,
+		TargetType: audit.TargetInvitation,
+		TargetID:   strconv.Itoa(id),
+		Outcome:    audit.OutcomeSuccess,
+	})
+	return nil
+}
+
+func (s *invitationService) AcceptInvitation(ctx context.Context, req *model.AcceptInvitationRequest) (*model.User, error) {
+	tokenHash := hashInvitationToken(req.Token)
+	invitation, err := s.invitationRepo.FindByTokenHash(ctx, tokenHash)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, response.NotFound("INVITATION_NOT_FOUND", "Invitation not found", err)
+		}
+		return nil, response.Internal(err)
+	}
+	invitation.ComputeStatus(time.Now())
+	switch invitation.Status {
+	case model.InvitationStatusAccepted:
+		return nil, response.Conflict("INVITATION_ALREADY_ACCEPTED", "The invitation has already been accepted", nil)
+	case model.InvitationStatusRevoked:
+		return nil, response.BadRequest("INVITATION_REVOKED", "The invitation has been revoked", nil)
+	case model.InvitationStatusExpired:
+		return nil, response.BadRequest("INVITATION_EXPIRED", "The invitation has expired. Please ask for a new one", nil)
+	}
+
+	if fieldErrors := s.passwordPolicy.ValidationErrors("password", req.Password, invitation.Email, req.Name); fieldErrors != nil {
+		return nil, response.BadRequestWithFields("VALIDATION_FAILED", "Validation failed", fieldErrors)
+	}
+	var phone string
+	if req.Phone != "" {
+		if phone, err = utils.NormalizeVietnamesePhone(req.Phone); err != nil {
+			return nil, response.BadRequest("INVALID_PHONE", "Invalid phone number format", err)
+		}
+	}
+	hashedPassword, err := s.passwordHasher.HashPassword(req.Password)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to hash password", slog.Int("invitation_id", invitation.ID), slog.Any("error", err))
+		return nil, response.Internal(err)
+	}
+
+	user := &model.User{
+		Name:     strings.TrimSpace(req.Name),
+		Password: hashedPassword,
+		Phone:    phone,
+	}
+	if _, err := s.invitationRepo.Accept(ctx, tokenHash, user); err != nil {
+		switch {
+		case errors.Is(err, repository.ErrNotFound):
+			// Accepted, revoked or expired since it was looked up
+			return nil, response.Conflict("INVITATION_CLOSED", "The invitation is no longer valid", err)
+		case errors.Is(err, repository.ErrDuplicatePhone):
+			return nil, response.Conflict("PHONE_ALREADY_REGISTERED", "Phone number is already registered", err)
+		case errors.Is(err, repository.ErrDuplicate):
+			return nil, response.Conflict("EMAIL_ALREADY_REGISTERED", "Email is already registered", err)
+		}
+		slog.ErrorContext(ctx, "failed to accept invitation", slog.Int("invitation_id", invitation.ID), slog.Any("error", err))
+		return nil, response.Internal(err)
+	}
+
+	s.auditor.Record(ctx, audit.Entry{
+		Action:     audit.ActionInvitationAccept,
+		TargetType: audit.TargetInvitation,
+		TargetID:   strconv.Itoa(invitation.ID),
+		Outcome:    audit.OutcomeSuccess,
+		ActorID:    &user.ID,
+	})
+	s.auditor.Record(ctx, audit.Entry{
+		Action:     audit.ActionUserCreate,
+		TargetType: audit.TargetUser,
+		TargetID:   strconv.Itoa(user.ID),
+		Outcome:    audit.OutcomeSuccess,
+		Diff: audit.Diff(nil, map[string]any{
+			"name":   user.Name,
+			"email":  user.Email,
+			"phone":  user.Phone,
+			"role":   user.Role,
+			"status": user.Status,
+		}),
+		Metadata: map[string]any{"invitation_id": invitation.ID},
+		ActorID:  &user.ID,
+	})
+
+	user.Password = ""
+	return user, nil
+}
+
+// findOpenInvitation returns the invitation if it can still be resent or revoked
+func (s *invitationService) findOpenInvitation(ctx context.Context, id int) (*model.UserInvitation, error) {
+	invitation, err := s.invitationRepo.FindByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, response.NotFound("INVITATION_NOT_FOUND", "Invitation not found", err)
+		}
+		return nil, response.Internal(err)
+	}
+	if invitation.AcceptedAt != nil || invitation.RevokedAt != nil {
+		return nil, response.Conflict("INVITATION_CLOSED", "The invitation has already been accepted or revoked", nil)
+	}
+	return invitation, nil
+}
+
+// send emails the invitation link; failures are logged and returned
+func (s *invitationService) send(ctx context.Context, invitation *model.UserInvitation, token string) error {
+	acceptURL, err := url.Parse(s.settings.AcceptURL)
+	if err != nil {
+		slog.ErrorContext(ctx, "invalid invitation accept url", slog.String("url", s.settings.AcceptURL), slog.Any("error", err))
+		return err
+	}
+	query := acceptURL.Query()
+	query.Set("token", token)
+	acceptURL.RawQuery = query.Encode()
+
+	msg := mailer.Message{
+		To:      invitation.Email,
+		Subject: "You're invited to create an account",
+		Body: fmt.Sprintf(
+			"You have been invited to create an account with the %s role.\n\n"+
+				"Choose your password here to get started:\n%s\n\n"+
+				"This invitation expires on %s. If you weren't expecting it, you can ignore this email.\n",
+			invitation.Role, acceptURL.String(), invitation.ExpiresAt.UTC().Format("2006-01-02 15:04 MST"),
+		),
+	}
+	if err := s.mailer.Send(ctx, msg); err != nil {
+		slog.ErrorContext(ctx, "failed to send invitation", slog.Int("invitation_id", invitation.ID), slog.Any("error", err))
+		return err
+	}
+	if err := s.invitationRepo.MarkSent(ctx, invitation.ID, time.Now()); err != nil {
+		slog.WarnContext(ctx, "failed to record invitation delivery", slog.Int("invitation_id", invitation.ID), slog.Any("error", err))
+	}
+	invitation.SendCount++
+	return nil
+}
+
+// newInvitationToken returns a random token for the invitation link and the hash to store
+func newInvitationToken() (token string, tokenHash string, err error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", err
+	}
+	token = invitationTokenPrefix + base64.RawURLEncoding.EncodeToString(b)
+	return token, hashInvitationToken(token), nil
+}
+
+// hashInvitationToken hashes an invitation token; like API keys, a fast hash suffices for 256 random bits
+func hashInvitationToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
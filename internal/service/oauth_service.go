@@ -0,0 +1,671 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"golang-echo/internal/model"
+	"golang-echo/internal/repository"
+	"golang-echo/pkg/constants"
+	"golang-echo/pkg/oauth"
+	"golang-echo/pkg/oidc"
+	"golang-echo/pkg/response"
+	"golang-echo/pkg/utils"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+type IOAuthService interface {
+	// Client registry
+	CreateClient(ctx context.Context, creatorID int, req *model.CreateOAuthClientRequest) (*model.CreateOAuthClientResponse, error)
+	FindAllClients(ctx context.Context, limit int, offset int) ([]*model.OAuthClient, int64, error)
+	RevokeClient(ctx context.Context, id int) error
+	// CheckClient rejects the access tokens of a revoked or deleted client
+	CheckClient(ctx context.Context, clientID string) error
+
+	// ValidateAuthorizeRequest checks an authorization request and returns the client and requested scopes
+	// Errors that can safely be sent back to the client are *oauth.Error; others (unknown client,
+	// unregistered redirect URI) must be shown to the user instead of redirecting
+	ValidateAuthorizeRequest(ctx context.Context, req *model.AuthorizeRequest) (*model.OAuthClient, []string, error)
+	// HasConsent reports whether the user already approved all scopes for the client
+	HasConsent(ctx context.Context, userID int, clientID string, scopes []string) (bool, error)
+	// Authorize records consent and issues an authorization code, returning the client redirect URL
+	Authorize(ctx context.Context, userID int, req *model.AuthorizeRequest, scopes []string) (string, error)
+
+	// Token implements the token endpoint; clientID/clientSecret come from HTTP Basic auth when present
+	Token(ctx context.Context, req *model.TokenRequest, clientID string, clientSecret string) (*model.TokenResponse, error)
+	// RevokeToken implements RFC 7009 for refresh tokens
+	RevokeToken(ctx context.Context, token string, clientID string, clientSecret string) error
+	UserInfo(ctx context.Context, userID int, clientID string, scope string) (*model.UserInfo, error)
+
+	FindMyConsents(ctx context.Context, userID int) ([]*model.OAuthConsent, error)
+	RevokeConsent(ctx context.Context, userID int, clientID string) error
+
+	Metadata() oauth.ServerMetadata
+	JWKS() oidc.JSONWebKeySet
+}
+
+// OAuthSettings are the authorization server settings that don't come from other components
+type OAuthSettings struct {
+	Issuer          string
+	AuthCodeTTL     time.Duration
+	AccessTokenTTL  time.Duration
+	RefreshTokenTTL time.Duration
+	// ClientCacheTTL is how long CheckClient trusts a client to be unrevoked; revocations on this
+	// instance take effect immediately
+	ClientCacheTTL time.Duration
+}
+
+// oauthClientCacheSize caps the number of clients whose revocation state is cached
+const oauthClientCacheSize = 1000
+
+type oauthService struct {
+	clientRepo repository.IOAuthClientRepository
+	grantRepo  repository.IOAuthGrantRepository
+	userRepo   repository.IUserRepository
+	jwtManager *utils.JWTManager
+	signer     *oauth.IDTokenSigner
+	settings   OAuthSettings
+	// revoked caches whether a client_id is revoked
+	revoked *utils.TTLCache[string, bool]
+}
+
+func NewOAuthService(
+	clientRepo repository.IOAuthClientRepository,
+	grantRepo repository.IOAuthGrantRepository,
+	userRepo repository.IUserRepository,
+	jwtManager *utils.JWTManager,
+	signer *oauth.IDTokenSigner,
+	settings OAuthSettings,
+) IOAuthService {
+	settings.Issuer = strings.TrimSuffix(settings.Issuer, "/")
+	return &oauthService{
+		clientRepo: clientRepo,
+		grantRepo:  grantRepo,
+		userRepo:   userRepo,
+		jwtManager: jwtManager,
+		signer:     signer,
+		settings:   settings,
+		revoked:    utils.NewTTLCache[string, bool](settings.ClientCacheTTL, oauthClientCacheSize),
+	}
+}
+
+// supportedScopes are the OpenID scopes used with authorization_code plus the permissions used with client_credentials
+func supportedScopes() []string {
+	return append(slices.Clone(oauth.OpenIDScopes), constants.Permissions...)
+}
+
+func (s *oauthService) CreateClient(ctx context.Context, creatorID int, req *model.CreateOAuthClientRequest) (*model.CreateOAuthClientResponse, error) {
+	if fieldErrors := validateClientRequest(req); fieldErrors != nil {
+		return nil, response.BadRequestWithFields("VALIDATION_FAILED", "Validation failed", fieldErrors)
+	}
+
+	clientID, err := oauth.NewClientID()
+	if err != nil {
+		return nil, response.Internal(err)
+	}
+	client := &model.OAuthClient{
+		ClientID:     clientID,
+		Name:         req.Name,
+		RedirectURIs: req.RedirectURIs,
+		GrantTypes:   req.GrantTypes,
+		Scopes:       req.Scopes,
+		CreatedBy:    &creatorID,
+	}
+
+	var secret string
+	if req.Confidential {
+		secret, err = oauth.NewOpaqueToken(oauth.ClientSecretPrefix)
+		if err != nil {
+			return nil, response.Internal(err)
+		}
+		secretHash := oauth.HashToken(secret)
+		client.ClientSecretHash = &secretHash
+	}
+	if slices.Contains(req.GrantTypes, oauth.GrantTypeClientCredentials) {
+		ownerID := req.UserID
+		if ownerID == 0 {
+			ownerID = creatorID
+		}
+		client.UserID = &ownerID
+	}
+
+	if err := s.clientRepo.Create(ctx, client); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, response.NotFound("USER_NOT_FOUND", "User not found", err)
+		}
+		slog.ErrorContext(ctx, "failed to create oauth client", slog.String("name", req.Name), slog.Any("error", err))
+		return nil, response.Internal(err)
+	}
+
+	return &model.CreateOAuthClientResponse{ClientSecret: secret, Client: client}, nil
+}
+
+func validateClientRequest(req *model.CreateOAuthClientRequest) map[string]string {
+	fieldErrors := map[string]string{}
+
+	usesCode := slices.Contains(req.GrantTypes, oauth.GrantTypeAuthorizationCode)
+	usesClientCredentials := slices.Contains(req.GrantTypes, oauth.GrantTypeClientCredentials)
+	if usesCode && len(req.RedirectURIs) == 0 {
+		fieldErrors["redirect_uris"] = "authorization_code clients need at least one redirect URI"
+	}
+	if usesClientCredentials && !req.Confidential {
+		fieldErrors["grant_types"] = "client_credentials requires a confidential client"
+	}
+	if !usesCode && slices.Contains(req.GrantTypes, oauth.GrantTypeRefreshToken) {
+		fieldErrors["grant_types"] = "refresh_token is only issued together with authorization_code"
+	}
+	for _, uri := range req.RedirectURIs {
+		if u, err := url.Parse(uri); err != nil || u.Fragment != "" {
+			fieldErrors["redirect_uris"] = "redirect URIs must be absolute and must not contain a fragment"
+		}
+	}
+	for _, scope := range req.Scopes {
+		if !oauth.IsOpenIDScope(scope) && !constants.IsValidPermission(scope) {
+			fieldErrors["scopes"] = "unknown scope " + scope
+		}
+	}
+
+	if len(fieldErrors) == 0 {
+		return nil
+	}
+	return fieldErrors
+}
+
+func (s *oauthService) FindAllClients(ctx context.Context, limit int, offset int) ([]*model.OAuthClient, int64, error) {
+	return s.clientRepo.FindAll(ctx, limit, offset)
+}
+
+func (s *oauthService) RevokeClient(ctx context.Context, id int) error {
+	clientID, err := s.clientRepo.Revoke(ctx, id)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return response.NotFound("OAUTH_CLIENT_NOT_FOUND", "OAuth client not found", err)
+		}
+		return response.Internal(err)
+	}
+	s.revoked.Set(clientID, true)
+	return nil
+}
+
+func (s *oauthService) CheckClient(ctx context.Context, clientID string) error {
+	revoked, ok := s.revoked.Get(clientID)
+	if !ok {
+		client, err := s.clientRepo.FindByClientID(ctx, clientID)
+		switch {
+		case errors.Is(err, repository.ErrNotFound):
+			revoked = true
+		case err != nil:
+			return response.Internal(err)
+		default:
+			revoked = client.RevokedAt != nil
+		}
+		s.revoked.Set(clientID, revoked)
+	}
+	if revoked {
+		return response.Unauthorized("TOKEN_REVOKED", "Token is no longer valid. Please sign in again", nil)
+	}
+	return nil
+}
+
+func (s *oauthService) ValidateAuthorizeRequest(ctx context.Context, req *model.AuthorizeRequest) (*model.OAuthClient, []string, error) {
+	client, err := s.clientRepo.FindByClientID(ctx, req.ClientID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, nil, response.BadRequest("OAUTH_INVALID_CLIENT", "Unknown OAuth client", err)
+		}
+		return nil, nil, response.Internal(err)
+	}
+	if client.RevokedAt != nil {
+		return nil, nil, response.BadRequest("OAUTH_INVALID_CLIENT", "Unknown OAuth client", nil)
+	}
+	if !matchRedirectURI(client.RedirectURIs, req.RedirectURI) {
+		return nil, nil, response.BadRequest("OAUTH_INVALID_REDIRECT_URI", "Redirect URI is not registered for this client", nil)
+	}
+
+	// From here on errors are reported to the client through the redirect URI
+	if req.ResponseType != "code" {
+		return nil, nil, oauth.NewError(http.StatusBadRequest, oauth.ErrorUnsupportedResponseType, "only response_type=code is supported")
+	}
+	if !slices.Contains(client.GrantTypes, oauth.GrantTypeAuthorizationCode) {
+		return nil, nil, oauth.UnauthorizedClient("client is not allowed to use authorization_code")
+	}
+	// PKCE is required for every client, confidential ones included (OAuth 2.1)
+	if req.CodeChallenge == "" || req.CodeChallengeMethod != "S256" {
+		return nil, nil, oauth.InvalidRequest("code_challenge with code_challenge_method=S256 is required")
+	}
+
+	scopes := oauth.ParseScope(req.Scope)
+	if len(scopes) == 0 {
+		scopes = []string{oauth.ScopeOpenID}
+	}
+	for _, scope := range scopes {
+		if !oauth.IsOpenIDScope(scope) {
+			return nil, nil, oauth.InvalidScope("scope " + scope + " can't be requested through authorization_code")
+		}
+	}
+	if !oauth.IsSubset(scopes, client.Scopes) {
+		return nil, nil, oauth.InvalidScope("requested scope exceeds the scope registered for this client")
+	}
+
+	return client, scopes, nil
+}
+
+// matchRedirectURI compares exactly, except that loopback redirects may use any port (RFC 8252 7.3),
+// so CLIs can listen on an ephemeral port
+func matchRedirectURI(registered []string, redirectURI string) bool {
+	if redirectURI == "" {
+		return false
+	}
+	if slices.Contains(registered, redirectURI) {
+		return true
+	}
+
+	requested, err := url.Parse(redirectURI)
+	if err != nil || requested.Scheme != "http" || !isLoopback(requested.Hostname()) {
+		return false
+	}
+	for _, uri := range registered {
+		candidate, err := url.Parse(uri)
+		if err != nil {
+			continue
+		}
+		if candidate.Scheme == requested.Scheme && candidate.Hostname() == requested.Hostname() &&
+			candidate.Path == requested.Path && candidate.RawQuery == requested.RawQuery {
+			return true
+		}
+	}
+	return false
+}
+
+func isLoopback(host string) bool {
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+func (s *oauthService) HasConsent(ctx context.Context, userID int, clientID string, scopes []string) (bool, error) {
+	consent, err := s.grantRepo.FindConsent(ctx, userID, clientID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return false, nil
+		}
+		return false, response.Internal(err)
+	}
+	return oauth.IsSubset(scopes, consent.Scopes), nil
+}
+
+func (s *oauthService) Authorize(ctx context.Context, userID int, req *model.AuthorizeRequest, scopes []string) (string, error) {
+	if err := s.grantRepo.SaveConsent(ctx, userID, req.ClientID, scopes); err != nil {
+		slog.ErrorContext(ctx, "failed to save oauth consent", slog.Int("user_id", userID), slog.String("client_id", req.ClientID), slog.Any("error", err))
+		return "", response.Internal(err)
+	}
+
+	code, err := oauth.NewOpaqueToken(oauth.AuthorizationCodePrefix)
+	if err != nil {
+		return "", response.Internal(err)
+	}
+	err = s.grantRepo.CreateAuthorizationCode(ctx, &model.OAuthAuthorizationCode{
+		CodeHash:      oauth.HashToken(code),
+		ClientID:      req.ClientID,
+		UserID:        userID,
+		RedirectURI:   req.RedirectURI,
+		Scope:         oauth.FormatScope(scopes),
+		CodeChallenge: req.CodeChallenge,
+		Nonce:         req.Nonce,
+		ExpiresAt:     time.Now().Add(s.settings.AuthCodeTTL),
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to create authorization code", slog.Int("user_id", userID), slog.String("client_id", req.ClientID), slog.Any("error", err))
+		return "", response.Internal(err)
+	}
+
+	params := url.Values{"code": {code}}
+	if req.State != "" {
+		params.Set("state", req.State)
+	}
+	return oauth.AppendQuery(req.RedirectURI, params), nil
+}
+
+func (s *oauthService) Token(ctx context.Context, req *model.TokenRequest, clientID string, clientSecret string) (*model.TokenResponse, error) {
+	if clientID == "" {
+		clientID, clientSecret = req.ClientID, req.ClientSecret
+	}
+	client, err := s.authenticateClient(ctx, clientID, clientSecret)
+	if err != nil {
+		return nil, err
+	}
+	if !slices.Contains(client.GrantTypes, req.GrantType) {
+		if !slices.Contains(oauth.GrantTypes, req.GrantType) {
+			return nil, oauth.NewError(http.StatusBadRequest, oauth.ErrorUnsupportedGrantType, "unsupported grant_type "+req.GrantType)
+		}
+		return nil, oauth.UnauthorizedClient("client is not allowed to use " + req.GrantType)
+	}
+
+	switch req.GrantType {
+	case oauth.GrantTypeAuthorizationCode:
+		return s.exchangeAuthorizationCode(ctx, client, req)
+	case oauth.GrantTypeRefreshToken:
+		return s.exchangeRefreshToken(ctx, client, req)
+	default:
+		return s.clientCredentials(ctx, client, req)
+	}
+}
+
+// authenticateClient identifies the client; confidential clients must present their secret
+func (s *oauthService) authenticateClient(ctx context.Context, clientID string, clientSecret string) (*model.OAuthClient, error) {
+	if clientID == "" {
+		return nil, oauth.InvalidClient("client authentication is required")
+	}
+	client, err := s.clientRepo.FindByClientID(ctx, clientID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, oauth.InvalidClient("client authentication failed")
+		}
+		return nil, oauth.ServerError(err)
+	}
+	if client.RevokedAt != nil {
+		return nil, oauth.InvalidClient("client authentication failed")
+	}
+	if client.IsConfidential() && !oauth.VerifyTokenHash(clientSecret, *client.ClientSecretHash) {
+		return nil, oauth.InvalidClient("client authentication failed")
+	}
+	return client, nil
+}
+
+func (s *oauthService) exchangeAuthorizationCode(ctx context.Context, client *model.OAuthClient, req *model.TokenRequest) (*model.TokenResponse, error) {
+	if req.Code == "" || req.CodeVerifier == "" {
+		return nil, oauth.InvalidRequest("code and code_verifier are required")
+	}
+
+	code, err := s.grantRepo.ConsumeAuthorizationCode(ctx, oauth.HashToken(req.Code), time.Now())
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, oauth.InvalidGrant("authorization code is invalid or was already used")
+		}
+		return nil, oauth.ServerError(err)
+	}
+	switch {
+	case time.Now().After(code.ExpiresAt):
+		return nil, oauth.InvalidGrant("authorization code has expired")
+	case code.ClientID != client.ClientID:
+		return nil, oauth.InvalidGrant("authorization code was issued to another client")
+	case code.RedirectURI != req.RedirectURI:
+		return nil, oauth.InvalidGrant("redirect_uri does not match the authorization request")
+	case !oauth.VerifyCodeChallenge(req.CodeVerifier, code.CodeChallenge):
+		return nil, oauth.InvalidGrant("PKCE verification failed")
+	}
+
+	user, err := s.userRepo.FindUserByID(ctx, code.UserID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, oauth.InvalidGrant("user no longer exists")
+		}
+		return nil, oauth.ServerError(err)
+	}
+	if user.Status != constants.UserStatusActive {
+		return nil, oauth.InvalidGrant("user account is not active")
+	}
+
+	familyID, err := oidc.RandomToken(16)
+	if err != nil {
+		return nil, oauth.ServerError(err)
+	}
+	return s.issueUserTokens(ctx, client, user, code.Scope, code.Nonce, familyID)
+}
+
+func (s *oauthService) exchangeRefreshToken(ctx context.Context, client *model.OAuthClient, req *model.TokenRequest) (*model.TokenResponse, error) {
+	if req.RefreshToken == "" {
+		return nil, oauth.InvalidRequest("refresh_token is required")
+	}
+
+	token, err := s.grantRepo.FindRefreshToken(ctx, oauth.HashToken(req.RefreshToken))
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, oauth.InvalidGrant("refresh token is invalid")
+		}
+		return nil, oauth.ServerError(err)
+	}
+	if token.ClientID != client.ClientID {
+		return nil, oauth.InvalidGrant("refresh token was issued to another client")
+	}
+	if token.RevokedAt != nil || time.Now().After(token.ExpiresAt) {
+		return nil, oauth.InvalidGrant("refresh token is revoked or expired")
+	}
+
+	// A rotated token being presented again means it leaked: revoke every token of the grant
+	if err := s.grantRepo.RotateRefreshToken(ctx, token.ID, time.Now()); err != nil {
+		if !errors.Is(err, repository.ErrNotFound) {
+			return nil, oauth.ServerError(err)
+		}
+		slog.WarnContext(ctx, "refresh token reuse detected, revoking token family",
+			slog.String("client_id", client.ClientID), slog.Int("user_id", token.UserID), slog.String("family_id", token.FamilyID))
+		if err := s.grantRepo.RevokeRefreshTokenFamily(ctx, token.FamilyID); err != nil {
+			slog.ErrorContext(ctx, "failed to revoke refresh token family", slog.String("family_id", token.FamilyID), slog.Any("error", err))
+		}
+		return nil, oauth.InvalidGrant("refresh token is invalid")
+	}
+
+	// The client may narrow, but never widen, the original scope (RFC 6749 6)
+	scope := token.Scope
+	if req.Scope != "" {
+		requested := oauth.ParseScope(req.Scope)
+		if !oauth.IsSubset(requested, oauth.ParseScope(token.Scope)) {
+			return nil, oauth.InvalidScope("requested scope exceeds the original grant")
+		}
+		scope = oauth.FormatScope(requested)
+	}
+
+	user, err := s.userRepo.FindUserByID(ctx, token.UserID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, oauth.InvalidGrant("user no longer exists")
+		}
+		return nil, oauth.ServerError(err)
+	}
+	if user.Status != constants.UserStatusActive {
+		return nil, oauth.InvalidGrant("user account is not active")
+	}
+	return s.issueUserTokens(ctx, client, user, scope, "", token.FamilyID)
+}
+
+func (s *oauthService) clientCredentials(ctx context.Context, client *model.OAuthClient, req *model.TokenRequest) (*model.TokenResponse, error) {
+	if !client.IsConfidential() || client.UserID == nil {
+		return nil, oauth.UnauthorizedClient("client is not allowed to use client_credentials")
+	}
+
+	// Default to every permission registered for the client
+	var scopes []string
+	if req.Scope == "" {
+		for _, scope := range client.Scopes {
+			if constants.IsValidPermission(scope) {
+				scopes = append(scopes, scope)
+			}
+		}
+	} else {
+		scopes = oauth.ParseScope(req.Scope)
+		for _, scope := range scopes {
+			if !constants.IsValidPermission(scope) {
+				return nil, oauth.InvalidScope("scope " + scope + " can't be requested through client_credentials")
+			}
+		}
+		if !oauth.IsSubset(scopes, client.Scopes) {
+			return nil, oauth.InvalidScope("requested scope exceeds the scope registered for this client")
+		}
+	}
+
+	owner, err := s.userRepo.FindUserByID(ctx, *client.UserID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, oauth.UnauthorizedClient("client owner no longer exists")
+		}
+		return nil, oauth.ServerError(err)
+	}
+	if owner.Status != constants.UserStatusActive {
+		return nil, oauth.UnauthorizedClient("client owner account is not active")
+	}
+
+	scope := oauth.FormatScope(scopes)
+	accessToken, err := s.jwtManager.GenerateOAuthToken(owner.ID, owner.Email, owner.Name, owner.Role, owner.TokenVersion, client.ClientID, scope, oauth.GrantTypeClientCredentials, s.settings.AccessTokenTTL)
+	if err != nil {
+		return nil, oauth.ServerError(err)
+	}
+	return &model.TokenResponse{
+		AccessToken: accessToken,
+		TokenType:   "Bearer",
+		ExpiresIn:   int(s.settings.AccessTokenTTL.Seconds()),
+		Scope:       scope,
+	}, nil
+}
+
+type idTokenClaims struct {
+	Nonce string `json:"nonce,omitempty"`
+	Name  string `json:"name,omitempty"`
+	Email string `json:"email,omitempty"`
+	jwt.RegisteredClaims
+}
+
+// issueUserTokens issues the access token, plus an ID token for openid and a refresh token for offline_access
+func (s *oauthService) issueUserTokens(ctx context.Context, client *model.OAuthClient, user *model.User, scope string, nonce string, familyID string) (*model.TokenResponse, error) {
+	accessToken, err := s.jwtManager.GenerateOAuthToken(user.ID, user.Email, user.Name, user.Role, user.TokenVersion, client.ClientID, scope, oauth.GrantTypeAuthorizationCode, s.settings.AccessTokenTTL)
+	if err != nil {
+		return nil, oauth.ServerError(err)
+	}
+	resp := &model.TokenResponse{
+		AccessToken: accessToken,
+		TokenType:   "Bearer",
+		ExpiresIn:   int(s.settings.AccessTokenTTL.Seconds()),
+		Scope:       scope,
+	}
+
+	scopes := oauth.ParseScope(scope)
+	if slices.Contains(scopes, oauth.ScopeOpenID) {
+		now := time.Now()
+		claims := idTokenClaims{
+			Nonce: nonce,
+			RegisteredClaims: jwt.RegisteredClaims{
+				Issuer:    s.settings.Issuer,
+				Subject:   strconv.Itoa(user.ID),
+				Audience:  jwt.ClaimStrings{client.ClientID},
+				IssuedAt:  jwt.NewNumericDate(now),
+				ExpiresAt: jwt.NewNumericDate(now.Add(s.jwtManager.Duration())),
+			},
+		}
+		if slices.Contains(scopes, oauth.ScopeProfile) {
+			claims.Name = user.Name
+		}
+		if slices.Contains(scopes, oauth.ScopeEmail) {
+			claims.Email = user.Email
+		}
+		if resp.IDToken, err = s.signer.Sign(claims); err != nil {
+			return nil, oauth.ServerError(err)
+		}
+	}
+
+	if slices.Contains(scopes, oauth.ScopeOfflineAccess) && slices.Contains(client.GrantTypes, oauth.GrantTypeRefreshToken) {
+		refreshToken, err := oauth.NewOpaqueToken(oauth.RefreshTokenPrefix)
+		if err != nil {
+			return nil, oauth.ServerError(err)
+		}
+		err = s.grantRepo.CreateRefreshToken(ctx, &model.OAuthRefreshToken{
+			TokenHash: oauth.HashToken(refreshToken),
+			FamilyID:  familyID,
+			ClientID:  client.ClientID,
+			UserID:    user.ID,
+			Scope:     scope,
+			ExpiresAt: time.Now().Add(s.settings.RefreshTokenTTL),
+		})
+		if err != nil {
+			slog.ErrorContext(ctx, "failed to store refresh token", slog.Int("user_id", user.ID), slog.String("client_id", client.ClientID), slog.Any("error", err))
+			return nil, oauth.ServerError(err)
+		}
+		resp.RefreshToken = refreshToken
+	}
+
+	return resp, nil
+}
+
+// RevokeToken revokes a refresh token and the rest of its family
+// Access tokens are self-contained JWTs and simply expire, after OAuthSettings.AccessTokenTTL;
+// unknown tokens are not an error (RFC 7009 2.2)
+func (s *oauthService) RevokeToken(ctx context.Context, rawToken string, clientID string, clientSecret string) error {
+	client, err := s.authenticateClient(ctx, clientID, clientSecret)
+	if err != nil {
+		return err
+	}
+	if rawToken == "" {
+		return oauth.InvalidRequest("token is required")
+	}
+
+	token, err := s.grantRepo.FindRefreshToken(ctx, oauth.HashToken(rawToken))
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil
+		}
+		return oauth.ServerError(err)
+	}
+	if token.ClientID != client.ClientID {
+		return nil
+	}
+	if err := s.grantRepo.RevokeRefreshTokenFamily(ctx, token.FamilyID); err != nil {
+		return oauth.ServerError(err)
+	}
+	return nil
+}
+
+func (s *oauthService) UserInfo(ctx context.Context, userID int, clientID string, scope string) (*model.UserInfo, error) {
+	scopes := oauth.ParseScope(scope)
+	// Tokens from /users/login aren't scoped; OAuth tokens need openid
+	if clientID != "" && !slices.Contains(scopes, oauth.ScopeOpenID) {
+		return nil, response.Forbidden("INSUFFICIENT_SCOPE", "The access token does not grant the openid scope", nil)
+	}
+
+	user, err := s.userRepo.FindUserByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, response.NotFound("USER_NOT_FOUND", "User not found", err)
+		}
+		return nil, response.Internal(err)
+	}
+
+	info := &model.UserInfo{Subject: strconv.Itoa(user.ID)}
+	if clientID == "" || slices.Contains(scopes, oauth.ScopeProfile) {
+		info.Name = user.Name
+	}
+	if clientID == "" || slices.Contains(scopes, oauth.ScopeEmail) {
+		info.Email = user.Email
+	}
+	return info, nil
+}
+
+func (s *oauthService) FindMyConsents(ctx context.Context, userID int) ([]*model.OAuthConsent, error) {
+	consents, err := s.grantRepo.FindConsentsByUser(ctx, userID)
+	if err != nil {
+		return nil, response.Internal(err)
+	}
+	return consents, nil
+}
+
+func (s *oauthService) RevokeConsent(ctx context.Context, userID int, clientID string) error {
+	if err := s.grantRepo.DeleteConsent(ctx, userID, clientID); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return response.NotFound("CONSENT_NOT_FOUND", "Consent not found", err)
+		}
+		return response.Internal(err)
+	}
+	return nil
+}
+
+func (s *oauthService) Metadata() oauth.ServerMetadata {
+	return oauth.NewServerMetadata(s.settings.Issuer, supportedScopes())
+}
+
+func (s *oauthService) JWKS() oidc.JSONWebKeySet {
+	return s.signer.JWKS()
+}
@@ -0,0 +1,221 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"golang-echo/internal/model"
+	"golang-echo/internal/repository"
+	"golang-echo/pkg/audit"
+	"golang-echo/pkg/oidc"
+	"golang-echo/pkg/response"
+	"golang-echo/pkg/utils"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type IOIDCService interface {
+	// StartLogin returns the provider authorization URL and the signed flow state to keep in a cookie
+	StartLogin(ctx context.Context, provider string) (authURL string, flowState string, err error)
+	// CompleteLogin validates the callback, links or creates the local user and issues an access token
+	CompleteLogin(ctx context.Context, provider string, code string, state string, flowState string, client model.SessionClient) (*model.LoginResponse, error)
+}
+
+type oidcService struct {
+	providers      *oidc.Registry
+	stateCodec     *oidc.StateCodec
+	userRepo       repository.IUserRepository
+	identityRepo   repository.IUserIdentityRepository
+	sessionService ISessionService
+	auditor        IAuditor
+	passwordHasher *utils.PasswordHasher
+}
+
+func NewOIDCService(
+	providers *oidc.Registry,
+	stateCodec *oidc.StateCodec,
+	userRepo repository.IUserRepository,
+	identityRepo repository.IUserIdentityRepository,
+	sessionService ISessionService,
+	auditor IAuditor,
+	passwordHasher *utils.PasswordHasher,
+) IOIDCService {
+	return &oidcService{
+		providers:      providers,
+		stateCodec:     stateCodec,
+		userRepo:       userRepo,
+		identityRepo:   identityRepo,
+		sessionService: sessionService,
+		auditor:        auditor,
+		passwordHasher: passwordHasher,
+	}
+}
+
+func (s *oidcService) StartLogin(ctx context.Context, providerName string) (string, string, error) {
+	provider, err := s.providers.Get(providerName)
+	if err != nil {
+		return "", "", response.NotFound("OIDC_PROVIDER_NOT_FOUND", "Identity provider not found", err)
+	}
+
+	flow, err := s.stateCodec.NewFlowState(providerName)
+	if err != nil {
+		return "", "", response.Internal(err)
+	}
+	authURL, err := provider.AuthCodeURL(ctx, flow.State, flow.Nonce, oidc.CodeChallengeS256(flow.CodeVerifier))
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to build oidc authorization url", slog.String("provider", providerName), slog.Any("error", err))
+		return "", "", response.NewAppError(http.StatusBadGateway, "OIDC_PROVIDER_UNAVAILABLE", "Identity provider is unavailable", err)
+	}
+	encoded, err := s.stateCodec.Encode(flow)
+	if err != nil {
+		return "", "", response.Internal(err)
+	}
+	return authURL, encoded, nil
+}
+
+func (s *oidcService) CompleteLogin(ctx context.Context, providerName string, code string, state string, flowState string, client model.SessionClient) (*model.LoginResponse, error) {
+	provider, err := s.providers.Get(providerName)
+	if err != nil {
+		return nil, response.NotFound("OIDC_PROVIDER_NOT_FOUND", "Identity provider not found", err)
+	}
+
+	flow, err := s.stateCodec.Decode(flowState)
+	if err != nil || flow.Provider != providerName || flow.State != state {
+		return nil, response.BadRequest("OIDC_INVALID_STATE", "Login state is invalid or expired. Please start again", err)
+	}
+	if code == "" {
+		return nil, response.BadRequest("OIDC_MISSING_CODE", "Authorization code is missing", nil)
+	}
+
+	identity, err := provider.Exchange(ctx, code, flow.CodeVerifier, flow.Nonce)
+	if err != nil {
+		slog.WarnContext(ctx, "oidc code exchange failed", slog.String("provider", providerName), slog.Any("error", err))
+		return nil, response.Unauthorized("OIDC_EXCHANGE_FAILED", "Could not verify the identity provider response", err)
+	}
+
+	user, err := s.resolveUser(ctx, identity)
+	if err != nil {
+		return nil, err
+	}
+	if err := accountStatusError(user.Status); err != nil {
+		s.auditor.Record(ctx, audit.Entry{
+			Action:     audit.ActionLoginFailed,
+			TargetType: audit.TargetUser,
+			TargetID:   strconv.Itoa(user.ID),
+			Outcome:    audit.OutcomeDenied,
+			Metadata:   map[string]any{"method": "oidc", "provider": providerName, "reason": "account_" + user.Status},
+		})
+		return nil, err
+	}
+
+	token, err := s.sessionService.CreateSession(ctx, user, client)
+	if err != nil {
+		return nil, err
+	}
+	s.auditor.Record(ctx, audit.Entry{
+		Action:     audit.ActionLogin,
+		TargetType: audit.TargetUser,
+		TargetID:   strconv.Itoa(user.ID),
+		Outcome:    audit.OutcomeSuccess,
+		Metadata:   map[string]any{"method": "oidc", "provider": providerName},
+		ActorID:    &user.ID,
+	})
+
+	user.Password = ""
+
+	return &model.LoginResponse{
+		AccessToken: token,
+		User:        user,
+	}, nil
+}
+
+// resolveUser finds the user for an external identity:
+//  1. an identity already linked to a user
+//  2. an existing user with the same (provider-verified) email, which gets linked
+//  3. a new user created from the identity's claims
+func (s *oidcService) resolveUser(ctx context.Context, identity *oidc.Identity) (*model.User, error) {
+	linked, err := s.identityRepo.FindByProviderSubject(ctx, identity.Provider, identity.Subject)
+	if err == nil {
+		if err := s.identityRepo.TouchLastLogin(ctx, linked.ID, time.Now()); err != nil {
+			slog.WarnContext(ctx, "failed to update identity last_login_at", slog.Int("identity_id", linked.ID), slog.Any("error", err))
+		}
+		user, err := s.userRepo.FindUserByID(ctx, linked.UserID)
+		if err != nil {
+			return nil, response.Internal(err)
+		}
+		return user, nil
+	}
+	if !errors.Is(err, repository.ErrNotFound) {
+		return nil, response.Internal(err)
+	}
+
+	// Linking or creating by email is only safe when the provider vouches for the address
+	if identity.Email == "" || !identity.EmailVerified {
+		return nil, response.Forbidden("OIDC_EMAIL_NOT_VERIFIED", "The identity provider did not return a verified email", nil)
+	}
+
+	user, err := s.userRepo.FindUserByEmail(ctx, identity.Email)
+	if errors.Is(err, repository.ErrNotFound) {
+		user, err = s.createUser(ctx, identity)
+	}
+	if err != nil {
+		return nil, response.Internal(err)
+	}
+
+	link := &model.UserIdentity{
+		UserID:   user.ID,
+		Provider: identity.Provider,
+		Subject:  identity.Subject,
+		Email:    identity.Email,
+	}
+	if err := s.identityRepo.Create(ctx, link); err != nil && !errors.Is(err, repository.ErrDuplicate) {
+		slog.ErrorContext(ctx, "failed to link identity", slog.Int("user_id", user.ID), slog.String("provider", identity.Provider), slog.Any("error", err))
+		return nil, response.Internal(err)
+	}
+	return user, nil
+}
+
+// createUser creates an account for a first-time external login
+// The password is random and never revealed, so the account can only sign in through the provider
+func (s *oidcService) createUser(ctx context.Context, identity *oidc.Identity) (*model.User, error) {
+	randomPassword, err := oidc.RandomToken(32)
+	if err != nil {
+		return nil, err
+	}
+	hashedPassword, err := s.passwordHasher.HashPassword(randomPassword)
+	if err != nil {
+		return nil, err
+	}
+
+	name := strings.TrimSpace(identity.Name)
+	if name == "" {
+		name, _, _ = strings.Cut(identity.Email, "@")
+	}
+
+	user := &model.User{
+		Name:     name,
+		Email:    identity.Email,
+		Password: hashedPassword,
+	}
+	if err := s.userRepo.Create(ctx, user); err != nil {
+		// Created concurrently by another callback for the same email
+		if errors.Is(err, repository.ErrDuplicate) {
+			return s.userRepo.FindUserByEmail(ctx, identity.Email)
+		}
+		return nil, err
+	}
+	slog.InfoContext(ctx, "user created from external identity", slog.Int("user_id", user.ID), slog.String("provider", identity.Provider))
+	s.auditor.Record(ctx, audit.Entry{
+		Action:     audit.ActionUserCreate,
+		TargetType: audit.TargetUser,
+		TargetID:   strconv.Itoa(user.ID),
+		Outcome:    audit.OutcomeSuccess,
+		Diff:       audit.Diff(nil, map[string]any{"name": user.Name, "email": user.Email, "role": user.Role, "status": user.Status}),
+		Metadata:   map[string]any{"provider": identity.Provider},
+	})
+
+	// Re-read to pick up database defaults such as role
+	return s.userRepo.FindUserByID(ctx, user.ID)
+}
@@ -0,0 +1,180 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"golang-echo/internal/model"
+	"golang-echo/internal/repository"
+	"golang-echo/pkg/response"
+	"golang-echo/pkg/utils"
+	"log/slog"
+	"strings"
+	"time"
+)
+
+// sessionLastSeenResolution bounds how often last_seen_at is written for an active session
+const sessionLastSeenResolution = time.Minute
+
+// maxDeviceLabelLength matches user_sessions.device_label
+const maxDeviceLabelLength = 100
+
+// sessionCacheSize caps the number of active sessions cached
+const sessionCacheSize = 10000
+
+type ISessionService interface {
+	// CreateSession records a login and returns an access token tied to the new session
+	CreateSession(ctx context.Context, user *model.User, client model.SessionClient) (string, error)
+	// CreateImpersonationSession records a session of user opened by actor and returns its short-lived token
+	CreateImpersonationSession(ctx context.Context, user *model.User, actor *model.User, client model.SessionClient, duration time.Duration) (string, *model.UserSession, error)
+	// FindSessions lists the user's active sessions, flagging the one for currentTokenID
+	FindSessions(ctx context.Context, userID int, currentTokenID string) ([]*model.UserSession, error)
+	RevokeSession(ctx context.Context, userID int, id int) error
+	// RevokeOtherSessions signs the user out everywhere except the session for currentTokenID
+	RevokeOtherSessions(ctx context.Context, userID int, currentTokenID string) (int64, error)
+	// ValidateSession checks that the session behind a token is still active
+	ValidateSession(ctx context.Context, tokenID string, userID int) error
+}
+
+type sessionService struct {
+	sessionRepo repository.IUserSessionRepository
+	jwtManager  *utils.JWTManager
+	// cache holds active sessions by token ID, so most requests are validated without a query
+	cache *utils.TTLCache[string, model.UserSession]
+}
+
+// NewSessionService caches active sessions for cacheTTL
+// Revocations made on this instance take effect immediately; revocations made elsewhere within cacheTTL
+func NewSessionService(sessionRepo repository.IUserSessionRepository, jwtManager *utils.JWTManager, cacheTTL time.Duration) ISessionService {
+	return &sessionService{
+		sessionRepo: sessionRepo,
+		jwtManager:  jwtManager,
+		cache:       utils.NewTTLCache[string, model.UserSession](cacheTTL, sessionCacheSize),
+	}
+}
+
+func (s *sessionService) CreateSession(ctx context.Context, user *model.User, client model.SessionClient) (string, error) {
+	session, err := s.createSession(ctx, user, client, s.jwtManager.Duration())
+	if err != nil {
+		return "", err
+	}
+
+	token, err := s.jwtManager.GenerateSessionToken(session.TokenID, user.ID, user.Email, user.Name, user.Role, user.TokenVersion)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to generate jwt token", slog.String("email", user.Email), slog.Any("error", err))
+		return "", response.Internal(err)
+	}
+	return token, nil
+}
+
+func (s *sessionService) CreateImpersonationSession(ctx context.Context, user *model.User, actor *model.User, client model.SessionClient, duration time.Duration) (string, *model.UserSession, error) {
+	// Labelled so the user can spot, and revoke, support sessions in their session list
+	client.DeviceName = "Impersonated by " + actor.Email
+	if len(client.DeviceName) > maxDeviceLabelLength {
+		client.DeviceName = client.DeviceName[:maxDeviceLabelLength]
+	}
+	session, err := s.createSession(ctx, user, client, duration)
+	if err != nil {
+		return "", nil, err
+	}
+
+	actorClaims := utils.ActorClaims{UserID: actor.ID, Email: actor.Email, TokenVersion: actor.TokenVersion}
+	token, err := s.jwtManager.GenerateImpersonationToken(session.TokenID, user.ID, user.Email, user.Name, user.Role, user.TokenVersion, actorClaims, duration)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to generate impersonation token", slog.Int("user_id", user.ID), slog.Int("actor_id", actor.ID), slog.Any("error", err))
+		return "", nil, response.Internal(err)
+	}
+	return token, session, nil
+}
+
+func (s *sessionService) createSession(ctx context.Context, user *model.User, client model.SessionClient, duration time.Duration) (*model.UserSession, error) {
+	tokenID, err := utils.NewTokenID()
+	if err != nil {
+		return nil, response.Internal(err)
+	}
+
+	label := strings.TrimSpace(client.DeviceName)
+	if label == "" {
+		label = utils.DeviceLabel(client.UserAgent)
+	}
+	session := &model.UserSession{
+		UserID:      user.ID,
+		TokenID:     tokenID,
+		UserAgent:   client.UserAgent,
+		IPAddress:   client.IPAddress,
+		DeviceLabel: label,
+		ExpiresAt:   time.Now().Add(duration),
+	}
+	if err := s.sessionRepo.Create(ctx, session); err != nil {
+		slog.ErrorContext(ctx, "failed to create session", slog.Int("user_id", user.ID), slog.Any("error", err))
+		return nil, response.Internal(err)
+	}
+	return session, nil
+}
+
+func (s *sessionService) FindSessions(ctx context.Context, userID int, currentTokenID string) ([]*model.UserSession, error) {
+	sessions, err := s.sessionRepo.FindActiveByUser(ctx, userID)
+	if err != nil {
+		return nil, response.Internal(err)
+	}
+	for _, session := range sessions {
+		session.Current = currentTokenID != "" && session.TokenID == currentTokenID
+	}
+	return sessions, nil
+}
+
+func (s *sessionService) RevokeSession(ctx context.Context, userID int, id int) error {
+	tokenID, err := s.sessionRepo.Revoke(ctx, userID, id)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return response.NotFound("SESSION_NOT_FOUND", "Session not found", err)
+		}
+		return response.Internal(err)
+	}
+	s.cache.Delete(tokenID)
+	return nil
+}
+
+func (s *sessionService) RevokeOtherSessions(ctx context.Context, userID int, currentTokenID string) (int64, error) {
+	tokenIDs, err := s.sessionRepo.RevokeAllExcept(ctx, userID, currentTokenID)
+	if err != nil {
+		return 0, response.Internal(err)
+	}
+	for _, tokenID := range tokenIDs {
+		s.cache.Delete(tokenID)
+	}
+	return int64(len(tokenIDs)), nil
+}
+
+func (s *sessionService) ValidateSession(ctx context.Context, tokenID string, userID int) error {
+	if session, ok := s.cache.Get(tokenID); ok {
+		if session.UserID != userID {
+			return response.Unauthorized("INVALID_TOKEN", "Token is invalid or expired", nil)
+		}
+		return nil
+	}
+
+	session, err := s.sessionRepo.FindByTokenID(ctx, tokenID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return response.Unauthorized("INVALID_TOKEN", "Token is invalid or expired", err)
+		}
+		return response.Internal(err)
+	}
+	if session.UserID != userID {
+		return response.Unauthorized("INVALID_TOKEN", "Token is invalid or expired", nil)
+	}
+	if session.RevokedAt != nil {
+		return response.Unauthorized("SESSION_REVOKED", "This session has been signed out", nil)
+	}
+
+	// last_seen_at is only touched when the session is looked up again, so at most once per cache TTL
+	now := time.Now()
+	if now.Sub(session.LastSeenAt) >= sessionLastSeenResolution {
+		if err := s.sessionRepo.TouchLastSeen(ctx, session.ID, now); err != nil {
+			slog.WarnContext(ctx, "failed to update session last_seen_at", slog.Int("session_id", session.ID), slog.Any("error", err))
+		}
+		session.LastSeenAt = now
+	}
+	s.cache.Set(tokenID, *session)
+	return nil
+}
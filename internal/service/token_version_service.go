@@ -0,0 +1,63 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"golang-echo/internal/model"
+	"golang-echo/internal/repository"
+	"golang-echo/pkg/response"
+	"golang-echo/pkg/utils"
+	"time"
+)
+
+// tokenVersionCacheSize caps the number of users whose token_version is cached
+const tokenVersionCacheSize = 10000
+
+type ITokenVersionService interface {
+	// CheckTokenVersion rejects tokens of non-active accounts and tokens issued before
+	// the user's token_version was last bumped
+	CheckTokenVersion(ctx context.Context, userID int, tokenVersion int) error
+	// Invalidate drops the cached state after a bump or status change on this instance
+	Invalidate(userID int)
+}
+
+type tokenVersionService struct {
+	userRepo repository.IUserRepository
+	cache    *utils.TTLCache[int, model.UserTokenState]
+}
+
+// NewTokenVersionService caches token versions and account statuses for ttl
+// Bumps made on this instance take effect immediately; bumps made elsewhere within ttl
+func NewTokenVersionService(userRepo repository.IUserRepository, ttl time.Duration) ITokenVersionService {
+	return &tokenVersionService{
+		userRepo: userRepo,
+		cache:    utils.NewTTLCache[int, model.UserTokenState](ttl, tokenVersionCacheSize),
+	}
+}
+
+func (s *tokenVersionService) CheckTokenVersion(ctx context.Context, userID int, tokenVersion int) error {
+	state, ok := s.cache.Get(userID)
+	if !ok {
+		found, err := s.userRepo.FindTokenState(ctx, userID)
+		if err != nil {
+			if errors.Is(err, repository.ErrNotFound) {
+				return response.Unauthorized("INVALID_TOKEN", "Token is invalid or expired", err)
+			}
+			return response.Internal(err)
+		}
+		s.cache.Set(userID, *found)
+		state = *found
+	}
+
+	if err := accountStatusError(state.Status); err != nil {
+		return err
+	}
+	if tokenVersion != state.TokenVersion {
+		return response.Unauthorized("TOKEN_REVOKED", "Token is no longer valid. Please sign in again", nil)
+	}
+	return nil
+}
+
+func (s *tokenVersionService) Invalidate(userID int) {
+	s.cache.Delete(userID)
+}
@@ -0,0 +1,255 @@
+package service
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"golang-echo/internal/model"
+	"golang-echo/internal/repository"
+	"golang-echo/pkg/audit"
+	"golang-echo/pkg/response"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// exportFlushRows is how often, in rows, an export pushes what it has written to the client
+const exportFlushRows = 500
+
+type IUserExportService interface {
+	// ExportUsers writes every user, limited to fields, to w in format (model.ExportFormatCSV or
+	// model.ExportFormatNDJSON) and returns how many were written
+	// The users come from one consistent snapshot; w is flushed every few hundred rows when it is an http.Flusher
+	ExportUsers(ctx context.Context, w io.Writer, format string, fields model.UserFields) (int64, error)
+	// CreateExportJob starts writing an export to a file in the background
+	CreateExportJob(ctx context.Context, actorID int, req *model.CreateUserExportRequest) (*model.Job, error)
+	FindExportJob(ctx context.Context, id int) (*model.Job, error)
+	// OpenExport opens the file of a succeeded export job until it expires; the caller closes it
+	OpenExport(ctx context.Context, id int) (*model.Job, *os.File, error)
+}
+
+type ExportSettings struct {
+	Dir    string
+	JobTTL time.Duration
+}
+
+type userExportService struct {
+	userRepo repository.IUserRepository
+	jobRepo  repository.IJobRepository
+	auditor  IAuditor
+	settings ExportSettings
+}
+
+func NewUserExportService(userRepo repository.IUserRepository, jobRepo repository.IJobRepository, auditor IAuditor, settings ExportSettings) IUserExportService {
+	return &userExportService{
+		userRepo: userRepo,
+		jobRepo:  jobRepo,
+		auditor:  auditor,
+		settings: settings,
+	}
+}
+
+func (s *userExportService) ExportUsers(ctx context.Context, w io.Writer, format string, fields model.UserFields) (int64, error) {
+	return s.export(ctx, w, format, fields, nil)
+}
+
+// export writes the users and audits the export; jobID is set for export jobs
+func (s *userExportService) export(ctx context.Context, w io.Writer, format string, fields model.UserFields, jobID *int) (int64, error) {
+	buf := bufio.NewWriter(w)
+	var encoder response.ItemEncoder
+	if format == model.ExportFormatNDJSON {
+		encoder = response.NewNDJSONEncoder(buf)
+	} else {
+		csv := response.NewCSVEncoder(buf)
+		if err := csv.WriteHeader(fields); err != nil {
+			return 0, err
+		}
+		encoder = csv
+	}
+	flush := func() error {
+		if err := encoder.Flush(); err != nil {
+			return err
+		}
+		if err := buf.Flush(); err != nil {
+			return err
+		}
+		if flusher, ok := w.(http.Flusher); ok {
+			flusher.Flush()
+		}
+		return nil
+	}
+
+	var rows int64
+	err := s.userRepo.ExportUsers(ctx, fields, func(user *model.User) error {
+		if err := encoder.Encode(fields.View(user)); err != nil {
+			return err
+		}
+		rows++
+		if rows%exportFlushRows == 0 {
+			return flush()
+		}
+		return nil
+	})
+	if err == nil {
+		err = flush()
+	}
+
+	metadata := map[string]any{"format": format, "fields": fields, "rows": rows}
+	if jobID != nil {
+		metadata["job_id"] = *jobID
+	}
+	s.auditor.Record(ctx, audit.Entry{
+		Action:     audit.ActionUserExport,
+		TargetType: audit.TargetUser,
+		Outcome:    audit.OutcomeOf(err),
+		Metadata:   metadata,
+	})
+	return rows, err
+}
+
+func (s *userExportService) CreateExportJob(ctx context.Context, actorID int, req *model.CreateUserExportRequest) (*model.Job, error) {
+	fields, fieldErrors := model.ParseUserFields("fields", strings.Join(req.Fields, ","))
+	if fieldErrors != nil {
+		return nil, response.BadRequestWithFields("VALIDATION_FAILED", "Validation failed", fieldErrors)
+	}
+	if fields == nil {
+		fields = append(model.UserFields{}, model.SelectableUserFields...)
+	}
+	params := model.UserExportParams{Format: req.Format, Fields: fields}
+	raw, err := json.Marshal(params)
+	if err != nil {
+		return nil, response.Internal(err)
+	}
+
+	job := &model.Job{Kind: model.JobKindUserExport, Params: raw}
+	if actorID != 0 {
+		job.CreatedBy = &actorID
+	}
+	if err := s.jobRepo.Create(ctx, job); err != nil {
+		slog.ErrorContext(ctx, "failed to create export job", slog.Any("error", err))
+		return nil, response.Internal(err)
+	}
+	// The job outlives the request, but keeps its request ID and actor for the logs and audit log
+	// It updates a copy, as job is returned to the caller
+	running := *job
+	go s.runExport(context.WithoutCancel(ctx), &running, params)
+	return job, nil
+}
+
+// runExport writes the export job's file and records the outcome on the job
+func (s *userExportService) runExport(ctx context.Context, job *model.Job, params model.UserExportParams) {
+	s.removeExpiredFiles(ctx)
+
+	started := time.Now()
+	if err := s.jobRepo.Start(ctx, job.ID, started); err != nil {
+		slog.ErrorContext(ctx, "failed to start export job", slog.Int("job_id", job.ID), slog.Any("error", err))
+		return
+	}
+	job.Status = model.JobStatusRunning
+	job.StartedAt = &started
+
+	path, result, err := s.writeExportFile(ctx, job.ID, params)
+	finished := time.Now()
+	job.FinishedAt = &finished
+	if err != nil {
+		slog.ErrorContext(ctx, "export job failed", slog.Int("job_id", job.ID), slog.Any("error", err))
+		message := "The export could not be written"
+		job.Status = model.JobStatusFailed
+		job.Error = &message
+	} else {
+		expiresAt := finished.Add(s.settings.JobTTL)
+		job.Status = model.JobStatusSucceeded
+		job.Result, _ = json.Marshal(result)
+		job.FilePath = &path
+		job.ExpiresAt = &expiresAt
+	}
+	if err := s.jobRepo.Finish(ctx, job); err != nil {
+		slog.ErrorContext(ctx, "failed to finish export job", slog.Int("job_id", job.ID), slog.Any("error", err))
+		if path != "" {
+			os.Remove(path)
+		}
+	}
+}
+
+// writeExportFile writes the export to a temporary file, renamed into place only once it is complete
+func (s *userExportService) writeExportFile(ctx context.Context, jobID int, params model.UserExportParams) (string, *model.UserExportResult, error) {
+	if err := os.MkdirAll(s.settings.Dir, 0o700); err != nil {
+		return "", nil, err
+	}
+	file, err := os.CreateTemp(s.settings.Dir, "users-*.tmp")
+	if err != nil {
+		return "", nil, err
+	}
+	defer os.Remove(file.Name()) // a no-op once renamed
+
+	rows, err := s.export(ctx, file, params.Format, params.Fields, &jobID)
+	if err != nil {
+		file.Close()
+		return "", nil, err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return "", nil, err
+	}
+	if err := file.Close(); err != nil {
+		return "", nil, err
+	}
+	path := filepath.Join(s.settings.Dir, "users-"+strconv.Itoa(jobID)+"."+params.Format)
+	if err := os.Rename(file.Name(), path); err != nil {
+		return "", nil, err
+	}
+	return path, &model.UserExportResult{Rows: rows, Bytes: info.Size()}, nil
+}
+
+// removeExpiredFiles deletes the files of expired export jobs
+func (s *userExportService) removeExpiredFiles(ctx context.Context) {
+	paths, err := s.jobRepo.ClaimExpiredFiles(ctx, time.Now())
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to find expired export files", slog.Any("error", err))
+		return
+	}
+	for _, path := range paths {
+		if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			slog.ErrorContext(ctx, "failed to remove expired export file", slog.String("path", path), slog.Any("error", err))
+		}
+	}
+}
+
+func (s *userExportService) FindExportJob(ctx context.Context, id int) (*model.Job, error) {
+	job, err := s.jobRepo.FindByID(ctx, model.JobKindUserExport, id)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, response.NotFound("EXPORT_JOB_NOT_FOUND", "Export job not found", err)
+		}
+		return nil, response.Internal(err)
+	}
+	return job, nil
+}
+
+func (s *userExportService) OpenExport(ctx context.Context, id int) (*model.Job, *os.File, error) {
+	job, err := s.FindExportJob(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	if job.Status != model.JobStatusSucceeded {
+		return nil, nil, response.Conflict("EXPORT_NOT_READY", "The export job has not succeeded; check its status", nil)
+	}
+	if job.FilePath == nil || job.ExpiresAt == nil || !job.ExpiresAt.After(time.Now()) {
+		return nil, nil, response.Gone("EXPORT_EXPIRED", "The export file has expired; start a new export", nil)
+	}
+	file, err := os.Open(*job.FilePath)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil, response.Gone("EXPORT_EXPIRED", "The export file has expired; start a new export", err)
+		}
+		return nil, nil, response.Internal(err)
+	}
+	return job, file, nil
+}
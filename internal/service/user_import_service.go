@@ -0,0 +1,478 @@
+package service
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"golang-echo/internal/model"
+	"golang-echo/internal/repository"
+	"golang-echo/pkg/audit"
+	"golang-echo/pkg/oidc"
+	"golang-echo/pkg/response"
+	"golang-echo/pkg/utils"
+	"io"
+	"log/slog"
+	"os"
+	"slices"
+	"strings"
+	"time"
+)
+
+const (
+	// importBatchSize is how many rows are copied to the database at a time
+	importBatchSize = 1000
+	// maxImportErrors bounds the row errors kept in an import report
+	maxImportErrors = 1000
+	// maxImportLine bounds an NDJSON line
+	maxImportLine = 64 * 1024
+)
+
+type IUserImportService interface {
+	// CreateImportJob saves body, a CSV or NDJSON file (model.ExportFormatCSV or model.ExportFormatNDJSON),
+	// checks that it can be read and imports it in the background
+	// Row errors are written in locale
+	CreateImportJob(ctx context.Context, actorID int, format string, body io.Reader, query *model.ImportUsersQuery, locale string) (*model.Job, error)
+	FindImportJob(ctx context.Context, id int) (*model.Job, error)
+}
+
+type ImportSettings struct {
+	// MaxBytes bounds the size of an import file
+	MaxBytes int64
+}
+
+type userImportService struct {
+	userRepo       repository.IUserRepository
+	jobRepo        repository.IJobRepository
+	tokenVersions  ITokenVersionService
+	auditor        IAuditor
+	validator      *utils.CustomValidator
+	passwordPolicy *utils.PasswordPolicy
+	passwordHasher *utils.PasswordHasher
+	settings       ImportSettings
+}
+
+func NewUserImportService(
+	userRepo repository.IUserRepository,
+	jobRepo repository.IJobRepository,
+	tokenVersions ITokenVersionService,
+	auditor IAuditor,
+	validator *utils.CustomValidator,
+	passwordPolicy *utils.PasswordPolicy,
+	passwordHasher *utils.PasswordHasher,
+	settings ImportSettings,
+) IUserImportService {
+	return &userImportService{
+		userRepo:       userRepo,
+		jobRepo:        jobRepo,
+		tokenVersions:  tokenVersions,
+		auditor:        auditor,
+		validator:      validator,
+		passwordPolicy: passwordPolicy,
+		passwordHasher: passwordHasher,
+		settings:       settings,
+	}
+}
+
+func (s *userImportService) CreateImportJob(ctx context.Context, actorID int, format string, body io.Reader, query *model.ImportUsersQuery, locale string) (*model.Job, error) {
+	// The file is saved first: the job outlives the request body
+	file, err := os.CreateTemp("", "user-import-*")
+	if err != nil {
+		return nil, response.Internal(err)
+	}
+	keep := false
+	defer func() {
+		if !keep {
+			file.Close()
+			os.Remove(file.Name())
+		}
+	}()
+	size, err := io.Copy(file, io.LimitReader(body, s.settings.MaxBytes+1))
+	if err != nil {
+		return nil, response.BadRequest("BIND_ERROR", "Invalid request body", err)
+	}
+	if size > s.settings.MaxBytes {
+		return nil, response.PayloadTooLarge("IMPORT_TOO_LARGE", fmt.Sprintf("Import files are limited to %d bytes", s.settings.MaxBytes), nil)
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return nil, response.Internal(err)
+	}
+	if _, err := newImportReader(file, format); err != nil {
+		return nil, response.BadRequestWithFields("INVALID_IMPORT_FILE", "The import file can't be read", map[string]string{"file": err.Error()})
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return nil, response.Internal(err)
+	}
+
+	params := model.UserImportParams{
+		Format:     format,
+		DryRun:     query.DryRun,
+		OnConflict: query.OnConflict,
+		Locale:     locale,
+		Bytes:      size,
+	}
+	if params.OnConflict == "" {
+		params.OnConflict = model.ImportConflictFail
+	}
+	raw, err := json.Marshal(params)
+	if err != nil {
+		return nil, response.Internal(err)
+	}
+	job := &model.Job{Kind: model.JobKindUserImport, Params: raw}
+	if actorID != 0 {
+		job.CreatedBy = &actorID
+	}
+	if err := s.jobRepo.Create(ctx, job); err != nil {
+		slog.ErrorContext(ctx, "failed to create import job", slog.Any("error", err))
+		return nil, response.Internal(err)
+	}
+	keep = true
+	// Like export jobs, the import runs on a copy of job with the request's ID and actor
+	running := *job
+	go s.runImport(context.WithoutCancel(ctx), &running, params, file)
+	return job, nil
+}
+
+// runImport imports file, which it then removes, and records the report on the job
+func (s *userImportService) runImport(ctx context.Context, job *model.Job, params model.UserImportParams, file *os.File) {
+	defer os.Remove(file.Name())
+	defer file.Close()
+
+	if err := s.jobRepo.Start(ctx, job.ID, time.Now()); err != nil {
+		slog.ErrorContext(ctx, "failed to start import job", slog.Int("job_id", job.ID), slog.Any("error", err))
+		return
+	}
+	report, err := s.importFile(ctx, job.ID, params, file)
+
+	finished := time.Now()
+	job.FinishedAt = &finished
+	job.Status = model.JobStatusSucceeded
+	if err != nil {
+		// Nothing was written: the import is one transaction
+		report.Inserted, report.Updated, report.Skipped = 0, 0, 0
+		message := "The import failed; no users were changed"
+		if errors.Is(err, repository.ErrDuplicate) {
+			message = "Some emails are already registered; with on_conflict=fail no users were imported"
+		} else {
+			slog.ErrorContext(ctx, "import job failed", slog.Int("job_id", job.ID), slog.Any("error", err))
+		}
+		job.Status = model.JobStatusFailed
+		job.Error = &message
+	}
+	// Conflicts are only known once their batch is written, after the batch's own row errors
+	slices.SortStableFunc(report.Errors, func(a, b model.UserImportRowError) int { return a.Row - b.Row })
+	job.Result, _ = json.Marshal(report)
+	if err := s.jobRepo.Finish(ctx, job); err != nil {
+		slog.ErrorContext(ctx, "failed to finish import job", slog.Int("job_id", job.ID), slog.Any("error", err))
+	}
+
+	s.auditor.Record(ctx, audit.Entry{
+		Action:     audit.ActionUserImport,
+		TargetType: audit.TargetUser,
+		Outcome:    audit.OutcomeOf(err),
+		Metadata: map[string]any{
+			"job_id": job.ID, "dry_run": params.DryRun, "on_conflict": params.OnConflict,
+			"rows": report.Rows, "inserted": report.Inserted, "updated": report.Updated,
+			"skipped": report.Skipped, "failed": report.Failed,
+		},
+	})
+}
+
+// importFile reads, validates and writes the rows in batches, saving the job's progress after each
+func (s *userImportService) importFile(ctx context.Context, jobID int, params model.UserImportParams, file io.Reader) (*model.UserImportReport, error) {
+	report := &model.UserImportReport{DryRun: params.DryRun, Errors: []model.UserImportRowError{}}
+	counter := &countingReader{r: file}
+	rows, err := newImportReader(counter, params.Format)
+	if err != nil {
+		return report, err
+	}
+
+	// Rows without a password share a hash of a random password nobody knows, so they can't sign in with one
+	unusable, err := oidc.RandomToken(32)
+	if err != nil {
+		return report, err
+	}
+	if unusable, err = s.passwordHasher.HashPassword(unusable); err != nil {
+		return report, err
+	}
+
+	rowEmails := map[int]string{}
+	emailTaken := false
+	var updatedIDs []int
+	eof := false
+	next := func() ([]*model.UserImportRecord, error) {
+		if eof {
+			return nil, nil
+		}
+		clear(rowEmails)
+		var batch []*model.UserImportRecord
+		for len(batch) < importBatchSize {
+			row, line, err := rows.Read()
+			if err == io.EOF {
+				eof = true
+				break
+			}
+			report.Rows++
+			var rowErr *importRowError
+			if errors.As(err, &rowErr) {
+				addImportError(report, line, "", map[string]string{"row": rowErr.Error()})
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+			record, fieldErrors := s.prepareImportRow(row, line, params)
+			if fieldErrors != nil {
+				addImportError(report, line, record.Email, fieldErrors)
+				continue
+			}
+			rowEmails[line] = record.Email
+			batch = append(batch, record)
+		}
+
+		progress, _ := json.Marshal(model.UserImportProgress{Rows: report.Rows, BytesRead: counter.n, Bytes: params.Bytes})
+		if err := s.jobRepo.UpdateProgress(ctx, jobID, progress); err != nil {
+			slog.WarnContext(ctx, "failed to save import progress", slog.Int("job_id", jobID), slog.Any("error", err))
+		}
+		if batch == nil && eof {
+			return nil, nil
+		}
+		return batch, nil
+	}
+	done := func(result *model.UserImportBatchResult) error {
+		report.Inserted += result.Inserted
+		report.Updated += result.Updated
+		report.Skipped += result.Skipped
+		updatedIDs = append(updatedIDs, result.UpdatedIDs...)
+		for _, conflict := range result.Conflicts {
+			var message string
+			switch {
+			case conflict.FirstRow != 0:
+				message = fmt.Sprintf("%s is already on row %d of the file", conflict.Field, conflict.FirstRow)
+			case conflict.Field == "email":
+				message = "email is already registered"
+				emailTaken = true
+			default:
+				message = "phone is already registered to another user"
+			}
+			addImportError(report, conflict.Row, rowEmails[conflict.Row], map[string]string{conflict.Field: message})
+		}
+		return nil
+	}
+
+	options := repository.UserImportOptions{OnConflict: params.OnConflict, DryRun: params.DryRun, Password: unusable}
+	if err := s.userRepo.ImportUsers(ctx, options, next, done); err != nil {
+		return report, err
+	}
+	// Updates bump token_version for a new role or password; once committed, drop the cached versions
+	for _, id := range updatedIDs {
+		s.tokenVersions.Invalidate(id)
+	}
+	if params.DryRun && emailTaken {
+		// The import itself would stop at the registered emails
+		report.Inserted = 0
+	}
+	return report, nil
+}
+
+// prepareImportRow validates row like a sign-up and returns its record, or the row's errors
+// Passwords are only hashed for real imports, hashing being the slow part
+func (s *userImportService) prepareImportRow(row *model.ImportUserRow, line int, params model.UserImportParams) (*model.UserImportRecord, map[string]string) {
+	row.Name = strings.TrimSpace(row.Name)
+	row.Email = strings.TrimSpace(row.Email)
+	row.Phone = strings.TrimSpace(row.Phone)
+	row.Role = strings.TrimSpace(row.Role)
+	record := &model.UserImportRecord{Row: line, Name: row.Name, Email: row.Email}
+
+	if err := s.validator.Validate(row); err != nil {
+		fieldErrors := s.validator.ExtractValidationErrors(err, params.Locale)
+		if len(fieldErrors) == 0 {
+			fieldErrors = map[string]string{"row": err.Error()}
+		}
+		return record, fieldErrors
+	}
+	if row.Password != "" {
+		if fieldErrors := s.passwordPolicy.ValidationErrors("password", row.Password, row.Email, row.Name); fieldErrors != nil {
+			return record, fieldErrors
+		}
+	}
+
+	phone, err := utils.NormalizeVietnamesePhone(row.Phone)
+	if err != nil {
+		return record, map[string]string{"phone": "Invalid phone number format"}
+	}
+	record.Phone = phone
+	if row.Role != "" {
+		record.Role = &row.Role
+	}
+	if row.Password != "" {
+		hash := "" // a dry run only needs to know there is one
+		if !params.DryRun {
+			if hash, err = s.passwordHasher.HashPassword(row.Password); err != nil {
+				return record, map[string]string{"password": "password could not be hashed"}
+			}
+		}
+		record.Password = &hash
+	}
+	return record, nil
+}
+
+func addImportError(report *model.UserImportReport, row int, email string, fieldErrors map[string]string) {
+	report.Failed++
+	if len(report.Errors) >= maxImportErrors {
+		report.ErrorsTruncated = true
+		return
+	}
+	report.Errors = append(report.Errors, model.UserImportRowError{Row: row, Email: email, Errors: fieldErrors})
+}
+
+func (s *userImportService) FindImportJob(ctx context.Context, id int) (*model.Job, error) {
+	job, err := s.jobRepo.FindByID(ctx, model.JobKindUserImport, id)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, response.NotFound("IMPORT_JOB_NOT_FOUND", "Import job not found", err)
+		}
+		return nil, response.Internal(err)
+	}
+	return job, nil
+}
+
+// importReader reads the rows of an import file one at a time
+type importReader interface {
+	// Read returns the next row and its line number, an *importRowError for a row that can't be
+	// read, or io.EOF
+	Read() (*model.ImportUserRow, int, error)
+}
+
+// importRowError is a row of the file that can't be read; the rest of the file still can
+type importRowError struct {
+	message string
+}
+
+func (e *importRowError) Error() string {
+	return e.message
+}
+
+// newImportReader starts reading a file in format; for CSV, that checks the header row
+func newImportReader(r io.Reader, format string) (importReader, error) {
+	if format == model.ExportFormatNDJSON {
+		scanner := bufio.NewScanner(r)
+		scanner.Buffer(make([]byte, 0, 4096), maxImportLine)
+		return &ndjsonImportReader{scanner: scanner}, nil
+	}
+
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.ReuseRecord = true
+	header, err := reader.Read()
+	if err != nil {
+		if err == io.EOF {
+			return nil, errors.New("the file is empty; it needs a header row")
+		}
+		return nil, fmt.Errorf("the header row can't be read: %w", err)
+	}
+	columns := map[string]int{}
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		if !isImportUserColumn(name) {
+			return nil, fmt.Errorf("unknown column %q; columns are %s", name, strings.Join(model.ImportUserColumns, ", "))
+		}
+		if _, ok := columns[name]; ok {
+			return nil, fmt.Errorf("column %q appears twice", name)
+		}
+		columns[name] = i
+	}
+	for _, name := range []string{"name", "email", "phone"} {
+		if _, ok := columns[name]; !ok {
+			return nil, fmt.Errorf("the header row has no %s column", name)
+		}
+	}
+	return &csvImportReader{reader: reader, columns: columns, width: len(header)}, nil
+}
+
+func isImportUserColumn(name string) bool {
+	for _, column := range model.ImportUserColumns {
+		if column == name {
+			return true
+		}
+	}
+	return false
+}
+
+type csvImportReader struct {
+	reader  *csv.Reader
+	columns map[string]int
+	width   int
+}
+
+func (r *csvImportReader) Read() (*model.ImportUserRow, int, error) {
+	record, err := r.reader.Read()
+	if err != nil {
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			return nil, parseErr.StartLine, &importRowError{message: parseErr.Err.Error()}
+		}
+		return nil, 0, err
+	}
+	line, _ := r.reader.FieldPos(0)
+	if len(record) != r.width {
+		return nil, line, &importRowError{message: fmt.Sprintf("the row has %d fields, the header %d", len(record), r.width)}
+	}
+	cell := func(name string) string {
+		if i, ok := r.columns[name]; ok {
+			return record[i]
+		}
+		return ""
+	}
+	return &model.ImportUserRow{
+		Name:     cell("name"),
+		Email:    cell("email"),
+		Phone:    cell("phone"),
+		Role:     cell("role"),
+		Password: cell("password"),
+	}, line, nil
+}
+
+type ndjsonImportReader struct {
+	scanner *bufio.Scanner
+	line    int
+}
+
+func (r *ndjsonImportReader) Read() (*model.ImportUserRow, int, error) {
+	for r.scanner.Scan() {
+		r.line++
+		data := bytes.TrimSpace(r.scanner.Bytes())
+		if len(data) == 0 {
+			continue
+		}
+		var row model.ImportUserRow
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(&row); err != nil {
+			return nil, r.line, &importRowError{message: "the line is not a JSON object of the import columns: " + err.Error()}
+		}
+		return &row, r.line, nil
+	}
+	if err := r.scanner.Err(); err != nil {
+		if errors.Is(err, bufio.ErrTooLong) {
+			return nil, r.line + 1, fmt.Errorf("line %d is longer than %d bytes", r.line+1, maxImportLine)
+		}
+		return nil, 0, err
+	}
+	return nil, 0, io.EOF
+}
+
+// countingReader counts the bytes read through it
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
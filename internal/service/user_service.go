@@ -3,36 +3,74 @@ package service
 import (
 	"context"
 	"errors"
-	"fmt"
 	"golang-echo/internal/model"
 	"golang-echo/internal/repository"
+	"golang-echo/pkg/audit"
+	"golang-echo/pkg/constants"
 	"golang-echo/pkg/response"
 	"golang-echo/pkg/utils"
 	"log/slog"
+	"strconv"
+	"strings"
 )
 
 type IUserService interface {
 	CreateUser(ctx context.Context, req *model.CreateUserRequest) (*model.User, error)
-	FindAllUsers(ctx context.Context, limit int, offset int) ([]*model.User, int64, error)
-	FindUserByID(ctx context.Context, id int) (*model.User, error)
-	FindUserByEmail(ctx context.Context, email string) (*model.User, error)
-	Login(ctx context.Context, req *model.LoginRequest) (*model.LoginResponse, error)
+	// The finders load only fields' columns; nil fields loads the whole user
+	FindAllUsers(ctx context.Context, limit int, offset int, fields model.UserFields) ([]*model.User, int64, error)
+	FindUserByID(ctx context.Context, id int, fields model.UserFields) (*model.User, error)
+	FindUserByEmail(ctx context.Context, email string, fields model.UserFields) (*model.User, error)
+	FindUserByPhone(ctx context.Context, phone string, fields model.UserFields) (*model.User, error)
+	Login(ctx context.Context, req *model.LoginRequest, client model.SessionClient) (*model.LoginResponse, error)
+	// ChangePassword sets a new password and signs the user out of every session, including the current one
+	ChangePassword(ctx context.Context, userID int, req *model.ChangePasswordRequest) error
+	// UpdateRole fails with PRECONDITION_FAILED unless the user is still at version; 0 skips the check
+	UpdateRole(ctx context.Context, userID int, version int, role string) (*model.User, error)
+	// UpdateStatus changes an account's status on behalf of actorID; the reason is kept for audit
+	// Like UpdateRole, it only applies to the given version of the user
+	UpdateStatus(ctx context.Context, actorID int, userID int, version int, req *model.UpdateUserStatusRequest) (*model.User, error)
+	FindStatusChanges(ctx context.Context, userID int) ([]*model.UserStatusChange, error)
 }
 
 type userService struct {
-	userRepo   repository.IUserRepository
-	jwtManager *utils.JWTManager
+	userRepo       repository.IUserRepository
+	sessionService ISessionService
+	tokenVersions  ITokenVersionService
+	auditor        IAuditor
+	passwordPolicy *utils.PasswordPolicy
+	passwordHasher *utils.PasswordHasher
 }
 
-func NewUserService(userRepo repository.IUserRepository, jwtManager *utils.JWTManager) IUserService {
+func NewUserService(
+	userRepo repository.IUserRepository,
+	sessionService ISessionService,
+	tokenVersions ITokenVersionService,
+	auditor IAuditor,
+	passwordPolicy *utils.PasswordPolicy,
+	passwordHasher *utils.PasswordHasher,
+) IUserService {
 	return &userService{
-		userRepo:   userRepo,
-		jwtManager: jwtManager,
+		userRepo:       userRepo,
+		sessionService: sessionService,
+		tokenVersions:  tokenVersions,
+		auditor:        auditor,
+		passwordPolicy: passwordPolicy,
+		passwordHasher: passwordHasher,
 	}
 }
 
 func (u *userService) CreateUser(ctx context.Context, req *model.CreateUserRequest) (*model.User, error) {
-	hashedPassword, err := utils.HashPassword(req.Password)
+	// Store phone in canonical E.164 so the same number can't be registered in different formats
+	phone, err := utils.NormalizeVietnamesePhone(req.Phone)
+	if err != nil {
+		return nil, response.BadRequest("INVALID_PHONE", "Invalid phone number format", err)
+	}
+
+	if fieldErrors := u.passwordPolicy.ValidationErrors("password", req.Password, req.Email, req.Name); fieldErrors != nil {
+		return nil, response.BadRequestWithFields("VALIDATION_FAILED", "Validation failed", fieldErrors)
+	}
+
+	hashedPassword, err := u.passwordHasher.HashPassword(req.Password)
 	if err != nil {
 		slog.ErrorContext(ctx, "failed to hash password", slog.String("email", req.Email), slog.Any("error", err))
 		return nil, response.Internal(err)
@@ -42,26 +80,50 @@ func (u *userService) CreateUser(ctx context.Context, req *model.CreateUserReque
 		Name:     req.Name,
 		Email:    req.Email,
 		Password: hashedPassword,
-		Phone:    req.Phone,
+		Phone:    phone,
 	}
 
 	err = u.userRepo.Create(ctx, user)
 	if err != nil {
+		if errors.Is(err, repository.ErrDuplicatePhone) {
+			return nil, response.Conflict("PHONE_ALREADY_REGISTERED", "Phone number is already registered", err)
+		}
 		if errors.Is(err, repository.ErrDuplicate) {
 			return nil, response.Conflict("EMAIL_ALREADY_REGISTERED", "Email is already registered", err)
 		}
 		slog.ErrorContext(ctx, "failed to create user", slog.String("email", req.Email), slog.Any("error", err))
 		return nil, response.Internal(err)
 	}
+	u.auditor.Record(ctx, audit.Entry{
+		Action:     audit.ActionUserCreate,
+		TargetType: audit.TargetUser,
+		TargetID:   strconv.Itoa(user.ID),
+		Outcome:    audit.OutcomeSuccess,
+		Diff: audit.Diff(nil, map[string]any{
+			"name":   user.Name,
+			"email":  user.Email,
+			"phone":  user.Phone,
+			"role":   user.Role,
+			"status": user.Status,
+		}),
+	})
 	return user, nil
 }
 
-func (u *userService) FindAllUsers(ctx context.Context, limit int, offset int) ([]*model.User, int64, error) {
-	return u.userRepo.FindAll(ctx, limit, offset)
+func (u *userService) FindAllUsers(ctx context.Context, limit int, offset int, fields model.UserFields) ([]*model.User, int64, error) {
+	return u.userRepo.FindAll(ctx, limit, offset, fields)
 }
 
-func (u *userService) FindUserByID(ctx context.Context, id int) (*model.User, error) {
-	user, err := u.userRepo.FindUserByID(ctx, id)
+func (u *userService) FindUserByID(ctx context.Context, id int, fields model.UserFields) (*model.User, error) {
+	var (
+		user *model.User
+		err  error
+	)
+	if fields != nil {
+		user, err = u.userRepo.FindUserFields(ctx, "id", id, fields)
+	} else {
+		user, err = u.userRepo.FindUserByID(ctx, id)
+	}
 	if err != nil {
 		if errors.Is(err, repository.ErrNotFound) {
 			return nil, response.NotFound("USER_NOT_FOUND", "User not found", err)
@@ -71,8 +133,16 @@ func (u *userService) FindUserByID(ctx context.Context, id int) (*model.User, er
 	return user, nil
 }
 
-func (u *userService) FindUserByEmail(ctx context.Context, email string) (*model.User, error) {
-	user, err := u.userRepo.FindUserByEmail(ctx, email)
+func (u *userService) FindUserByEmail(ctx context.Context, email string, fields model.UserFields) (*model.User, error) {
+	var (
+		user *model.User
+		err  error
+	)
+	if fields != nil {
+		user, err = u.userRepo.FindUserFields(ctx, "email", email, fields)
+	} else {
+		user, err = u.userRepo.FindUserByEmail(ctx, email)
+	}
 	if err != nil {
 		if errors.Is(err, repository.ErrNotFound) {
 			return nil, response.NotFound("USER_NOT_FOUND", "User not found", err)
@@ -82,24 +152,66 @@ func (u *userService) FindUserByEmail(ctx context.Context, email string) (*model
 	return user, nil
 }
 
-func (u *userService) Login(ctx context.Context, req *model.LoginRequest) (*model.LoginResponse, error) {
+func (u *userService) FindUserByPhone(ctx context.Context, phone string, fields model.UserFields) (*model.User, error) {
+	normalized, err := utils.NormalizeVietnamesePhone(phone)
+	if err != nil {
+		return nil, response.BadRequest("INVALID_PHONE", "Invalid phone number format", err)
+	}
+
+	var user *model.User
+	if fields != nil {
+		user, err = u.userRepo.FindUserFields(ctx, "phone", normalized, fields)
+	} else {
+		user, err = u.userRepo.FindUserByPhone(ctx, normalized)
+	}
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, response.NotFound("USER_NOT_FOUND", "User not found", err)
+		}
+		return nil, response.Internal(err)
+	}
+	return user, nil
+}
+
+func (u *userService) Login(ctx context.Context, req *model.LoginRequest, client model.SessionClient) (*model.LoginResponse, error) {
 	user, err := u.userRepo.FindUserByEmail(ctx, req.Email)
 	if err != nil {
 		if errors.Is(err, repository.ErrNotFound) {
+			u.recordLoginFailure(ctx, nil, req.Email, "unknown_email", audit.OutcomeFailure)
 			return nil, response.Unauthorized("INVALID_CREDENTIALS", "Invalid email or password", err)
 		}
 		return nil, response.Internal(err)
 	}
 
-	if err := utils.VerifyPassword(user.Password, req.Password); err != nil {
+	needsRehash, err := u.passwordHasher.VerifyPassword(user.Password, req.Password)
+	if err != nil {
+		u.recordLoginFailure(ctx, user, req.Email, "invalid_password", audit.OutcomeFailure)
 		return nil, response.Unauthorized("INVALID_CREDENTIALS", "Invalid email or password", err)
 	}
-	fmt.Println("User::::", user.Role)
-	token, err := u.jwtManager.GenerateToken(user.ID, user.Email, user.Name, user.Role)
+	// Checked only after the password so the status of an account isn't disclosed to guessers
+	if err := accountStatusError(user.Status); err != nil {
+		u.recordLoginFailure(ctx, user, req.Email, "account_"+user.Status, audit.OutcomeDenied)
+		return nil, err
+	}
+	if needsRehash {
+		// Migrate the stored hash to the current algorithm/parameters without delaying the login
+		go u.rehashPassword(context.WithoutCancel(ctx), user.ID, user.Password, req.Password)
+	}
+	if req.DeviceName != "" {
+		client.DeviceName = req.DeviceName
+	}
+	token, err := u.sessionService.CreateSession(ctx, user, client)
 	if err != nil {
-		slog.ErrorContext(ctx, "failed to generate jwt token", slog.String("email", req.Email), slog.Any("error", err))
-		return nil, response.Internal(err)
+		return nil, err
 	}
+	u.auditor.Record(ctx, audit.Entry{
+		Action:     audit.ActionLogin,
+		TargetType: audit.TargetUser,
+		TargetID:   strconv.Itoa(user.ID),
+		Outcome:    audit.OutcomeSuccess,
+		Metadata:   map[string]any{"method": "password"},
+		ActorID:    &user.ID,
+	})
 
 	user.Password = ""
 
@@ -108,3 +220,186 @@ func (u *userService) Login(ctx context.Context, req *model.LoginRequest) (*mode
 		User:        user,
 	}, nil
 }
+
+func (u *userService) ChangePassword(ctx context.Context, userID int, req *model.ChangePasswordRequest) error {
+	user, err := u.userRepo.FindUserByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return response.NotFound("USER_NOT_FOUND", "User not found", err)
+		}
+		return response.Internal(err)
+	}
+
+	if _, err := u.passwordHasher.VerifyPassword(user.Password, req.CurrentPassword); err != nil {
+		return response.BadRequestWithFields("VALIDATION_FAILED", "Validation failed", map[string]string{
+			"current_password": "current_password is incorrect",
+		})
+	}
+
+	if fieldErrors := u.passwordPolicy.ValidationErrors("new_password", req.NewPassword, user.Email, user.Name); fieldErrors != nil {
+		return response.BadRequestWithFields("VALIDATION_FAILED", "Validation failed", fieldErrors)
+	}
+	if req.NewPassword == req.CurrentPassword {
+		return response.BadRequestWithFields("VALIDATION_FAILED", "Validation failed", map[string]string{
+			"new_password": "new_password must be different from current_password",
+		})
+	}
+
+	hashedPassword, err := u.passwordHasher.HashPassword(req.NewPassword)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to hash password", slog.Int("user_id", userID), slog.Any("error", err))
+		return response.Internal(err)
+	}
+
+	if err := u.userRepo.UpdatePassword(ctx, userID, hashedPassword); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return response.NotFound("USER_NOT_FOUND", "User not found", err)
+		}
+		slog.ErrorContext(ctx, "failed to update password", slog.Int("user_id", userID), slog.Any("error", err))
+		return response.Internal(err)
+	}
+	u.tokenVersions.Invalidate(userID)
+	u.auditor.Record(ctx, audit.Entry{
+		Action:     audit.ActionPasswordChange,
+		TargetType: audit.TargetUser,
+		TargetID:   strconv.Itoa(userID),
+		Outcome:    audit.OutcomeSuccess,
+	})
+	return nil
+}
+
+func (u *userService) UpdateRole(ctx context.Context, userID int, version int, role string) (*model.User, error) {
+	before, err := u.FindUserByID(ctx, userID, nil)
+	if err != nil {
+		return nil, err
+	}
+	if err := u.userRepo.UpdateRole(ctx, userID, role, version); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, response.NotFound("USER_NOT_FOUND", "User not found", err)
+		}
+		if errors.Is(err, repository.ErrVersionMismatch) {
+			return nil, errUserChanged(err)
+		}
+		slog.ErrorContext(ctx, "failed to update role", slog.Int("user_id", userID), slog.Any("error", err))
+		return nil, response.Internal(err)
+	}
+	u.tokenVersions.Invalidate(userID)
+	u.auditor.Record(ctx, audit.Entry{
+		Action:     audit.ActionUserRoleUpdate,
+		TargetType: audit.TargetUser,
+		TargetID:   strconv.Itoa(userID),
+		Outcome:    audit.OutcomeSuccess,
+		Diff:       audit.Diff(map[string]any{"role": before.Role}, map[string]any{"role": role}),
+	})
+	return u.findUpdatedUser(ctx, userID)
+}
+
+func (u *userService) UpdateStatus(ctx context.Context, actorID int, userID int, version int, req *model.UpdateUserStatusRequest) (*model.User, error) {
+	if actorID == userID {
+		return nil, response.BadRequest("CANNOT_CHANGE_OWN_STATUS", "You cannot change the status of your own account", nil)
+	}
+
+	change := &model.UserStatusChange{
+		UserID:   userID,
+		ActorID:  &actorID,
+		ToStatus: req.Status,
+		Reason:   strings.TrimSpace(req.Reason),
+	}
+	if err := u.userRepo.UpdateStatus(ctx, change, version); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, response.NotFound("USER_NOT_FOUND", "User not found", err)
+		}
+		if errors.Is(err, repository.ErrVersionMismatch) {
+			return nil, errUserChanged(err)
+		}
+		slog.ErrorContext(ctx, "failed to update status", slog.Int("user_id", userID), slog.Any("error", err))
+		return nil, response.Internal(err)
+	}
+	u.tokenVersions.Invalidate(userID)
+	u.auditor.Record(ctx, audit.Entry{
+		Action:     audit.ActionUserStatusUpdate,
+		TargetType: audit.TargetUser,
+		TargetID:   strconv.Itoa(userID),
+		Outcome:    audit.OutcomeSuccess,
+		Diff:       audit.Diff(map[string]any{"status": change.FromStatus}, map[string]any{"status": change.ToStatus}),
+		Metadata:   map[string]any{"reason": change.Reason},
+	})
+
+	slog.InfoContext(ctx, "user status changed",
+		slog.Int("user_id", userID),
+		slog.Int("actor_id", actorID),
+		slog.String("from", change.FromStatus),
+		slog.String("to", change.ToStatus),
+		slog.String("reason", change.Reason),
+	)
+	return u.findUpdatedUser(ctx, userID)
+}
+
+// findUpdatedUser reads a user back after an update, for the response
+func (u *userService) findUpdatedUser(ctx context.Context, userID int) (*model.User, error) {
+	user, err := u.FindUserByID(ctx, userID, nil)
+	if err != nil {
+		return nil, err
+	}
+	user.Password = ""
+	return user, nil
+}
+
+// errUserChanged rejects an update made against an outdated copy of the user
+func errUserChanged(err error) *response.AppError {
+	return response.PreconditionFailed("PRECONDITION_FAILED", "The user has changed since it was read; fetch it again and retry", err)
+}
+
+func (u *userService) FindStatusChanges(ctx context.Context, userID int) ([]*model.UserStatusChange, error) {
+	if _, err := u.FindUserByID(ctx, userID, nil); err != nil {
+		return nil, err
+	}
+	changes, err := u.userRepo.FindStatusChanges(ctx, userID)
+	if err != nil {
+		return nil, response.Internal(err)
+	}
+	return changes, nil
+}
+
+// recordLoginFailure audits a rejected password login; user is nil when the email is unknown
+func (u *userService) recordLoginFailure(ctx context.Context, user *model.User, email string, reason string, outcome string) {
+	entry := audit.Entry{
+		Action:     audit.ActionLoginFailed,
+		TargetType: audit.TargetUser,
+		Outcome:    outcome,
+		Metadata:   map[string]any{"email": email, "reason": reason},
+	}
+	if user != nil {
+		entry.TargetID = strconv.Itoa(user.ID)
+	}
+	u.auditor.Record(ctx, entry)
+}
+
+// accountStatusError returns the error for an account that may not sign in or use its tokens
+func accountStatusError(status string) error {
+	switch status {
+	case constants.UserStatusActive:
+		return nil
+	case constants.UserStatusSuspended:
+		return response.Forbidden("ACCOUNT_SUSPENDED", "Your account has been suspended", nil)
+	case constants.UserStatusInactive:
+		return response.Forbidden("ACCOUNT_INACTIVE", "Your account is inactive", nil)
+	case constants.UserStatusPending:
+		return response.Forbidden("ACCOUNT_PENDING", "Your account is pending activation", nil)
+	}
+	return response.Forbidden("ACCOUNT_DISABLED", "Your account cannot be used", nil)
+}
+
+// rehashPassword re-hashes a verified password with the current hasher settings and stores it
+func (u *userService) rehashPassword(ctx context.Context, userID int, oldHash string, password string) {
+	newHash, err := u.passwordHasher.HashPassword(password)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to rehash password", slog.Int("user_id", userID), slog.Any("error", err))
+		return
+	}
+	if err := u.userRepo.UpdatePasswordHash(ctx, userID, oldHash, newHash); err != nil {
+		slog.ErrorContext(ctx, "failed to store rehashed password", slog.Int("user_id", userID), slog.Any("error", err))
+		return
+	}
+	slog.InfoContext(ctx, "password rehashed", slog.Int("user_id", userID))
+}
@@ -0,0 +1,137 @@
+// Package audit holds the vocabulary of the audit log and the per-request information
+// (request ID, client IP, authenticated actor) that audit events are stamped with.
+package audit
+
+import (
+	"context"
+	"errors"
+	"golang-echo/pkg/response"
+	"net/http"
+	"reflect"
+)
+
+// Actions recorded in the audit log
+const (
+	ActionLogin            = "auth.login"
+	ActionLoginFailed      = "auth.login_failed"
+	ActionUserCreate       = "user.create"
+	ActionUserRoleUpdate   = "user.role_update"
+	ActionUserStatusUpdate = "user.status_update"
+	ActionUserExport       = "user.export"
+	ActionUserImport       = "user.import"
+	ActionPasswordChange   = "user.password_change"
+	ActionAdminRead        = "admin.read"
+	ActionImpersonate      = "user.impersonate"
+	ActionInvitationCreate = "invitation.create"
+	ActionInvitationResend = "invitation.resend"
+	ActionInvitationRevoke = "invitation.revoke"
+	ActionInvitationAccept = "invitation.accept"
+	// ActionImpersonatedRequest is recorded for every request made with an impersonation token
+	ActionImpersonatedRequest = "impersonation.request"
+)
+
+// Outcomes of an audited action
+const (
+	OutcomeSuccess = "success"
+	OutcomeFailure = "failure"
+	OutcomeDenied  = "denied"
+)
+
+// Actor and target types
+const (
+	ActorAnonymous = "anonymous"
+	ActorSystem    = "system"
+
+	TargetUser       = "user"
+	TargetInvitation = "invitation"
+)
+
+// Entry describes an action to record; request ID, IP and actor are taken from the context
+type Entry struct {
+	Action     string
+	TargetType string
+	TargetID   string
+	Outcome    string
+	// Diff is typically the result of Diff; Metadata holds any other context (never secrets)
+	Diff     any
+	Metadata any
+	// ActorID overrides the context's actor, e.g. for logins where the caller isn't authenticated yet
+	ActorID *int
+}
+
+// OutcomeOf maps the error returned by an audited action to its outcome
+func OutcomeOf(err error) string {
+	if err == nil {
+		return OutcomeSuccess
+	}
+	var appErr *response.AppError
+	if errors.As(err, &appErr) && (appErr.Code == http.StatusUnauthorized || appErr.Code == http.StatusForbidden) {
+		return OutcomeDenied
+	}
+	return OutcomeFailure
+}
+
+// Request carries who is making the current request and from where
+// It is attached to the request context once per request; the authenticating middleware fills in the actor
+type Request struct {
+	RequestID string
+	IPAddress string
+	UserAgent string
+	ActorID   int
+	// ActorType is the authentication method of the actor (jwt, api_key, oauth_client, oauth_user) or ActorAnonymous
+	ActorType string
+	// ImpersonatorID is the admin behind an impersonation token; ActorID is then the impersonated user
+	ImpersonatorID int
+}
+
+type requestKey struct{}
+
+// WithRequest attaches request information to ctx
+func WithRequest(ctx context.Context, req *Request) context.Context {
+	return context.WithValue(ctx, requestKey{}, req)
+}
+
+// RequestFromContext returns the request information attached to ctx, or nil outside a request
+func RequestFromContext(ctx context.Context) *Request {
+	req, _ := ctx.Value(requestKey{}).(*Request)
+	return req
+}
+
+// SetActor records the authenticated caller of the request in ctx, if it carries request information
+func SetActor(ctx context.Context, actorID int, actorType string) {
+	if req := RequestFromContext(ctx); req != nil {
+		req.ActorID = actorID
+		req.ActorType = actorType
+	}
+}
+
+// SetImpersonator records the admin acting through an impersonation token
+func SetImpersonator(ctx context.Context, impersonatorID int) {
+	if req := RequestFromContext(ctx); req != nil {
+		req.ImpersonatorID = impersonatorID
+	}
+}
+
+// Change is the before/after value of one field in an event's diff
+type Change struct {
+	From any `json:"from,omitempty"`
+	To   any `json:"to,omitempty"`
+}
+
+// Diff returns the fields whose values differ between before and after
+// A nil before describes a creation, a nil after a deletion
+func Diff(before, after map[string]any) map[string]Change {
+	diff := make(map[string]Change)
+	for field, to := range after {
+		from, ok := before[field]
+		if !ok || !reflect.DeepEqual(from, to) {
+			diff[field] = Change{From: from, To: to}
+		}
+	}
+	for field, from := range before {
+		if _, ok := after[field]; !ok {
+			diff[field] = Change{From: from}
+		}
+	}
+	return diff
+}
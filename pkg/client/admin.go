@@ -0,0 +1,167 @@
+package client
+
+import (
+	"context"
+	"io"
+	"strings"
+
+	"golang-echo/internal/model"
+	"golang-echo/pkg/request"
+)
+
+// The methods below need an admin token, or an API key with the route's permission
+
+// The user reads take optional fields (model.SelectableUserFields) to load only those;
+// the other fields of the returned users are left zero
+
+func (c *Client) ListUsers(ctx context.Context, query request.PaginationReq, fields ...string) (*Page[model.User], error) {
+	q := struct {
+		request.PaginationReq
+		userFieldsQuery
+	}{query, userFields(fields)}
+	return page[model.User](ctx, c, call{route: routeListUsers, query: q})
+}
+
+func (c *Client) GetUser(ctx context.Context, id int, fields ...string) (*model.User, error) {
+	return data[*model.User](ctx, c, call{route: routeGetUser, params: []any{id}, query: userFields(fields)})
+}
+
+func (c *Client) FindUserByEmail(ctx context.Context, email string, fields ...string) (*model.User, error) {
+	query := struct {
+		Email string `query:"email"`
+		userFieldsQuery
+	}{email, userFields(fields)}
+	return data[*model.User](ctx, c, call{route: routeFindUserByEmail, query: query})
+}
+
+func (c *Client) FindUserByPhone(ctx context.Context, phone string, fields ...string) (*model.User, error) {
+	query := struct {
+		Phone string `query:"phone"`
+		userFieldsQuery
+	}{phone, userFields(fields)}
+	return data[*model.User](ctx, c, call{route: routeFindUserByPhone, query: query})
+}
+
+// userFieldsQuery is the fields parameter of the user reads
+type userFieldsQuery struct {
+	Fields string `query:"fields"`
+}
+
+func userFields(fields []string) userFieldsQuery {
+	return userFieldsQuery{Fields: strings.Join(fields, ",")}
+}
+
+func (c *Client) UserSessions(ctx context.Context, userID int) ([]model.UserSession, error) {
+	return list[model.UserSession](ctx, c, call{route: routeUserSessions, params: []any{userID}})
+}
+
+// UpdateUserRole changes a user's role, revoking the user's existing tokens
+// etag is the ETag of the user as read, e.g. user.ETag(), or "*" to overwrite whatever is current;
+// when the user has changed since, the error's code is PRECONDITION_FAILED
+func (c *Client) UpdateUserRole(ctx context.Context, userID int, etag string, req model.UpdateRoleRequest) (*model.User, error) {
+	return data[*model.User](ctx, c, call{route: routeUpdateUserRole, params: []any{userID}, body: req, ifMatch: etag})
+}
+
+// UpdateUserStatus changes a user's account status; leaving active revokes the user's existing tokens
+// etag works as in UpdateUserRole
+func (c *Client) UpdateUserStatus(ctx context.Context, userID int, etag string, req model.UpdateUserStatusRequest) (*model.User, error) {
+	return data[*model.User](ctx, c, call{route: routeUpdateUserStatus, params: []any{userID}, body: req, ifMatch: etag})
+}
+
+func (c *Client) UserStatusChanges(ctx context.Context, userID int) ([]model.UserStatusChange, error) {
+	return list[model.UserStatusChange](ctx, c, call{route: routeUserStatusChanges, params: []any{userID}})
+}
+
+// Impersonate issues a short-lived token acting as the user; use it with StaticToken
+func (c *Client) Impersonate(ctx context.Context, userID int, req model.ImpersonateRequest) (*model.ImpersonationResponse, error) {
+	return data[*model.ImpersonationResponse](ctx, c, call{route: routeImpersonate, params: []any{userID}, body: req})
+}
+
+// ExportUsers streams every user in format (model.ExportFormatCSV or model.ExportFormatNDJSON),
+// with only fields when given; the caller reads and closes the body
+// A body that ends in an error was cut short by a failure on the server, not a complete export
+func (c *Client) ExportUsers(ctx context.Context, format string, fields ...string) (io.ReadCloser, error) {
+	query := struct {
+		model.UserExportQuery
+		userFieldsQuery
+	}{model.UserExportQuery{Format: format}, userFields(fields)}
+	return stream(ctx, c, call{route: routeExportUsers, query: query, accept: "*/*"})
+}
+
+// CreateUserExportJob starts writing an export in the background; poll UserExportJob until
+// DownloadURL is set, then fetch the file with DownloadUserExport
+func (c *Client) CreateUserExportJob(ctx context.Context, req model.CreateUserExportRequest) (*model.Job, error) {
+	return data[*model.Job](ctx, c, call{route: routeCreateExportJob, body: req, idempotent: true})
+}
+
+func (c *Client) UserExportJob(ctx context.Context, id int) (*model.Job, error) {
+	return data[*model.Job](ctx, c, call{route: routeExportJob, params: []any{id}})
+}
+
+// DownloadUserExport returns the file of a succeeded export job; the caller reads and closes it
+func (c *Client) DownloadUserExport(ctx context.Context, id int) (io.ReadCloser, error) {
+	return stream(ctx, c, call{route: routeDownloadExport, params: []any{id}, accept: "*/*"})
+}
+
+// ImportUsers uploads a CSV or NDJSON file of users, contentType being text/csv or
+// application/x-ndjson, and returns the job importing it; poll UserImportJob for its progress
+// and, once finished, the model.UserImportReport in its Result
+func (c *Client) ImportUsers(ctx context.Context, query model.ImportUsersQuery, contentType string, file io.ReadSeeker) (*model.Job, error) {
+	return data[*model.Job](ctx, c, call{route: routeImportUsers, query: query, file: file, fileType: contentType})
+}
+
+func (c *Client) UserImportJob(ctx context.Context, id int) (*model.Job, error) {
+	return data[*model.Job](ctx, c, call{route: routeImportJob, params: []any{id}})
+}
+
+func (c *Client) ListAuditEvents(ctx context.Context, query model.AuditEventQuery) (*Page[model.AuditEvent], error) {
+	return page[model.AuditEvent](ctx, c, call{route: routeListAuditEvents, query: query})
+}
+
+func (c *Client) VerifyAuditChain(ctx context.Context) (*model.AuditChainReport, error) {
+	return data[*model.AuditChainReport](ctx, c, call{route: routeVerifyAuditChain})
+}
+
+// CreateInvitation invites a user; the invitation exists even when EmailSent is false
+func (c *Client) CreateInvitation(ctx context.Context, req model.CreateInvitationRequest) (*model.InvitationResponse, error) {
+	return data[*model.InvitationResponse](ctx, c, call{route: routeCreateInvitation, body: req, idempotent: true})
+}
+
+func (c *Client) ListInvitations(ctx context.Context, query model.InvitationQuery) (*Page[model.UserInvitation], error) {
+	return page[model.UserInvitation](ctx, c, call{route: routeListInvitations, query: query})
+}
+
+// ResendInvitation issues a new link, invalidating the previous one
+func (c *Client) ResendInvitation(ctx context.Context, id int) (*model.InvitationResponse, error) {
+	return data[*model.InvitationResponse](ctx, c, call{route: routeResendInvitation, params: []any{id}})
+}
+
+func (c *Client) RevokeInvitation(ctx context.Context, id int) error {
+	return noContent(ctx, c, call{route: routeRevokeInvitation, params: []any{id}})
+}
+
+// CreateAPIKey creates an API key; the response is the only place its plaintext appears
+func (c *Client) CreateAPIKey(ctx context.Context, req model.CreateAPIKeyRequest) (*model.CreateAPIKeyResponse, error) {
+	return data[*model.CreateAPIKeyResponse](ctx, c, call{route: routeCreateAPIKey, body: req})
+}
+
+func (c *Client) ListAPIKeys(ctx context.Context, query request.PaginationReq) (*Page[model.APIKey], error) {
+	return page[model.APIKey](ctx, c, call{route: routeListAPIKeys, query: query})
+}
+
+func (c *Client) RevokeAPIKey(ctx context.Context, id int) error {
+	return noContent(ctx, c, call{route: routeRevokeAPIKey, params: []any{id}})
+}
+
+// CreateOAuthClient registers an OAuth client; the response is the only place a confidential client's secret appears
+func (c *Client) CreateOAuthClient(ctx context.Context, req model.CreateOAuthClientRequest) (*model.CreateOAuthClientResponse, error) {
+	return data[*model.CreateOAuthClientResponse](ctx, c, call{route: routeCreateOAuthClient, body: req})
+}
+
+func (c *Client) ListOAuthClients(ctx context.Context, query request.PaginationReq) (*Page[model.OAuthClient], error) {
+	return page[model.OAuthClient](ctx, c, call{route: routeListOAuthClients, query: query})
+}
+
+func (c *Client) RevokeOAuthClient(ctx context.Context, id int) error {
+	return noContent(ctx, c, call{route: routeRevokeOAuthClient, params: []any{id}})
+}
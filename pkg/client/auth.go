@@ -0,0 +1,147 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"golang-echo/internal/model"
+)
+
+// TokenSource supplies the bearer token for authenticated routes
+type TokenSource interface {
+	Token(ctx context.Context) (string, error)
+}
+
+// Refresher is implemented by token sources that can replace a token the API rejected
+// (expired, revoked or signed out); the request is then retried once with the new token
+type Refresher interface {
+	// Refresh replaces rejected, unless another caller already did, and returns the current token
+	Refresh(ctx context.Context, rejected string) (string, error)
+}
+
+// clientBound token sources call the API themselves; New hands them the client
+type clientBound interface {
+	bind(c *Client)
+}
+
+// StaticToken always returns the same token, e.g. an impersonation or client_credentials access token
+type StaticToken string
+
+func (t StaticToken) Token(context.Context) (string, error) {
+	if t == "" {
+		return "", errors.New("client: empty static token")
+	}
+	return string(t), nil
+}
+
+// PasswordLogin logs in with email and password on first use and again whenever the token is rejected
+// There is no refresh token for password logins, so logging in again is how the token is refreshed
+func PasswordLogin(email string, password string, deviceName string) TokenSource {
+	return &passwordLogin{request: model.LoginRequest{Email: email, Password: password, DeviceName: deviceName}}
+}
+
+type passwordLogin struct {
+	request model.LoginRequest
+	client  *Client
+
+	mu    sync.Mutex
+	token string
+}
+
+func (p *passwordLogin) bind(c *Client) {
+	p.client = c
+}
+
+func (p *passwordLogin) Token(ctx context.Context) (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.token != "" {
+		return p.token, nil
+	}
+	return p.login(ctx)
+}
+
+func (p *passwordLogin) Refresh(ctx context.Context, rejected string) (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.token != "" && p.token != rejected {
+		return p.token, nil
+	}
+	return p.login(ctx)
+}
+
+func (p *passwordLogin) login(ctx context.Context) (string, error) {
+	if p.client == nil {
+		return "", errors.New("client: PasswordLogin used outside a Client")
+	}
+	res, err := p.client.Login(ctx, p.request)
+	if err != nil {
+		return "", err
+	}
+	p.token = res.AccessToken
+	return p.token, nil
+}
+
+// RefreshToken uses an OAuth refresh token, exchanging it at the token endpoint for an access token
+// on first use and whenever the access token is rejected. Refresh tokens rotate on every use;
+// onRotate, when set, is given each new one so it can be persisted
+func RefreshToken(clientID string, clientSecret string, refreshToken string, onRotate func(refreshToken string)) TokenSource {
+	return &oauthRefresh{clientID: clientID, clientSecret: clientSecret, refreshToken: refreshToken, onRotate: onRotate}
+}
+
+type oauthRefresh struct {
+	clientID     string
+	clientSecret string
+	onRotate     func(string)
+	client       *Client
+
+	mu           sync.Mutex
+	refreshToken string
+	accessToken  string
+}
+
+func (o *oauthRefresh) bind(c *Client) {
+	o.client = c
+}
+
+func (o *oauthRefresh) Token(ctx context.Context) (string, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.accessToken != "" {
+		return o.accessToken, nil
+	}
+	return o.refresh(ctx)
+}
+
+func (o *oauthRefresh) Refresh(ctx context.Context, rejected string) (string, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.accessToken != "" && o.accessToken != rejected {
+		return o.accessToken, nil
+	}
+	return o.refresh(ctx)
+}
+
+func (o *oauthRefresh) refresh(ctx context.Context) (string, error) {
+	if o.client == nil {
+		return "", errors.New("client: RefreshToken used outside a Client")
+	}
+	res, err := o.client.Token(ctx, model.TokenRequest{
+		GrantType:    "refresh_token",
+		RefreshToken: o.refreshToken,
+		ClientID:     o.clientID,
+		ClientSecret: o.clientSecret,
+	})
+	if err != nil {
+		return "", err
+	}
+	if res.RefreshToken != "" && res.RefreshToken != o.refreshToken {
+		o.refreshToken = res.RefreshToken
+		if o.onRotate != nil {
+			o.onRotate(res.RefreshToken)
+		}
+	}
+	o.accessToken = res.AccessToken
+	return o.accessToken, nil
+}
@@ -0,0 +1,37 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"golang-echo/internal/model"
+	"golang-echo/pkg/response"
+)
+
+// Batch sends several API requests in one round trip, with the client's credentials, and returns
+// their results in order; a failed request doesn't fail the batch. Read each result with BatchData
+func (c *Client) Batch(ctx context.Context, req model.BatchRequest) ([]model.BatchResult, error) {
+	return data[[]model.BatchResult](ctx, c, call{route: routeBatch, body: req})
+}
+
+// BatchData returns the data of a batch result's envelope, or the *Error it was answered with
+func BatchData[T any](result model.BatchResult) (T, error) {
+	var envelope response.Response[T]
+	if result.Status >= 300 {
+		return envelope.Data, readError(&http.Response{
+			StatusCode: result.Status,
+			Header:     http.Header{},
+			Body:       io.NopCloser(bytes.NewReader(result.Body)),
+		})
+	}
+	if len(result.Body) > 0 {
+		if err := json.Unmarshal(result.Body, &envelope); err != nil {
+			return envelope.Data, fmt.Errorf("client: decode batch result: %w", err)
+		}
+	}
+	return envelope.Data, nil
+}
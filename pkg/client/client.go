@@ -0,0 +1,362 @@
+// Package client is a typed Go SDK for the API.
+//
+// Every route meant for programs has a method; payloads are the server's own model types, so a
+// change to a request or response struct reaches callers at compile time. The route table the
+// methods use is checked against the server's by the server's tests (see CheckRoutes), so a new
+// or renamed route can't ship without the SDK following.
+package client
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang-echo/pkg/response"
+)
+
+const (
+	defaultMaxRetries   = 3
+	defaultMaxRetryWait = 30 * time.Second
+	// retryBackoff is the first wait when a 429 has no usable Retry-After; it doubles on every retry
+	retryBackoff = 500 * time.Millisecond
+)
+
+type Config struct {
+	// BaseURL is the server root, e.g. https://api.example.com
+	BaseURL    string
+	HTTPClient *http.Client
+	// Tokens supplies bearer tokens for authenticated routes; see StaticToken, PasswordLogin and RefreshToken
+	Tokens TokenSource
+	// APIKey authenticates with X-API-Key instead, when Tokens is nil
+	APIKey string
+	// MaxRetries bounds how many times a request answered with 429, or with 409 IDEMPOTENCY_KEY_IN_USE,
+	// is retried; negative disables retries
+	MaxRetries int
+	// MaxRetryWait caps the wait before a retry, whatever Retry-After asks for
+	MaxRetryWait time.Duration
+	UserAgent    string
+	// AcceptLanguage selects the language of error messages, e.g. vi
+	AcceptLanguage string
+}
+
+// Client calls the API; it is safe for concurrent use
+type Client struct {
+	config     Config
+	baseURL    *url.URL
+	httpClient *http.Client
+}
+
+func New(config Config) (*Client, error) {
+	baseURL, err := url.Parse(strings.TrimSuffix(config.BaseURL, "/"))
+	if err != nil || baseURL.Scheme == "" || baseURL.Host == "" {
+		return nil, fmt.Errorf("client: invalid base URL %q", config.BaseURL)
+	}
+	if config.HTTPClient == nil {
+		config.HTTPClient = &http.Client{Timeout: 30 * time.Second}
+	}
+	if config.MaxRetries == 0 {
+		config.MaxRetries = defaultMaxRetries
+	}
+	if config.MaxRetryWait <= 0 {
+		config.MaxRetryWait = defaultMaxRetryWait
+	}
+	c := &Client{config: config, baseURL: baseURL, httpClient: config.HTTPClient}
+	if bound, ok := config.Tokens.(clientBound); ok {
+		bound.bind(c)
+	}
+	return c, nil
+}
+
+// call is one request to a route
+type call struct {
+	route  route
+	params []any // path parameters, in the order they appear in the route
+	query  any   // struct with query tags
+	body   any   // JSON body
+	form   any   // struct with form tags, sent url-encoded
+	// file is sent as is with fileType, rewound for every attempt
+	file     io.ReadSeeker
+	fileType string
+	// ifMatch is sent as If-Match, for updates that require the resource's ETag
+	ifMatch string
+	// accept overrides the Accept header, application/json by default
+	accept string
+	// idempotent sends an Idempotency-Key, the same on every attempt, for routes that take one
+	idempotent     bool
+	idempotencyKey string
+}
+
+type idempotencyKeyContextKey struct{}
+
+// WithIdempotencyKey sets the Idempotency-Key sent by calls made with ctx to routes that take one
+// (Register, CreateInvitation, CreateUserExportJob); without it every call gets a random key
+// Reuse the key to retry a call whose outcome is unknown, e.g. after a network error: if the
+// first call went through, the retry gets its response instead of acting twice
+func WithIdempotencyKey(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, idempotencyKeyContextKey{}, key)
+}
+
+// do sends the call and decodes a successful JSON response into out
+func (c *Client) do(ctx context.Context, call call, out any) error {
+	res, err := c.roundTrip(ctx, call)
+	if err != nil {
+		return err
+	}
+	return decode(res, out)
+}
+
+// roundTrip sends the call, retrying on 429 and refreshing a rejected bearer token once,
+// and returns the successful response for the caller to read and close
+func (c *Client) roundTrip(ctx context.Context, call call) (*http.Response, error) {
+	target, err := c.url(call)
+	if err != nil {
+		return nil, err
+	}
+	var body []byte
+	contentType := ""
+	switch {
+	case call.body != nil:
+		if body, err = json.Marshal(call.body); err != nil {
+			return nil, fmt.Errorf("client: encode request body: %w", err)
+		}
+		contentType = "application/json"
+	case call.form != nil:
+		values, err := encodeValues(call.form, "form")
+		if err != nil {
+			return nil, err
+		}
+		body = []byte(values.Encode())
+		contentType = "application/x-www-form-urlencoded"
+	case call.file != nil:
+		contentType = call.fileType
+	}
+	if call.idempotent {
+		call.idempotencyKey, _ = ctx.Value(idempotencyKeyContextKey{}).(string)
+		if call.idempotencyKey == "" {
+			random := make([]byte, 16)
+			rand.Read(random)
+			call.idempotencyKey = hex.EncodeToString(random)
+		}
+	}
+
+	refreshed := false
+	for attempt := 0; ; attempt++ {
+		token := ""
+		if call.route.auth && c.config.Tokens != nil {
+			if token, err = c.config.Tokens.Token(ctx); err != nil {
+				return nil, fmt.Errorf("client: get token: %w", err)
+			}
+		}
+		res, err := c.send(ctx, call, target, contentType, body, token)
+		if err != nil {
+			return nil, err
+		}
+		if res.StatusCode < 300 {
+			return res, nil
+		}
+
+		apiErr := readError(res)
+		// A retry while the first request with the key is still running is answered with 409 IDEMPOTENCY_KEY_IN_USE
+		retry := res.StatusCode == http.StatusTooManyRequests || apiErr.Code == "IDEMPOTENCY_KEY_IN_USE"
+		if retry && attempt < c.config.MaxRetries {
+			if err := sleep(ctx, c.retryWait(apiErr.RetryAfter, attempt)); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if res.StatusCode == http.StatusUnauthorized && token != "" && !refreshed && apiErr.tokenRejected() {
+			refresher, ok := c.config.Tokens.(Refresher)
+			if ok {
+				refreshed = true
+				if _, err := refresher.Refresh(ctx, token); err != nil {
+					return nil, fmt.Errorf("client: refresh token: %w", err)
+				}
+				continue
+			}
+		}
+		return nil, apiErr
+	}
+}
+
+func (c *Client) send(ctx context.Context, call call, target string, contentType string, body []byte, token string) (*http.Response, error) {
+	var reader io.Reader
+	switch {
+	case body != nil:
+		reader = bytes.NewReader(body)
+	case call.file != nil:
+		if _, err := call.file.Seek(0, io.SeekStart); err != nil {
+			return nil, fmt.Errorf("client: rewind request body: %w", err)
+		}
+		reader = io.NopCloser(call.file) // the caller closes the file
+	}
+	req, err := http.NewRequestWithContext(ctx, call.route.method, target, reader)
+	if err != nil {
+		return nil, err
+	}
+	accept := "application/json"
+	if call.accept != "" {
+		accept = call.accept
+	}
+	req.Header.Set("Accept", accept)
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	if c.config.UserAgent != "" {
+		req.Header.Set("User-Agent", c.config.UserAgent)
+	}
+	if c.config.AcceptLanguage != "" {
+		req.Header.Set("Accept-Language", c.config.AcceptLanguage)
+	}
+	if call.ifMatch != "" {
+		req.Header.Set("If-Match", call.ifMatch)
+	}
+	if call.idempotencyKey != "" {
+		req.Header.Set("Idempotency-Key", call.idempotencyKey)
+	}
+	switch {
+	case token != "":
+		req.Header.Set("Authorization", "Bearer "+token)
+	case call.route.auth && c.config.APIKey != "":
+		req.Header.Set("X-API-Key", c.config.APIKey)
+	}
+	return c.httpClient.Do(req)
+}
+
+// url expands the route's path parameters and appends the query
+func (c *Client) url(call call) (string, error) {
+	segments := strings.Split(call.route.path, "/")
+	params := call.params
+	for i, segment := range segments {
+		if !strings.HasPrefix(segment, ":") {
+			continue
+		}
+		if len(params) == 0 {
+			return "", fmt.Errorf("client: missing path parameter %s for %s", segment, call.route)
+		}
+		value := fmt.Sprint(params[0])
+		if value == "" {
+			return "", fmt.Errorf("client: empty path parameter %s for %s", segment, call.route)
+		}
+		segments[i] = url.PathEscape(value)
+		params = params[1:]
+	}
+	target := c.baseURL.String() + strings.Join(segments, "/")
+	if call.query != nil {
+		values, err := encodeValues(call.query, "query")
+		if err != nil {
+			return "", err
+		}
+		if len(values) > 0 {
+			target += "?" + values.Encode()
+		}
+	}
+	return target, nil
+}
+
+// retryWait is how long to wait before retrying a 429 or 409 IDEMPOTENCY_KEY_IN_USE: Retry-After when the server sent one, exponential backoff otherwise
+func (c *Client) retryWait(retryAfter time.Duration, attempt int) time.Duration {
+	wait := retryAfter
+	if wait <= 0 {
+		wait = retryBackoff << attempt
+	}
+	return min(wait, c.config.MaxRetryWait)
+}
+
+func decode(res *http.Response, out any) error {
+	defer res.Body.Close()
+	if out == nil || res.StatusCode == http.StatusNoContent {
+		io.Copy(io.Discard, res.Body)
+		return nil
+	}
+	if err := json.NewDecoder(res.Body).Decode(out); err != nil {
+		return fmt.Errorf("client: decode response: %w", err)
+	}
+	return nil
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// parseRetryAfter reads Retry-After as delay-seconds or an HTTP date
+func parseRetryAfter(value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return time.Duration(seconds) * time.Second
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		return time.Until(at)
+	}
+	return 0
+}
+
+// data sends call and returns the data of the response.Response envelope
+func data[T any](ctx context.Context, c *Client, call call) (T, error) {
+	var envelope response.Response[T]
+	err := c.do(ctx, call, &envelope)
+	return envelope.Data, err
+}
+
+// list sends call and returns the items of a response.ListResponse without pagination
+func list[T any](ctx context.Context, c *Client, call call) ([]T, error) {
+	var envelope response.ListResponse[[]T]
+	err := c.do(ctx, call, &envelope)
+	return envelope.Data, err
+}
+
+// Page is one page of a paginated list
+type Page[T any] struct {
+	Items      []T
+	Pagination response.PaginationMeta
+}
+
+func page[T any](ctx context.Context, c *Client, call call) (*Page[T], error) {
+	var envelope response.ListResponse[[]T]
+	if err := c.do(ctx, call, &envelope); err != nil {
+		return nil, err
+	}
+	result := &Page[T]{Items: envelope.Data}
+	if envelope.Pagination != nil {
+		result.Pagination = *envelope.Pagination
+	}
+	return result, nil
+}
+
+// noContent sends call and discards the response
+func noContent(ctx context.Context, c *Client, call call) error {
+	return c.do(ctx, call, nil)
+}
+
+// stream sends call and returns the response body unread, for downloads; the caller closes it
+func stream(ctx context.Context, c *Client, call call) (io.ReadCloser, error) {
+	res, err := c.roundTrip(ctx, call)
+	if err != nil {
+		return nil, err
+	}
+	return res.Body, nil
+}
+
+// raw sends call and decodes the response as is, for routes without the envelope
+func raw[T any](ctx context.Context, c *Client, call call) (T, error) {
+	var out T
+	err := c.do(ctx, call, &out)
+	return out, err
+}
@@ -0,0 +1,344 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"golang-echo/internal/model"
+	"golang-echo/pkg/response"
+)
+
+func newTestClient(t *testing.T, handler http.HandlerFunc, config Config) *Client {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	config.BaseURL = server.URL
+	c, err := New(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func writeJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
+
+func writeHealth(w http.ResponseWriter) {
+	writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+func tooManyRequests(w http.ResponseWriter, retryAfter string) {
+	w.Header().Set("Retry-After", retryAfter)
+	writeJSON(w, http.StatusTooManyRequests, response.ErrorResponse{Code: "RATE_LIMIT_EXCEEDED", Message: "Too many requests"})
+}
+
+func TestRetryOn429(t *testing.T) {
+	tests := []struct {
+		name       string
+		retryAfter func() string
+		want       time.Duration // RetryAfter of the error once retries run out
+	}{
+		{"seconds", func() string { return "120" }, 120 * time.Second},
+		{"HTTP date", func() string { return time.Now().Add(time.Hour).UTC().Format(http.TimeFormat) }, time.Hour},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Retry-After asks for minutes; MaxRetryWait keeps the test fast, and bounds every wait
+			var requests atomic.Int32
+			c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+				if requests.Add(1) <= 2 {
+					tooManyRequests(w, tt.retryAfter())
+					return
+				}
+				writeHealth(w)
+			}, Config{MaxRetryWait: 10 * time.Millisecond})
+
+			start := time.Now()
+			status, err := c.Health(context.Background())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if status != "ok" || requests.Load() != 3 {
+				t.Errorf("got %q after %d requests, want ok after 3", status, requests.Load())
+			}
+			if elapsed := time.Since(start); elapsed < 20*time.Millisecond || elapsed > 5*time.Second {
+				t.Errorf("took %v, want two waits capped at MaxRetryWait", elapsed)
+			}
+
+			// Once retries run out, the 429 is returned with the wait the server asked for
+			requests.Store(-10)
+			_, err = c.Health(context.Background())
+			var apiErr *Error
+			if !errors.As(err, &apiErr) {
+				t.Fatalf("got %v, want *Error", err)
+			}
+			if apiErr.Status != http.StatusTooManyRequests || apiErr.Code != "RATE_LIMIT_EXCEEDED" {
+				t.Errorf("got %d %s, want 429 RATE_LIMIT_EXCEEDED", apiErr.Status, apiErr.Code)
+			}
+			if got := requests.Load(); got != -10+defaultMaxRetries+1 {
+				t.Errorf("sent %d requests, want %d", got+10, defaultMaxRetries+1)
+			}
+			if diff := apiErr.RetryAfter - tt.want; diff < -2*time.Second || diff > 0 {
+				t.Errorf("RetryAfter = %v, want about %v", apiErr.RetryAfter, tt.want)
+			}
+		})
+	}
+}
+
+func TestRetryDisabled(t *testing.T) {
+	var requests atomic.Int32
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		tooManyRequests(w, "1")
+	}, Config{MaxRetries: -1})
+
+	if _, err := c.Health(context.Background()); !IsCode(err, "RATE_LIMIT_EXCEEDED") {
+		t.Fatalf("got %v, want RATE_LIMIT_EXCEEDED", err)
+	}
+	if requests.Load() != 1 {
+		t.Errorf("sent %d requests, want 1", requests.Load())
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	tests := []struct {
+		value string
+		want  time.Duration
+	}{
+		{"", 0},
+		{"0", 0},
+		{"3", 3 * time.Second},
+		{"soon", 0},
+		{time.Now().Add(time.Minute).UTC().Format(http.TimeFormat), time.Minute},
+	}
+	for _, tt := range tests {
+		if got := parseRetryAfter(tt.value); got > tt.want || got < tt.want-2*time.Second {
+			t.Errorf("parseRetryAfter(%q) = %v, want about %v", tt.value, got, tt.want)
+		}
+	}
+	if got := parseRetryAfter(time.Now().Add(-time.Minute).UTC().Format(http.TimeFormat)); got > 0 {
+		t.Errorf("parseRetryAfter(past date) = %v, want no wait", got)
+	}
+}
+
+func TestRetryWait(t *testing.T) {
+	c := &Client{config: Config{MaxRetryWait: 10 * time.Second}}
+	tests := []struct {
+		retryAfter time.Duration
+		attempt    int
+		want       time.Duration
+	}{
+		{3 * time.Second, 0, 3 * time.Second},
+		{time.Minute, 0, 10 * time.Second},
+		{0, 0, retryBackoff},
+		{0, 2, 4 * retryBackoff},
+		{-time.Second, 1, 2 * retryBackoff},
+		{0, 10, 10 * time.Second},
+	}
+	for _, tt := range tests {
+		if got := c.retryWait(tt.retryAfter, tt.attempt); got != tt.want {
+			t.Errorf("retryWait(%v, %d) = %v, want %v", tt.retryAfter, tt.attempt, got, tt.want)
+		}
+	}
+}
+
+// loginServer issues token-1, token-2, ... on each login and, as if token-1 had been revoked, accepts token-2 on /my-info
+func loginServer(logins *atomic.Int32, myInfo *atomic.Int32, rejection string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case routeLogin.path:
+			n := logins.Add(1)
+			writeJSON(w, http.StatusOK, response.Response[model.LoginResponse]{
+				Code: "SUCCESS",
+				Data: model.LoginResponse{AccessToken: fmt.Sprintf("token-%d", n)},
+			})
+		case routeMyInfo.path:
+			myInfo.Add(1)
+			if r.Header.Get("Authorization") != "Bearer token-2" {
+				writeJSON(w, http.StatusUnauthorized, response.ErrorResponse{Code: rejection, Message: "Token is no longer valid"})
+				return
+			}
+			writeJSON(w, http.StatusOK, response.Response[model.User]{Code: "SUCCESS", Data: model.User{ID: 7}})
+		default:
+			http.NotFound(w, r)
+		}
+	}
+}
+
+func TestTokenRefreshedOnce(t *testing.T) {
+	var logins, myInfo atomic.Int32
+	c := newTestClient(t, loginServer(&logins, &myInfo, "TOKEN_REVOKED"), Config{Tokens: PasswordLogin("an@example.com", "secret", "")})
+
+	user, err := c.MyInfo(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user.ID != 7 {
+		t.Errorf("got user %d, want 7", user.ID)
+	}
+	// The first token is rejected, so the client logs in again and retries with the second
+	if logins.Load() != 2 || myInfo.Load() != 2 {
+		t.Errorf("logged in %d times and called my-info %d times, want 2 and 2", logins.Load(), myInfo.Load())
+	}
+
+	// The new token is kept for later calls
+	if _, err := c.MyInfo(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if logins.Load() != 2 || myInfo.Load() != 3 {
+		t.Errorf("logged in %d times and called my-info %d times, want 2 and 3", logins.Load(), myInfo.Load())
+	}
+}
+
+// rejectingTokens counts refreshes of a token the server never accepts
+type rejectingTokens struct {
+	refreshes atomic.Int32
+}
+
+func (s *rejectingTokens) Token(context.Context) (string, error) {
+	return "token", nil
+}
+
+func (s *rejectingTokens) Refresh(context.Context, string) (string, error) {
+	s.refreshes.Add(1)
+	return "token", nil
+}
+
+func TestTokenRefreshNotRepeated(t *testing.T) {
+	tests := []struct {
+		code          string
+		wantRefreshes int32
+	}{
+		{"INVALID_TOKEN", 1},
+		{"SESSION_REVOKED", 1},
+		// Not about the token itself, so a new one wouldn't help
+		{"MISSING_TOKEN", 0},
+		{"INVALID_API_KEY", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.code, func(t *testing.T) {
+			var requests atomic.Int32
+			tokens := &rejectingTokens{}
+			c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+				requests.Add(1)
+				writeJSON(w, http.StatusUnauthorized, response.ErrorResponse{Code: tt.code})
+			}, Config{Tokens: tokens})
+
+			if _, err := c.MyInfo(context.Background()); !IsCode(err, tt.code) {
+				t.Fatalf("got %v, want %s", err, tt.code)
+			}
+			if tokens.refreshes.Load() != tt.wantRefreshes || requests.Load() != tt.wantRefreshes+1 {
+				t.Errorf("refreshed %d times over %d requests, want %d over %d",
+					tokens.refreshes.Load(), requests.Load(), tt.wantRefreshes, tt.wantRefreshes+1)
+			}
+		})
+	}
+}
+
+func TestStaticTokenNotRefreshed(t *testing.T) {
+	var requests atomic.Int32
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		writeJSON(w, http.StatusUnauthorized, response.ErrorResponse{Code: "TOKEN_REVOKED"})
+	}, Config{Tokens: StaticToken("token")})
+
+	if _, err := c.MyInfo(context.Background()); !IsCode(err, "TOKEN_REVOKED") {
+		t.Fatalf("got %v, want TOKEN_REVOKED", err)
+	}
+	if requests.Load() != 1 {
+		t.Errorf("sent %d requests, want 1", requests.Load())
+	}
+}
+
+func TestErrorDecoding(t *testing.T) {
+	tests := []struct {
+		name        string
+		status      int
+		contentType string
+		body        string
+		want        Error
+	}{
+		{
+			name:   "ErrorResponse",
+			status: http.StatusBadRequest,
+			body:   `{"code":"VALIDATION_FAILED","message":"Validation failed","errors":{"email":"email must be a valid email address"},"request_id":"req-1"}`,
+			want: Error{
+				Status: http.StatusBadRequest, Code: "VALIDATION_FAILED", Message: "Validation failed",
+				Fields: map[string]string{"email": "email must be a valid email address"}, RequestID: "req-1",
+			},
+		},
+		{
+			name:        "problem details",
+			status:      http.StatusNotFound,
+			contentType: response.MIMEApplicationProblemJSON,
+			body:        `{"type":"about:blank","title":"Not Found","status":404,"detail":"User not found","code":"USER_NOT_FOUND","request_id":"req-2"}`,
+			want:        Error{Status: http.StatusNotFound, Code: "USER_NOT_FOUND", Message: "User not found", RequestID: "req-2"},
+		},
+		{
+			name:   "OAuth error",
+			status: http.StatusBadRequest,
+			body:   `{"error":"invalid_grant","error_description":"refresh token is invalid"}`,
+			want:   Error{Status: http.StatusBadRequest, Code: "invalid_grant", Message: "refresh token is invalid", RequestID: "header-id"},
+		},
+		{
+			name:        "not JSON",
+			status:      http.StatusBadGateway,
+			contentType: "text/html",
+			body:        `<html>Bad Gateway</html>`,
+			want:        Error{Status: http.StatusBadGateway, Code: "Bad Gateway", RequestID: "header-id"},
+		},
+		{
+			name:   "JSON without a code",
+			status: http.StatusConflict,
+			body:   `{}`,
+			want:   Error{Status: http.StatusConflict, Code: "Conflict", RequestID: "header-id"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+				contentType := tt.contentType
+				if contentType == "" {
+					contentType = "application/json"
+				}
+				w.Header().Set("Content-Type", contentType)
+				w.Header().Set("X-Request-Id", "header-id")
+				w.WriteHeader(tt.status)
+				w.Write([]byte(tt.body))
+			}, Config{MaxRetries: -1})
+
+			_, err := c.GetUser(context.Background(), 1)
+			var apiErr *Error
+			if !errors.As(err, &apiErr) {
+				t.Fatalf("got %v, want *Error", err)
+			}
+			if apiErr.Status != tt.want.Status || apiErr.Code != tt.want.Code || apiErr.Message != tt.want.Message ||
+				apiErr.RequestID != tt.want.RequestID || fmt.Sprint(apiErr.Fields) != fmt.Sprint(tt.want.Fields) {
+				t.Errorf("got %+v, want %+v", *apiErr, tt.want)
+			}
+			if ErrorCode(fmt.Errorf("wrapped: %w", err)) != tt.want.Code {
+				t.Errorf("ErrorCode of a wrapped error = %q, want %q", ErrorCode(fmt.Errorf("wrapped: %w", err)), tt.want.Code)
+			}
+		})
+	}
+
+	if ErrorCode(errors.New("network down")) != "" {
+		t.Error("ErrorCode of a non-API error should be empty")
+	}
+	if got := (&Error{Status: 404, Code: "USER_NOT_FOUND", Message: "User not found"}).Error(); got != "api: 404 USER_NOT_FOUND: User not found" {
+		t.Errorf("Error() = %q", got)
+	}
+	if got := (&Error{Status: 404, Code: "USER_NOT_FOUND", Fields: map[string]string{"id": "bad"}}).Field("id"); got != "bad" {
+		t.Errorf("Field(id) = %q, want bad", got)
+	}
+}
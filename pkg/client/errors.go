@@ -0,0 +1,105 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// maxErrorBody bounds how much of an error response is read
+const maxErrorBody = 1 << 20
+
+// Error is an error response from the API
+// Code is the AppError key (e.g. USER_NOT_FOUND, VALIDATION_FAILED), or the OAuth error code
+// (e.g. invalid_grant) for the token and revocation endpoints
+type Error struct {
+	Status    int
+	Code      string
+	Message   string
+	Fields    map[string]string // field → message, for VALIDATION_FAILED
+	RequestID string
+	// RetryAfter is the wait the server asked for, on 429 and 409 IDEMPOTENCY_KEY_IN_USE responses
+	RetryAfter time.Duration
+}
+
+func (e *Error) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("api: %d %s", e.Status, e.Code)
+	}
+	return fmt.Sprintf("api: %d %s: %s", e.Status, e.Code, e.Message)
+}
+
+// Field returns the error message for a request field, "" if it has none
+func (e *Error) Field(name string) string {
+	return e.Fields[name]
+}
+
+// tokenRejected reports whether a 401 means the bearer token itself is no longer accepted
+func (e *Error) tokenRejected() bool {
+	switch e.Code {
+	case "INVALID_TOKEN", "TOKEN_REVOKED", "SESSION_REVOKED":
+		return true
+	}
+	return false
+}
+
+// ErrorCode returns the API error code of err, "" if err is not an *Error
+func ErrorCode(err error) string {
+	var apiErr *Error
+	if errors.As(err, &apiErr) {
+		return apiErr.Code
+	}
+	return ""
+}
+
+// IsCode reports whether err is an API error with the given code
+func IsCode(err error, code string) bool {
+	return ErrorCode(err) == code
+}
+
+// readError decodes an ErrorResponse, problem+json or OAuth error body
+func readError(res *http.Response) *Error {
+	defer res.Body.Close()
+	apiErr := &Error{
+		Status:     res.StatusCode,
+		RequestID:  res.Header.Get("X-Request-Id"),
+		RetryAfter: parseRetryAfter(res.Header.Get("Retry-After")),
+	}
+
+	var body struct {
+		Code      string            `json:"code"`
+		Message   string            `json:"message"`
+		Errors    map[string]string `json:"errors"`
+		RequestID string            `json:"request_id"`
+		Detail    string            `json:"detail"` // problem+json
+		// OAuth errors
+		OAuthError       string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	data, _ := io.ReadAll(io.LimitReader(res.Body, maxErrorBody))
+	if err := json.Unmarshal(data, &body); err != nil {
+		apiErr.Code = http.StatusText(res.StatusCode)
+		return apiErr
+	}
+
+	apiErr.Code = body.Code
+	apiErr.Message = body.Message
+	apiErr.Fields = body.Errors
+	if body.RequestID != "" {
+		apiErr.RequestID = body.RequestID
+	}
+	if apiErr.Message == "" {
+		apiErr.Message = body.Detail
+	}
+	if body.OAuthError != "" {
+		apiErr.Code = body.OAuthError
+		apiErr.Message = body.ErrorDescription
+	}
+	if apiErr.Code == "" {
+		apiErr.Code = http.StatusText(res.StatusCode)
+	}
+	return apiErr
+}
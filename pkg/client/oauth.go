@@ -0,0 +1,53 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+
+	"golang-echo/internal/model"
+	"golang-echo/pkg/oauth"
+	"golang-echo/pkg/oidc"
+)
+
+// Health reports the server's status, "ok" when it is up
+func (c *Client) Health(ctx context.Context) (string, error) {
+	res, err := raw[struct {
+		Status string `json:"status"`
+	}](ctx, c, call{route: routeHealth})
+	return res.Status, err
+}
+
+// OpenAPI returns the server's OpenAPI document
+func (c *Client) OpenAPI(ctx context.Context) (json.RawMessage, error) {
+	return raw[json.RawMessage](ctx, c, call{route: routeOpenAPI})
+}
+
+// Discovery returns the OpenID Provider metadata
+func (c *Client) Discovery(ctx context.Context) (*oauth.ServerMetadata, error) {
+	return raw[*oauth.ServerMetadata](ctx, c, call{route: routeDiscovery})
+}
+
+// JWKS returns the keys that sign ID tokens
+func (c *Client) JWKS(ctx context.Context) (*oidc.JSONWebKeySet, error) {
+	return raw[*oidc.JSONWebKeySet](ctx, c, call{route: routeJWKS})
+}
+
+// Token calls the token endpoint; errors carry the OAuth error code, e.g. invalid_grant
+func (c *Client) Token(ctx context.Context, req model.TokenRequest) (*model.TokenResponse, error) {
+	return raw[*model.TokenResponse](ctx, c, call{route: routeToken, form: req})
+}
+
+// RevokeToken revokes an OAuth refresh token; unknown tokens are not an error
+func (c *Client) RevokeToken(ctx context.Context, token string, clientID string, clientSecret string) error {
+	form := struct {
+		Token        string `form:"token"`
+		ClientID     string `form:"client_id"`
+		ClientSecret string `form:"client_secret"`
+	}{token, clientID, clientSecret}
+	return noContent(ctx, c, call{route: routeRevoke, form: form})
+}
+
+// UserInfo returns the OpenID Connect claims the token's scope releases
+func (c *Client) UserInfo(ctx context.Context) (*model.UserInfo, error) {
+	return raw[*model.UserInfo](ctx, c, call{route: routeUserInfo})
+}
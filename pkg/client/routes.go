@@ -0,0 +1,132 @@
+package client
+
+import (
+	"errors"
+	"net/http"
+	"sort"
+	"strings"
+
+	"golang-echo/pkg/oauth"
+
+	"github.com/labstack/echo/v4"
+)
+
+// route is a server route a method calls, with its Echo path
+type route struct {
+	method string
+	path   string
+	auth   bool // sends the bearer token or API key
+}
+
+func (r route) String() string {
+	return r.method + " " + r.path
+}
+
+// routes is every route the client calls, filled in by newRoute
+var routes = map[string]route{}
+
+func newRoute(method string, path string, auth bool) route {
+	r := route{method: method, path: path, auth: auth}
+	routes[r.String()] = r
+	return r
+}
+
+// skippedRoutes are the routes the client has no method for: pages and redirects for browsers,
+// and the POST form of userinfo, which UserInfo calls with GET
+var skippedRoutes = map[string]bool{
+	"GET /docs":                                true,
+	"GET " + oauth.PathAuthorize:               true,
+	"POST " + oauth.PathAuthorize:              true,
+	"POST " + oauth.PathUserInfo:               true,
+	"GET /api/v1/auth/oidc/:provider/start":    true,
+	"GET /api/v1/auth/oidc/:provider/callback": true,
+}
+
+var (
+	routeHealth    = newRoute(http.MethodGet, "/health", false)
+	routeOpenAPI   = newRoute(http.MethodGet, "/openapi.json", false)
+	routeDiscovery = newRoute(http.MethodGet, oauth.PathDiscovery, false)
+	routeJWKS      = newRoute(http.MethodGet, oauth.PathJWKS, false)
+	routeToken     = newRoute(http.MethodPost, oauth.PathToken, false)
+	routeRevoke    = newRoute(http.MethodPost, oauth.PathRevoke, false)
+	routeUserInfo  = newRoute(http.MethodGet, oauth.PathUserInfo, true)
+
+	routeRegister         = newRoute(http.MethodPost, "/api/v1/users", false)
+	routeLogin            = newRoute(http.MethodPost, "/api/v1/users/login", false)
+	routeAcceptInvitation = newRoute(http.MethodPost, "/api/v1/invitations/accept", false)
+
+	routeMyInfo              = newRoute(http.MethodGet, "/api/v1/my-info", true)
+	routeChangePassword      = newRoute(http.MethodPut, "/api/v1/my-info/password", true)
+	routeMySessions          = newRoute(http.MethodGet, "/api/v1/my-info/sessions", true)
+	routeRevokeOtherSessions = newRoute(http.MethodDelete, "/api/v1/my-info/sessions", true)
+	routeRevokeMySession     = newRoute(http.MethodDelete, "/api/v1/my-info/sessions/:id", true)
+	routeMyConsents          = newRoute(http.MethodGet, "/api/v1/my-info/consents", true)
+	routeRevokeMyConsent     = newRoute(http.MethodDelete, "/api/v1/my-info/consents/:client_id", true)
+	routeBatch               = newRoute(http.MethodPost, "/api/v1/batch", true)
+
+	routeListUsers         = newRoute(http.MethodGet, "/api/v1/users", true)
+	routeGetUser           = newRoute(http.MethodGet, "/api/v1/users/:id", true)
+	routeFindUserByEmail   = newRoute(http.MethodGet, "/api/v1/users/by-email", true)
+	routeFindUserByPhone   = newRoute(http.MethodGet, "/api/v1/users/by-phone", true)
+	routeUserSessions      = newRoute(http.MethodGet, "/api/v1/users/:id/sessions", true)
+	routeUpdateUserRole    = newRoute(http.MethodPut, "/api/v1/users/:id/role", true)
+	routeUpdateUserStatus  = newRoute(http.MethodPut, "/api/v1/users/:id/status", true)
+	routeUserStatusChanges = newRoute(http.MethodGet, "/api/v1/users/:id/status-changes", true)
+	routeImpersonate       = newRoute(http.MethodPost, "/api/v1/admin/users/:id/impersonate", true)
+	routeExportUsers       = newRoute(http.MethodGet, "/api/v1/admin/users/export", true)
+	routeCreateExportJob   = newRoute(http.MethodPost, "/api/v1/admin/users/export-jobs", true)
+	routeExportJob         = newRoute(http.MethodGet, "/api/v1/admin/users/export-jobs/:id", true)
+	routeDownloadExport    = newRoute(http.MethodGet, "/api/v1/admin/users/export-jobs/:id/download", true)
+	routeImportUsers       = newRoute(http.MethodPost, "/api/v1/admin/users/import", true)
+	routeImportJob         = newRoute(http.MethodGet, "/api/v1/admin/users/import-jobs/:id", true)
+
+	routeListAuditEvents  = newRoute(http.MethodGet, "/api/v1/admin/audit-events", true)
+	routeVerifyAuditChain = newRoute(http.MethodGet, "/api/v1/admin/audit-events/verify", true)
+
+	routeCreateInvitation = newRoute(http.MethodPost, "/api/v1/admin/invitations", true)
+	routeListInvitations  = newRoute(http.MethodGet, "/api/v1/admin/invitations", true)
+	routeResendInvitation = newRoute(http.MethodPost, "/api/v1/admin/invitations/:id/resend", true)
+	routeRevokeInvitation = newRoute(http.MethodDelete, "/api/v1/admin/invitations/:id", true)
+
+	routeCreateAPIKey = newRoute(http.MethodPost, "/api/v1/admin/api-keys", true)
+	routeListAPIKeys  = newRoute(http.MethodGet, "/api/v1/admin/api-keys", true)
+	routeRevokeAPIKey = newRoute(http.MethodDelete, "/api/v1/admin/api-keys/:id", true)
+
+	routeCreateOAuthClient = newRoute(http.MethodPost, "/api/v1/admin/oauth-clients", true)
+	routeListOAuthClients  = newRoute(http.MethodGet, "/api/v1/admin/oauth-clients", true)
+	routeRevokeOAuthClient = newRoute(http.MethodDelete, "/api/v1/admin/oauth-clients/:id", true)
+)
+
+// CheckRoutes compares the client with the server's route table: every route must have a client
+// method (or be listed in skippedRoutes), and every route the client calls must exist
+func CheckRoutes(registered []*echo.Route) error {
+	var problems []string
+	seen := map[string]bool{}
+	for _, r := range registered {
+		switch r.Method {
+		case http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		default:
+			continue // e.g. Echo's RouteNotFound entries
+		}
+		key := r.Method + " " + r.Path
+		seen[key] = true
+		if _, ok := routes[key]; !ok && !skippedRoutes[key] {
+			problems = append(problems, "route "+key+" has no method in pkg/client")
+		}
+	}
+	for key := range routes {
+		if !seen[key] {
+			problems = append(problems, "pkg/client calls "+key+", which is not registered")
+		}
+	}
+	for key := range skippedRoutes {
+		if !seen[key] {
+			problems = append(problems, "pkg/client skips "+key+", which is not registered")
+		}
+	}
+	if len(problems) == 0 {
+		return nil
+	}
+	sort.Strings(problems)
+	return errors.New(strings.Join(problems, "\n"))
+}
@@ -0,0 +1,58 @@
+package client
+
+import (
+	"context"
+
+	"golang-echo/internal/model"
+)
+
+// Register creates an account
+func (c *Client) Register(ctx context.Context, req model.CreateUserRequest) (*model.User, error) {
+	return data[*model.User](ctx, c, call{route: routeRegister, body: req, idempotent: true})
+}
+
+// Login exchanges email and password for an access token
+// To have the client log in and keep the token itself, use PasswordLogin as Config.Tokens
+func (c *Client) Login(ctx context.Context, req model.LoginRequest) (*model.LoginResponse, error) {
+	return data[*model.LoginResponse](ctx, c, call{route: routeLogin, body: req})
+}
+
+// AcceptInvitation creates the invited account
+func (c *Client) AcceptInvitation(ctx context.Context, req model.AcceptInvitationRequest) (*model.User, error) {
+	return data[*model.User](ctx, c, call{route: routeAcceptInvitation, body: req})
+}
+
+// MyInfo returns the signed-in user, only its fields if any are given
+func (c *Client) MyInfo(ctx context.Context, fields ...string) (*model.User, error) {
+	return data[*model.User](ctx, c, call{route: routeMyInfo, query: userFields(fields)})
+}
+
+// ChangePassword changes the signed-in user's password, signing out every session including this one
+func (c *Client) ChangePassword(ctx context.Context, req model.ChangePasswordRequest) error {
+	return noContent(ctx, c, call{route: routeChangePassword, body: req})
+}
+
+// MySessions lists the signed-in user's sessions
+func (c *Client) MySessions(ctx context.Context) ([]model.UserSession, error) {
+	return list[model.UserSession](ctx, c, call{route: routeMySessions})
+}
+
+// RevokeOtherSessions signs out every session but the current one
+func (c *Client) RevokeOtherSessions(ctx context.Context) (*model.RevokeSessionsResponse, error) {
+	return data[*model.RevokeSessionsResponse](ctx, c, call{route: routeRevokeOtherSessions})
+}
+
+// RevokeMySession signs out one of the signed-in user's sessions
+func (c *Client) RevokeMySession(ctx context.Context, sessionID int) error {
+	return noContent(ctx, c, call{route: routeRevokeMySession, params: []any{sessionID}})
+}
+
+// MyConsents lists the applications the signed-in user authorized
+func (c *Client) MyConsents(ctx context.Context) ([]model.OAuthConsent, error) {
+	return list[model.OAuthConsent](ctx, c, call{route: routeMyConsents})
+}
+
+// RevokeMyConsent withdraws an application's access
+func (c *Client) RevokeMyConsent(ctx context.Context, clientID string) error {
+	return noContent(ctx, c, call{route: routeRevokeMyConsent, params: []any{clientID}})
+}
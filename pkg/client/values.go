@@ -0,0 +1,65 @@
+package client
+
+import (
+	"fmt"
+	"net/url"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// encodeValues turns a struct into query or form values, naming fields by tag the way Echo's binder reads them
+// Zero values are left out, so the server applies its defaults; embedded structs are flattened
+func encodeValues(v any, tag string) (url.Values, error) {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Pointer {
+		if rv.IsNil() {
+			return url.Values{}, nil
+		}
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("client: %s values must be a struct, got %s", tag, rv.Type())
+	}
+	values := url.Values{}
+	if err := addValues(values, rv, tag); err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+func addValues(values url.Values, rv reflect.Value, tag string) error {
+	t := rv.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get(tag), ",")
+		value := rv.Field(i)
+		if field.Anonymous && name == "" && value.Kind() == reflect.Struct {
+			if err := addValues(values, value, tag); err != nil {
+				return err
+			}
+			continue
+		}
+		if !field.IsExported() || name == "" || name == "-" || value.IsZero() {
+			continue
+		}
+		if value.Kind() == reflect.Pointer {
+			value = value.Elem()
+		}
+		if value.Kind() == reflect.Slice {
+			for j := 0; j < value.Len(); j++ {
+				values.Add(name, formatValue(value.Index(j)))
+			}
+			continue
+		}
+		values.Set(name, formatValue(value))
+	}
+	return nil
+}
+
+func formatValue(v reflect.Value) string {
+	if t, ok := v.Interface().(time.Time); ok {
+		return t.Format(time.RFC3339)
+	}
+	return fmt.Sprint(v.Interface())
+}
@@ -3,6 +3,9 @@ package config
 import (
 	"fmt"
 	"log"
+	"os"
+	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/spf13/viper"
@@ -10,11 +13,23 @@ import (
 
 // Config holds all configuration for the application
 type Config struct {
-	Database  DatabaseConfig  `mapstructure:"database"`
-	Server    ServerConfig    `mapstructure:"server"`
-	JWT       JWTConfig       `mapstructure:"jwt"`
-	Logging   LoggingConfig   `mapstructure:"logging"`
-	RateLimit RateLimitConfig `mapstructure:"rate_limit"`
+	Database   DatabaseConfig   `mapstructure:"database"`
+	Server     ServerConfig     `mapstructure:"server"`
+	JWT        JWTConfig        `mapstructure:"jwt"`
+	Logging    LoggingConfig    `mapstructure:"logging"`
+	RateLimit  RateLimitConfig  `mapstructure:"rate_limit"`
+	I18n       I18nConfig       `mapstructure:"i18n"`
+	Password   PasswordConfig   `mapstructure:"password"`
+	OIDC       OIDCConfig       `mapstructure:"oidc"`
+	OAuth      OAuthConfig      `mapstructure:"oauth"`
+	Mail       MailConfig       `mapstructure:"mail"`
+	Invitation InvitationConfig `mapstructure:"invitation"`
+	OpenAPI    OpenAPIConfig    `mapstructure:"openapi"`
+	Export     ExportConfig     `mapstructure:"export"`
+	Import     ImportConfig     `mapstructure:"import"`
+	// Idempotency configures Idempotency-Key handling on create endpoints
+	Idempotency IdempotencyConfig `mapstructure:"idempotency"`
+	Batch       BatchConfig       `mapstructure:"batch"`
 }
 
 type DatabaseConfig struct {
@@ -34,6 +49,10 @@ type ServerConfig struct {
 type JWTConfig struct {
 	Secret   string        `mapstructure:"secret"`
 	Duration time.Duration `mapstructure:"duration"`
+	// VersionCacheTTL bounds how long a token_version bump or session revocation on another instance takes to be enforced
+	VersionCacheTTL time.Duration `mapstructure:"version_cache_ttl"`
+	// ImpersonationDuration is the lifetime of admin impersonation tokens
+	ImpersonationDuration time.Duration `mapstructure:"impersonation_duration"`
 }
 
 type LoggingConfig struct {
@@ -48,6 +67,105 @@ type RateLimitConfig struct {
 	LimiterType    string `mapstructure:"limiter_type"`
 }
 
+type I18nConfig struct {
+	Locales       []string `mapstructure:"locales"`
+	DefaultLocale string   `mapstructure:"default_locale"`
+}
+
+type PasswordConfig struct {
+	MinLength        int    `mapstructure:"min_length"`
+	MaxLength        int    `mapstructure:"max_length"`
+	RequireUpper     bool   `mapstructure:"require_upper"`
+	RequireLower     bool   `mapstructure:"require_lower"`
+	RequireDigit     bool   `mapstructure:"require_digit"`
+	RequireSymbol    bool   `mapstructure:"require_symbol"`
+	ForbidEmail      bool   `mapstructure:"forbid_email"`
+	MinStrengthScore int    `mapstructure:"min_strength_score"`
+	BreachedListPath string `mapstructure:"breached_list_path"`
+
+	// Hashing: new hashes use HashAlgorithm, hashes from the other algorithm are still verified
+	HashAlgorithm     string `mapstructure:"hash_algorithm"` // argon2id | bcrypt
+	Argon2Memory      uint32 `mapstructure:"argon2_memory"`  // KiB
+	Argon2Iterations  uint32 `mapstructure:"argon2_iterations"`
+	Argon2Parallelism uint8  `mapstructure:"argon2_parallelism"`
+	BcryptCost        int    `mapstructure:"bcrypt_cost"`
+}
+
+type OIDCConfig struct {
+	StateTTL  time.Duration                 `mapstructure:"state_ttl"`
+	Providers map[string]OIDCProviderConfig `mapstructure:"providers"`
+}
+
+// OIDCProviderConfig configures one external identity provider
+// Set OIDC_PROVIDERS=google,mock and OIDC_<NAME>_ISSUER, OIDC_<NAME>_CLIENT_ID, ... per provider
+type OIDCProviderConfig struct {
+	Issuer       string   `mapstructure:"issuer"`
+	ClientID     string   `mapstructure:"client_id"`
+	ClientSecret string   `mapstructure:"client_secret"`
+	RedirectURL  string   `mapstructure:"redirect_url"`
+	Scopes       []string `mapstructure:"scopes"`
+}
+
+// OAuthConfig configures the built-in OAuth2 authorization server; access tokens are signed with the JWT secret
+type OAuthConfig struct {
+	Issuer      string        `mapstructure:"issuer"` // public base URL of this service
+	AuthCodeTTL time.Duration `mapstructure:"auth_code_ttl"`
+	// AccessTokenTTL is kept short: access tokens aren't tied to a session, so revoking their consent
+	// or refresh token only takes effect once they expire
+	AccessTokenTTL  time.Duration `mapstructure:"access_token_ttl"`
+	RefreshTokenTTL time.Duration `mapstructure:"refresh_token_ttl"`
+	SigningKeyPath  string        `mapstructure:"signing_key_path"` // RSA key for ID tokens; empty generates an ephemeral key
+}
+
+// MailConfig selects how outgoing email is delivered
+type MailConfig struct {
+	Driver       string `mapstructure:"driver"` // log (not allowed in production) or smtp
+	From         string `mapstructure:"from"`
+	SMTPHost     string `mapstructure:"smtp_host"`
+	SMTPPort     int    `mapstructure:"smtp_port"`
+	SMTPUsername string `mapstructure:"smtp_username"`
+	SMTPPassword string `mapstructure:"smtp_password"`
+}
+
+type InvitationConfig struct {
+	TTL    time.Duration `mapstructure:"ttl"`     // default lifetime of an invitation
+	MaxTTL time.Duration `mapstructure:"max_ttl"` // latest expiry an admin may choose
+	// AcceptURL is the page where invitees set their password; the token is appended as ?token=
+	AcceptURL string `mapstructure:"accept_url"`
+}
+
+type ExportConfig struct {
+	// Dir is where export jobs write their files; it is local to the instance that ran the job
+	Dir    string        `mapstructure:"dir"`
+	JobTTL time.Duration `mapstructure:"job_ttl"` // how long an export job's file can be downloaded
+}
+
+type ImportConfig struct {
+	MaxBytes int64 `mapstructure:"max_bytes"` // largest import file accepted
+}
+
+type IdempotencyConfig struct {
+	// Store keeps the responses: postgres, shared by every instance, or memory, for a single instance
+	Store string        `mapstructure:"store"`
+	TTL   time.Duration `mapstructure:"ttl"` // how long a response is replayed for its key
+	// LockTimeout is how long a request holds its key; a key still held after that (e.g. the
+	// instance died mid-request) can be used again
+	LockTimeout time.Duration `mapstructure:"lock_timeout"`
+}
+
+type BatchConfig struct {
+	MaxRequests    int `mapstructure:"max_requests"`    // requests in one POST /api/v1/batch
+	MaxConcurrency int `mapstructure:"max_concurrency"` // requests of a parallel batch run at once
+}
+
+type OpenAPIConfig struct {
+	// ValidateRequests rejects requests that do not match the documented parameters and body
+	ValidateRequests bool `mapstructure:"validate_requests"`
+	// ResponseValidation checks responses against the document: off, log or fail
+	// Empty picks log in development, fail in test and off elsewhere
+	ResponseValidation string `mapstructure:"response_validation"`
+}
+
 // Load loads configuration from environment variables and .env file
 func Load() (*Config, error) {
 	// Set defaults
@@ -61,12 +179,55 @@ func Load() (*Config, error) {
 	viper.SetDefault("server.env", "development")
 	viper.SetDefault("jwt.secret", "your-secret-key-change-in-production")
 	viper.SetDefault("jwt.duration", "24h")
+	viper.SetDefault("jwt.version_cache_ttl", "30s")
+	viper.SetDefault("jwt.impersonation_duration", "15m")
 	viper.SetDefault("logging.level", "info")
 	viper.SetDefault("logging.format", "json")
 	viper.SetDefault("logging.add_source", false)
 	viper.SetDefault("rate_limit.enabled", true)
 	viper.SetDefault("rate_limit.requests_per_min", 60)
 	viper.SetDefault("rate_limit.limiter_type", "sliding-window")
+	viper.SetDefault("i18n.locales", []string{"en", "vi"})
+	viper.SetDefault("i18n.default_locale", "en")
+	viper.SetDefault("password.min_length", 8)
+	viper.SetDefault("password.max_length", 128)
+	viper.SetDefault("password.require_upper", false)
+	viper.SetDefault("password.require_lower", true)
+	viper.SetDefault("password.require_digit", true)
+	viper.SetDefault("password.require_symbol", false)
+	viper.SetDefault("password.forbid_email", true)
+	viper.SetDefault("password.min_strength_score", 2)
+	viper.SetDefault("password.breached_list_path", "")
+	viper.SetDefault("password.hash_algorithm", "argon2id")
+	viper.SetDefault("password.argon2_memory", 19456)
+	viper.SetDefault("password.argon2_iterations", 2)
+	viper.SetDefault("password.argon2_parallelism", 1)
+	viper.SetDefault("password.bcrypt_cost", 10)
+	viper.SetDefault("oidc.state_ttl", "10m")
+	viper.SetDefault("oauth.issuer", "http://localhost:8080")
+	viper.SetDefault("oauth.auth_code_ttl", "1m")
+	viper.SetDefault("oauth.access_token_ttl", "15m")
+	viper.SetDefault("oauth.refresh_token_ttl", "720h")
+	viper.SetDefault("oauth.signing_key_path", "")
+	viper.SetDefault("mail.driver", "log")
+	viper.SetDefault("mail.from", "no-reply@localhost")
+	viper.SetDefault("mail.smtp_host", "localhost")
+	viper.SetDefault("mail.smtp_port", 587)
+	viper.SetDefault("mail.smtp_username", "")
+	viper.SetDefault("mail.smtp_password", "")
+	viper.SetDefault("invitation.ttl", "72h")
+	viper.SetDefault("invitation.max_ttl", "720h")
+	viper.SetDefault("invitation.accept_url", "http://localhost:3000/accept-invitation")
+	viper.SetDefault("openapi.validate_requests", false)
+	viper.SetDefault("openapi.response_validation", "")
+	viper.SetDefault("export.dir", filepath.Join(os.TempDir(), "golang-echo-exports"))
+	viper.SetDefault("export.job_ttl", "24h")
+	viper.SetDefault("import.max_bytes", 50<<20)
+	viper.SetDefault("idempotency.store", "postgres")
+	viper.SetDefault("idempotency.ttl", "24h")
+	viper.SetDefault("idempotency.lock_timeout", "1m")
+	viper.SetDefault("batch.max_requests", 50)
+	viper.SetDefault("batch.max_concurrency", 5)
 
 	// Enable reading from .env file
 	viper.SetConfigName(".env")
@@ -93,12 +254,57 @@ func Load() (*Config, error) {
 	viper.BindEnv("server.env", "SERVER_ENV")
 	viper.BindEnv("jwt.secret", "JWT_SECRET")
 	viper.BindEnv("jwt.duration", "JWT_DURATION")
+	viper.BindEnv("jwt.version_cache_ttl", "JWT_VERSION_CACHE_TTL")
+	viper.BindEnv("jwt.impersonation_duration", "JWT_IMPERSONATION_DURATION")
 	viper.BindEnv("logging.level", "LOG_LEVEL")
 	viper.BindEnv("logging.format", "LOG_FORMAT")
 	viper.BindEnv("logging.add_source", "LOG_ADD_SOURCE")
 	viper.BindEnv("rate_limit.enabled", "RATE_LIMIT_ENABLED")
 	viper.BindEnv("rate_limit.requests_per_min", "RATE_LIMIT_REQUESTS_PER_MIN")
 	viper.BindEnv("rate_limit.limiter_type", "RATE_LIMIT_TYPE")
+	viper.BindEnv("i18n.locales", "I18N_LOCALES")
+	viper.BindEnv("i18n.default_locale", "I18N_DEFAULT_LOCALE")
+	viper.BindEnv("password.min_length", "PASSWORD_MIN_LENGTH")
+	viper.BindEnv("password.max_length", "PASSWORD_MAX_LENGTH")
+	viper.BindEnv("password.require_upper", "PASSWORD_REQUIRE_UPPER")
+	viper.BindEnv("password.require_lower", "PASSWORD_REQUIRE_LOWER")
+	viper.BindEnv("password.require_digit", "PASSWORD_REQUIRE_DIGIT")
+	viper.BindEnv("password.require_symbol", "PASSWORD_REQUIRE_SYMBOL")
+	viper.BindEnv("password.forbid_email", "PASSWORD_FORBID_EMAIL")
+	viper.BindEnv("password.min_strength_score", "PASSWORD_MIN_STRENGTH_SCORE")
+	viper.BindEnv("password.breached_list_path", "PASSWORD_BREACHED_LIST_PATH")
+	viper.BindEnv("password.hash_algorithm", "PASSWORD_HASH_ALGORITHM")
+	viper.BindEnv("password.argon2_memory", "PASSWORD_ARGON2_MEMORY")
+	viper.BindEnv("password.argon2_iterations", "PASSWORD_ARGON2_ITERATIONS")
+	viper.BindEnv("password.argon2_parallelism", "PASSWORD_ARGON2_PARALLELISM")
+	viper.BindEnv("password.bcrypt_cost", "PASSWORD_BCRYPT_COST")
+	viper.BindEnv("oidc.state_ttl", "OIDC_STATE_TTL")
+	viper.BindEnv("oidc.provider_names", "OIDC_PROVIDERS")
+	bindOIDCProviderEnv(viper.GetString("oidc.provider_names"))
+	viper.BindEnv("oauth.issuer", "OAUTH_ISSUER")
+	viper.BindEnv("oauth.auth_code_ttl", "OAUTH_AUTH_CODE_TTL")
+	viper.BindEnv("oauth.access_token_ttl", "OAUTH_ACCESS_TOKEN_TTL")
+	viper.BindEnv("oauth.refresh_token_ttl", "OAUTH_REFRESH_TOKEN_TTL")
+	viper.BindEnv("oauth.signing_key_path", "OAUTH_SIGNING_KEY_PATH")
+	viper.BindEnv("mail.driver", "MAIL_DRIVER")
+	viper.BindEnv("mail.from", "MAIL_FROM")
+	viper.BindEnv("mail.smtp_host", "SMTP_HOST")
+	viper.BindEnv("mail.smtp_port", "SMTP_PORT")
+	viper.BindEnv("mail.smtp_username", "SMTP_USERNAME")
+	viper.BindEnv("mail.smtp_password", "SMTP_PASSWORD")
+	viper.BindEnv("invitation.ttl", "INVITATION_TTL")
+	viper.BindEnv("invitation.max_ttl", "INVITATION_MAX_TTL")
+	viper.BindEnv("invitation.accept_url", "INVITATION_ACCEPT_URL")
+	viper.BindEnv("openapi.validate_requests", "OPENAPI_VALIDATE_REQUESTS")
+	viper.BindEnv("openapi.response_validation", "OPENAPI_RESPONSE_VALIDATION")
+	viper.BindEnv("export.dir", "EXPORT_DIR")
+	viper.BindEnv("export.job_ttl", "EXPORT_JOB_TTL")
+	viper.BindEnv("import.max_bytes", "IMPORT_MAX_BYTES")
+	viper.BindEnv("idempotency.store", "IDEMPOTENCY_STORE")
+	viper.BindEnv("idempotency.ttl", "IDEMPOTENCY_TTL")
+	viper.BindEnv("idempotency.lock_timeout", "IDEMPOTENCY_LOCK_TIMEOUT")
+	viper.BindEnv("batch.max_requests", "BATCH_MAX_REQUESTS")
+	viper.BindEnv("batch.max_concurrency", "BATCH_MAX_CONCURRENCY")
 
 	var config Config
 	if err := viper.Unmarshal(&config); err != nil {
@@ -109,6 +315,24 @@ func Load() (*Config, error) {
 	return &config, nil
 }
 
+// bindOIDCProviderEnv maps OIDC_<NAME>_* variables for every provider listed in OIDC_PROVIDERS
+func bindOIDCProviderEnv(providerNames string) {
+	for _, name := range strings.Split(providerNames, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		envPrefix := "OIDC_" + strings.ToUpper(name) + "_"
+		key := "oidc.providers." + name + "."
+		viper.SetDefault(key+"scopes", "openid,email,profile")
+		viper.BindEnv(key+"issuer", envPrefix+"ISSUER")
+		viper.BindEnv(key+"client_id", envPrefix+"CLIENT_ID")
+		viper.BindEnv(key+"client_secret", envPrefix+"CLIENT_SECRET")
+		viper.BindEnv(key+"redirect_url", envPrefix+"REDIRECT_URL")
+		viper.BindEnv(key+"scopes", envPrefix+"SCOPES")
+	}
+}
+
 // GetDSN returns PostgreSQL connection string
 func (c *Config) GetDSN() string {
 	return fmt.Sprintf(
@@ -131,3 +355,21 @@ func (c *Config) IsDevelopment() bool {
 func (c *Config) IsProduction() bool {
 	return c.Server.Env == "production"
 }
+
+// ResponseValidationMode returns the configured response validation mode
+// Responses are never validated in production
+func (c *Config) ResponseValidationMode() string {
+	if c.IsProduction() {
+		return "off"
+	}
+	if c.OpenAPI.ResponseValidation != "" {
+		return c.OpenAPI.ResponseValidation
+	}
+	switch c.Server.Env {
+	case "development":
+		return "log"
+	case "test":
+		return "fail"
+	}
+	return "off"
+}
@@ -0,0 +1,36 @@
+package constants
+
+// Permissions that can be granted to API keys and, as scopes, to OAuth client_credentials clients
+// Admins authenticated with a JWT implicitly hold every permission
+const (
+	PermissionUsersRead          = "users:read"
+	PermissionUsersWrite         = "users:write"
+	PermissionUsersImpersonate   = "users:impersonate"
+	PermissionUsersExport        = "users:export"
+	PermissionUsersImport        = "users:import"
+	PermissionAPIKeysManage      = "api_keys:manage"
+	PermissionOAuthClientsManage = "oauth_clients:manage"
+	PermissionAuditRead          = "audit:read"
+	PermissionInvitationsManage  = "invitations:manage"
+)
+
+var Permissions = []string{
+	PermissionUsersRead,
+	PermissionUsersWrite,
+	PermissionUsersImpersonate,
+	PermissionUsersExport,
+	PermissionUsersImport,
+	PermissionAPIKeysManage,
+	PermissionOAuthClientsManage,
+	PermissionAuditRead,
+	PermissionInvitationsManage,
+}
+
+func IsValidPermission(permission string) bool {
+	for _, p := range Permissions {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,17 @@
+package constants
+
+// Account statuses, matching the chk_users_status constraint
+const (
+	UserStatusActive    = "active"
+	UserStatusInactive  = "inactive"
+	UserStatusSuspended = "suspended"
+	UserStatusPending   = "pending"
+)
+
+func IsValidUserStatus(status string) bool {
+	switch status {
+	case UserStatusActive, UserStatusInactive, UserStatusSuspended, UserStatusPending:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,455 @@
+// Package msgpack encodes and decodes MessagePack (https://msgpack.org) through encoding/json:
+// values are marshalled to JSON and the JSON is transcoded, so json tags, omitempty and MarshalJSON
+// decide the shape exactly as they do for JSON responses. Only the JSON data model is supported;
+// on decoding, binary becomes a base64 string and the timestamp extension an RFC 3339 string.
+package msgpack
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"time"
+)
+
+// MIMEApplicationMsgpack is the media type registered for MessagePack
+const MIMEApplicationMsgpack = "application/msgpack"
+
+// maxDepth bounds nesting when decoding, so hostile input can't exhaust the stack
+const maxDepth = 1000
+
+// timestampExt is the extension type of the MessagePack timestamp
+const timestampExt = -1
+
+// Marshal returns the MessagePack encoding of v's JSON encoding
+func Marshal(v any) ([]byte, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return FromJSON(data)
+}
+
+// Unmarshal decodes MessagePack into v the way json.Unmarshal decodes the equivalent JSON
+func Unmarshal(data []byte, v any) error {
+	j, err := ToJSON(data)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(j, v)
+}
+
+// FromJSON transcodes a JSON document to MessagePack, keeping the order of object keys
+func FromJSON(data []byte) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var buf bytes.Buffer
+	if err := encodeJSONValue(&buf, dec); err != nil {
+		return nil, err
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, errors.New("msgpack: trailing data after JSON value")
+	}
+	return buf.Bytes(), nil
+}
+
+func encodeJSONValue(buf *bytes.Buffer, dec *json.Decoder) error {
+	token, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	switch t := token.(type) {
+	case json.Delim:
+		// Collection headers carry the element count, so elements are encoded first
+		var body bytes.Buffer
+		n := 0
+		for dec.More() {
+			if t == '{' {
+				if err := encodeJSONValue(&body, dec); err != nil { // the key
+					return err
+				}
+			}
+			if err := encodeJSONValue(&body, dec); err != nil {
+				return err
+			}
+			n++
+		}
+		if _, err := dec.Token(); err != nil { // the closing delimiter
+			return err
+		}
+		if t == '{' {
+			writeHeader(buf, n, 0x80, 0xde, 0xdf)
+		} else {
+			writeHeader(buf, n, 0x90, 0xdc, 0xdd)
+		}
+		buf.Write(body.Bytes())
+	case nil:
+		buf.WriteByte(0xc0)
+	case bool:
+		if t {
+			buf.WriteByte(0xc3)
+		} else {
+			buf.WriteByte(0xc2)
+		}
+	case string:
+		writeString(buf, t)
+	case json.Number:
+		return writeNumber(buf, t)
+	}
+	return nil
+}
+
+// writeHeader writes a map or array header: fix for up to 15 elements, then 16 or 32 bit lengths
+func writeHeader(buf *bytes.Buffer, n int, fix byte, code16 byte, code32 byte) {
+	switch {
+	case n < 16:
+		buf.WriteByte(fix | byte(n))
+	case n <= math.MaxUint16:
+		buf.WriteByte(code16)
+		buf.Write(binary.BigEndian.AppendUint16(nil, uint16(n)))
+	default:
+		buf.WriteByte(code32)
+		buf.Write(binary.BigEndian.AppendUint32(nil, uint32(n)))
+	}
+}
+
+func writeString(buf *bytes.Buffer, s string) {
+	n := len(s)
+	switch {
+	case n < 32:
+		buf.WriteByte(0xa0 | byte(n))
+	case n <= math.MaxUint8:
+		buf.WriteByte(0xd9)
+		buf.WriteByte(byte(n))
+	case n <= math.MaxUint16:
+		buf.WriteByte(0xda)
+		buf.Write(binary.BigEndian.AppendUint16(nil, uint16(n)))
+	default:
+		buf.WriteByte(0xdb)
+		buf.Write(binary.BigEndian.AppendUint32(nil, uint32(n)))
+	}
+	buf.WriteString(s)
+}
+
+// writeNumber writes integers in the smallest format that holds them, anything else as float64
+func writeNumber(buf *bytes.Buffer, n json.Number) error {
+	if i, err := strconv.ParseInt(n.String(), 10, 64); err == nil {
+		writeInt(buf, i)
+		return nil
+	}
+	if u, err := strconv.ParseUint(n.String(), 10, 64); err == nil {
+		buf.WriteByte(0xcf)
+		buf.Write(binary.BigEndian.AppendUint64(nil, u))
+		return nil
+	}
+	f, err := strconv.ParseFloat(n.String(), 64)
+	if err != nil {
+		return fmt.Errorf("msgpack: number %s: %w", n, err)
+	}
+	buf.WriteByte(0xcb)
+	buf.Write(binary.BigEndian.AppendUint64(nil, math.Float64bits(f)))
+	return nil
+}
+
+func writeInt(buf *bytes.Buffer, i int64) {
+	switch {
+	case i >= 0 && i <= math.MaxInt8:
+		buf.WriteByte(byte(i))
+	case i < 0 && i >= -32:
+		buf.WriteByte(byte(int8(i)))
+	case i >= 0 && i <= math.MaxUint8:
+		buf.WriteByte(0xcc)
+		buf.WriteByte(byte(i))
+	case i >= 0 && i <= math.MaxUint16:
+		buf.WriteByte(0xcd)
+		buf.Write(binary.BigEndian.AppendUint16(nil, uint16(i)))
+	case i >= 0 && i <= math.MaxUint32:
+		buf.WriteByte(0xce)
+		buf.Write(binary.BigEndian.AppendUint32(nil, uint32(i)))
+	case i >= 0:
+		buf.WriteByte(0xcf)
+		buf.Write(binary.BigEndian.AppendUint64(nil, uint64(i)))
+	case i >= math.MinInt8:
+		buf.WriteByte(0xd0)
+		buf.WriteByte(byte(int8(i)))
+	case i >= math.MinInt16:
+		buf.WriteByte(0xd1)
+		buf.Write(binary.BigEndian.AppendUint16(nil, uint16(int16(i))))
+	case i >= math.MinInt32:
+		buf.WriteByte(0xd2)
+		buf.Write(binary.BigEndian.AppendUint32(nil, uint32(int32(i))))
+	default:
+		buf.WriteByte(0xd3)
+		buf.Write(binary.BigEndian.AppendUint64(nil, uint64(i)))
+	}
+}
+
+// ToJSON transcodes one MessagePack value to JSON
+func ToJSON(data []byte) ([]byte, error) {
+	d := &decoder{data: data}
+	var buf bytes.Buffer
+	if err := d.value(&buf, 0, false); err != nil {
+		return nil, err
+	}
+	if d.pos != len(d.data) {
+		return nil, errors.New("msgpack: trailing data after value")
+	}
+	return buf.Bytes(), nil
+}
+
+type decoder struct {
+	data []byte
+	pos  int
+}
+
+var errShort = errors.New("msgpack: unexpected end of data")
+
+func (d *decoder) next(n int) ([]byte, error) {
+	if n < 0 || len(d.data)-d.pos < n {
+		return nil, errShort
+	}
+	b := d.data[d.pos : d.pos+n]
+	d.pos += n
+	return b, nil
+}
+
+// length reads a big-endian length of size bytes
+func (d *decoder) length(size int) (int, error) {
+	b, err := d.next(size)
+	if err != nil {
+		return 0, err
+	}
+	switch size {
+	case 1:
+		return int(b[0]), nil
+	case 2:
+		return int(binary.BigEndian.Uint16(b)), nil
+	default:
+		return int(binary.BigEndian.Uint32(b)), nil
+	}
+}
+
+// value writes the next value as JSON; key values must be strings (integers are formatted as strings,
+// like encoding/json does for integer map keys)
+func (d *decoder) value(buf *bytes.Buffer, depth int, key bool) error {
+	if depth > maxDepth {
+		return errors.New("msgpack: nesting too deep")
+	}
+	b, err := d.next(1)
+	if err != nil {
+		return err
+	}
+	code := b[0]
+	switch {
+	case code <= 0x7f:
+		return writeJSONInt(buf, strconv.FormatInt(int64(code), 10), key)
+	case code >= 0xe0:
+		return writeJSONInt(buf, strconv.FormatInt(int64(int8(code)), 10), key)
+	case code&0xe0 == 0xa0:
+		return d.str(buf, int(code&0x1f))
+	case code&0xf0 == 0x80:
+		return d.object(buf, int(code&0x0f), depth, key)
+	case code&0xf0 == 0x90:
+		return d.array(buf, int(code&0x0f), depth, key)
+	}
+
+	switch code {
+	case 0xd9, 0xda, 0xdb:
+		n, err := d.length(1 << (code - 0xd9))
+		if err != nil {
+			return err
+		}
+		return d.str(buf, n)
+	case 0xde, 0xdf:
+		n, err := d.length(2 << (code - 0xde))
+		if err != nil {
+			return err
+		}
+		return d.object(buf, n, depth, key)
+	case 0xdc, 0xdd:
+		n, err := d.length(2 << (code - 0xdc))
+		if err != nil {
+			return err
+		}
+		return d.array(buf, n, depth, key)
+	case 0xcc, 0xcd, 0xce, 0xcf:
+		v, err := d.next(1 << (code - 0xcc))
+		if err != nil {
+			return err
+		}
+		var u uint64
+		for _, c := range v {
+			u = u<<8 | uint64(c)
+		}
+		return writeJSONInt(buf, strconv.FormatUint(u, 10), key)
+	case 0xd0, 0xd1, 0xd2, 0xd3:
+		v, err := d.next(1 << (code - 0xd0))
+		if err != nil {
+			return err
+		}
+		var i int64
+		switch len(v) {
+		case 1:
+			i = int64(int8(v[0]))
+		case 2:
+			i = int64(int16(binary.BigEndian.Uint16(v)))
+		case 4:
+			i = int64(int32(binary.BigEndian.Uint32(v)))
+		default:
+			i = int64(binary.BigEndian.Uint64(v))
+		}
+		return writeJSONInt(buf, strconv.FormatInt(i, 10), key)
+	}
+
+	if key {
+		return fmt.Errorf("msgpack: map key of type 0x%02x is not a string", code)
+	}
+	switch code {
+	case 0xc0:
+		buf.WriteString("null")
+	case 0xc2:
+		buf.WriteString("false")
+	case 0xc3:
+		buf.WriteString("true")
+	case 0xca, 0xcb:
+		var f float64
+		if code == 0xca {
+			v, err := d.next(4)
+			if err != nil {
+				return err
+			}
+			f = float64(math.Float32frombits(binary.BigEndian.Uint32(v)))
+		} else {
+			v, err := d.next(8)
+			if err != nil {
+				return err
+			}
+			f = math.Float64frombits(binary.BigEndian.Uint64(v))
+		}
+		if math.IsNaN(f) || math.IsInf(f, 0) {
+			return errors.New("msgpack: NaN and infinity have no JSON representation")
+		}
+		buf.WriteString(strconv.FormatFloat(f, 'g', -1, 64))
+	case 0xc4, 0xc5, 0xc6:
+		n, err := d.length(1 << (code - 0xc4))
+		if err != nil {
+			return err
+		}
+		v, err := d.next(n)
+		if err != nil {
+			return err
+		}
+		return writeJSONString(buf, base64.StdEncoding.EncodeToString(v))
+	case 0xd4, 0xd5, 0xd6, 0xd7, 0xd8:
+		return d.ext(buf, 1<<(code-0xd4))
+	case 0xc7, 0xc8, 0xc9:
+		n, err := d.length(1 << (code - 0xc7))
+		if err != nil {
+			return err
+		}
+		return d.ext(buf, n)
+	default:
+		return fmt.Errorf("msgpack: invalid type code 0x%02x", code)
+	}
+	return nil
+}
+
+func (d *decoder) str(buf *bytes.Buffer, n int) error {
+	v, err := d.next(n)
+	if err != nil {
+		return err
+	}
+	return writeJSONString(buf, string(v))
+}
+
+func (d *decoder) object(buf *bytes.Buffer, n int, depth int, key bool) error {
+	if key {
+		return errors.New("msgpack: map key of type map is not a string")
+	}
+	buf.WriteByte('{')
+	for i := 0; i < n; i++ {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		if err := d.value(buf, depth+1, true); err != nil {
+			return err
+		}
+		buf.WriteByte(':')
+		if err := d.value(buf, depth+1, false); err != nil {
+			return err
+		}
+	}
+	buf.WriteByte('}')
+	return nil
+}
+
+func (d *decoder) array(buf *bytes.Buffer, n int, depth int, key bool) error {
+	if key {
+		return errors.New("msgpack: map key of type array is not a string")
+	}
+	buf.WriteByte('[')
+	for i := 0; i < n; i++ {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		if err := d.value(buf, depth+1, false); err != nil {
+			return err
+		}
+	}
+	buf.WriteByte(']')
+	return nil
+}
+
+// ext decodes the timestamp extension to an RFC 3339 string; other extension types are rejected
+func (d *decoder) ext(buf *bytes.Buffer, n int) error {
+	typ, err := d.next(1)
+	if err != nil {
+		return err
+	}
+	v, err := d.next(n)
+	if err != nil {
+		return err
+	}
+	if int8(typ[0]) != timestampExt {
+		return fmt.Errorf("msgpack: unsupported extension type %d", int8(typ[0]))
+	}
+	var t time.Time
+	switch n {
+	case 4:
+		t = time.Unix(int64(binary.BigEndian.Uint32(v)), 0)
+	case 8:
+		u := binary.BigEndian.Uint64(v)
+		t = time.Unix(int64(u&(1<<34-1)), int64(u>>34))
+	case 12:
+		t = time.Unix(int64(binary.BigEndian.Uint64(v[4:])), int64(binary.BigEndian.Uint32(v[:4])))
+	default:
+		return fmt.Errorf("msgpack: timestamp of %d bytes", n)
+	}
+	return writeJSONString(buf, t.UTC().Format(time.RFC3339Nano))
+}
+
+func writeJSONString(buf *bytes.Buffer, s string) error {
+	data, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+	buf.Write(data)
+	return nil
+}
+
+// writeJSONInt writes an integer, quoted when it is a map key
+func writeJSONInt(buf *bytes.Buffer, digits string, key bool) error {
+	if key {
+		buf.WriteByte('"')
+		buf.WriteString(digits)
+		buf.WriteByte('"')
+		return nil
+	}
+	buf.WriteString(digits)
+	return nil
+}
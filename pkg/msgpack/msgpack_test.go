@@ -0,0 +1,270 @@
+package msgpack
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+type sample struct {
+	ID        int               `json:"id"`
+	Name      string            `json:"name"`
+	Email     string            `json:"email,omitempty"`
+	Active    bool              `json:"active"`
+	Score     float64           `json:"score"`
+	Balance   int64             `json:"balance"`
+	Tags      []string          `json:"tags"`
+	Labels    map[string]string `json:"labels"`
+	Parent    *sample           `json:"parent"`
+	CreatedAt time.Time         `json:"created_at"`
+}
+
+func TestRoundTrip(t *testing.T) {
+	in := sample{
+		ID:        42,
+		Name:      "Nguyễn Văn An " + strings.Repeat("x", 300),
+		Active:    true,
+		Score:     -1.25,
+		Balance:   math.MinInt64,
+		Tags:      []string{"a", "", strings.Repeat("b", 70000)},
+		Labels:    map[string]string{"team": "core"},
+		Parent:    &sample{ID: 1, Balance: math.MaxInt64, Tags: []string{}},
+		CreatedAt: time.Date(2026, 10, 18, 9, 30, 0, 123456789, time.UTC),
+	}
+	data, err := Marshal(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out sample
+	if err := Unmarshal(data, &out); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(in, out) {
+		t.Errorf("round trip changed the value:\n got %+v\nwant %+v", out, in)
+	}
+}
+
+func TestJSONRoundTrip(t *testing.T) {
+	tests := []string{
+		`null`, `true`, `false`, `0`, `127`, `128`, `255`, `256`, `65535`, `65536`, `4294967295`, `4294967296`,
+		`9223372036854775807`, `18446744073709551615`,
+		`-1`, `-32`, `-33`, `-128`, `-129`, `-32768`, `-32769`, `-2147483648`, `-2147483649`, `-9223372036854775808`,
+		`0.5`, `-1e+300`, `""`, `"héllo"`, `[]`, `{}`,
+		`[1,"two",[3],{"four":4}]`,
+		`{"z":1,"a":2,"m":{"nested":[true,null]}}`,
+	}
+	for _, in := range tests {
+		data, err := FromJSON([]byte(in))
+		if err != nil {
+			t.Errorf("FromJSON(%s): %v", in, err)
+			continue
+		}
+		out, err := ToJSON(data)
+		if err != nil {
+			t.Errorf("ToJSON(FromJSON(%s)): %v", in, err)
+			continue
+		}
+		if string(out) != in {
+			t.Errorf("round trip of %s = %s", in, out)
+		}
+	}
+}
+
+func TestCollectionSizes(t *testing.T) {
+	// 15/16 and 65535/65536 elements cross the fix, 16-bit and 32-bit header boundaries
+	for _, n := range []int{15, 16, 65535, 65536} {
+		items := make([]int, n)
+		object := make(map[string]int, n)
+		for i := range items {
+			items[i] = i
+			object[strconv.Itoa(i)] = i
+		}
+		for _, in := range []any{items, object} {
+			data, err := Marshal(in)
+			if err != nil {
+				t.Fatal(err)
+			}
+			out := reflect.New(reflect.TypeOf(in))
+			if err := Unmarshal(data, out.Interface()); err != nil {
+				t.Fatalf("%d elements: %v", n, err)
+			}
+			if !reflect.DeepEqual(in, out.Elem().Interface()) {
+				t.Errorf("%T of %d elements changed in the round trip", in, n)
+			}
+		}
+	}
+}
+
+func TestEncoding(t *testing.T) {
+	// Encodings from the MessagePack specification
+	tests := []struct {
+		json string
+		want string
+	}{
+		{`null`, "c0"},
+		{`false`, "c2"},
+		{`true`, "c3"},
+		{`1`, "01"},
+		{`-1`, "ff"},
+		{`-33`, "d0df"},
+		{`200`, "ccc8"},
+		{`1000`, "cd03e8"},
+		{`70000`, "ce00011170"},
+		{`-1000`, "d1fc18"},
+		{`1.5`, "cb3ff8000000000000"},
+		{`"a"`, "a161"},
+		{`[1,2]`, "920102"},
+		{`{"a":1}`, "81a16101"},
+	}
+	for _, tt := range tests {
+		got, err := FromJSON([]byte(tt.json))
+		if err != nil {
+			t.Errorf("FromJSON(%s): %v", tt.json, err)
+			continue
+		}
+		if hex.EncodeToString(got) != tt.want {
+			t.Errorf("FromJSON(%s) = %x, want %s", tt.json, got, tt.want)
+		}
+	}
+}
+
+func TestDecoding(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want string
+	}{
+		{"float32", "ca3fc00000", `1.5`},
+		{"str8", "d903616263", `"abc"`},
+		{"str16", "da0003616263", `"abc"`},
+		{"str32", "db00000003616263", `"abc"`},
+		{"bin8 as base64", "c403010203", `"AQID"`},
+		{"array16", "dc000201c0", `[1,null]`},
+		{"map32", "df00000001a16101", `{"a":1}`},
+		{"uint64", "cfffffffffffffffff", `18446744073709551615`},
+		{"integer map key", "81 07 c3", `{"7":true}`},
+		{"negative integer map key", "81 d0 80 c2", `{"-128":false}`},
+		{"timestamp32", "d6ff 00000000", `"1970-01-01T00:00:00Z"`},
+		{"timestamp64", "d7ff 00000004 00000001", `"1970-01-01T00:00:01.000000001Z"`},
+		{"timestamp96", "c70cff 00000005 0000000000000002", `"1970-01-01T00:00:02.000000005Z"`},
+		{"escaped string", "a2225c", `"\"\\"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ToJSON(mustHex(t, tt.data))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("got %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMalformed(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+	}{
+		{"empty", ""},
+		{"reserved type code", "c1"},
+		{"trailing data", "c0c0"},
+		{"str8 longer than the data", "d905616263"},
+		{"str32 with a huge length", "dbffffffff61"},
+		{"array32 with a huge count", "ddffffffff01"},
+		{"map missing its value", "81a161"},
+		{"array key", "8190c0"},
+		{"map key", "8180c0"},
+		{"nil key", "81c0c0"},
+		{"float key", "81cb3ff8000000000000c0"},
+		{"NaN", "cb7ff8000000000000"},
+		{"infinity", "ca7f800000"},
+		{"unsupported extension", "d40101"},
+		{"timestamp of 2 bytes", "d5ff0000"},
+		{"ext8 without its type", "c701"},
+		{"bin16 truncated", "c50010"},
+		{"uint32 truncated", "ce0001"},
+		{"int64 truncated", "d3ffffffff"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got, err := ToJSON(mustHex(t, tt.data)); err == nil {
+				t.Errorf("got %s, want an error", got)
+			}
+		})
+	}
+}
+
+func TestTruncated(t *testing.T) {
+	data, err := Marshal(sample{
+		Name: "An", Tags: []string{"x", strings.Repeat("y", 300)}, Labels: map[string]string{"k": "v"},
+		Parent: &sample{ID: 70000, Score: 0.1}, Balance: -1 << 40,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Every proper prefix of a value is incomplete
+	for n := range len(data) {
+		if _, err := ToJSON(data[:n]); err == nil {
+			t.Errorf("%d of %d bytes decoded without an error", n, len(data))
+		}
+	}
+}
+
+func TestNestingTooDeep(t *testing.T) {
+	data := append(bytes.Repeat([]byte{0x91}, maxDepth+1), 0xc0)
+	if _, err := ToJSON(data); err == nil {
+		t.Error("nesting beyond maxDepth decoded without an error")
+	}
+	data = append(bytes.Repeat([]byte{0x91}, maxDepth), 0xc0)
+	if _, err := ToJSON(data); err != nil {
+		t.Errorf("nesting of maxDepth: %v", err)
+	}
+}
+
+func TestFromJSONErrors(t *testing.T) {
+	for _, in := range []string{``, `{`, `[1,`, `{"a"}`, `1 2`, `nul`} {
+		if _, err := FromJSON([]byte(in)); err == nil {
+			t.Errorf("FromJSON(%q) succeeded, want an error", in)
+		}
+	}
+	if _, err := Marshal(math.NaN()); err == nil {
+		t.Error("Marshal(NaN) succeeded, want an error")
+	}
+}
+
+func FuzzToJSON(f *testing.F) {
+	for _, seed := range []string{"c0", "81a16101", "dc000201c0", "d7ff0000000400000001", "dbffffffff61", "c1"} {
+		data, _ := hex.DecodeString(seed)
+		f.Add(data)
+	}
+	f.Fuzz(func(t *testing.T, data []byte) {
+		out, err := ToJSON(data)
+		if err != nil {
+			return
+		}
+		// Whatever decodes is valid JSON that encodes back to MessagePack
+		if !json.Valid(out) {
+			t.Fatalf("ToJSON(%x) = %s, which is not valid JSON", data, out)
+		}
+		if _, err := FromJSON(out); err != nil {
+			t.Fatalf("FromJSON(ToJSON(%x)): %v", data, err)
+		}
+	})
+}
+
+func mustHex(t *testing.T, s string) []byte {
+	t.Helper()
+	data, err := hex.DecodeString(strings.ReplaceAll(s, " ", ""))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
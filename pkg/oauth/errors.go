@@ -0,0 +1,95 @@
+package oauth
+
+import (
+	"net/http"
+	"net/url"
+)
+
+// Error codes from RFC 6749 section 4.1.2.1 and 5.2
+const (
+	ErrorInvalidRequest          = "invalid_request"
+	ErrorInvalidClient           = "invalid_client"
+	ErrorInvalidGrant            = "invalid_grant"
+	ErrorUnauthorizedClient      = "unauthorized_client"
+	ErrorUnsupportedGrantType    = "unsupported_grant_type"
+	ErrorUnsupportedResponseType = "unsupported_response_type"
+	ErrorInvalidScope            = "invalid_scope"
+	ErrorAccessDenied            = "access_denied"
+	ErrorServerError             = "server_error"
+)
+
+// Error is an OAuth 2.0 protocol error
+// Token endpoint errors are written as {"error", "error_description"} instead of the usual envelope,
+// because OAuth client libraries expect exactly that shape
+type Error struct {
+	Status      int    `json:"-"`
+	Code        string `json:"error"`
+	Description string `json:"error_description,omitempty"`
+	Err         error  `json:"-"`
+}
+
+func (e *Error) Error() string {
+	if e.Description == "" {
+		return e.Code
+	}
+	return e.Code + ": " + e.Description
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+func NewError(status int, code string, description string) *Error {
+	return &Error{Status: status, Code: code, Description: description}
+}
+
+func InvalidRequest(description string) *Error {
+	return NewError(http.StatusBadRequest, ErrorInvalidRequest, description)
+}
+
+// InvalidClient is returned with 401 as required when client authentication fails
+func InvalidClient(description string) *Error {
+	return NewError(http.StatusUnauthorized, ErrorInvalidClient, description)
+}
+
+func InvalidGrant(description string) *Error {
+	return NewError(http.StatusBadRequest, ErrorInvalidGrant, description)
+}
+
+func UnauthorizedClient(description string) *Error {
+	return NewError(http.StatusBadRequest, ErrorUnauthorizedClient, description)
+}
+
+func InvalidScope(description string) *Error {
+	return NewError(http.StatusBadRequest, ErrorInvalidScope, description)
+}
+
+func ServerError(err error) *Error {
+	return &Error{Status: http.StatusInternalServerError, Code: ErrorServerError, Err: err}
+}
+
+// RedirectURL reports the error to the client through its redirect URI (RFC 6749 4.1.2.1)
+func (e *Error) RedirectURL(redirectURI string, state string) string {
+	params := url.Values{"error": {e.Code}}
+	if e.Description != "" {
+		params.Set("error_description", e.Description)
+	}
+	if state != "" {
+		params.Set("state", state)
+	}
+	return AppendQuery(redirectURI, params)
+}
+
+// AppendQuery adds params to uri, keeping any query the client registered
+func AppendQuery(uri string, params url.Values) string {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return uri
+	}
+	query := u.Query()
+	for key, values := range params {
+		query[key] = values
+	}
+	u.RawQuery = query.Encode()
+	return u.String()
+}
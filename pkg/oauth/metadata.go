@@ -0,0 +1,52 @@
+package oauth
+
+import "strings"
+
+// Endpoint paths, relative to the issuer URL
+const (
+	PathAuthorize = "/oauth/authorize"
+	PathToken     = "/oauth/token"
+	PathRevoke    = "/oauth/revoke"
+	PathUserInfo  = "/oauth/userinfo"
+	PathJWKS      = "/oauth/jwks"
+	PathDiscovery = "/.well-known/openid-configuration"
+)
+
+// ServerMetadata is served at /.well-known/openid-configuration (OpenID Connect Discovery 1.0, RFC 8414)
+type ServerMetadata struct {
+	Issuer                            string   `json:"issuer"`
+	AuthorizationEndpoint             string   `json:"authorization_endpoint"`
+	TokenEndpoint                     string   `json:"token_endpoint"`
+	RevocationEndpoint                string   `json:"revocation_endpoint"`
+	UserinfoEndpoint                  string   `json:"userinfo_endpoint"`
+	JWKSURI                           string   `json:"jwks_uri"`
+	ScopesSupported                   []string `json:"scopes_supported"`
+	ResponseTypesSupported            []string `json:"response_types_supported"`
+	GrantTypesSupported               []string `json:"grant_types_supported"`
+	SubjectTypesSupported             []string `json:"subject_types_supported"`
+	IDTokenSigningAlgValuesSupported  []string `json:"id_token_signing_alg_values_supported"`
+	TokenEndpointAuthMethodsSupported []string `json:"token_endpoint_auth_methods_supported"`
+	CodeChallengeMethodsSupported     []string `json:"code_challenge_methods_supported"`
+	ClaimsSupported                   []string `json:"claims_supported"`
+}
+
+// NewServerMetadata describes the authorization server; scopes lists every scope clients may request
+func NewServerMetadata(issuer string, scopes []string) ServerMetadata {
+	issuer = strings.TrimSuffix(issuer, "/")
+	return ServerMetadata{
+		Issuer:                            issuer,
+		AuthorizationEndpoint:             issuer + PathAuthorize,
+		TokenEndpoint:                     issuer + PathToken,
+		RevocationEndpoint:                issuer + PathRevoke,
+		UserinfoEndpoint:                  issuer + PathUserInfo,
+		JWKSURI:                           issuer + PathJWKS,
+		ScopesSupported:                   scopes,
+		ResponseTypesSupported:            []string{"code"},
+		GrantTypesSupported:               GrantTypes,
+		SubjectTypesSupported:             []string{"public"},
+		IDTokenSigningAlgValuesSupported:  []string{"RS256"},
+		TokenEndpointAuthMethodsSupported: []string{"client_secret_basic", "client_secret_post", "none"},
+		CodeChallengeMethodsSupported:     []string{"S256"},
+		ClaimsSupported:                   []string{"iss", "sub", "aud", "exp", "iat", "nonce", "name", "email"},
+	}
+}
@@ -0,0 +1,46 @@
+package oauth
+
+import (
+	"slices"
+	"strings"
+)
+
+// OpenID Connect scopes understood by the authorization server
+const (
+	ScopeOpenID        = "openid"
+	ScopeProfile       = "profile"
+	ScopeEmail         = "email"
+	ScopeOfflineAccess = "offline_access"
+)
+
+var OpenIDScopes = []string{ScopeOpenID, ScopeProfile, ScopeEmail, ScopeOfflineAccess}
+
+// ParseScope splits a space-delimited scope string, dropping duplicates and keeping order
+func ParseScope(scope string) []string {
+	fields := strings.Fields(scope)
+	scopes := make([]string, 0, len(fields))
+	for _, s := range fields {
+		if !slices.Contains(scopes, s) {
+			scopes = append(scopes, s)
+		}
+	}
+	return scopes
+}
+
+func FormatScope(scopes []string) string {
+	return strings.Join(scopes, " ")
+}
+
+// IsSubset reports whether every requested scope is in allowed
+func IsSubset(requested []string, allowed []string) bool {
+	for _, s := range requested {
+		if !slices.Contains(allowed, s) {
+			return false
+		}
+	}
+	return true
+}
+
+func IsOpenIDScope(scope string) bool {
+	return slices.Contains(OpenIDScopes, scope)
+}
@@ -0,0 +1,77 @@
+package oauth
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"golang-echo/pkg/oidc"
+)
+
+// IDTokenSigner signs OpenID Connect ID tokens with RS256
+// Access tokens stay HS256 (utils.JWTManager) because only this API verifies them; ID tokens are verified
+// by client apps, which can't hold the HMAC secret, so they use a published asymmetric key
+type IDTokenSigner struct {
+	key *rsa.PrivateKey
+	kid string
+}
+
+// NewIDTokenSigner loads a PEM-encoded RSA private key (PKCS#1 or PKCS#8) from path
+// With an empty path an ephemeral key is generated, which is only suitable for development:
+// tokens stop verifying after a restart and replicas don't share the key
+func NewIDTokenSigner(path string) (*IDTokenSigner, error) {
+	if path == "" {
+		key, err := rsa.GenerateKey(rand.Reader, 2048)
+		if err != nil {
+			return nil, err
+		}
+		return newIDTokenSigner(key), nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read signing key: %w", err)
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("signing key: no PEM block found")
+	}
+
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return newIDTokenSigner(key), nil
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("parse signing key: %w", err)
+	}
+	key, ok := parsed.(*rsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("signing key: only RSA keys are supported")
+	}
+	return newIDTokenSigner(key), nil
+}
+
+func newIDTokenSigner(key *rsa.PrivateKey) *IDTokenSigner {
+	// Derive the kid from the public key so it changes whenever the key is rotated
+	sum := sha256.Sum256(x509.MarshalPKCS1PublicKey(&key.PublicKey))
+	return &IDTokenSigner{key: key, kid: base64.RawURLEncoding.EncodeToString(sum[:8])}
+}
+
+func (s *IDTokenSigner) Sign(claims jwt.Claims) (string, error) {
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = s.kid
+	return token.SignedString(s.key)
+}
+
+// JWKS returns the public key set published at the jwks_uri
+func (s *IDTokenSigner) JWKS() oidc.JSONWebKeySet {
+	return oidc.JSONWebKeySet{Keys: []oidc.JSONWebKey{oidc.NewRSAJSONWebKey(s.kid, &s.key.PublicKey)}}
+}
@@ -0,0 +1,65 @@
+package oauth
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"strings"
+
+	"golang-echo/pkg/oidc"
+)
+
+// Grant types supported by the token endpoint
+const (
+	GrantTypeAuthorizationCode = "authorization_code"
+	GrantTypeRefreshToken      = "refresh_token"
+	GrantTypeClientCredentials = "client_credentials"
+)
+
+var GrantTypes = []string{GrantTypeAuthorizationCode, GrantTypeRefreshToken, GrantTypeClientCredentials}
+
+// Prefixes make our opaque credentials recognisable (logs, secret scanners)
+const (
+	ClientIDPrefix          = "cl_"
+	ClientSecretPrefix      = "cs_"
+	AuthorizationCodePrefix = "ac_"
+	RefreshTokenPrefix      = "rt_"
+)
+
+// NewOpaqueToken returns prefix followed by 256 random bits
+func NewOpaqueToken(prefix string) (string, error) {
+	token, err := oidc.RandomToken(32)
+	if err != nil {
+		return "", err
+	}
+	return prefix + token, nil
+}
+
+// NewClientID returns a shorter, non-secret client identifier
+func NewClientID() (string, error) {
+	id, err := oidc.RandomToken(12)
+	if err != nil {
+		return "", err
+	}
+	return ClientIDPrefix + strings.ToLower(strings.NewReplacer("-", "", "_", "").Replace(id)), nil
+}
+
+// HashToken hashes an opaque token or client secret for storage
+// A fast hash is fine: the values are 256 random bits, not user-chosen passwords
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// VerifyTokenHash compares a token with a stored hash in constant time
+func VerifyTokenHash(token string, tokenHash string) bool {
+	return subtle.ConstantTimeCompare([]byte(HashToken(token)), []byte(tokenHash)) == 1
+}
+
+// VerifyCodeChallenge checks a PKCE code verifier against the S256 challenge sent to /authorize
+func VerifyCodeChallenge(codeVerifier string, codeChallenge string) bool {
+	if len(codeVerifier) < 43 || len(codeVerifier) > 128 {
+		return false
+	}
+	return oidc.CodeChallengeS256(codeVerifier) == codeChallenge
+}
@@ -0,0 +1,241 @@
+package oidc
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	testClientID = "web-app"
+	testNonce    = "nonce-123"
+	testKid      = "key-1"
+)
+
+// testIssuer is an OpenID provider serving discovery, a JWKS with one RSA key and a token
+// endpoint that answers with whatever ID token the test put in idToken
+type testIssuer struct {
+	server      *httptest.Server
+	key         *rsa.PrivateKey
+	idToken     string
+	jwksFetches atomic.Int32
+}
+
+func newTestIssuer(t *testing.T) *testIssuer {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	issuer := &testIssuer{key: key}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(DiscoveryDocument{
+			Issuer:                issuer.server.URL,
+			AuthorizationEndpoint: issuer.server.URL + "/authorize",
+			TokenEndpoint:         issuer.server.URL + "/token",
+			JWKSURI:               issuer.server.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		issuer.jwksFetches.Add(1)
+		json.NewEncoder(w).Encode(JSONWebKeySet{Keys: []JSONWebKey{NewRSAJSONWebKey(testKid, &key.PublicKey)}})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(tokenResponse{AccessToken: "access", IDToken: issuer.idToken, TokenType: "Bearer"})
+	})
+	issuer.server = httptest.NewServer(mux)
+	t.Cleanup(issuer.server.Close)
+	return issuer
+}
+
+func (i *testIssuer) provider() *Provider {
+	return NewProvider(ProviderConfig{
+		Name:        "test",
+		Issuer:      i.server.URL,
+		ClientID:    testClientID,
+		RedirectURL: "https://app.example.com/callback",
+	}, i.server.Client())
+}
+
+// claims are valid ID token claims for the test client, for a test to break one of
+func (i *testIssuer) claims() idTokenClaims {
+	now := time.Now()
+	return idTokenClaims{
+		Email:         "An@Example.com",
+		EmailVerified: true,
+		Name:          "Nguyễn Văn An",
+		Nonce:         testNonce,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    i.server.URL,
+			Subject:   "subject-1",
+			Audience:  jwt.ClaimStrings{testClientID},
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(5 * time.Minute)),
+		},
+	}
+}
+
+func sign(t *testing.T, method jwt.SigningMethod, kid string, key any, claims idTokenClaims) string {
+	t.Helper()
+	token := jwt.NewWithClaims(method, claims)
+	if kid != "" {
+		token.Header["kid"] = kid
+	}
+	signed, err := token.SignedString(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signed
+}
+
+func TestExchange(t *testing.T) {
+	issuer := newTestIssuer(t)
+	issuer.idToken = sign(t, jwt.SigningMethodRS256, testKid, issuer.key, issuer.claims())
+
+	identity, err := issuer.provider().Exchange(context.Background(), "code", "verifier", testNonce)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Identity{Provider: "test", Subject: "subject-1", Email: "an@example.com", EmailVerified: true, Name: "Nguyễn Văn An"}
+	if *identity != want {
+		t.Errorf("got %+v, want %+v", *identity, want)
+	}
+}
+
+func TestExchangeRejectsIDToken(t *testing.T) {
+	issuer := newTestIssuer(t)
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		// token returns the ID token the issuer answers with
+		token func(claims idTokenClaims) string
+		want  error
+	}{
+		{"bad signature", func(c idTokenClaims) string {
+			return sign(t, jwt.SigningMethodRS256, testKid, otherKey, c)
+		}, ErrInvalidIDToken},
+		{"tampered payload", func(c idTokenClaims) string {
+			parts := strings.Split(sign(t, jwt.SigningMethodRS256, testKid, issuer.key, c), ".")
+			c.Subject = "someone-else"
+			parts[1] = strings.Split(sign(t, jwt.SigningMethodRS256, testKid, issuer.key, c), ".")[1]
+			return strings.Join(parts, ".")
+		}, ErrInvalidIDToken},
+		{"unknown kid", func(c idTokenClaims) string {
+			return sign(t, jwt.SigningMethodRS256, "key-2", issuer.key, c)
+		}, ErrInvalidIDToken},
+		{"no kid", func(c idTokenClaims) string {
+			return sign(t, jwt.SigningMethodRS256, "", issuer.key, c)
+		}, ErrInvalidIDToken},
+		{"algorithm not allowed", func(c idTokenClaims) string {
+			return sign(t, jwt.SigningMethodHS256, testKid, []byte("secret"), c)
+		}, ErrInvalidIDToken},
+		{"EC key under the RSA kid", func(c idTokenClaims) string {
+			return sign(t, jwt.SigningMethodES256, testKid, ecKey, c)
+		}, ErrInvalidIDToken},
+		{"issuer mismatch", func(c idTokenClaims) string {
+			c.Issuer = "https://evil.example.com"
+			return sign(t, jwt.SigningMethodRS256, testKid, issuer.key, c)
+		}, ErrInvalidIDToken},
+		{"audience mismatch", func(c idTokenClaims) string {
+			c.Audience = jwt.ClaimStrings{"other-app"}
+			return sign(t, jwt.SigningMethodRS256, testKid, issuer.key, c)
+		}, ErrInvalidIDToken},
+		{"expired", func(c idTokenClaims) string {
+			c.ExpiresAt = jwt.NewNumericDate(time.Now().Add(-time.Minute))
+			return sign(t, jwt.SigningMethodRS256, testKid, issuer.key, c)
+		}, ErrInvalidIDToken},
+		{"no expiry", func(c idTokenClaims) string {
+			c.ExpiresAt = nil
+			return sign(t, jwt.SigningMethodRS256, testKid, issuer.key, c)
+		}, ErrInvalidIDToken},
+		{"no subject", func(c idTokenClaims) string {
+			c.Subject = ""
+			return sign(t, jwt.SigningMethodRS256, testKid, issuer.key, c)
+		}, ErrInvalidIDToken},
+		{"nonce mismatch", func(c idTokenClaims) string {
+			c.Nonce = "replayed"
+			return sign(t, jwt.SigningMethodRS256, testKid, issuer.key, c)
+		}, ErrNonceMismatch},
+		{"no id_token", func(c idTokenClaims) string {
+			return ""
+		}, ErrInvalidIDToken},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			issuer.idToken = tt.token(issuer.claims())
+			identity, err := issuer.provider().Exchange(context.Background(), "code", "verifier", testNonce)
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("got %+v, %v; want %v", identity, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestExpiryLeeway(t *testing.T) {
+	issuer := newTestIssuer(t)
+	claims := issuer.claims()
+	claims.ExpiresAt = jwt.NewNumericDate(time.Now().Add(-10 * time.Second))
+	issuer.idToken = sign(t, jwt.SigningMethodRS256, testKid, issuer.key, claims)
+
+	if _, err := issuer.provider().Exchange(context.Background(), "code", "verifier", testNonce); err != nil {
+		t.Errorf("token expired within the leeway: %v", err)
+	}
+}
+
+func TestUnknownKidRefetchLimited(t *testing.T) {
+	issuer := newTestIssuer(t)
+	provider := issuer.provider()
+	issuer.idToken = sign(t, jwt.SigningMethodRS256, "key-2", issuer.key, issuer.claims())
+
+	for range 3 {
+		if _, err := provider.Exchange(context.Background(), "code", "verifier", testNonce); !errors.Is(err, ErrInvalidIDToken) {
+			t.Fatalf("got %v, want %v", err, ErrInvalidIDToken)
+		}
+	}
+	// The first lookup fetches the JWKS; unknown kids within jwksRefreshInterval don't refetch it
+	if n := issuer.jwksFetches.Load(); n != 1 {
+		t.Errorf("JWKS fetched %d times, want 1", n)
+	}
+}
+
+func TestDiscoveryIssuerMismatch(t *testing.T) {
+	issuer := newTestIssuer(t)
+	provider := NewProvider(ProviderConfig{Name: "test", Issuer: issuer.server.URL + "/", ClientID: testClientID}, issuer.server.Client())
+	if _, err := provider.AuthCodeURL(context.Background(), "state", testNonce, "challenge"); err == nil {
+		t.Error("discovery accepted a document for another issuer")
+	}
+}
+
+func TestAuthCodeURL(t *testing.T) {
+	issuer := newTestIssuer(t)
+	got, err := issuer.provider().AuthCodeURL(context.Background(), "state-1", testNonce, "challenge")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, param := range []string{"client_id=web-app", "state=state-1", "nonce=" + testNonce, "code_challenge=challenge", "code_challenge_method=S256"} {
+		if !strings.Contains(got, param) {
+			t.Errorf("%s is missing %s", got, param)
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package oidc
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"errors"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+var ErrInvalidFlowState = errors.New("invalid or expired login state")
+
+// FlowState is the per-login data kept between /start and /callback
+// It travels in a signed, short-lived cookie, so no server-side session storage is needed
+type FlowState struct {
+	Provider     string `json:"provider"`
+	State        string `json:"state"`
+	Nonce        string `json:"nonce"`
+	CodeVerifier string `json:"code_verifier"`
+	jwt.RegisteredClaims
+}
+
+// StateCodec signs and verifies FlowState values
+type StateCodec struct {
+	secret []byte
+	ttl    time.Duration
+}
+
+// NewStateCodec derives its signing key from secret, so state cookies can't be replayed as access tokens
+func NewStateCodec(secret string, ttl time.Duration) *StateCodec {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte("oidc-flow-state"))
+	return &StateCodec{secret: mac.Sum(nil), ttl: ttl}
+}
+
+func (s *StateCodec) TTL() time.Duration {
+	return s.ttl
+}
+
+// NewFlowState creates a fresh state, nonce and PKCE code verifier for provider
+func (s *StateCodec) NewFlowState(provider string) (*FlowState, error) {
+	state, err := RandomToken(32)
+	if err != nil {
+		return nil, err
+	}
+	nonce, err := RandomToken(32)
+	if err != nil {
+		return nil, err
+	}
+	verifier, err := RandomToken(32)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	return &FlowState{
+		Provider:     provider,
+		State:        state,
+		Nonce:        nonce,
+		CodeVerifier: verifier,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   "oidc_flow", // distinguishes this token from access tokens signed with the same secret
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(s.ttl)),
+		},
+	}, nil
+}
+
+func (s *StateCodec) Encode(state *FlowState) (string, error) {
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, state).SignedString(s.secret)
+}
+
+func (s *StateCodec) Decode(value string) (*FlowState, error) {
+	var state FlowState
+	_, err := jwt.ParseWithClaims(value, &state, func(token *jwt.Token) (interface{}, error) {
+		return s.secret, nil
+	}, jwt.WithValidMethods([]string{"HS256"}), jwt.WithSubject("oidc_flow"), jwt.WithExpirationRequired())
+	if err != nil {
+		return nil, ErrInvalidFlowState
+	}
+	return &state, nil
+}
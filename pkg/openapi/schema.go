@@ -0,0 +1,404 @@
+package openapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schema is the subset of JSON Schema 2020-12 the generator produces
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 any                `json:"type,omitempty"` // a type name, or [name, "null"] for nullable values
+	Format               string             `json:"format,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Enum                 []any              `json:"enum,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	MinLength            *int               `json:"minLength,omitempty"`
+	MaxLength            *int               `json:"maxLength,omitempty"`
+	MinItems             *int               `json:"minItems,omitempty"`
+	MaxItems             *int               `json:"maxItems,omitempty"`
+	Minimum              *float64           `json:"minimum,omitempty"`
+	Maximum              *float64           `json:"maximum,omitempty"`
+	ExclusiveMinimum     *float64           `json:"exclusiveMinimum,omitempty"`
+	ExclusiveMaximum     *float64           `json:"exclusiveMaximum,omitempty"`
+	AllOf                []*Schema          `json:"allOf,omitempty"`
+	AnyOf                []*Schema          `json:"anyOf,omitempty"`
+	// Validate lists validator tags without a JSON Schema equivalent, e.g. vi_phone
+	Validate []string `json:"x-validate,omitempty"`
+}
+
+// Ref returns a schema referencing the component name
+func Ref(name string) *Schema {
+	return &Schema{Ref: "#/components/schemas/" + name}
+}
+
+// Nullable returns a schema that also accepts null
+func Nullable(s *Schema) *Schema {
+	if name, ok := s.Type.(string); ok && s.Ref == "" {
+		nullable := *s
+		nullable.Type = []string{name, "null"}
+		return &nullable
+	}
+	return &Schema{AnyOf: []*Schema{s, {Type: "null"}}}
+}
+
+// Mode decides which struct fields a generated object schema marks as required
+type Mode int
+
+const (
+	// Request objects require the fields whose validate tag has "required"
+	RequestMode Mode = iota
+	// Response objects require every field that is always serialised, i.e. has no omitempty
+	ResponseMode
+)
+
+var (
+	timeType       = reflect.TypeOf(time.Time{})
+	rawMessageType = reflect.TypeOf(json.RawMessage{})
+)
+
+// Generator derives schemas from Go types and collects named structs as components
+// Fields are named by their json tag (or the tag passed to Object/Parameters), and
+// go-playground/validator tags become constraints: required, min, max, len, gt, gte, lt, lte,
+// oneof, email, url and uuid; dive applies the tags after it to slice items
+type Generator struct {
+	schemas map[string]*Schema
+	names   map[reflect.Type]string
+}
+
+func NewGenerator() *Generator {
+	return &Generator{
+		schemas: make(map[string]*Schema),
+		names:   make(map[reflect.Type]string),
+	}
+}
+
+// Components returns every named schema generated so far
+func (g *Generator) Components() map[string]*Schema {
+	return g.schemas
+}
+
+// Define registers t as the component name and returns a reference to it
+// Use it for generic types, whose Go names aren't usable as component names
+func (g *Generator) Define(name string, t reflect.Type, mode Mode) (*Schema, error) {
+	t = indirect(t)
+	if existing, ok := g.names[t]; ok {
+		return Ref(existing), nil
+	}
+	if _, taken := g.schemas[name]; taken {
+		return nil, fmt.Errorf("openapi: schema name %q is already used by another type", name)
+	}
+	g.names[t] = name
+	g.schemas[name] = &Schema{} // placeholder for recursive types
+	schema, err := g.object(t, "json", mode)
+	if err != nil {
+		return nil, err
+	}
+	g.schemas[name] = schema
+	return Ref(name), nil
+}
+
+// Schema returns the schema for t; named structs are referenced as components
+func (g *Generator) Schema(t reflect.Type, mode Mode) (*Schema, error) {
+	return g.typeSchema(t, "json", mode)
+}
+
+// Object returns an inline request object schema for the struct t with fields named by tag, e.g. form
+func (g *Generator) Object(t reflect.Type, tag string) (*Schema, error) {
+	return g.object(indirect(t), tag, RequestMode)
+}
+
+// Parameters returns one parameter per field of the struct t carrying tag (e.g. query), located in
+func (g *Generator) Parameters(t reflect.Type, tag string, in string) ([]*Parameter, error) {
+	object, err := g.object(indirect(t), tag, RequestMode)
+	if err != nil {
+		return nil, err
+	}
+	params := make([]*Parameter, 0, len(object.Properties))
+	for _, name := range sortedKeys(object.Properties) {
+		params = append(params, &Parameter{
+			Name:     name,
+			In:       in,
+			Required: contains(object.Required, name),
+			Schema:   object.Properties[name],
+		})
+	}
+	return params, nil
+}
+
+func (g *Generator) typeSchema(t reflect.Type, tag string, mode Mode) (*Schema, error) {
+	if t.Kind() == reflect.Pointer {
+		schema, err := g.typeSchema(t.Elem(), tag, mode)
+		if err != nil {
+			return nil, err
+		}
+		return Nullable(schema), nil
+	}
+
+	switch t {
+	case timeType:
+		return &Schema{Type: "string", Format: "date-time"}, nil
+	case rawMessageType:
+		return &Schema{}, nil
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return &Schema{Type: "string"}, nil
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &Schema{Type: "integer", Format: "int32"}, nil
+	case reflect.Int64, reflect.Uint64:
+		return &Schema{Type: "integer", Format: "int64"}, nil
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}, nil
+	case reflect.Interface:
+		return &Schema{}, nil
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte"}, nil
+		}
+		items, err := g.typeSchema(t.Elem(), tag, mode)
+		if err != nil {
+			return nil, err
+		}
+		return &Schema{Type: "array", Items: items}, nil
+	case reflect.Map:
+		if t.Key().Kind() != reflect.String {
+			return nil, fmt.Errorf("openapi: map key of %s must be a string", t)
+		}
+		values, err := g.typeSchema(t.Elem(), tag, mode)
+		if err != nil {
+			return nil, err
+		}
+		return &Schema{Type: "object", AdditionalProperties: values}, nil
+	case reflect.Struct:
+		if t.Name() == "" {
+			return g.object(t, tag, mode)
+		}
+		if name, ok := g.names[t]; ok {
+			return Ref(name), nil
+		}
+		return g.Define(g.componentName(t), t, mode)
+	}
+	return nil, fmt.Errorf("openapi: unsupported type %s", t)
+}
+
+// componentName is the type's name, qualified with its package when another type already took it
+func (g *Generator) componentName(t reflect.Type) string {
+	name := t.Name()
+	if _, taken := g.schemas[name]; !taken {
+		return name
+	}
+	pkg := t.PkgPath()
+	if i := strings.LastIndex(pkg, "/"); i >= 0 {
+		pkg = pkg[i+1:]
+	}
+	return pkg + "." + name
+}
+
+func (g *Generator) object(t reflect.Type, tag string, mode Mode) (*Schema, error) {
+	if t.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("openapi: %s is not a struct", t)
+	}
+	object := &Schema{Type: "object", Properties: map[string]*Schema{}}
+	if err := g.addFields(object, t, tag, mode); err != nil {
+		return nil, err
+	}
+	return object, nil
+}
+
+func (g *Generator) addFields(object *Schema, t reflect.Type, tag string, mode Mode) error {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, opts, _ := strings.Cut(field.Tag.Get(tag), ",")
+		if name == "-" {
+			continue
+		}
+		// Embedded structs without a name of their own are flattened, like encoding/json and Echo's binder do
+		if field.Anonymous && name == "" && indirect(field.Type).Kind() == reflect.Struct {
+			if err := g.addFields(object, indirect(field.Type), tag, mode); err != nil {
+				return err
+			}
+			continue
+		}
+		if !field.IsExported() || name == "" && tag != "json" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		schema, err := g.typeSchema(field.Type, tag, mode)
+		if err != nil {
+			return fmt.Errorf("%s.%s: %w", t.Name(), field.Name, err)
+		}
+		required, err := applyValidateTag(schema, field.Type, field.Tag.Get("validate"))
+		if err != nil {
+			return fmt.Errorf("%s.%s: %w", t.Name(), field.Name, err)
+		}
+		// encoding/json writes nil slices and maps as null
+		if kind := field.Type.Kind(); mode == ResponseMode && (kind == reflect.Slice || kind == reflect.Map) {
+			schema = Nullable(schema)
+		}
+		object.Properties[name] = schema
+
+		switch mode {
+		case RequestMode:
+			if required {
+				object.Required = append(object.Required, name)
+			}
+		case ResponseMode:
+			if !strings.Contains(","+opts+",", ",omitempty,") {
+				object.Required = append(object.Required, name)
+			}
+		}
+	}
+	return nil
+}
+
+// applyValidateTag turns validator rules into schema constraints and reports whether the field is required
+func applyValidateTag(schema *Schema, t reflect.Type, tag string) (required bool, err error) {
+	if tag == "" || tag == "-" {
+		return false, nil
+	}
+	target, kind, diving := schema, indirect(t), false
+	for _, rule := range strings.Split(tag, ",") {
+		name, param, _ := strings.Cut(rule, "=")
+		if target.AnyOf != nil { // nullable value: constrain the non-null branch
+			target = target.AnyOf[0]
+		}
+		switch name {
+		case "required":
+			// validator's required rejects zero values, so a required string can't be empty
+			if kind.Kind() == reflect.String && target.MinLength == nil {
+				one := 1
+				target.MinLength = &one
+			}
+			required = required || !diving
+		case "omitempty":
+		case "dive":
+			if target.Items == nil {
+				return false, fmt.Errorf("dive on non-slice %s", t)
+			}
+			target, kind, diving = target.Items, indirect(kind.Elem()), true
+		case "email":
+			target.Format = "email"
+		case "url":
+			target.Format = "uri"
+		case "uuid":
+			target.Format = "uuid"
+		case "oneof":
+			for _, value := range strings.Fields(param) {
+				target.Enum = append(target.Enum, enumValue(kind, value))
+			}
+		case "min", "max", "len", "gt", "gte", "lt", "lte":
+			n, err := strconv.ParseFloat(param, 64)
+			if err != nil {
+				return false, fmt.Errorf("invalid %s value %q", name, param)
+			}
+			applyBound(target, kind, name, n)
+		default:
+			target.Validate = append(target.Validate, rule)
+		}
+	}
+	return required, nil
+}
+
+// applyBound maps a size rule onto length, item count or value, depending on the field's kind
+func applyBound(schema *Schema, kind reflect.Type, rule string, n float64) {
+	size := int(n)
+	switch kind.Kind() {
+	case reflect.String:
+		switch rule {
+		case "min", "gte":
+			schema.MinLength = &size
+		case "gt":
+			size++
+			schema.MinLength = &size
+		case "max", "lte":
+			schema.MaxLength = &size
+		case "lt":
+			size--
+			schema.MaxLength = &size
+		case "len":
+			schema.MinLength, schema.MaxLength = &size, &size
+		}
+	case reflect.Slice, reflect.Array, reflect.Map:
+		switch rule {
+		case "min", "gte":
+			schema.MinItems = &size
+		case "gt":
+			size++
+			schema.MinItems = &size
+		case "max", "lte":
+			schema.MaxItems = &size
+		case "lt":
+			size--
+			schema.MaxItems = &size
+		case "len":
+			schema.MinItems, schema.MaxItems = &size, &size
+		}
+	default:
+		switch rule {
+		case "min", "gte":
+			schema.Minimum = &n
+		case "gt":
+			schema.ExclusiveMinimum = &n
+		case "max", "lte":
+			schema.Maximum = &n
+		case "lt":
+			schema.ExclusiveMaximum = &n
+		case "len":
+			schema.Minimum, schema.Maximum = &n, &n
+		}
+	}
+}
+
+func enumValue(kind reflect.Type, value string) any {
+	switch kind.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if n, err := strconv.ParseInt(value, 10, 64); err == nil {
+			return n
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if n, err := strconv.ParseUint(value, 10, 64); err == nil {
+			return n
+		}
+	}
+	return value
+}
+
+func indirect(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return t
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
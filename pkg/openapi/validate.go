@@ -0,0 +1,365 @@
+package openapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"mime"
+	"net/mail"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// FieldError is one schema violation
+// Field is the path of the offending value, e.g. email, permissions[0] or data.user.id; messages follow
+// the wording of the validator's English translations so both sources of errors read alike
+type FieldError struct {
+	Field   string
+	Message string
+}
+
+// FieldErrorMap converts errs to the field → message map used by VALIDATION_FAILED responses
+func FieldErrorMap(errs []FieldError) map[string]string {
+	fields := make(map[string]string, len(errs))
+	for _, e := range errs {
+		if _, ok := fields[e.Field]; !ok {
+			fields[e.Field] = e.Message
+		}
+	}
+	return fields
+}
+
+// Resolve follows a $ref to the component schema it names
+func (d *Document) Resolve(s *Schema) (*Schema, error) {
+	for s != nil && s.Ref != "" {
+		name, ok := strings.CutPrefix(s.Ref, "#/components/schemas/")
+		if !ok {
+			return nil, fmt.Errorf("openapi: unsupported $ref %q", s.Ref)
+		}
+		target, ok := d.Components.Schemas[name]
+		if !ok {
+			return nil, fmt.Errorf("openapi: $ref %q not found", s.Ref)
+		}
+		s = target
+	}
+	return s, nil
+}
+
+// ValidateJSON decodes a JSON body and validates it against schema
+// A decoding error is returned as is; fields are named from the top of the body, as the validator does
+func (d *Document) ValidateJSON(schema *Schema, data []byte) ([]FieldError, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var value any
+	if err := decoder.Decode(&value); err != nil {
+		return nil, err
+	}
+	return d.ValidateValue(schema, value, "")
+}
+
+// ValidateValue validates a decoded JSON value (numbers as json.Number) against schema
+// field names the value in messages, "" for a whole body; x-validate rules are left to the handlers' validator
+func (d *Document) ValidateValue(schema *Schema, value any, field string) ([]FieldError, error) {
+	v := &valueValidator{doc: d}
+	if err := v.validate(schema, value, field); err != nil {
+		return nil, err
+	}
+	sort.SliceStable(v.errs, func(i, j int) bool { return v.errs[i].Field < v.errs[j].Field })
+	return v.errs, nil
+}
+
+// ParseParameter converts a path or query parameter to the JSON value its schema describes
+// Values that do not parse are returned as strings, so validating them reports the type mismatch
+func (d *Document) ParseParameter(schema *Schema, raw string) any {
+	resolved, err := d.Resolve(schema)
+	if err != nil || resolved == nil {
+		return raw
+	}
+	switch primaryType(resolved.Type) {
+	case "integer", "number":
+		if _, err := strconv.ParseFloat(raw, 64); err == nil {
+			return json.Number(raw)
+		}
+	case "boolean":
+		if b, err := strconv.ParseBool(raw); err == nil {
+			return b
+		}
+	}
+	return raw
+}
+
+// ValidateResponse checks a response to op: its status must be documented and its body must match
+// the schema of its JSON content type; tests can call it on recorded responses to catch contract drift
+func (d *Document) ValidateResponse(op *Operation, status int, contentType string, body []byte) []FieldError {
+	documented, ok := op.Responses[strconv.Itoa(status)]
+	if !ok {
+		return []FieldError{{Field: "status", Message: "status " + strconv.Itoa(status) + " is not documented"}}
+	}
+	if len(documented.Content) == 0 {
+		if len(body) > 0 {
+			return []FieldError{{Field: "body", Message: "body must be empty"}}
+		}
+		return nil
+	}
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	content, ok := documented.Content[mediaType]
+	if !ok {
+		return []FieldError{{Field: "content-type", Message: "content-type " + mediaType + " is not documented"}}
+	}
+	if content.Schema == nil || mediaType != "application/json" && !strings.HasSuffix(mediaType, "+json") {
+		return nil
+	}
+	errs, err := d.ValidateJSON(content.Schema, body)
+	if err != nil {
+		return []FieldError{{Field: "body", Message: "body must be valid JSON"}}
+	}
+	return errs
+}
+
+// TemplatePath converts Echo path parameters (:id) to OpenAPI ones ({id})
+func TemplatePath(path string) string {
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		if strings.HasPrefix(segment, ":") {
+			segments[i] = "{" + segment[1:] + "}"
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+type valueValidator struct {
+	doc  *Document
+	errs []FieldError
+}
+
+func (v *valueValidator) fail(field string, format string, args ...any) {
+	if field == "" {
+		field = "body"
+	}
+	v.errs = append(v.errs, FieldError{Field: field, Message: field + " " + fmt.Sprintf(format, args...)})
+}
+
+func (v *valueValidator) validate(schema *Schema, value any, field string) error {
+	schema, err := v.doc.Resolve(schema)
+	if err != nil || schema == nil {
+		return err
+	}
+
+	for _, part := range schema.AllOf {
+		if err := v.validate(part, value, field); err != nil {
+			return err
+		}
+	}
+	if len(schema.AnyOf) > 0 {
+		var firstErrs []FieldError
+		for i, option := range schema.AnyOf {
+			branch := &valueValidator{doc: v.doc}
+			if err := branch.validate(option, value, field); err != nil {
+				return err
+			}
+			if len(branch.errs) == 0 {
+				firstErrs = nil
+				break
+			}
+			if i == 0 {
+				firstErrs = branch.errs
+			}
+		}
+		v.errs = append(v.errs, firstErrs...)
+	}
+
+	if schema.Type != nil && !v.checkType(schema, value, field) {
+		return nil
+	}
+	if len(schema.Enum) > 0 && !enumContains(schema.Enum, value) {
+		options := make([]string, len(schema.Enum))
+		for i, option := range schema.Enum {
+			options[i] = fmt.Sprint(option)
+		}
+		v.fail(field, "must be one of [%s]", strings.Join(options, " "))
+	}
+
+	switch value := value.(type) {
+	case string:
+		v.validateString(schema, value, field)
+	case json.Number:
+		v.validateNumber(schema, value, field)
+	case []any:
+		if schema.MinItems != nil && len(value) < *schema.MinItems {
+			v.fail(field, "must contain at least %d item(s)", *schema.MinItems)
+		}
+		if schema.MaxItems != nil && len(value) > *schema.MaxItems {
+			v.fail(field, "must contain at maximum %d item(s)", *schema.MaxItems)
+		}
+		if schema.Items != nil {
+			for i, item := range value {
+				if err := v.validate(schema.Items, item, fmt.Sprintf("%s[%d]", field, i)); err != nil {
+					return err
+				}
+			}
+		}
+	case map[string]any:
+		for _, name := range schema.Required {
+			if _, ok := value[name]; !ok {
+				v.fail(join(field, name), "is a required field")
+			}
+		}
+		for _, name := range sortedKeys(value) {
+			property, ok := schema.Properties[name]
+			if !ok {
+				property = schema.AdditionalProperties
+			}
+			if property == nil {
+				continue
+			}
+			if err := v.validate(property, value[name], join(field, name)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// checkType reports whether value has one of the schema's types, recording an error if not
+func (v *valueValidator) checkType(schema *Schema, value any, field string) bool {
+	var types []string
+	switch t := schema.Type.(type) {
+	case string:
+		types = []string{t}
+	case []string:
+		types = t
+	}
+	for _, t := range types {
+		if hasType(t, schema.Format, value) {
+			return true
+		}
+	}
+	v.fail(field, "must be %s", article(strings.Join(types, " or ")))
+	return false
+}
+
+func hasType(t string, format string, value any) bool {
+	switch t {
+	case "null":
+		return value == nil
+	case "string":
+		_, ok := value.(string)
+		return ok
+	case "boolean":
+		_, ok := value.(bool)
+		return ok
+	case "number":
+		_, ok := value.(json.Number)
+		return ok
+	case "integer":
+		n, ok := value.(json.Number)
+		if !ok {
+			return false
+		}
+		i, err := strconv.ParseInt(string(n), 10, 64)
+		if err != nil {
+			return false
+		}
+		return format != "int32" || i >= math.MinInt32 && i <= math.MaxInt32
+	case "array":
+		_, ok := value.([]any)
+		return ok
+	case "object":
+		_, ok := value.(map[string]any)
+		return ok
+	}
+	return false
+}
+
+func (v *valueValidator) validateString(schema *Schema, value string, field string) {
+	length := len([]rune(value))
+	if schema.MinLength != nil && length < *schema.MinLength {
+		if *schema.MinLength == 1 {
+			v.fail(field, "is a required field")
+		} else {
+			v.fail(field, "must be at least %d characters in length", *schema.MinLength)
+		}
+	}
+	if schema.MaxLength != nil && length > *schema.MaxLength {
+		v.fail(field, "must be a maximum of %d characters in length", *schema.MaxLength)
+	}
+	if value == "" {
+		return // formats apply to non-empty values, like validator's omitempty
+	}
+	switch schema.Format {
+	case "email":
+		if addr, err := mail.ParseAddress(value); err != nil || addr.Address != value {
+			v.fail(field, "must be a valid email address")
+		}
+	case "uri":
+		if u, err := url.Parse(value); err != nil || u.Scheme == "" {
+			v.fail(field, "must be a valid URL")
+		}
+	case "date-time":
+		if _, err := time.Parse(time.RFC3339, value); err != nil {
+			v.fail(field, "must be a valid RFC 3339 date-time")
+		}
+	}
+}
+
+func (v *valueValidator) validateNumber(schema *Schema, value json.Number, field string) {
+	n, err := value.Float64()
+	if err != nil {
+		return
+	}
+	if schema.Minimum != nil && n < *schema.Minimum {
+		v.fail(field, "must be %s or greater", formatNumber(*schema.Minimum))
+	}
+	if schema.Maximum != nil && n > *schema.Maximum {
+		v.fail(field, "must be %s or less", formatNumber(*schema.Maximum))
+	}
+	if schema.ExclusiveMinimum != nil && n <= *schema.ExclusiveMinimum {
+		v.fail(field, "must be greater than %s", formatNumber(*schema.ExclusiveMinimum))
+	}
+	if schema.ExclusiveMaximum != nil && n >= *schema.ExclusiveMaximum {
+		v.fail(field, "must be less than %s", formatNumber(*schema.ExclusiveMaximum))
+	}
+}
+
+func enumContains(enum []any, value any) bool {
+	for _, option := range enum {
+		if fmt.Sprint(option) == fmt.Sprint(value) {
+			return true
+		}
+	}
+	return false
+}
+
+func primaryType(t any) string {
+	switch t := t.(type) {
+	case string:
+		return t
+	case []string:
+		if len(t) > 0 {
+			return t[0]
+		}
+	}
+	return ""
+}
+
+func article(noun string) string {
+	if strings.ContainsAny(noun[:1], "aeiou") {
+		return "an " + noun
+	}
+	return "a " + noun
+}
+
+func formatNumber(n float64) string {
+	return strconv.FormatFloat(n, 'f', -1, 64)
+}
+
+func join(parent string, name string) string {
+	if parent == "" {
+		return name
+	}
+	return parent + "." + name
+}
@@ -0,0 +1,263 @@
+package openapi
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func intPtr(n int) *int { return &n }
+
+func floatPtr(n float64) *float64 { return &n }
+
+// testDocument has a User component referenced from the other schemas
+func testDocument() *Document {
+	return &Document{
+		Components: Components{Schemas: map[string]*Schema{
+			"Role": {Type: "string", Enum: []any{"user", "admin"}},
+			"User": {
+				Type:     "object",
+				Required: []string{"id", "email", "role"},
+				Properties: map[string]*Schema{
+					"id":         {Type: "integer", Format: "int32", Minimum: floatPtr(1)},
+					"email":      {Type: "string", Format: "email"},
+					"name":       {Type: "string", MinLength: intPtr(2), MaxLength: intPtr(5)},
+					"role":       Ref("Role"),
+					"phone":      {Type: []string{"string", "null"}},
+					"created_at": {Type: "string", Format: "date-time"},
+					"tags":       {Type: "array", Items: &Schema{Type: "string"}, MaxItems: intPtr(2)},
+				},
+			},
+			"Page": {
+				Type:     "object",
+				Required: []string{"data"},
+				Properties: map[string]*Schema{
+					"data": {Type: "array", Items: Ref("User")},
+				},
+			},
+		}},
+	}
+}
+
+func TestValidateJSON(t *testing.T) {
+	doc := testDocument()
+	tests := []struct {
+		name   string
+		schema *Schema
+		body   string
+		want   []FieldError
+	}{
+		{
+			name:   "valid",
+			schema: Ref("User"),
+			body:   `{"id":1,"email":"an@example.com","name":"An","role":"admin","phone":null,"created_at":"2026-01-02T03:04:05Z","tags":["a"]}`,
+		},
+		{
+			name:   "missing required fields",
+			schema: Ref("User"),
+			body:   `{"name":"An"}`,
+			want: []FieldError{
+				{Field: "email", Message: "email is a required field"},
+				{Field: "id", Message: "id is a required field"},
+				{Field: "role", Message: "role is a required field"},
+			},
+		},
+		{
+			name:   "wrong types",
+			schema: Ref("User"),
+			body:   `{"id":"1","email":1,"role":"user","phone":2,"tags":"a"}`,
+			want: []FieldError{
+				{Field: "email", Message: "email must be a string"},
+				{Field: "id", Message: "id must be an integer"},
+				{Field: "phone", Message: "phone must be a string or null"},
+				{Field: "tags", Message: "tags must be an array"},
+			},
+		},
+		{
+			name:   "integer that is not whole",
+			schema: Ref("User"),
+			body:   `{"id":1.5,"email":"an@example.com","role":"user"}`,
+			want:   []FieldError{{Field: "id", Message: "id must be an integer"}},
+		},
+		{
+			name:   "int32 overflow",
+			schema: Ref("User"),
+			body:   `{"id":2147483648,"email":"an@example.com","role":"user"}`,
+			want:   []FieldError{{Field: "id", Message: "id must be an integer"}},
+		},
+		{
+			name:   "enum through $ref",
+			schema: Ref("User"),
+			body:   `{"id":1,"email":"an@example.com","role":"owner"}`,
+			want:   []FieldError{{Field: "role", Message: "role must be one of [user admin]"}},
+		},
+		{
+			name:   "constraints and formats",
+			schema: Ref("User"),
+			body:   `{"id":0,"email":"not an email","name":"Nguyen","role":"user","created_at":"yesterday","tags":["a","b","c"]}`,
+			want: []FieldError{
+				{Field: "created_at", Message: "created_at must be a valid RFC 3339 date-time"},
+				{Field: "email", Message: "email must be a valid email address"},
+				{Field: "id", Message: "id must be 1 or greater"},
+				{Field: "name", Message: "name must be a maximum of 5 characters in length"},
+				{Field: "tags", Message: "tags must contain at maximum 2 item(s)"},
+			},
+		},
+		{
+			name:   "nested $ref in array items",
+			schema: Ref("Page"),
+			body:   `{"data":[{"id":1,"email":"an@example.com","role":"user"},{"id":2,"role":"guest"}]}`,
+			want: []FieldError{
+				{Field: "data[1].email", Message: "data[1].email is a required field"},
+				{Field: "data[1].role", Message: "data[1].role must be one of [user admin]"},
+			},
+		},
+		{
+			name:   "whole body of the wrong type",
+			schema: Ref("User"),
+			body:   `[]`,
+			want:   []FieldError{{Field: "body", Message: "body must be an object"}},
+		},
+		{
+			name:   "unknown properties are allowed",
+			schema: Ref("User"),
+			body:   `{"id":1,"email":"an@example.com","role":"user","extra":true}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := doc.ValidateJSON(tt.schema, []byte(tt.body))
+			if err != nil {
+				t.Fatalf("ValidateJSON: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateJSONErrors(t *testing.T) {
+	doc := testDocument()
+	if _, err := doc.ValidateJSON(Ref("User"), []byte(`{"id":`)); err == nil {
+		t.Error("malformed JSON: want an error")
+	}
+	if _, err := doc.ValidateJSON(Ref("Missing"), []byte(`{}`)); err == nil {
+		t.Error("unknown $ref: want an error")
+	}
+	if _, err := doc.ValidateJSON(&Schema{Ref: "other.json#/User"}, []byte(`{}`)); err == nil {
+		t.Error("external $ref: want an error")
+	}
+}
+
+func TestValidateValue(t *testing.T) {
+	doc := testDocument()
+	tests := []struct {
+		name   string
+		schema *Schema
+		value  any
+		field  string
+		want   []FieldError
+	}{
+		{"number in range", &Schema{Type: "number", ExclusiveMinimum: floatPtr(0), Maximum: floatPtr(10)}, json.Number("10"), "limit", nil},
+		{"number below exclusive minimum", &Schema{Type: "number", ExclusiveMinimum: floatPtr(0)}, json.Number("0"), "limit",
+			[]FieldError{{Field: "limit", Message: "limit must be greater than 0"}}},
+		{"number above maximum", &Schema{Type: "number", Maximum: floatPtr(10)}, json.Number("10.5"), "limit",
+			[]FieldError{{Field: "limit", Message: "limit must be 10 or less"}}},
+		{"boolean", &Schema{Type: "boolean"}, true, "active", nil},
+		{"string for a boolean", &Schema{Type: "boolean"}, "yes", "active",
+			[]FieldError{{Field: "active", Message: "active must be a boolean"}}},
+		{"empty string with minLength 1", &Schema{Type: "string", MinLength: intPtr(1)}, "", "q",
+			[]FieldError{{Field: "q", Message: "q is a required field"}}},
+		{"empty string skips format", &Schema{Type: "string", Format: "email"}, "", "email", nil},
+		{"enum through $ref", Ref("Role"), "owner", "role",
+			[]FieldError{{Field: "role", Message: "role must be one of [user admin]"}}},
+		{"anyOf matching the second option", &Schema{AnyOf: []*Schema{{Type: "integer"}, {Type: "null"}}}, nil, "id", nil},
+		{"anyOf reports the first option", &Schema{AnyOf: []*Schema{{Type: "integer"}, {Type: "null"}}}, "1", "id",
+			[]FieldError{{Field: "id", Message: "id must be an integer"}}},
+		{"allOf applies every part", &Schema{AllOf: []*Schema{Ref("Role"), {MinLength: intPtr(5)}}}, "user", "role",
+			[]FieldError{{Field: "role", Message: "role must be at least 5 characters in length"}}},
+		{"additionalProperties", &Schema{Type: "object", AdditionalProperties: &Schema{Type: "integer"}},
+			map[string]any{"a": json.Number("1"), "b": "2"}, "counts",
+			[]FieldError{{Field: "counts.b", Message: "counts.b must be an integer"}}},
+		{"array of parsed query values", &Schema{Type: "array", Items: &Schema{Type: "integer"}},
+			[]any{json.Number("1"), "x"}, "ids",
+			[]FieldError{{Field: "ids[1]", Message: "ids[1] must be an integer"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := doc.ValidateValue(tt.schema, tt.value, tt.field)
+			if err != nil {
+				t.Fatalf("ValidateValue: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseParameter(t *testing.T) {
+	doc := testDocument()
+	tests := []struct {
+		schema *Schema
+		raw    string
+		want   any
+	}{
+		{&Schema{Type: "integer"}, "42", json.Number("42")},
+		{&Schema{Type: "integer"}, "forty", "forty"},
+		{&Schema{Type: "boolean"}, "true", true},
+		{&Schema{Type: "boolean"}, "maybe", "maybe"},
+		{Ref("Role"), "admin", "admin"},
+	}
+	for _, tt := range tests {
+		if got := doc.ParseParameter(tt.schema, tt.raw); got != tt.want {
+			t.Errorf("ParseParameter(%q) = %#v, want %#v", tt.raw, got, tt.want)
+		}
+	}
+}
+
+func TestValidateResponse(t *testing.T) {
+	doc := testDocument()
+	op := &Operation{Responses: map[string]*Response{
+		"200": {Content: map[string]MediaType{"application/json": {Schema: Ref("User")}}},
+		"204": {Description: "No Content"},
+		"400": {Content: map[string]MediaType{"application/problem+json": {Schema: &Schema{Type: "object", Required: []string{"title"}}}}},
+	}}
+	tests := []struct {
+		name        string
+		status      int
+		contentType string
+		body        string
+		want        []FieldError
+	}{
+		{"matching", 200, "application/json; charset=UTF-8", `{"id":1,"email":"an@example.com","role":"user"}`, nil},
+		{"schema drift", 200, "application/json", `{"id":1,"email":"an@example.com"}`,
+			[]FieldError{{Field: "role", Message: "role is a required field"}}},
+		{"undocumented status", 201, "application/json", `{}`,
+			[]FieldError{{Field: "status", Message: "status 201 is not documented"}}},
+		{"undocumented content type", 200, "application/xml", `<user/>`,
+			[]FieldError{{Field: "content-type", Message: "content-type application/xml is not documented"}}},
+		{"invalid JSON", 200, "application/json", `{`,
+			[]FieldError{{Field: "body", Message: "body must be valid JSON"}}},
+		{"empty response", 204, "", ``, nil},
+		{"body on an empty response", 204, "application/json", `{}`,
+			[]FieldError{{Field: "body", Message: "body must be empty"}}},
+		{"+json content type", 400, "application/problem+json", `{}`,
+			[]FieldError{{Field: "title", Message: "title is a required field"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := doc.ValidateResponse(op, tt.status, tt.contentType, []byte(tt.body))
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTemplatePath(t *testing.T) {
+	if got := TemplatePath("/api/v1/users/:id/sessions/:session_id"); got != "/api/v1/users/{id}/sessions/{session_id}" {
+		t.Errorf("TemplatePath = %q", got)
+	}
+}
@@ -0,0 +1,66 @@
+package request
+
+import (
+	"io"
+	"mime"
+	"net/http"
+
+	"golang-echo/pkg/msgpack"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Binder is Echo's DefaultBinder that also binds MessagePack bodies (Content-Type application/msgpack)
+// MessagePack is decoded like the equivalent JSON, so request structs keep their json tags
+type Binder struct {
+	echo.DefaultBinder
+}
+
+func NewBinder() *Binder {
+	return &Binder{}
+}
+
+func (b *Binder) Bind(i any, c echo.Context) error {
+	if !IsMessagePack(c.Request().Header.Get(echo.HeaderContentType)) {
+		return b.DefaultBinder.Bind(i, c)
+	}
+	// The same steps as DefaultBinder.Bind: path params, the query for bodiless methods, then the body
+	if err := b.BindPathParams(c, i); err != nil {
+		return err
+	}
+	method := c.Request().Method
+	if method == http.MethodGet || method == http.MethodDelete || method == http.MethodHead {
+		if err := b.BindQueryParams(c, i); err != nil {
+			return err
+		}
+	}
+	return b.BindBody(c, i)
+}
+
+func (b *Binder) BindBody(c echo.Context, i any) error {
+	req := c.Request()
+	if req.ContentLength == 0 || !IsMessagePack(req.Header.Get(echo.HeaderContentType)) {
+		return b.DefaultBinder.BindBody(c, i)
+	}
+	data, err := io.ReadAll(req.Body)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
+	}
+	if err := msgpack.Unmarshal(data, i); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
+	}
+	return nil
+}
+
+// IsMessagePack reports whether contentType is MessagePack, under its registered or a legacy name
+func IsMessagePack(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	switch mediaType {
+	case msgpack.MIMEApplicationMsgpack, "application/x-msgpack", "application/vnd.msgpack":
+		return true
+	}
+	return false
+}
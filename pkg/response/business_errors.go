@@ -99,6 +99,66 @@ func Conflict(key string, message string, err error) *AppError {
 	}
 }
 
+// Gone returns a 410 Gone error, for resources that existed but have expired
+func Gone(key string, message string, err error) *AppError {
+	return &AppError{
+		Code:    http.StatusGone,
+		Key:     key,
+		Message: message,
+		Err:     err,
+	}
+}
+
+// PayloadTooLarge returns a 413 Payload Too Large error
+func PayloadTooLarge(key string, message string, err error) *AppError {
+	return &AppError{
+		Code:    http.StatusRequestEntityTooLarge,
+		Key:     key,
+		Message: message,
+		Err:     err,
+	}
+}
+
+// UnsupportedMediaType returns a 415 Unsupported Media Type error, for a body in a format the route doesn't take
+func UnsupportedMediaType(key string, message string, err error) *AppError {
+	return &AppError{
+		Code:    http.StatusUnsupportedMediaType,
+		Key:     key,
+		Message: message,
+		Err:     err,
+	}
+}
+
+// PreconditionFailed returns a 412 Precondition Failed error, e.g. for an If-Match that no longer matches
+func PreconditionFailed(key string, message string, err error) *AppError {
+	return &AppError{
+		Code:    http.StatusPreconditionFailed,
+		Key:     key,
+		Message: message,
+		Err:     err,
+	}
+}
+
+// PreconditionRequired returns a 428 Precondition Required error, for updates sent without If-Match
+func PreconditionRequired(key string, message string, err error) *AppError {
+	return &AppError{
+		Code:    http.StatusPreconditionRequired,
+		Key:     key,
+		Message: message,
+		Err:     err,
+	}
+}
+
+// UnprocessableEntity returns a 422 Unprocessable Entity error, for a well-formed request that can't be carried out
+func UnprocessableEntity(key string, message string, err error) *AppError {
+	return &AppError{
+		Code:    http.StatusUnprocessableEntity,
+		Key:     key,
+		Message: message,
+		Err:     err,
+	}
+}
+
 // Internal returns a 500 Internal Server Error
 func Internal(err error) *AppError {
 	return &AppError{
@@ -0,0 +1,66 @@
+package response
+
+import (
+	"net/http"
+	"strings"
+)
+
+const (
+	HeaderETag        = "ETag"
+	HeaderIfMatch     = "If-Match"
+	HeaderIfNoneMatch = "If-None-Match"
+)
+
+// Tagged is implemented by resources with a strong entity tag (RFC 9110 8.8.3), e.g. derived from a version column
+// ETag returns the quoted tag, or "" when there is none
+type Tagged interface {
+	ETag() string
+}
+
+// NotModified reports whether a GET or HEAD request's If-None-Match matches etag
+// If-None-Match uses weak comparison, so W/"x" matches "x"
+func NotModified(r *http.Request, etag string) bool {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		return false
+	}
+	for _, tag := range ETagList(r.Header.Get(HeaderIfNoneMatch)) {
+		if tag == "*" || strings.TrimPrefix(tag, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// ETagList splits an If-Match or If-None-Match header into its entity tags, keeping "*" and W/ prefixes
+func ETagList(header string) []string {
+	var tags []string
+	for header = strings.TrimSpace(header); header != ""; header = strings.TrimLeft(header, " \t,") {
+		if header[0] == '*' {
+			tags = append(tags, "*")
+			header = header[1:]
+			continue
+		}
+		// An entity tag is an optional W/ and a quoted string, which may itself contain commas
+		start := 0
+		if strings.HasPrefix(header, "W/") {
+			start = 2
+		}
+		end := -1
+		if start < len(header) && header[start] == '"' {
+			if i := strings.IndexByte(header[start+1:], '"'); i >= 0 {
+				end = start + 1 + i + 1
+			}
+		}
+		if end < 0 {
+			// Not an entity tag; skip to the next list member
+			if i := strings.IndexByte(header, ','); i >= 0 {
+				header = header[i:]
+				continue
+			}
+			break
+		}
+		tags = append(tags, header[:end])
+		header = header[end:]
+	}
+	return tags
+}
@@ -0,0 +1,112 @@
+package response
+
+// MessageCatalog holds localised AppError messages keyed by locale, then by AppError.Key
+// Keys missing from a locale fall back to the message the AppError was created with
+type MessageCatalog struct {
+	messages map[string]map[string]string
+}
+
+// NewMessageCatalog creates an empty catalogue
+func NewMessageCatalog() *MessageCatalog {
+	return &MessageCatalog{messages: make(map[string]map[string]string)}
+}
+
+// DefaultMessageCatalog creates a catalogue with the built-in translations
+// English needs no entries because AppError messages are already written in English
+func DefaultMessageCatalog() *MessageCatalog {
+	catalog := NewMessageCatalog()
+	catalog.Add("vi", viMessages)
+	return catalog
+}
+
+// Add registers (or overrides) messages for a locale
+func (m *MessageCatalog) Add(locale string, messages map[string]string) {
+	if m.messages[locale] == nil {
+		m.messages[locale] = make(map[string]string, len(messages))
+	}
+	for key, message := range messages {
+		m.messages[locale][key] = message
+	}
+}
+
+// Message returns the message for key in locale, or fallback if there is none
+func (m *MessageCatalog) Message(locale string, key string, fallback string) string {
+	if m == nil {
+		return fallback
+	}
+	if message, ok := m.messages[locale][key]; ok {
+		return message
+	}
+	return fallback
+}
+
+var viMessages = map[string]string{
+	"SERVER_INTERNAL_ERROR":       "Lỗi máy chủ nội bộ",
+	"INTERNAL_SERVER_ERROR":       "Lỗi máy chủ nội bộ",
+	"BIND_ERROR":                  "Dữ liệu yêu cầu không hợp lệ",
+	"VALIDATION_FAILED":           "Dữ liệu không hợp lệ",
+	"INVALID_ID":                  "Định dạng ID không hợp lệ",
+	"INVALID_CONTEXT":             "Không tìm thấy thông tin người dùng trong phiên",
+	"USER_NOT_FOUND":              "Không tìm thấy người dùng",
+	"EMAIL_ALREADY_REGISTERED":    "Email đã được đăng ký",
+	"PHONE_ALREADY_REGISTERED":    "Số điện thoại đã được đăng ký",
+	"INVALID_PHONE":               "Định dạng số điện thoại không hợp lệ",
+	"INVALID_CREDENTIALS":         "Email hoặc mật khẩu không đúng",
+	"MISSING_TOKEN":               "Thiếu header Authorization",
+	"INVALID_TOKEN_FORMAT":        "Định dạng token không hợp lệ. Hãy dùng 'Bearer <token>'",
+	"INVALID_TOKEN":               "Token không hợp lệ hoặc đã hết hạn",
+	"ACCOUNT_SUSPENDED":           "Tài khoản của bạn đã bị tạm khóa",
+	"ACCOUNT_INACTIVE":            "Tài khoản của bạn đang bị vô hiệu hóa",
+	"ACCOUNT_PENDING":             "Tài khoản của bạn đang chờ kích hoạt",
+	"ACCOUNT_DISABLED":            "Tài khoản của bạn không thể sử dụng",
+	"CANNOT_CHANGE_OWN_STATUS":    "Bạn không thể thay đổi trạng thái tài khoản của chính mình",
+	"IMPERSONATION_FORBIDDEN":     "Không thể thực hiện thao tác này khi đang đăng nhập thay người dùng",
+	"CANNOT_IMPERSONATE_SELF":     "Bạn không thể đăng nhập thay chính mình",
+	"CANNOT_IMPERSONATE_ADMIN":    "Không thể đăng nhập thay tài khoản quản trị viên",
+	"USER_NOT_ACTIVE":             "Chỉ có thể đăng nhập thay người dùng đang hoạt động",
+	"TOKEN_REVOKED":               "Token không còn hiệu lực. Vui lòng đăng nhập lại",
+	"FORBIDDEN":                   "Bạn không có quyền truy cập tài nguyên này",
+	"INVALID_API_KEY":             "API key không hợp lệ",
+	"API_KEY_REVOKED":             "API key đã bị thu hồi",
+	"API_KEY_EXPIRED":             "API key đã hết hạn",
+	"API_KEY_NOT_FOUND":           "Không tìm thấy API key",
+	"INSUFFICIENT_PERMISSION":     "Thông tin xác thực không có quyền thực hiện thao tác này",
+	"OIDC_PROVIDER_NOT_FOUND":     "Không tìm thấy nhà cung cấp danh tính",
+	"OIDC_PROVIDER_UNAVAILABLE":   "Nhà cung cấp danh tính hiện không khả dụng",
+	"OIDC_INVALID_STATE":          "Phiên đăng nhập không hợp lệ hoặc đã hết hạn. Vui lòng thử lại",
+	"OIDC_MISSING_CODE":           "Thiếu mã xác thực",
+	"OIDC_EXCHANGE_FAILED":        "Không thể xác minh phản hồi từ nhà cung cấp danh tính",
+	"OIDC_EMAIL_NOT_VERIFIED":     "Nhà cung cấp danh tính không trả về email đã xác minh",
+	"OIDC_LOGIN_DENIED":           "Đăng nhập qua nhà cung cấp danh tính thất bại",
+	"OAUTH_INVALID_CLIENT":        "Ứng dụng OAuth không hợp lệ",
+	"OAUTH_INVALID_REDIRECT_URI":  "Redirect URI chưa được đăng ký cho ứng dụng này",
+	"OAUTH_CLIENT_NOT_FOUND":      "Không tìm thấy ứng dụng OAuth",
+	"CONSENT_NOT_FOUND":           "Không tìm thấy quyền đã cấp",
+	"SESSION_NOT_FOUND":           "Không tìm thấy phiên đăng nhập",
+	"SESSION_REVOKED":             "Phiên đăng nhập này đã bị đăng xuất",
+	"INSUFFICIENT_SCOPE":          "Access token không được cấp phạm vi openid",
+	"INVITATION_NOT_FOUND":        "Không tìm thấy lời mời",
+	"INVITATION_ALREADY_PENDING":  "Email này đã có một lời mời đang chờ",
+	"INVITATION_ALREADY_ACCEPTED": "Lời mời đã được chấp nhận",
+	"INVITATION_REVOKED":          "Lời mời đã bị thu hồi",
+	"INVITATION_EXPIRED":          "Lời mời đã hết hạn. Vui lòng yêu cầu lời mời mới",
+	"INVITATION_CLOSED":           "Lời mời đã được chấp nhận hoặc đã bị thu hồi",
+	"IMPORT_JOB_NOT_FOUND":        "Không tìm thấy tác vụ nhập dữ liệu",
+	"IMPORT_TOO_LARGE":            "Tệp nhập dữ liệu vượt quá dung lượng cho phép",
+	"UNSUPPORTED_IMPORT_FORMAT":   "Vui lòng gửi tệp dưới dạng text/csv hoặc application/x-ndjson",
+	"INVALID_IMPORT_FILE":         "Không thể đọc tệp nhập dữ liệu",
+	"EXPORT_JOB_NOT_FOUND":        "Không tìm thấy tác vụ xuất dữ liệu",
+	"EXPORT_NOT_READY":            "Tác vụ xuất dữ liệu chưa hoàn tất thành công; vui lòng kiểm tra trạng thái",
+	"EXPORT_EXPIRED":              "Tệp xuất dữ liệu đã hết hạn; vui lòng tạo lần xuất mới",
+	"MAIL_DELIVERY_FAILED":        "Không thể gửi email. Vui lòng thử lại sau",
+	"PRECONDITION_FAILED":         "Người dùng đã thay đổi kể từ lần đọc trước; vui lòng tải lại và thử lại",
+	"BATCH_NESTED":                "Lô yêu cầu không thể chứa một lô yêu cầu khác",
+	"BATCH_TOO_LARGE":             "Lô yêu cầu có quá nhiều yêu cầu",
+	"BATCH_UNSUPPORTED_RESPONSE":  "Phản hồi này không thể trả về trong lô yêu cầu; vui lòng gửi yêu cầu riêng",
+	"INVALID_IDEMPOTENCY_KEY":     "Idempotency-Key phải từ 1 đến 255 ký tự ASCII in được",
+	"IDEMPOTENCY_BODY_TOO_LARGE":  "Nội dung yêu cầu quá lớn để dùng với Idempotency-Key",
+	"IDEMPOTENCY_KEY_IN_USE":      "Một yêu cầu khác với cùng Idempotency-Key đang được xử lý; vui lòng thử lại sau",
+	"IDEMPOTENCY_KEY_REUSED":      "Idempotency-Key này đã được dùng cho một yêu cầu khác",
+	"PRECONDITION_REQUIRED":       "Vui lòng gửi ETag của người dùng trong header If-Match",
+	"RATE_LIMIT_EXCEEDED":         "Quá nhiều yêu cầu. Vui lòng thử lại sau.",
+}
@@ -0,0 +1,314 @@
+package response
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"golang-echo/pkg/msgpack"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Media types offered besides JSON
+const (
+	MIMETextCSV            = "text/csv"
+	MIMEApplicationNDJSON  = "application/x-ndjson"
+	MIMEApplicationMsgpack = msgpack.MIMEApplicationMsgpack
+)
+
+// Pagination headers, which carry PaginationMeta for the formats without an envelope (CSV and NDJSON)
+const (
+	HeaderTotalCount = "X-Total-Count"
+	HeaderPage       = "X-Page"
+	HeaderPageSize   = "X-Page-Size"
+	HeaderTotalPages = "X-Total-Pages"
+)
+
+// mediaTypeAliases are the other names clients send for offered media types
+var mediaTypeAliases = map[string]string{
+	"application/x-msgpack":   MIMEApplicationMsgpack,
+	"application/vnd.msgpack": MIMEApplicationMsgpack,
+	"application/ndjson":      MIMEApplicationNDJSON,
+	"application/jsonl":       MIMEApplicationNDJSON,
+}
+
+var (
+	singleOffers = []string{echo.MIMEApplicationJSON, MIMEApplicationMsgpack, MIMEApplicationNDJSON}
+	listOffers   = []string{echo.MIMEApplicationJSON, MIMEApplicationMsgpack, MIMETextCSV, MIMEApplicationNDJSON}
+	errorOffers  = []string{echo.MIMEApplicationJSON, MIMEApplicationMsgpack}
+)
+
+// Negotiate returns the offer the request's Accept header prefers (RFC 9110 section 12.5.1), the
+// earlier offer on a tie; without an Accept header that is the first one. It returns "" when Accept
+// rules out every offer
+func Negotiate(r *http.Request, offers ...string) string {
+	header := r.Header.Values(echo.HeaderAccept)
+	if len(header) == 0 {
+		return offers[0]
+	}
+	type mediaRange struct {
+		typ, subtype string
+		q            float64
+	}
+	var ranges []mediaRange
+	for _, part := range strings.Split(strings.Join(header, ","), ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		if alias, ok := mediaTypeAliases[mediaType]; ok {
+			mediaType = alias
+		}
+		q := 1.0
+		if raw, ok := params["q"]; ok {
+			if q, err = strconv.ParseFloat(raw, 64); err != nil {
+				continue
+			}
+		}
+		typ, subtype, _ := strings.Cut(mediaType, "/")
+		ranges = append(ranges, mediaRange{typ, subtype, q})
+	}
+
+	best, bestQ := "", 0.0
+	for _, offer := range offers {
+		typ, subtype, _ := strings.Cut(offer, "/")
+		// The most specific matching range sets the offer's quality
+		q, specificity := 0.0, -1
+		for _, r := range ranges {
+			s := -1
+			switch {
+			case r.typ == typ && r.subtype == subtype:
+				s = 2
+			case r.typ == typ && r.subtype == "*":
+				s = 1
+			case r.typ == "*" && r.subtype == "*":
+				s = 0
+			}
+			if s > specificity {
+				q, specificity = r.q, s
+			}
+		}
+		if q > bestQ {
+			best, bestQ = offer, q
+		}
+	}
+	return best
+}
+
+// Send sends v as JSON or, when the client prefers it, MessagePack
+// The error handler uses it for ErrorResponse: errors are never CSV or NDJSON rows
+func Send(c echo.Context, status int, v any) error {
+	c.Response().Header().Add(echo.HeaderVary, echo.HeaderAccept)
+	if Negotiate(c.Request(), errorOffers...) == MIMEApplicationMsgpack {
+		return MessagePack(c, status, v)
+	}
+	return c.JSON(status, v)
+}
+
+// MessagePack sends v encoded as MessagePack
+func MessagePack(c echo.Context, status int, v any) error {
+	data, err := msgpack.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return c.Blob(status, MIMEApplicationMsgpack, data)
+}
+
+// sendSingle sends an envelope around one resource: JSON, MessagePack, or the bare data as a line of NDJSON
+func sendSingle(c echo.Context, status int, envelope any, data any) error {
+	c.Response().Header().Add(echo.HeaderVary, echo.HeaderAccept)
+	switch Negotiate(c.Request(), singleOffers...) {
+	case MIMEApplicationMsgpack:
+		return MessagePack(c, status, envelope)
+	case MIMEApplicationNDJSON:
+		return streamItems(c, status, MIMEApplicationNDJSON, []any{data})
+	}
+	return c.JSON(status, envelope)
+}
+
+// sendList sends a list envelope: JSON, MessagePack, or its items as CSV or NDJSON rows,
+// with the pagination in headers
+func sendList(c echo.Context, status int, envelope any, data any, pagination *PaginationMeta) error {
+	c.Response().Header().Add(echo.HeaderVary, echo.HeaderAccept)
+	switch format := Negotiate(c.Request(), listOffers...); format {
+	case MIMEApplicationMsgpack:
+		return MessagePack(c, status, envelope)
+	case MIMETextCSV, MIMEApplicationNDJSON:
+		items, ok := listItems(data)
+		if !ok {
+			break
+		}
+		if pagination != nil {
+			header := c.Response().Header()
+			header.Set(HeaderTotalCount, strconv.FormatInt(pagination.TotalItems, 10))
+			header.Set(HeaderPage, strconv.Itoa(pagination.Page))
+			header.Set(HeaderPageSize, strconv.Itoa(pagination.PageSize))
+			header.Set(HeaderTotalPages, strconv.Itoa(pagination.TotalPages))
+		}
+		return streamItems(c, status, format, items)
+	}
+	return c.JSON(status, envelope)
+}
+
+// listItems returns the elements of a slice
+func listItems(data any) ([]any, bool) {
+	v := reflect.ValueOf(data)
+	if v.Kind() != reflect.Slice {
+		return nil, false
+	}
+	items := make([]any, v.Len())
+	for i := range items {
+		items[i] = v.Index(i).Interface()
+	}
+	return items, true
+}
+
+func streamItems(c echo.Context, status int, format string, items []any) error {
+	res := c.Response()
+	var encoder ItemEncoder
+	if format == MIMETextCSV {
+		res.Header().Set(echo.HeaderContentType, MIMETextCSV+"; charset=utf-8")
+		encoder = NewCSVEncoder(res)
+	} else {
+		res.Header().Set(echo.HeaderContentType, MIMEApplicationNDJSON)
+		encoder = NewNDJSONEncoder(res)
+	}
+	res.WriteHeader(status)
+	for _, item := range items {
+		if err := encoder.Encode(item); err != nil {
+			return err
+		}
+	}
+	if err := encoder.Flush(); err != nil {
+		return err
+	}
+	res.Flush()
+	return nil
+}
+
+// ItemEncoder writes list items one at a time, for responses streamed as they are read
+type ItemEncoder interface {
+	Encode(item any) error
+	// Flush writes anything buffered to the underlying writer
+	Flush() error
+}
+
+// NDJSONEncoder writes each item's JSON encoding on a line of its own
+type NDJSONEncoder struct {
+	enc *json.Encoder
+}
+
+func NewNDJSONEncoder(w io.Writer) *NDJSONEncoder {
+	return &NDJSONEncoder{enc: json.NewEncoder(w)}
+}
+
+func (e *NDJSONEncoder) Encode(item any) error {
+	return e.enc.Encode(item) // json.Encoder ends every value with a newline
+}
+
+func (e *NDJSONEncoder) Flush() error {
+	return nil
+}
+
+// CSVEncoder writes items as CSV rows, with a header row of the first item's JSON field names
+// Later items are written under the same columns; nested objects and arrays become JSON text,
+// null an empty cell
+type CSVEncoder struct {
+	w       *csv.Writer
+	columns []string
+}
+
+func NewCSVEncoder(w io.Writer) *CSVEncoder {
+	return &CSVEncoder{w: csv.NewWriter(w)}
+}
+
+// WriteHeader writes the header row before any item, for lists that may be empty; Encode then
+// fills these columns instead of the first item's
+func (e *CSVEncoder) WriteHeader(columns []string) error {
+	if e.columns != nil {
+		return errors.New("response: CSV header already written")
+	}
+	e.columns = columns
+	return e.w.Write(columns)
+}
+
+func (e *CSVEncoder) Encode(item any) error {
+	data, err := json.Marshal(item)
+	if err != nil {
+		return err
+	}
+	names, values, err := jsonFields(data)
+	if err != nil {
+		return err
+	}
+	if e.columns == nil {
+		if err := e.WriteHeader(names); err != nil {
+			return err
+		}
+	}
+	row := make([]string, len(e.columns))
+	for i, column := range e.columns {
+		row[i] = csvCell(values[column])
+	}
+	return e.w.Write(row)
+}
+
+func (e *CSVEncoder) Flush() error {
+	e.w.Flush()
+	return e.w.Error()
+}
+
+// jsonFields splits a JSON object into its keys, in order, and raw values; any other value is
+// a single field named "value"
+func jsonFields(data []byte) ([]string, map[string]json.RawMessage, error) {
+	if len(data) == 0 || data[0] != '{' {
+		return []string{"value"}, map[string]json.RawMessage{"value": data}, nil
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	if _, err := dec.Token(); err != nil {
+		return nil, nil, err
+	}
+	var names []string
+	values := map[string]json.RawMessage{}
+	for dec.More() {
+		token, err := dec.Token()
+		if err != nil {
+			return nil, nil, err
+		}
+		name, _ := token.(string)
+		var value json.RawMessage
+		if err := dec.Decode(&value); err != nil {
+			return nil, nil, err
+		}
+		names = append(names, name)
+		values[name] = value
+	}
+	return names, values, nil
+}
+
+// csvCell formats a JSON value for a CSV cell. Strings a spreadsheet would run as a formula
+// get a leading quote (OWASP CSV injection guidance)
+func csvCell(value json.RawMessage) string {
+	if len(value) == 0 || string(value) == "null" {
+		return ""
+	}
+	if value[0] != '"' {
+		return string(value)
+	}
+	var s string
+	if err := json.Unmarshal(value, &s); err != nil {
+		return string(value)
+	}
+	if s != "" && strings.ContainsRune("=+-@\t\r", rune(s[0])) {
+		return "'" + s
+	}
+	return s
+}
@@ -0,0 +1,78 @@
+package response
+
+import (
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+// MIMEApplicationProblemJSON is the media type defined by RFC 9457
+const MIMEApplicationProblemJSON = "application/problem+json"
+
+// ProblemTypeBaseURI is the prefix used to build stable problem type URIs from AppError keys
+// Example: "EMAIL_ALREADY_REGISTERED" → "/problems/email-already-registered"
+var ProblemTypeBaseURI = "/problems/"
+
+// ProblemDetails represents an RFC 9457 problem details object
+// Code, RequestID and Errors are extension members that mirror ErrorResponse
+type ProblemDetails struct {
+	Type      string            `json:"type"`
+	Title     string            `json:"title"`
+	Status    int               `json:"status"`
+	Detail    string            `json:"detail,omitempty"`
+	Instance  string            `json:"instance,omitempty"`
+	Code      string            `json:"code"`
+	RequestID string            `json:"request_id,omitempty"`
+	Errors    map[string]string `json:"errors,omitempty"`
+}
+
+// ProblemTypeURI maps an AppError key to its stable problem type URI
+func ProblemTypeURI(key string) string {
+	if key == "" {
+		return "about:blank"
+	}
+	return ProblemTypeBaseURI + strings.ToLower(strings.ReplaceAll(key, "_", "-"))
+}
+
+// ToProblemDetails converts ErrorResponse to ProblemDetails for the given status and request
+func (e ErrorResponse) ToProblemDetails(status int, c echo.Context) ProblemDetails {
+	return ProblemDetails{
+		Type:      ProblemTypeURI(e.Code),
+		Title:     http.StatusText(status),
+		Status:    status,
+		Detail:    e.Message,
+		Instance:  c.Request().URL.RequestURI(),
+		Code:      e.Code,
+		RequestID: e.RequestID,
+		Errors:    e.Errors,
+	}
+}
+
+// WantsProblemJSON reports whether the client explicitly asked for application/problem+json
+// Clients that don't mention it keep receiving the default ErrorResponse envelope
+func WantsProblemJSON(c echo.Context) bool {
+	for _, part := range strings.Split(c.Request().Header.Get(echo.HeaderAccept), ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil || mediaType != MIMEApplicationProblemJSON {
+			continue
+		}
+		// q=0 means "not acceptable"
+		if q, ok := params["q"]; ok {
+			if weight, err := strconv.ParseFloat(q, 64); err == nil && weight == 0 {
+				continue
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// Problem sends a problem details response with the RFC 9457 content type
+func Problem(c echo.Context, problem ProblemDetails) error {
+	// c.JSON keeps an explicitly set Content-Type
+	c.Response().Header().Set(echo.HeaderContentType, MIMEApplicationProblemJSON)
+	return c.JSON(problem.Status, problem)
+}
@@ -51,45 +51,69 @@ func (e *AppError) ToErrorResponse(c echo.Context) ErrorResponse {
 	}
 }
 
+// The envelope helpers below negotiate the format on Accept: JSON by default, MessagePack, and
+// NDJSON; lists can also be CSV. CSV and NDJSON leave out the envelope and send the data only
+
 // Success returns a 200 OK success response with data
+// Resources implementing Tagged get an ETag header, and GET requests whose If-None-Match
+// matches it are answered with 304 Not Modified instead
 func Success[T any](c echo.Context, code string, message string, data T) error {
-	return c.JSON(http.StatusOK, Response[T]{
+	if tagged, ok := any(data).(Tagged); ok {
+		if etag := tagged.ETag(); etag != "" {
+			c.Response().Header().Set(HeaderETag, etag)
+			if NotModified(c.Request(), etag) {
+				return c.NoContent(http.StatusNotModified)
+			}
+		}
+	}
+	return sendSingle(c, http.StatusOK, Response[T]{
 		Code:      code,
 		Message:   message,
 		Data:      data,
 		RequestID: c.Response().Header().Get(echo.HeaderXRequestID),
-	})
+	}, data)
 }
 
 // Created returns a 201 Created success response with data
 func Created[T any](c echo.Context, code string, message string, data T) error {
-	return c.JSON(http.StatusCreated, Response[T]{
+	return sendSingle(c, http.StatusCreated, Response[T]{
+		Code:      code,
+		Message:   message,
+		Data:      data,
+		RequestID: c.Response().Header().Get(echo.HeaderXRequestID),
+	}, data)
+}
+
+// Accepted returns a 202 Accepted response with data, typically a job that finishes later
+func Accepted[T any](c echo.Context, code string, message string, data T) error {
+	return sendSingle(c, http.StatusAccepted, Response[T]{
 		Code:      code,
 		Message:   message,
 		Data:      data,
 		RequestID: c.Response().Header().Get(echo.HeaderXRequestID),
-	})
+	}, data)
 }
 
 // List returns a 200 OK list response without pagination
 func List[T any](c echo.Context, code string, message string, data T) error {
-	return c.JSON(http.StatusOK, ListResponse[T]{
+	return sendList(c, http.StatusOK, ListResponse[T]{
 		Code:      code,
 		Message:   message,
 		Data:      data,
 		RequestID: c.Response().Header().Get(echo.HeaderXRequestID),
-	})
+	}, data, nil)
 }
 
 // ListWithPagination returns a 200 OK list response with pagination
+// CSV and NDJSON carry the pagination in the X-Total-Count, X-Page, X-Page-Size and X-Total-Pages headers
 func ListWithPagination[T any](c echo.Context, code string, message string, data T, pagination *PaginationMeta) error {
-	return c.JSON(http.StatusOK, ListResponse[T]{
+	return sendList(c, http.StatusOK, ListResponse[T]{
 		Code:       code,
 		Message:    message,
 		Data:       data,
 		Pagination: pagination,
 		RequestID:  c.Response().Header().Get(echo.HeaderXRequestID),
-	})
+	}, data, pagination)
 }
 
 // NoContent returns a 204 No Content response
@@ -0,0 +1,51 @@
+package utils
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"strings"
+)
+
+// APIKeyPrefix marks a string as one of our API keys (helps secret scanners too)
+const APIKeyPrefix = "ak_"
+
+// GenerateAPIKey creates a new API key of the form ak_<8 hex>.<secret>
+// The prefix (ak_<8 hex>) is stored in clear for lookup and display; only the secret's hash is stored
+func GenerateAPIKey() (key string, prefix string, secretHash string, err error) {
+	id := make([]byte, 4)
+	if _, err := rand.Read(id); err != nil {
+		return "", "", "", err
+	}
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", "", "", err
+	}
+
+	prefix = APIKeyPrefix + hex.EncodeToString(id)
+	encodedSecret := base64.RawURLEncoding.EncodeToString(secret)
+	return prefix + "." + encodedSecret, prefix, HashAPIKeySecret(encodedSecret), nil
+}
+
+// ParseAPIKey splits a raw key into its prefix and secret
+func ParseAPIKey(key string) (prefix string, secret string, ok bool) {
+	prefix, secret, ok = strings.Cut(key, ".")
+	if !ok || !strings.HasPrefix(prefix, APIKeyPrefix) || secret == "" {
+		return "", "", false
+	}
+	return prefix, secret, true
+}
+
+// HashAPIKeySecret hashes an API key secret
+// A fast hash is fine here: the secret is 256 random bits, not a user-chosen password
+func HashAPIKeySecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+// VerifyAPIKeySecret compares a secret with a stored hash in constant time
+func VerifyAPIKeySecret(secret string, secretHash string) bool {
+	return subtle.ConstantTimeCompare([]byte(HashAPIKeySecret(secret)), []byte(secretHash)) == 1
+}
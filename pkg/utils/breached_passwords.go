@@ -0,0 +1,68 @@
+package utils
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// BreachedPasswordChecker reports whether a password is known to have been exposed in a breach
+type BreachedPasswordChecker interface {
+	IsBreached(password string) bool
+}
+
+// hashPrefixLength is the k-anonymity range size used by Have I Been Pwned
+const hashPrefixLength = 5
+
+// BreachedPasswordList is a local, offline k-anonymity breached-password corpus
+// The file uses the Pwned Passwords format: one upper-case SHA-1 hash per line,
+// optionally followed by ":<count>". Blank lines and lines starting with # are ignored
+// Hashes are bucketed by their 5-character prefix exactly like the HIBP range API,
+// so a lookup only ever touches the bucket for its own prefix
+type BreachedPasswordList struct {
+	ranges map[string]map[string]struct{}
+}
+
+// NewBreachedPasswordList loads a breached-password hash list from path
+func NewBreachedPasswordList(path string) (*BreachedPasswordList, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("open breached password list: %w", err)
+	}
+	defer file.Close()
+
+	list := &BreachedPasswordList{ranges: make(map[string]map[string]struct{})}
+	scanner := bufio.NewScanner(file)
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		hash, _, _ := strings.Cut(line, ":")
+		hash = strings.ToUpper(hash)
+		if len(hash) != sha1.Size*2 {
+			return nil, fmt.Errorf("breached password list line %d: invalid SHA-1 hash", lineNo)
+		}
+		prefix, suffix := hash[:hashPrefixLength], hash[hashPrefixLength:]
+		if list.ranges[prefix] == nil {
+			list.ranges[prefix] = make(map[string]struct{})
+		}
+		list.ranges[prefix][suffix] = struct{}{}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read breached password list: %w", err)
+	}
+
+	return list, nil
+}
+
+// IsBreached reports whether password's SHA-1 hash is in the list
+func (l *BreachedPasswordList) IsBreached(password string) bool {
+	sum := sha1.Sum([]byte(password))
+	hash := strings.ToUpper(hex.EncodeToString(sum[:]))
+	_, found := l.ranges[hash[:hashPrefixLength]][hash[hashPrefixLength:]]
+	return found
+}
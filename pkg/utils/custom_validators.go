@@ -2,8 +2,11 @@ package utils
 
 import (
 	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
 
-	ut "github.com/go-playground/universal-translator"
 	"github.com/go-playground/validator/v10"
 )
 
@@ -13,33 +16,170 @@ import (
 //   - +84912345678 (international format)
 //   - 84912345678 (without +)
 func RegisterVietnamesePhoneValidator(cv *CustomValidator) error {
-	// Vietnamese phone number patterns
-	viPhoneRegex := regexp.MustCompile(`^(0|\+84|84)(3[2-9]|5[689]|7[06-9]|8[1-9]|9[0-9])\d{7}$`)
-
-	err := cv.validator.RegisterValidation("vi_phone", func(fl validator.FieldLevel) bool {
+	return cv.RegisterCustomValidator("vi_phone", func(fl validator.FieldLevel) bool {
 		phone := fl.Field().String()
 		if phone == "" {
 			return true // Let 'required' tag handle empty values
 		}
 		return viPhoneRegex.MatchString(phone)
+	}, map[string]string{
+		"en": "{0} must be a valid Vietnamese phone number",
+		"vi": "{0} phải là số điện thoại Việt Nam hợp lệ",
 	})
+}
 
-	if err != nil {
-		return err
+// viProvinceCodes are the 3-digit province/city codes that prefix a 12-digit CCCD
+var viProvinceCodes = map[string]bool{
+	"001": true, "002": true, "004": true, "006": true, "008": true, "010": true, "011": true,
+	"012": true, "014": true, "015": true, "017": true, "019": true, "020": true, "022": true,
+	"024": true, "025": true, "026": true, "027": true, "030": true, "031": true, "033": true,
+	"034": true, "035": true, "036": true, "037": true, "038": true, "040": true, "042": true,
+	"044": true, "045": true, "046": true, "048": true, "049": true, "051": true, "052": true,
+	"054": true, "056": true, "058": true, "060": true, "062": true, "064": true, "066": true,
+	"067": true, "068": true, "070": true, "072": true, "074": true, "075": true, "077": true,
+	"079": true, "080": true, "082": true, "083": true, "084": true, "086": true, "087": true,
+	"089": true, "091": true, "092": true, "093": true, "094": true, "095": true, "096": true,
+}
+
+var (
+	digitsRegex       = regexp.MustCompile(`^\d+$`)
+	viTaxCodeRegex    = regexp.MustCompile(`^(\d{10})(?:-?(\d{3}))?$`)
+	viNapasBINRegex   = regexp.MustCompile(`^9704\d{2}$`)
+	viPostalCodeRegex = regexp.MustCompile(`^[1-9]\d{4}$`)
+)
+
+// IsValidVietnameseCitizenID validates a citizen ID number
+//   - CCCD, 12 digits: PPP G YY NNNNNN
+//     PPP = province code, G = gender/century digit, YY = last two digits of birth year
+//     G: 0/1 → 1900s, 2/3 → 2000s, 4/5 → 2100s, 6/7 → 2200s, 8/9 → 2300s (even = male, odd = female)
+//     The resulting birth year must not be in the future
+//   - CMND (legacy), 9 digits not starting with 00
+func IsValidVietnameseCitizenID(id string) bool {
+	if !digitsRegex.MatchString(id) {
+		return false
 	}
 
-	// Register translation for Vietnamese phone validator
-	return cv.validator.RegisterTranslation(
-		"vi_phone",
-		cv.translator,
-		func(ut ut.Translator) error {
-			return ut.Add("vi_phone", "{0} must be a valid Vietnamese phone number", true)
-		},
-		func(ut ut.Translator, fe validator.FieldError) string {
-			t, _ := ut.T("vi_phone", fe.Field())
-			return t
-		},
-	)
+	switch len(id) {
+	case 9:
+		return !strings.HasPrefix(id, "00")
+	case 12:
+		if !viProvinceCodes[id[:3]] {
+			return false
+		}
+		century := 1900 + int(id[3]-'0')/2*100
+		yy, _ := strconv.Atoi(id[4:6])
+		return century+yy <= time.Now().Year()
+	default:
+		return false
+	}
+}
+
+// IsValidVietnameseTaxCode validates an MST (mã số thuế)
+//   - 10 digits for the parent organisation/individual, where the 10th digit is a checksum
+//   - 13 digits for a dependent branch: the parent's 10 digits followed by a 001-999 suffix,
+//     written either as 0123456789-001 or 0123456789001
+func IsValidVietnameseTaxCode(code string) bool {
+	matches := viTaxCodeRegex.FindStringSubmatch(code)
+	if matches == nil {
+		return false
+	}
+	if matches[2] == "000" {
+		return false
+	}
+
+	// Weighted checksum over the first 9 digits
+	weights := [9]int{31, 29, 23, 19, 17, 13, 7, 5, 3}
+	sum := 0
+	for i, w := range weights {
+		sum += int(matches[1][i]-'0') * w
+	}
+	return 10-sum%11 == int(matches[1][9]-'0')
+}
+
+// IsValidVietnameseBankAccount validates a domestic bank account number (6-19 digits)
+func IsValidVietnameseBankAccount(account string) bool {
+	return len(account) >= 6 && len(account) <= 19 && digitsRegex.MatchString(account)
+}
+
+// IsValidNapasBIN validates a 6-digit NAPAS bank identification number (9704xx)
+func IsValidNapasBIN(bin string) bool {
+	return viNapasBINRegex.MatchString(bin)
+}
+
+// IsValidVietnamesePostalCode validates a 5-digit postal code (2018 national postal code scheme)
+func IsValidVietnamesePostalCode(code string) bool {
+	return viPostalCodeRegex.MatchString(code)
+}
+
+// IsValidVietnameseName validates a personal name
+// Accepts Latin letters including every Vietnamese diacritic (precomposed or combining),
+// separated by single spaces. Digits, punctuation and leading/trailing spaces are rejected
+func IsValidVietnameseName(name string) bool {
+	if name == "" || strings.TrimSpace(name) != name || strings.Contains(name, "  ") {
+		return false
+	}
+	for _, r := range name {
+		switch {
+		case r == ' ':
+		case unicode.Is(unicode.Latin, r):
+		case unicode.Is(unicode.Mn, r): // combining diacritics from NFD input
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// stringRule adapts a string predicate to validator.Func, leaving empty values to 'required'
+func stringRule(valid func(string) bool) validator.Func {
+	return func(fl validator.FieldLevel) bool {
+		value := fl.Field().String()
+		if value == "" {
+			return true // Let 'required' tag handle empty values
+		}
+		return valid(value)
+	}
+}
+
+// RegisterVietnameseIdentityValidators registers Vietnam-specific identity, tax, banking and address rules
+func RegisterVietnameseIdentityValidators(cv *CustomValidator) error {
+	rules := []struct {
+		tag      string
+		valid    func(string) bool
+		messages map[string]string
+	}{
+		{"vi_citizen_id", IsValidVietnameseCitizenID, map[string]string{
+			"en": "{0} must be a valid Vietnamese citizen ID (12-digit CCCD or 9-digit CMND)",
+			"vi": "{0} phải là số CCCD (12 số) hoặc CMND (9 số) hợp lệ",
+		}},
+		{"vi_tax_code", IsValidVietnameseTaxCode, map[string]string{
+			"en": "{0} must be a valid Vietnamese tax code (10 digits, or 13 digits for a branch)",
+			"vi": "{0} phải là mã số thuế hợp lệ (10 số, hoặc 13 số đối với chi nhánh)",
+		}},
+		{"vi_bank_account", IsValidVietnameseBankAccount, map[string]string{
+			"en": "{0} must be a valid bank account number (6-19 digits)",
+			"vi": "{0} phải là số tài khoản ngân hàng hợp lệ (6-19 chữ số)",
+		}},
+		{"vi_napas_bin", IsValidNapasBIN, map[string]string{
+			"en": "{0} must be a valid NAPAS bank BIN (9704xx)",
+			"vi": "{0} phải là mã BIN ngân hàng NAPAS hợp lệ (9704xx)",
+		}},
+		{"vi_postal_code", IsValidVietnamesePostalCode, map[string]string{
+			"en": "{0} must be a valid Vietnamese postal code (5 digits)",
+			"vi": "{0} phải là mã bưu chính hợp lệ (5 chữ số)",
+		}},
+		{"vi_name", IsValidVietnameseName, map[string]string{
+			"en": "{0} may only contain letters (including Vietnamese diacritics) and single spaces",
+			"vi": "{0} chỉ được chứa chữ cái (có dấu tiếng Việt) và khoảng trắng đơn",
+		}},
+	}
+
+	for _, rule := range rules {
+		if err := cv.RegisterCustomValidator(rule.tag, stringRule(rule.valid), rule.messages); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 // RegisterAllCustomValidators registers all custom validators at once
@@ -49,5 +189,10 @@ func (cv *CustomValidator) RegisterAllCustomValidators() error {
 		return err
 	}
 
+	// Register Vietnamese identity, tax, banking and address validators
+	if err := RegisterVietnameseIdentityValidators(cv); err != nil {
+		return err
+	}
+
 	return nil
 }
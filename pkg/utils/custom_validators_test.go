@@ -0,0 +1,261 @@
+package utils
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestIsValidVietnameseCitizenID(t *testing.T) {
+	// The 12-digit form born next year, in the century digit of the current one
+	year := time.Now().Year() + 1
+	nextYear := fmt.Sprintf("001%d%02d123456", (year-1900)/100*2, year%100)
+
+	tests := []struct {
+		name string
+		id   string
+		want bool
+	}{
+		{"CCCD born in the 1900s, male", "001090123456", true},
+		{"CCCD born in the 1900s, female", "079185123456", true},
+		{"CCCD born in the 2000s", "079205123456", true},
+		{"CCCD born in the 2000s, female", "096301123456", true},
+		{"CCCD born in the future", nextYear, false},
+		{"CCCD born in the 2100s", "001400123456", false},
+		{"CCCD born in the 2300s", "001999123456", false},
+		{"CCCD with an unassigned province code", "003090123456", false},
+		{"CCCD with province code 000", "000090123456", false},
+		{"CCCD with a province code above 096", "097090123456", false},
+		{"CMND", "123456789", true},
+		{"CMND starting with a single 0", "012345678", true},
+		{"CMND starting with 00", "001234567", false},
+		{"10 digits", "0123456789", false},
+		{"11 digits", "00109012345", false},
+		{"13 digits", "0010901234567", false},
+		{"letters", "00109012345A", false},
+		{"spaces", "001 090 123456", false},
+		{"empty", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsValidVietnameseCitizenID(tt.id); got != tt.want {
+				t.Errorf("IsValidVietnameseCitizenID(%q) = %v, want %v", tt.id, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsValidVietnameseTaxCode(t *testing.T) {
+	tests := []struct {
+		name string
+		code string
+		want bool
+	}{
+		{"10 digits", "0100100015", true},
+		{"10 digits with check digit 0", "0300123450", true},
+		{"10 digits with check digit 3", "1234567893", true},
+		{"wrong check digit", "0100100016", false},
+		{"wrong check digit 0", "1234567890", false},
+		// A weighted sum divisible by 11 needs a check digit of 10, so no code is valid
+		{"no possible check digit", "0000000000", false},
+		{"branch with a dash", "0100100015-001", true},
+		{"branch without a dash", "0100100015001", true},
+		{"branch 999", "0100100015-999", true},
+		{"branch 000", "0100100015-000", false},
+		{"branch 000 without a dash", "0100100015000", false},
+		{"branch of an invalid parent", "0100100016-001", false},
+		{"branch with a 2-digit suffix", "0100100015-01", false},
+		{"branch with a 4-digit suffix", "0100100015-0001", false},
+		{"9 digits", "010010001", false},
+		{"11 digits", "01001000151", false},
+		{"letters", "01001000A5", false},
+		{"empty", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsValidVietnameseTaxCode(tt.code); got != tt.want {
+				t.Errorf("IsValidVietnameseTaxCode(%q) = %v, want %v", tt.code, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsValidVietnameseBankAccount(t *testing.T) {
+	tests := []struct {
+		account string
+		want    bool
+	}{
+		{"123456", true},
+		{"0011001234567", true},
+		{"1234567890123456789", true},
+		{"12345", false},
+		{"12345678901234567890", false},
+		{"1234-5678", false},
+		{"12345a", false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		if got := IsValidVietnameseBankAccount(tt.account); got != tt.want {
+			t.Errorf("IsValidVietnameseBankAccount(%q) = %v, want %v", tt.account, got, tt.want)
+		}
+	}
+}
+
+func TestIsValidNapasBIN(t *testing.T) {
+	tests := []struct {
+		bin  string
+		want bool
+	}{
+		{"970436", true},
+		{"970415", true},
+		{"970400", true},
+		{"970536", false},
+		{"97043", false},
+		{"9704366", false},
+		{"97043a", false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		if got := IsValidNapasBIN(tt.bin); got != tt.want {
+			t.Errorf("IsValidNapasBIN(%q) = %v, want %v", tt.bin, got, tt.want)
+		}
+	}
+}
+
+func TestIsValidVietnamesePostalCode(t *testing.T) {
+	tests := []struct {
+		code string
+		want bool
+	}{
+		{"10000", true},
+		{"70000", true},
+		{"99999", true},
+		{"01000", false},
+		{"1000", false},
+		{"100000", false},
+		{"1000a", false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		if got := IsValidVietnamesePostalCode(tt.code); got != tt.want {
+			t.Errorf("IsValidVietnamesePostalCode(%q) = %v, want %v", tt.code, got, tt.want)
+		}
+	}
+}
+
+func TestIsValidVietnameseName(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		want  bool
+	}{
+		{"no diacritics", "Nguyen Van An", true},
+		{"precomposed diacritics", "Nguyễn Văn An", true},
+		{"every vowel with a tone", "Đặng Thị Ngọc Ánh Ươ Ữ Ỳ Ỷ Ỹ Ỵ ý", true},
+		{"d with stroke", "Đỗ Đức Đạt", true},
+		{"combining diacritics", "Nguye\u0302\u0303n Va\u0306n An", true},
+		{"single word", "Hà", true},
+		{"double space", "Nguyễn  Văn An", false},
+		{"leading space", " Nguyễn Văn An", false},
+		{"trailing space", "Nguyễn Văn An ", false},
+		{"tab", "Nguyễn\tVăn", false},
+		{"digit", "Nguyễn Văn 2", false},
+		{"apostrophe", "O'Brien", false},
+		{"hyphen", "Nguyễn-Văn", false},
+		{"dot", "Nguyễn V. An", false},
+		{"Cyrillic", "Иван", false},
+		{"Han", "阮文安", false},
+		{"emoji", "An 😀", false},
+		{"empty", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsValidVietnameseName(tt.value); got != tt.want {
+				t.Errorf("IsValidVietnameseName(%q) = %v, want %v", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestVietnameseIdentityValidatorTags(t *testing.T) {
+	cv, err := NewValidator([]string{"en", "vi"}, "en")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cv.RegisterAllCustomValidators(); err != nil {
+		t.Fatal(err)
+	}
+
+	type profile struct {
+		CitizenID   string `json:"citizen_id" validate:"omitempty,vi_citizen_id"`
+		TaxCode     string `json:"tax_code" validate:"vi_tax_code"`
+		BankAccount string `json:"bank_account" validate:"vi_bank_account"`
+		NapasBIN    string `json:"napas_bin" validate:"vi_napas_bin"`
+		PostalCode  string `json:"postal_code" validate:"vi_postal_code"`
+		Name        string `json:"name" validate:"required,vi_name"`
+	}
+
+	valid := profile{
+		CitizenID:   "001090123456",
+		TaxCode:     "0100100015-001",
+		BankAccount: "0011001234567",
+		NapasBIN:    "970436",
+		PostalCode:  "10000",
+		Name:        "Nguyễn Văn An",
+	}
+	if err := cv.Validate(&valid); err != nil {
+		t.Fatalf("valid profile: %v", err)
+	}
+
+	// Empty values are left to 'required'
+	if err := cv.Validate(&profile{Name: "An"}); err != nil {
+		t.Fatalf("empty optional fields: %v", err)
+	}
+
+	invalid := profile{
+		CitizenID:   "003090123456",
+		TaxCode:     "0100100016",
+		BankAccount: "12345",
+		NapasBIN:    "970536",
+		PostalCode:  "01000",
+		Name:        "Nguyễn Văn 2",
+	}
+	err = cv.Validate(&invalid)
+	if err == nil {
+		t.Fatal("invalid profile passed validation")
+	}
+	tests := []struct {
+		locale string
+		want   map[string]string
+	}{
+		{"en", map[string]string{
+			"citizen_id":   "citizen_id must be a valid Vietnamese citizen ID (12-digit CCCD or 9-digit CMND)",
+			"tax_code":     "tax_code must be a valid Vietnamese tax code (10 digits, or 13 digits for a branch)",
+			"bank_account": "bank_account must be a valid bank account number (6-19 digits)",
+			"napas_bin":    "napas_bin must be a valid NAPAS bank BIN (9704xx)",
+			"postal_code":  "postal_code must be a valid Vietnamese postal code (5 digits)",
+			"name":         "name may only contain letters (including Vietnamese diacritics) and single spaces",
+		}},
+		{"vi", map[string]string{
+			"citizen_id":   "citizen_id phải là số CCCD (12 số) hoặc CMND (9 số) hợp lệ",
+			"tax_code":     "tax_code phải là mã số thuế hợp lệ (10 số, hoặc 13 số đối với chi nhánh)",
+			"bank_account": "bank_account phải là số tài khoản ngân hàng hợp lệ (6-19 chữ số)",
+			"napas_bin":    "napas_bin phải là mã BIN ngân hàng NAPAS hợp lệ (9704xx)",
+			"postal_code":  "postal_code phải là mã bưu chính hợp lệ (5 chữ số)",
+			"name":         "name chỉ được chứa chữ cái (có dấu tiếng Việt) và khoảng trắng đơn",
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.locale, func(t *testing.T) {
+			got := cv.ExtractValidationErrors(err, tt.locale)
+			if len(got) != len(tt.want) {
+				t.Errorf("got %d field errors, want %d: %v", len(got), len(tt.want), got)
+			}
+			for field, message := range tt.want {
+				if got[field] != message {
+					t.Errorf("%s: got %q, want %q", field, got[field], message)
+				}
+			}
+		})
+	}
+}
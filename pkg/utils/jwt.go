@@ -1,6 +1,8 @@
 package utils
 
 import (
+	"crypto/rand"
+	"encoding/hex"
 	"errors"
 	"time"
 
@@ -12,9 +14,26 @@ type JWTClaims struct {
 	Email  string `json:"email"`
 	Name   string `json:"name"`
 	Role   string `json:"role"`
+	// TokenVersion must match the user's current token_version for the token to be accepted
+	TokenVersion int `json:"ver"`
+
+	// Actor is the admin acting as the user, set only on impersonation tokens
+	Actor *ActorClaims `json:"act,omitempty"`
+
+	// Set only for tokens issued by the OAuth2 authorization server
+	ClientID  string `json:"client_id,omitempty"`
+	Scope     string `json:"scope,omitempty"`
+	GrantType string `json:"gty,omitempty"`
 	jwt.RegisteredClaims
 }
 
+// ActorClaims identifies who is really behind an impersonation token (RFC 8693 "act")
+type ActorClaims struct {
+	UserID       int    `json:"user_id"`
+	Email        string `json:"email"`
+	TokenVersion int    `json:"ver"`
+}
+
 type JWTManager struct {
 	secretKey string
 	duration  time.Duration
@@ -28,21 +47,65 @@ func NewJWTManager(secretKey string, duration time.Duration) *JWTManager {
 	}
 }
 
-// GenerateToken generates a new JWT token
-func (m *JWTManager) GenerateToken(userID int, email, name, role string) (string, error) {
-	now := time.Now()
-	expiresAt := now.Add(m.duration)
+// Duration returns the lifetime of generated tokens
+func (m *JWTManager) Duration() time.Duration {
+	return m.duration
+}
 
+// GenerateSessionToken generates a token whose jti is tokenID, tying it to a server-side session
+func (m *JWTManager) GenerateSessionToken(tokenID string, userID int, email, name, role string, tokenVersion int) (string, error) {
 	claims := JWTClaims{
-		UserID: userID,
-		Email:  email,
-		Name:   name,
-		Role:   role,
-		RegisteredClaims: jwt.RegisteredClaims{
-			IssuedAt:  jwt.NewNumericDate(now),
-			ExpiresAt: jwt.NewNumericDate(expiresAt),
-			NotBefore: jwt.NewNumericDate(now),
-		},
+		UserID:       userID,
+		Email:        email,
+		Name:         name,
+		Role:         role,
+		TokenVersion: tokenVersion,
+	}
+	claims.ID = tokenID
+	return m.sign(claims)
+}
+
+// GenerateImpersonationToken generates a session token for userID that carries the acting admin
+// It expires after duration rather than the manager's default lifetime
+func (m *JWTManager) GenerateImpersonationToken(tokenID string, userID int, email, name, role string, tokenVersion int, actor ActorClaims, duration time.Duration) (string, error) {
+	claims := JWTClaims{
+		UserID:       userID,
+		Email:        email,
+		Name:         name,
+		Role:         role,
+		TokenVersion: tokenVersion,
+		Actor:        &actor,
+	}
+	claims.ID = tokenID
+	return m.signFor(claims, duration)
+}
+
+// GenerateOAuthToken generates an access token for an OAuth2 client, expiring after duration
+// scope is the space-delimited granted scope, grantType the grant the token was issued through
+func (m *JWTManager) GenerateOAuthToken(userID int, email, name, role string, tokenVersion int, clientID, scope, grantType string, duration time.Duration) (string, error) {
+	return m.signFor(JWTClaims{
+		UserID:       userID,
+		Email:        email,
+		Name:         name,
+		Role:         role,
+		TokenVersion: tokenVersion,
+		ClientID:     clientID,
+		Scope:        scope,
+		GrantType:    grantType,
+	}, duration)
+}
+
+func (m *JWTManager) sign(claims JWTClaims) (string, error) {
+	return m.signFor(claims, m.duration)
+}
+
+func (m *JWTManager) signFor(claims JWTClaims, duration time.Duration) (string, error) {
+	now := time.Now()
+	claims.RegisteredClaims = jwt.RegisteredClaims{
+		ID:        claims.ID,
+		IssuedAt:  jwt.NewNumericDate(now),
+		ExpiresAt: jwt.NewNumericDate(now.Add(duration)),
+		NotBefore: jwt.NewNumericDate(now),
 	}
 
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
@@ -54,6 +117,15 @@ func (m *JWTManager) GenerateToken(userID int, email, name, role string) (string
 	return tokenString, nil
 }
 
+// NewTokenID returns a random token identifier for the jti claim
+func NewTokenID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
 // VerifyToken verifies and parses a JWT token
 func (m *JWTManager) VerifyToken(tokenString string) (*JWTClaims, error) {
 	token, err := jwt.ParseWithClaims(tokenString, &JWTClaims{}, func(token *jwt.Token) (interface{}, error) {
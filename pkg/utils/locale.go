@@ -0,0 +1,86 @@
+package utils
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// LocaleQueryParam is the query parameter that overrides Accept-Language
+const LocaleQueryParam = "lang"
+
+// NegotiateLocale picks the best supported locale for a request
+// Order: ?lang= override → Accept-Language (by q-value) → fallback
+// Region subtags are matched by their base language (vi-VN → vi)
+func NegotiateLocale(r *http.Request, supported []string, fallback string) string {
+	if lang := r.URL.Query().Get(LocaleQueryParam); lang != "" {
+		if locale, ok := matchLocale(lang, supported); ok {
+			return locale
+		}
+	}
+
+	for _, lang := range parseAcceptLanguage(r.Header.Get("Accept-Language")) {
+		if locale, ok := matchLocale(lang, supported); ok {
+			return locale
+		}
+	}
+
+	return fallback
+}
+
+// matchLocale matches a language tag against supported locales, exact match first then base language
+func matchLocale(tag string, supported []string) (string, bool) {
+	tag = strings.ToLower(strings.ReplaceAll(strings.TrimSpace(tag), "_", "-"))
+	base, _, _ := strings.Cut(tag, "-")
+	for _, locale := range supported {
+		if strings.EqualFold(locale, tag) {
+			return locale, true
+		}
+	}
+	for _, locale := range supported {
+		if strings.EqualFold(locale, base) {
+			return locale, true
+		}
+	}
+	return "", false
+}
+
+// parseAcceptLanguage returns language tags ordered by descending q-value
+// Tags with q=0 and the "*" wildcard are dropped
+func parseAcceptLanguage(header string) []string {
+	type weighted struct {
+		tag string
+		q   float64
+	}
+
+	var tags []weighted
+	for _, part := range strings.Split(header, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		tag = strings.TrimSpace(tag)
+		if tag == "" || tag == "*" {
+			continue
+		}
+		q := 1.0
+		if value, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				continue
+			}
+			q = parsed
+		}
+		if q <= 0 {
+			continue
+		}
+		tags = append(tags, weighted{tag: tag, q: q})
+	}
+
+	// Stable sort keeps header order for equal weights
+	sort.SliceStable(tags, func(i, j int) bool { return tags[i].q > tags[j].q })
+
+	result := make([]string, len(tags))
+	for i, t := range tags {
+		result[i] = t.tag
+	}
+	return result
+}
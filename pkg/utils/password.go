@@ -1,19 +1,190 @@
 package utils
 
 import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
 	"golang.org/x/crypto/bcrypt"
 )
 
-// HashPassword hashes a password using bcrypt
-func HashPassword(password string) (string, error) {
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+var (
+	ErrPasswordMismatch      = errors.New("password does not match")
+	ErrUnknownHashFormat     = errors.New("unknown password hash format")
+	ErrMalformedPasswordHash = errors.New("malformed password hash")
+)
+
+// PasswordHashAlgorithm is one password hashing scheme producing PHC-style encoded hashes
+// ($<id>$...), so the algorithm and its parameters travel with every stored hash
+type PasswordHashAlgorithm interface {
+	// IDs returns the PHC identifiers this algorithm can verify (e.g. "argon2id", or "2a", "2b", "2y")
+	IDs() []string
+	Hash(password string) (string, error)
+	// Verify returns ErrPasswordMismatch when password doesn't match encodedHash
+	Verify(encodedHash string, password string) error
+	// NeedsRehash reports whether encodedHash was produced with different parameters
+	NeedsRehash(encodedHash string) bool
+}
+
+// PasswordHasher hashes new passwords with its primary algorithm and verifies
+// hashes produced by any registered algorithm
+type PasswordHasher struct {
+	primary    PasswordHashAlgorithm
+	algorithms map[string]PasswordHashAlgorithm
+}
+
+// NewPasswordHasher creates a hasher; legacy algorithms are only used for verification
+func NewPasswordHasher(primary PasswordHashAlgorithm, legacy ...PasswordHashAlgorithm) *PasswordHasher {
+	h := &PasswordHasher{
+		primary:    primary,
+		algorithms: make(map[string]PasswordHashAlgorithm),
+	}
+	for _, algorithm := range append(legacy, primary) {
+		for _, id := range algorithm.IDs() {
+			h.algorithms[id] = algorithm
+		}
+	}
+	return h
+}
+
+// HashPassword hashes a password with the primary algorithm
+func (h *PasswordHasher) HashPassword(password string) (string, error) {
+	return h.primary.Hash(password)
+}
+
+// VerifyPassword verifies a password against its hash
+// needsRehash is true when the password matched but the hash uses an outdated algorithm or parameters
+func (h *PasswordHasher) VerifyPassword(encodedHash, password string) (needsRehash bool, err error) {
+	algorithm, ok := h.algorithms[phcID(encodedHash)]
+	if !ok {
+		return false, ErrUnknownHashFormat
+	}
+	if err := algorithm.Verify(encodedHash, password); err != nil {
+		return false, err
+	}
+	return algorithm != h.primary || h.primary.NeedsRehash(encodedHash), nil
+}
+
+// phcID extracts the algorithm identifier from "$<id>$..."
+func phcID(encodedHash string) string {
+	parts := strings.SplitN(encodedHash, "$", 3)
+	if len(parts) < 3 || parts[0] != "" {
+		return ""
+	}
+	return parts[1]
+}
+
+// Argon2idHasher hashes passwords with Argon2id
+// Encoded as $argon2id$v=19$m=<memory KiB>,t=<iterations>,p=<parallelism>$<salt>$<key> (unpadded base64)
+type Argon2idHasher struct {
+	Memory      uint32 // KiB
+	Iterations  uint32
+	Parallelism uint8
+	SaltLength  uint32
+	KeyLength   uint32
+}
+
+func (a *Argon2idHasher) IDs() []string {
+	return []string{"argon2id"}
+}
+
+func (a *Argon2idHasher) Hash(password string) (string, error) {
+	salt := make([]byte, a.SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+
+	key := argon2.IDKey([]byte(password), salt, a.Iterations, a.Memory, a.Parallelism, a.KeyLength)
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2.Version, a.Memory, a.Iterations, a.Parallelism,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	), nil
+}
+
+func (a *Argon2idHasher) Verify(encodedHash string, password string) error {
+	params, salt, key, err := decodeArgon2id(encodedHash)
+	if err != nil {
+		return err
+	}
+
+	candidate := argon2.IDKey([]byte(password), salt, params.Iterations, params.Memory, params.Parallelism, uint32(len(key)))
+	if subtle.ConstantTimeCompare(candidate, key) != 1 {
+		return ErrPasswordMismatch
+	}
+	return nil
+}
+
+func (a *Argon2idHasher) NeedsRehash(encodedHash string) bool {
+	params, salt, key, err := decodeArgon2id(encodedHash)
+	if err != nil {
+		return true
+	}
+	return params.Memory != a.Memory ||
+		params.Iterations != a.Iterations ||
+		params.Parallelism != a.Parallelism ||
+		uint32(len(salt)) != a.SaltLength ||
+		uint32(len(key)) != a.KeyLength
+}
+
+func decodeArgon2id(encodedHash string) (params Argon2idHasher, salt []byte, key []byte, err error) {
+	// "", "argon2id", "v=19", "m=...,t=...,p=...", salt, key
+	parts := strings.Split(encodedHash, "$")
+	if len(parts) != 6 || parts[1] != "argon2id" {
+		return params, nil, nil, ErrMalformedPasswordHash
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return params, nil, nil, ErrMalformedPasswordHash
+	}
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.Memory, &params.Iterations, &params.Parallelism); err != nil {
+		return params, nil, nil, ErrMalformedPasswordHash
+	}
+	if salt, err = base64.RawStdEncoding.DecodeString(parts[4]); err != nil {
+		return params, nil, nil, ErrMalformedPasswordHash
+	}
+	if key, err = base64.RawStdEncoding.DecodeString(parts[5]); err != nil {
+		return params, nil, nil, ErrMalformedPasswordHash
+	}
+	return params, salt, key, nil
+}
+
+// BcryptMaxPasswordBytes is the longest password bcrypt can hash
+const BcryptMaxPasswordBytes = 72
+
+// BcryptHasher hashes passwords with bcrypt ($2a$<cost>$...)
+// bcrypt only uses the first 72 bytes of a password, so longer passwords are rejected when hashing;
+// PasswordPolicy.MaxBytes turns them away first
+type BcryptHasher struct {
+	Cost int
+}
+
+func (b *BcryptHasher) IDs() []string {
+	return []string{"2a", "2b", "2y"}
+}
+
+func (b *BcryptHasher) Hash(password string) (string, error) {
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), b.Cost)
 	if err != nil {
 		return "", err
 	}
 	return string(hashedPassword), nil
 }
 
-// VerifyPassword verifies a password against its hash
-func VerifyPassword(hashedPassword, password string) error {
-	return bcrypt.CompareHashAndPassword([]byte(hashedPassword), []byte(password))
+func (b *BcryptHasher) Verify(encodedHash string, password string) error {
+	err := bcrypt.CompareHashAndPassword([]byte(encodedHash), []byte(password))
+	if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+		return ErrPasswordMismatch
+	}
+	return err
+}
+
+func (b *BcryptHasher) NeedsRehash(encodedHash string) bool {
+	cost, err := bcrypt.Cost([]byte(encodedHash))
+	return err != nil || cost != b.Cost
 }
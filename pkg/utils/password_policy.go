@@ -0,0 +1,96 @@
+package utils
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// PasswordPolicy holds the rules applied when a password is set (registration, change, reset)
+// Zero values disable a rule
+type PasswordPolicy struct {
+	MinLength        int
+	MaxLength        int
+	MaxBytes         int // the hash only takes this many bytes, e.g. BcryptMaxPasswordBytes
+	RequireUpper     bool
+	RequireLower     bool
+	RequireDigit     bool
+	RequireSymbol    bool
+	ForbidEmail      bool // password must not contain the email or its local part
+	MinStrengthScore int  // 0-4, see PasswordStrength
+	Breached         BreachedPasswordChecker
+}
+
+// Validate checks password against the policy and returns every violated rule as a message
+// email is used for the no-email-substring rule; userInputs (e.g. name) also weaken the strength score
+func (p *PasswordPolicy) Validate(password string, email string, userInputs ...string) []string {
+	var violations []string
+
+	length := utf8.RuneCountInString(password)
+	if p.MinLength > 0 && length < p.MinLength {
+		violations = append(violations, fmt.Sprintf("must be at least %d characters in length", p.MinLength))
+	}
+	if p.MaxLength > 0 && length > p.MaxLength {
+		violations = append(violations, fmt.Sprintf("must be at most %d characters in length", p.MaxLength))
+	} else if p.MaxBytes > 0 && len(password) > p.MaxBytes {
+		violations = append(violations, fmt.Sprintf("must be at most %d bytes long; accented letters count as 2 or 3", p.MaxBytes))
+	}
+
+	var hasUpper, hasLower, hasDigit, hasSymbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			hasUpper = true
+		case unicode.IsLower(r):
+			hasLower = true
+		case unicode.IsDigit(r):
+			hasDigit = true
+		case unicode.IsPunct(r), unicode.IsSymbol(r), unicode.IsSpace(r):
+			hasSymbol = true
+		}
+	}
+	if p.RequireUpper && !hasUpper {
+		violations = append(violations, "must contain an uppercase letter")
+	}
+	if p.RequireLower && !hasLower {
+		violations = append(violations, "must contain a lowercase letter")
+	}
+	if p.RequireDigit && !hasDigit {
+		violations = append(violations, "must contain a digit")
+	}
+	if p.RequireSymbol && !hasSymbol {
+		violations = append(violations, "must contain a symbol")
+	}
+
+	localPart, _, _ := strings.Cut(email, "@")
+	if p.ForbidEmail && email != "" {
+		lower := strings.ToLower(password)
+		if strings.Contains(lower, strings.ToLower(email)) ||
+			(utf8.RuneCountInString(localPart) >= 3 && strings.Contains(lower, strings.ToLower(localPart))) {
+			violations = append(violations, "must not contain your email address")
+		}
+	}
+
+	if p.MinStrengthScore > 0 {
+		if score, _ := PasswordStrength(password, append([]string{localPart}, userInputs...)...); score < p.MinStrengthScore {
+			violations = append(violations, "is too easy to guess")
+		}
+	}
+
+	if p.Breached != nil && p.Breached.IsBreached(password) {
+		violations = append(violations, "has appeared in a data breach and cannot be used")
+	}
+
+	return violations
+}
+
+// ValidationErrors returns policy violations as a field error map for BadRequestWithFields
+// All violations for field are joined into one message, e.g. "password must contain a digit; is too easy to guess"
+func (p *PasswordPolicy) ValidationErrors(field string, password string, email string, userInputs ...string) map[string]string {
+	violations := p.Validate(password, email, userInputs...)
+	if len(violations) == 0 {
+		return nil
+	}
+	return map[string]string{field: field + " " + strings.Join(violations, "; ")}
+}
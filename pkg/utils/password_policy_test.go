@@ -0,0 +1,40 @@
+package utils
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestPasswordPolicyMaxBytes(t *testing.T) {
+	policy := &PasswordPolicy{MaxLength: BcryptMaxPasswordBytes, MaxBytes: BcryptMaxPasswordBytes}
+	tests := []struct {
+		name     string
+		password string
+		want     []string
+	}{
+		{"72 ASCII characters", strings.Repeat("a", 72), nil},
+		{"73 ASCII characters", strings.Repeat("a", 73), []string{"must be at most 72 characters in length"}},
+		{"36 two-byte letters", strings.Repeat("é", 36), nil},
+		{"37 two-byte letters", strings.Repeat("é", 37), []string{"must be at most 72 bytes long; accented letters count as 2 or 3"}},
+		{"25 three-byte letters", strings.Repeat("ệ", 25), []string{"must be at most 72 bytes long; accented letters count as 2 or 3"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := policy.Validate(tt.password, ""); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// Whatever the policy lets through, bcrypt can hash
+func TestBcryptHashesPolicyMaximum(t *testing.T) {
+	hasher := &BcryptHasher{Cost: 4}
+	if _, err := hasher.Hash(strings.Repeat("ệ", 24)); err != nil {
+		t.Errorf("72 bytes: %v", err)
+	}
+	if _, err := hasher.Hash(strings.Repeat("é", 37)); err == nil {
+		t.Error("74 bytes hashed, want an error")
+	}
+}
@@ -0,0 +1,184 @@
+package utils
+
+import (
+	"math"
+	"strings"
+	"unicode"
+)
+
+// Password strength estimation in the spirit of zxcvbn:
+// the password is covered by the cheapest sequence of patterns (dictionary words, sequences,
+// repeats, keyboard walks, years) and brute-force characters, and the estimated number of guesses
+// is bucketed into a 0-4 score. Everything is computed in log10 space to avoid overflow.
+
+// Score thresholds in log10(guesses), same buckets as zxcvbn
+var strengthThresholds = [4]float64{3, 6, 8, 10}
+
+// commonPasswordWords are ranked by frequency; a word's guesses equal its rank
+var commonPasswordWords = []string{
+	"password", "123456", "qwerty", "admin", "welcome", "login", "abc", "letmein", "monkey", "dragon",
+	"master", "sunshine", "princess", "football", "baseball", "iloveyou", "trustno1", "shadow", "superman",
+	"michael", "jesus", "ninja", "mustang", "access", "secret", "hello", "charlie", "freedom", "whatever",
+	"passw0rd", "starwars", "computer", "internet", "summer", "winter", "spring", "autumn", "love",
+	"matkhau", "anhyeuem", "yeuem", "vietnam", "hanoi", "saigon", "iloveu", "user", "test", "guest",
+	"root", "changeme", "default", "pass", "company", "office", "golang", "echo",
+}
+
+var keyboardRows = []string{
+	"qwertyuiop", "asdfghjkl", "zxcvbnm", "1234567890", "qazwsxedc", "!@#$%^&*()",
+}
+
+var leetReplacer = strings.NewReplacer(
+	"0", "o", "1", "i", "3", "e", "4", "a", "5", "s", "7", "t", "@", "a", "$", "s", "!", "i",
+)
+
+// PasswordStrength returns a 0 (very weak) to 4 (very strong) score and the log10 of estimated guesses
+// userInputs (email, name, ...) are treated as the most likely dictionary words
+func PasswordStrength(password string, userInputs ...string) (score int, log10Guesses float64) {
+	runes := []rune(password)
+	n := len(runes)
+	if n == 0 {
+		return 0, 0
+	}
+
+	dictionary := make(map[string]int, len(commonPasswordWords)+len(userInputs))
+	for i, input := range userInputs {
+		if input = strings.ToLower(strings.TrimSpace(input)); len([]rune(input)) >= 3 {
+			dictionary[input] = i + 1
+		}
+	}
+	for i, word := range commonPasswordWords {
+		if _, ok := dictionary[word]; !ok {
+			dictionary[word] = len(userInputs) + i + 1
+		}
+	}
+
+	// best[i] = minimum log10 guesses to produce the first i runes
+	best := make([]float64, n+1)
+	for i := 1; i <= n; i++ {
+		best[i] = best[i-1] + math.Log10(charCardinality(runes[i-1]))
+		for j := 0; j < i-1; j++ {
+			if g, ok := patternLog10Guesses(runes[j:i], dictionary); ok && best[j]+g < best[i] {
+				best[i] = best[j] + g
+			}
+		}
+	}
+
+	log10Guesses = best[n]
+	for score < len(strengthThresholds) && log10Guesses >= strengthThresholds[score] {
+		score++
+	}
+	return score, log10Guesses
+}
+
+// patternLog10Guesses returns the cheapest known pattern that matches the whole token
+func patternLog10Guesses(token []rune, dictionary map[string]int) (float64, bool) {
+	best, found := math.Inf(1), false
+	consider := func(g float64) {
+		if g < best {
+			best, found = g, true
+		}
+	}
+
+	word := string(token)
+	lower := strings.ToLower(word)
+	caseFactor := 0.0
+	if lower != word {
+		caseFactor = math.Log10(2)
+	}
+	if rank, ok := dictionary[lower]; ok {
+		consider(math.Log10(float64(rank)) + caseFactor)
+	}
+	if unleet := leetReplacer.Replace(lower); unleet != lower {
+		if rank, ok := dictionary[unleet]; ok {
+			consider(math.Log10(float64(rank)) + caseFactor + math.Log10(2))
+		}
+	}
+
+	if len(token) >= 3 {
+		if isRepeat(token) {
+			consider(math.Log10(charCardinality(token[0]) * float64(len(token))))
+		}
+		if isSequence(token) {
+			base := 26.0
+			switch {
+			case strings.ContainsRune("aAzZ019", token[0]):
+				base = 4
+			case unicode.IsDigit(token[0]):
+				base = 10
+			}
+			consider(math.Log10(base * float64(len(token))))
+		}
+		if len(token) == 4 && (strings.HasPrefix(word, "19") || strings.HasPrefix(word, "20")) && isDigits(word) {
+			consider(math.Log10(120))
+		}
+	}
+	if len(token) >= 4 && isKeyboardWalk(lower) {
+		consider(math.Log10(float64(10 * len(token))))
+	}
+
+	return best, found
+}
+
+// charCardinality is the size of the character class a brute-force attacker must try for r
+func charCardinality(r rune) float64 {
+	switch {
+	case r >= '0' && r <= '9':
+		return 10
+	case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z':
+		return 26
+	case r < unicode.MaxASCII:
+		return 33
+	default:
+		return 100
+	}
+}
+
+func isRepeat(token []rune) bool {
+	for _, r := range token[1:] {
+		if r != token[0] {
+			return false
+		}
+	}
+	return true
+}
+
+// isSequence detects runs with a constant step of +1 or -1 (abc, 987)
+func isSequence(token []rune) bool {
+	step := token[1] - token[0]
+	if step != 1 && step != -1 {
+		return false
+	}
+	for i := 2; i < len(token); i++ {
+		if token[i]-token[i-1] != step {
+			return false
+		}
+	}
+	return true
+}
+
+func isKeyboardWalk(token string) bool {
+	for _, row := range keyboardRows {
+		if strings.Contains(row, token) || strings.Contains(reverseString(row), token) {
+			return true
+		}
+	}
+	return false
+}
+
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+func reverseString(s string) string {
+	runes := []rune(s)
+	for i, j := 0, len(runes)-1; i < j; i, j = i+1, j-1 {
+		runes[i], runes[j] = runes[j], runes[i]
+	}
+	return string(runes)
+}
@@ -0,0 +1,82 @@
+package utils
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestPasswordStrength(t *testing.T) {
+	tests := []struct {
+		password string
+		want     int
+	}{
+		// 0: found among the first thousand guesses
+		{"", 0},
+		{"password", 0},
+		{"P@ssw0rd", 0},
+		{"123456", 0},
+		{"qwertyuiop", 0},
+		{"aaaaaaaaaaaa", 0},
+		{"abcdefgh", 0},
+		{"1987", 0},
+		{"Matkhau123", 0},
+		{"4nhy3u3m", 0},
+		// 1: under a million
+		{"summer2024", 1},
+		{"hanoi1990!", 1},
+		{"iloveyou99", 1},
+		// 2: under 10^8, the default PASSWORD_MIN_STRENGTH_SCORE
+		{"summer2024ab", 2},
+		{"freedom!x9q", 2},
+		{"kx9mq2", 2},
+		// 3: under 10^10
+		{"kX9#mQ", 3},
+		// 4
+		{"kx9mq2vb", 4},
+		{"Tr0ub4dor&3", 4},
+		{"correct horse battery staple", 4},
+		{"mật khẩu rất dài", 4},
+	}
+	for _, tt := range tests {
+		if got, guesses := PasswordStrength(tt.password); got != tt.want {
+			t.Errorf("PasswordStrength(%q) = %d (10^%.2f guesses), want %d", tt.password, got, guesses, tt.want)
+		}
+	}
+}
+
+func TestPasswordStrengthUserInputs(t *testing.T) {
+	const password = "nguyenvanan"
+	without, _ := PasswordStrength(password)
+	with, _ := PasswordStrength(password, "Nguyenvanan ")
+	if without < 2 || with != 0 {
+		t.Errorf("score without the user input = %d, with it = %d; want 2+ and 0", without, with)
+	}
+	// Inputs shorter than 3 characters are not dictionary words
+	if got, _ := PasswordStrength("an", "an"); got != 0 {
+		t.Errorf("PasswordStrength(an) = %d, want 0", got)
+	}
+}
+
+func TestPasswordPolicyStrength(t *testing.T) {
+	tests := []struct {
+		name     string
+		minScore int
+		password string
+		email    string
+		want     []string
+	}{
+		{"score below the threshold", 2, "summer2024", "an@example.com", []string{"is too easy to guess"}},
+		{"score at the threshold", 2, "summer2024ab", "an@example.com", nil},
+		{"strict threshold", 4, "kX9#mQ", "an@example.com", []string{"is too easy to guess"}},
+		{"disabled", 0, "password", "an@example.com", nil},
+		{"email local part is a user input", 2, "nguyenvanan", "nguyenvanan@example.com", []string{"is too easy to guess"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			policy := &PasswordPolicy{MinStrengthScore: tt.minScore}
+			if got := policy.Validate(tt.password, tt.email); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
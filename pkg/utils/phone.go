@@ -0,0 +1,30 @@
+package utils
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+)
+
+// Vietnamese phone number patterns: national prefix (0, +84 or 84), mobile network code, 7 digits
+var viPhoneRegex = regexp.MustCompile(`^(0|\+84|84)(3[2-9]|5[689]|7[06-9]|8[1-9]|9[0-9])\d{7}$`)
+
+var ErrInvalidPhone = errors.New("invalid Vietnamese phone number")
+
+// phoneSeparators are stripped before normalisation so "091 234 5678" and "091-234-5678" are accepted
+var phoneSeparators = strings.NewReplacer(" ", "", "-", "", ".", "", "(", "", ")", "")
+
+// NormalizeVietnamesePhone converts any accepted Vietnamese phone format to canonical E.164
+// Examples: 0912345678, 84912345678, +84912345678 → +84912345678
+// Surrounding whitespace is ignored, which also covers an unescaped '+' decoded as a space in query strings
+func NormalizeVietnamesePhone(phone string) (string, error) {
+	phone = phoneSeparators.Replace(strings.TrimSpace(phone))
+
+	matches := viPhoneRegex.FindStringSubmatch(phone)
+	if matches == nil {
+		return "", ErrInvalidPhone
+	}
+
+	// Drop the national prefix and keep the subscriber number
+	return "+84" + phone[len(matches[1]):], nil
+}
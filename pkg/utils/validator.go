@@ -1,27 +1,78 @@
 package utils
 
 import (
+	"fmt"
 	"reflect"
 	"strings"
 
+	"github.com/go-playground/locales"
+	"github.com/go-playground/locales/en"
+	"github.com/go-playground/locales/vi"
 	ut "github.com/go-playground/universal-translator"
 	"github.com/go-playground/validator/v10"
 	en_translations "github.com/go-playground/validator/v10/translations/en"
+	vi_translations "github.com/go-playground/validator/v10/translations/vi"
 )
 
+// localeSupport pairs a CLDR locale with the validator's built-in translations for it
+type localeSupport struct {
+	locale       func() locales.Translator
+	translations func(v *validator.Validate, trans ut.Translator) error
+}
+
+// supportedLocales lists every locale that can be enabled through configuration
+var supportedLocales = map[string]localeSupport{
+	"en": {locale: en.New, translations: en_translations.RegisterDefaultTranslations},
+	"vi": {locale: vi.New, translations: vi_translations.RegisterDefaultTranslations},
+}
+
 type CustomValidator struct {
-	validator  *validator.Validate
-	translator ut.Translator
+	validator     *validator.Validate
+	translators   map[string]ut.Translator
+	locales       []string
+	defaultLocale string
 }
 
-// NewValidator creates a new validator instance with dependency injection
-// No global state - translator is injected
-func NewValidator(trans ut.Translator) *CustomValidator {
+// NewValidator creates a new validator instance with one translator per configured locale
+// No global state - translators are owned by the instance
+// defaultLocale is used when a request's locale is unknown and must be one of enabled
+func NewValidator(enabled []string, defaultLocale string) (*CustomValidator, error) {
+	if len(enabled) == 0 {
+		enabled = []string{defaultLocale}
+	}
+
+	fallback, ok := supportedLocales[defaultLocale]
+	if !ok {
+		return nil, fmt.Errorf("unsupported default locale %q", defaultLocale)
+	}
+	fallbackLocale := fallback.locale()
+	uni := ut.New(fallbackLocale, fallbackLocale)
+
 	// Initialize validator
 	validate := validator.New()
 
-	// Register default translations
-	_ = en_translations.RegisterDefaultTranslations(validate, trans)
+	translators := make(map[string]ut.Translator, len(enabled))
+	for _, name := range enabled {
+		support, ok := supportedLocales[name]
+		if !ok {
+			return nil, fmt.Errorf("unsupported locale %q", name)
+		}
+		if name != defaultLocale {
+			if err := uni.AddTranslator(support.locale(), true); err != nil {
+				return nil, err
+			}
+		}
+		trans, _ := uni.GetTranslator(name)
+
+		// Register default translations for this locale
+		if err := support.translations(validate, trans); err != nil {
+			return nil, fmt.Errorf("register %s translations: %w", name, err)
+		}
+		translators[name] = trans
+	}
+	if _, ok := translators[defaultLocale]; !ok {
+		return nil, fmt.Errorf("default locale %q is not in configured locales %v", defaultLocale, enabled)
+	}
 
 	// Register tag name function to use json tags for field names
 	// This makes error field names match JSON keys (lowercase)
@@ -34,27 +85,48 @@ func NewValidator(trans ut.Translator) *CustomValidator {
 	})
 
 	return &CustomValidator{
-		validator:  validate,
-		translator: trans,
-	}
+		validator:     validate,
+		translators:   translators,
+		locales:       enabled,
+		defaultLocale: defaultLocale,
+	}, nil
 }
 
 func (cv *CustomValidator) Validate(i interface{}) error {
 	return cv.validator.Struct(i)
 }
 
-// ExtractValidationErrors extracts field-level validation errors using translator
+// Locales returns the configured locales
+func (cv *CustomValidator) Locales() []string {
+	return cv.locales
+}
+
+// DefaultLocale returns the locale used when no configured locale matches the request
+func (cv *CustomValidator) DefaultLocale() string {
+	return cv.defaultLocale
+}
+
+// translator returns the translator for locale, falling back to the default locale
+func (cv *CustomValidator) translator(locale string) ut.Translator {
+	if trans, ok := cv.translators[locale]; ok {
+		return trans
+	}
+	return cv.translators[cv.defaultLocale]
+}
+
+// ExtractValidationErrors extracts field-level validation errors using the translator for locale
 // Returns map[string]string for easier client-side consumption
 // Key = field name (lowercase from json tag)
 // Value = translated error message
-func (cv *CustomValidator) ExtractValidationErrors(err error) map[string]string {
+func (cv *CustomValidator) ExtractValidationErrors(err error, locale string) map[string]string {
 	errorsMap := make(map[string]string)
+	trans := cv.translator(locale)
 
 	if validationErrors, ok := err.(validator.ValidationErrors); ok {
 		for _, fieldErr := range validationErrors {
 			// Use translator to get localized error message
 			// fieldErr.Field() returns json tag name (already lowercase from RegisterTagNameFunc)
-			errorsMap[fieldErr.Field()] = fieldErr.Translate(cv.translator)
+			errorsMap[fieldErr.Field()] = fieldErr.Translate(trans)
 		}
 		return errorsMap
 	}
@@ -64,27 +136,46 @@ func (cv *CustomValidator) ExtractValidationErrors(err error) map[string]string
 }
 
 // RegisterCustomValidator registers a custom validation rule on this validator instance
-// No global state - uses instance translator via dependency injection
+// messages maps locale → message template ({0} is the field name)
+// Locales without a message fall back to the default locale's message
 func (cv *CustomValidator) RegisterCustomValidator(
 	tag string,
 	fn validator.Func,
-	message string,
+	messages map[string]string,
 ) error {
 	err := cv.validator.RegisterValidation(tag, fn)
 	if err != nil {
 		return err
 	}
 
-	// Register translation for custom validator
-	return cv.validator.RegisterTranslation(
-		tag,
-		cv.translator, // ← Use instance translator, not global
-		func(ut ut.Translator) error {
-			return ut.Add(tag, message, true)
-		},
-		func(ut ut.Translator, fe validator.FieldError) string {
-			t, _ := ut.T(tag, fe.Field())
-			return t
-		},
-	)
+	return cv.registerTranslations(tag, messages)
+}
+
+// registerTranslations registers a translation of tag for every configured locale
+func (cv *CustomValidator) registerTranslations(tag string, messages map[string]string) error {
+	for locale, trans := range cv.translators {
+		message, ok := messages[locale]
+		if !ok {
+			message, ok = messages[cv.defaultLocale]
+		}
+		if !ok {
+			return fmt.Errorf("no %s or %s message for validator %q", locale, cv.defaultLocale, tag)
+		}
+
+		err := cv.validator.RegisterTranslation(
+			tag,
+			trans, // ← Use instance translator, not global
+			func(ut ut.Translator) error {
+				return ut.Add(tag, message, true)
+			},
+			func(ut ut.Translator, fe validator.FieldError) string {
+				t, _ := ut.T(tag, fe.Field())
+				return t
+			},
+		)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
 }